@@ -5,6 +5,7 @@ import (
 	"errors"
 	stdLog "log"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -13,12 +14,16 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"google.golang.org/grpc"
 
 	"github.com/nextlag/gomart/internal/config"
 	"github.com/nextlag/gomart/internal/controllers"
+	"github.com/nextlag/gomart/internal/grpcserver"
+	"github.com/nextlag/gomart/internal/mw/auth"
 	"github.com/nextlag/gomart/internal/repository/psql"
 	"github.com/nextlag/gomart/internal/usecase"
 	"github.com/nextlag/gomart/pkg/logger/l"
+	"github.com/nextlag/gomart/pkg/oidc"
 )
 
 func main() {
@@ -35,13 +40,24 @@ func main() {
 
 	log.Debug("initialized flags",
 		l.StringAttr("-a", cfg.Host),
+		l.StringAttr("-g", cfg.GRPCHost),
+		l.StringAttr("-gc", cfg.GRPCTLSCert),
 		l.StringAttr("-d", cfg.DSN),
 		l.StringAttr("-k", cfg.SecretToken),
 		l.StringAttr("-l", cfg.LogLevel.String()),
 		l.StringAttr("-r", cfg.Accrual),
 		l.StringAttr("-p", cfg.ProjectRoot),
+		l.StringAttr("-t", cfg.Tiers.String()),
+		l.StringAttr("-ph", cfg.PasswordHash),
+		l.StringAttr("-kf", cfg.KeyringFile),
 	)
 
+	// Загрузка ключей подписи токенов доступа
+	if err := auth.LoadKeyring(ctx); err != nil {
+		log.Error("failed to load keyring", l.ErrAttr(err))
+		os.Exit(1)
+	}
+
 	// init repository
 	db, err := psql.New(ctx, cfg.DSN)
 	if err != nil {
@@ -57,17 +73,53 @@ func main() {
 
 	// create new server
 	srv := controllers.New(ctx, uc).NewServer(r)
+
+	// Локальный поставщик удостоверений для разработки и тестов вместо внешнего
+	if cfg.OIDC.Stub {
+		stub, err := oidc.NewStub(cfg.OIDC.Issuer)
+		if err != nil {
+			log.Error("failed to start identity provider stub", l.ErrAttr(err))
+			os.Exit(1)
+		}
+		r.Mount(config.OIDCStubPath, http.StripPrefix(config.OIDCStubPath, stub))
+		log.Info("identity provider stub enabled", slog.String("issuer", cfg.OIDC.Issuer))
+	}
 	r.Mount("/", srv.Handler)
 
+	// create new gRPC server
+	var grpcOpts []grpc.ServerOption
+	switch {
+	case cfg.GRPCHost == "":
+	case cfg.GRPCTLSCert != "":
+		creds, err := grpcserver.Credentials(cfg.GRPCTLSCert, cfg.GRPCTLSKey)
+		if err != nil {
+			log.Error("failed to load gRPC TLS credentials", l.ErrAttr(err))
+			os.Exit(1)
+		}
+		grpcOpts = append(grpcOpts, creds)
+	default:
+		log.Warn("gRPC server without TLS: tokens and passwords are sent in plaintext, use only on a trusted network")
+	}
+	grpcSrv := grpcserver.New(ctx, uc).NewServer(grpcOpts...)
+
 	log.Info("server starting", slog.String("host", srv.Addr))
 
 	// WaitGroup для ожидания завершения работы горутин
 	var wg sync.WaitGroup
-	wg.Add(2)
+	wg.Add(4)
 
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 
+	// Перечитывание файла ключей подписи токенов по сигналу SIGHUP
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer wg.Done()
+		auth.WatchKeyring(ctx, hup)
+	}()
+
 	go func() {
 		defer wg.Done()
 		if err = db.Sync(ctx); err != nil {
@@ -77,6 +129,15 @@ func main() {
 		}
 	}()
 
+	go func() {
+		defer wg.Done()
+		if err := db.TierSync(ctx); err != nil {
+			log.Error("db.TierSync()", l.ErrAttr(err))
+			sigs <- os.Interrupt
+			return
+		}
+	}()
+
 	go func() {
 		defer wg.Done()
 		if err = srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -86,6 +147,26 @@ func main() {
 		}
 	}()
 
+	// Сервер gRPC API запускается на отдельном адресе, если он задан
+	if cfg.GRPCHost != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			listener, err := net.Listen("tcp", cfg.GRPCHost)
+			if err != nil {
+				log.Error("failed to listen gRPC address", l.ErrAttr(err))
+				sigs <- os.Interrupt
+				return
+			}
+			log.Info("gRPC server starting", slog.String("host", cfg.GRPCHost), slog.Bool("tls", len(grpcOpts) > 0))
+			if err = grpcSrv.Serve(listener); err != nil {
+				log.Error("failed to start gRPC server", l.ErrAttr(err))
+				sigs <- os.Interrupt
+				return
+			}
+		}()
+	}
+
 	// Ожидание получения сигнала от OS
 	<-sigs
 
@@ -99,6 +180,18 @@ func main() {
 		log.Error("server shutdown error", l.ErrAttr(err))
 	}
 
+	// Сервер gRPC дожидается завершения текущих вызовов в пределах того же таймаута
+	stopped := make(chan struct{})
+	go func() {
+		grpcSrv.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctxTime.Done():
+		grpcSrv.Stop()
+	}
+
 	// Ожидание завершения работы горутин
 	wg.Wait()
 
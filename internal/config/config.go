@@ -3,7 +3,12 @@ package config
 import "C"
 import (
 	"flag"
+	"fmt"
 	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+	"time"
 
 	"github.com/caarlos0/env/v6"
 )
@@ -15,17 +20,218 @@ type HTTPServer struct {
 	LogLevel    slog.Level `json:"log_level" env:"LOG_LEVEL"`
 	Accrual     string     `json:"accrual" env:"ACCRUAL_SYSTEM_ADDRESS" envDefault:"http://localhost:8081"`
 	ProjectRoot string     `json:"projectRoot" env:"PROJECT ROOT" envDefault:"/Users/nextbug/GoProjects/gomart/"`
+	// GRPCHost - адрес сервера gRPC API; по умолчанию не задан, и сервер не запускается
+	GRPCHost string `json:"grpc_host" env:"GRPC_ADDRESS"`
+	// GRPCTLSCert, GRPCTLSKey - сертификат и закрытый ключ TLS сервера gRPC API в формате PEM
+	GRPCTLSCert string `json:"grpc_tls_cert" env:"GRPC_TLS_CERT"`
+	GRPCTLSKey  string `json:"grpc_tls_key" env:"GRPC_TLS_KEY"`
+	// IdempotencyTTL - время хранения ответов на запросы с заголовком Idempotency-Key
+	IdempotencyTTL time.Duration `json:"idempotency_ttl" env:"IDEMPOTENCY_TTL" envDefault:"24h"`
+	// Tiers - уровни программы лояльности с порогами и множителями начислений
+	Tiers Tiers `json:"tiers" env:"LOYALTY_TIERS" envDefault:"SILVER:1000:1.05,GOLD:5000:1.1,PLATINUM:20000:1.2"`
+	// TierInterval - период пересчета уровней программы лояльности
+	TierInterval time.Duration `json:"tier_interval" env:"TIER_INTERVAL" envDefault:"1h"`
+	// AdminToken - токен для назначения роли первому администратору; по умолчанию не задан и не принимается
+	AdminToken string `json:"admin_token" env:"ADMIN_TOKEN"`
+	// KeyringFile - файл с ключами подписи токенов доступа; если не задан, токены подписываются SecretToken
+	KeyringFile string `json:"keyring_file" env:"KEYRING_FILE"`
+	// APIKeyRateLimit - ограничение запросов в минуту для API-ключей, созданных без явного ограничения
+	APIKeyRateLimit int `json:"api_key_rate_limit" env:"API_KEY_RATE_LIMIT" envDefault:"600"`
+	// AccessTokenTTL - время жизни токена доступа
+	AccessTokenTTL time.Duration `json:"access_token_ttl" env:"ACCESS_TOKEN_TTL" envDefault:"15m"`
+	// RefreshTokenTTL - время жизни refresh-токена
+	RefreshTokenTTL time.Duration `json:"refresh_token_ttl" env:"REFRESH_TOKEN_TTL" envDefault:"720h"`
+	// PasswordHash - алгоритм хеширования паролей: argon2id или bcrypt
+	PasswordHash string `json:"password_hash" env:"PASSWORD_HASH" envDefault:"argon2id"`
+	// PasswordPolicy - требования к паролям пользователей
+	PasswordPolicy PasswordPolicy `json:"password_policy"`
+	// TOTPIssuer - название сервиса в приложении-аутентификаторе двухфакторной аутентификации
+	TOTPIssuer string `json:"totp_issuer" env:"TOTP_ISSUER" envDefault:"GopherMart"`
+	// PasswordResetTTL - время действия токена сброса пароля
+	PasswordResetTTL time.Duration `json:"password_reset_ttl" env:"PASSWORD_RESET_TTL" envDefault:"1h"`
+	// Notifier - способ доставки уведомлений пользователям: none, log или file; log и file - только для разработки
+	Notifier string `json:"notifier" env:"NOTIFIER" envDefault:"none"`
+	// NotifyFile - файл для доставки уведомлений способом file
+	NotifyFile string `json:"notify_file" env:"NOTIFY_FILE"`
+	// WithdrawLimits - ограничения на списание баллов
+	WithdrawLimits WithdrawLimits `json:"withdraw_limits"`
+	// LoginThrottle - защита входа от подбора пароля
+	LoginThrottle LoginThrottle `json:"login_throttle"`
+	// OIDC - вход через внешний поставщик удостоверений OpenID Connect
+	OIDC OIDC `json:"oidc"`
+	// Cookies - атрибуты безопасности кук аутентификации и защита от CSRF
+	Cookies Cookies `json:"cookies"`
+	// Events - доставка событий пользователям в реальном времени
+	Events Events `json:"events"`
+}
+
+// Events содержит параметры доставки событий об изменении заказов и баланса в реальном времени.
+type Events struct {
+	History   int           `json:"history" env:"EVENTS_HISTORY" envDefault:"1000"`    // Последних событий, хранимых для возобновления подписки
+	Buffer    int           `json:"buffer" env:"EVENTS_BUFFER" envDefault:"64"`        // Неотправленных событий, после которых медленный подписчик отключается
+	Heartbeat time.Duration `json:"heartbeat" env:"EVENTS_HEARTBEAT" envDefault:"15s"` // Интервал отправки комментария, поддерживающего соединение
+	// Интервал проверки, не отозван ли токен доступа, с которым открыто соединение WebSocket
+	RevocationCheck time.Duration `json:"revocation_check" env:"EVENTS_REVOCATION_CHECK" envDefault:"30s"`
+}
+
+// OIDCStubPath - путь, по которому подключается локальный поставщик удостоверений в режиме OIDC_STUB
+const OIDCStubPath = "/oidc/stub"
+
+// OIDC содержит параметры входа через внешний поставщик удостоверений OpenID Connect.
+// Пустой Issuer без режима Stub отключает вход.
+type OIDC struct {
+	Issuer       string `json:"issuer" env:"OIDC_ISSUER"`                                           // Идентификатор поставщика удостоверений
+	ClientID     string `json:"client_id" env:"OIDC_CLIENT_ID"`                                     // Идентификатор клиента у поставщика
+	ClientSecret string `json:"client_secret,omitempty" env:"OIDC_CLIENT_SECRET"`                   // Секрет клиента; пустое значение для публичных клиентов
+	RedirectURL  string `json:"redirect_url" env:"OIDC_REDIRECT_URL"`                               // Адрес возврата /api/user/oidc/callback, зарегистрированный у поставщика
+	LoginClaim   string `json:"login_claim" env:"OIDC_LOGIN_CLAIM" envDefault:"preferred_username"` // Клейм ID-токена с логином пользователя; с существующим пользователем связывается только email
+	Stub         bool   `json:"stub" env:"OIDC_STUB"`                                               // Локальный поставщик удостоверений для разработки и тестов
+}
+
+// Enabled сообщает, настроен ли вход через поставщик удостоверений.
+func (o OIDC) Enabled() bool {
+	return o.Issuer != ""
+}
+
+// withStubDefaults заполняет в режиме Stub незаданные параметры адресами локального поставщика удостоверений
+// и обработчика возврата на сервере host.
+func (o OIDC) withStubDefaults(host string) OIDC {
+	if !o.Stub {
+		return o
+	}
+	base := "http://" + host
+	if strings.HasPrefix(host, ":") {
+		base = "http://localhost" + host
+	}
+	if o.Issuer == "" {
+		o.Issuer = base + OIDCStubPath
+	}
+	if o.ClientID == "" {
+		o.ClientID = "gophermart"
+	}
+	if o.RedirectURL == "" {
+		o.RedirectURL = base + "/api/user/oidc/callback"
+	}
+	return o
+}
+
+// LoginThrottle содержит параметры защиты входа от подбора пароля.
+// Неудачные попытки считаются отдельно для логина и для IP-адреса клиента.
+type LoginThrottle struct {
+	MaxFailures   int           `json:"max_failures" env:"LOGIN_MAX_FAILURES" envDefault:"5"`        // Неудачных попыток для логина до блокировки
+	IPMaxFailures int           `json:"ip_max_failures" env:"LOGIN_IP_MAX_FAILURES" envDefault:"50"` // Неудачных попыток с IP-адреса до блокировки
+	BaseDelay     time.Duration `json:"base_delay" env:"LOGIN_BASE_DELAY" envDefault:"1s"`           // Задержка после первой неудачной попытки, удваивается с каждой следующей
+	Lockout       time.Duration `json:"lockout" env:"LOGIN_LOCKOUT" envDefault:"15m"`                // Время блокировки и максимальная задержка
+	Window        time.Duration `json:"window" env:"LOGIN_FAILURE_WINDOW" envDefault:"1h"`           // Время без неудачных попыток, после которого счетчик сбрасывается
+}
+
+// PasswordPolicy содержит требования к паролям пользователей. Нулевое значение длины отключает правило.
+type PasswordPolicy struct {
+	MinLength       int    `json:"min_length" env:"PASSWORD_MIN_LENGTH" envDefault:"8"`              // Минимальная длина пароля
+	MaxLength       int    `json:"max_length" env:"PASSWORD_MAX_LENGTH" envDefault:"72"`             // Максимальная длина пароля
+	RequireUpper    bool   `json:"require_upper" env:"PASSWORD_REQUIRE_UPPER"`                       // Обязательна заглавная буква
+	RequireLower    bool   `json:"require_lower" env:"PASSWORD_REQUIRE_LOWER"`                       // Обязательна строчная буква
+	RequireDigit    bool   `json:"require_digit" env:"PASSWORD_REQUIRE_DIGIT"`                       // Обязательна цифра
+	RequireSymbol   bool   `json:"require_symbol" env:"PASSWORD_REQUIRE_SYMBOL"`                     // Обязателен специальный символ
+	DenylistFile    string `json:"denylist_file" env:"PASSWORD_DENYLIST_FILE"`                       // Файл запрещенных паролей в дополнение к встроенному списку
+	GeneratedLength int    `json:"generated_length" env:"PASSWORD_GENERATED_LENGTH" envDefault:"16"` // Длина пароля, генерируемого при регистрации без пароля
+}
+
+// WithdrawLimits содержит правила, проверяемые перед списанием баллов. Нулевое значение отключает правило.
+type WithdrawLimits struct {
+	MaxSum          float32       `json:"max_sum" env:"WITHDRAW_MAX_SUM"`                                    // Максимальная сумма одного списания
+	MaxDailySum     float32       `json:"max_daily_sum" env:"WITHDRAW_MAX_DAILY_SUM"`                        // Максимальная сумма списаний за 24 часа
+	MaxHourlyCount  int           `json:"max_hourly_count" env:"WITHDRAW_MAX_HOURLY_COUNT"`                  // Максимальное количество списаний за час
+	PasswordCooloff time.Duration `json:"password_cooloff" env:"WITHDRAW_PASSWORD_COOLOFF" envDefault:"24h"` // Запрет списаний после смены пароля
+	StepUpSum       float32       `json:"step_up_sum" env:"WITHDRAW_STEP_UP_SUM" envDefault:"1000"`          // Сумма списания, выше которой требуется одноразовый код
 }
 
 var Cfg HTTPServer
 
+// MakeConfig заполняет Cfg значениями по умолчанию, переменными окружения и флагами запуска.
+// Переменные окружения имеют наибольший приоритет, флаги - приоритет над значениями по умолчанию.
+// Значения envDefault становятся значениями флагов по умолчанию, а после разбора флагов применяются только
+// заданные переменные окружения, поэтому envDefault не перезаписывают явно указанные флаги.
 func MakeConfig() error {
+	if err := env.Parse(&Cfg, env.Options{Environment: map[string]string{}}); err != nil {
+		return err
+	}
 	flag.StringVar(&Cfg.Host, "a", Cfg.Host, "Host HTTP-server")
+	flag.StringVar(&Cfg.GRPCHost, "g", Cfg.GRPCHost, "Host gRPC-server")
+	flag.StringVar(&Cfg.GRPCTLSCert, "gc", Cfg.GRPCTLSCert, "gRPC-server TLS certificate file")
+	flag.StringVar(&Cfg.GRPCTLSKey, "gk", Cfg.GRPCTLSKey, "gRPC-server TLS key file")
 	flag.StringVar(&Cfg.DSN, "d", Cfg.DSN, "Connect to database")
 	flag.StringVar(&Cfg.SecretToken, "k", Cfg.SecretToken, "Secret key for the token")
 	flag.Var(&LogLevelValue{&Cfg.LogLevel}, "l", "Log level (debug, info, warn, error)")
 	flag.StringVar(&Cfg.Accrual, "r", Cfg.Accrual, "Accrual system address")
 	flag.StringVar(&Cfg.ProjectRoot, "p", Cfg.ProjectRoot, "Path to the current project")
+	flag.DurationVar(&Cfg.IdempotencyTTL, "i", Cfg.IdempotencyTTL, "Idempotency-Key response TTL")
+	flag.Var(&Cfg.Tiers, "t", "Loyalty tiers (NAME:THRESHOLD:MULTIPLIER,...)")
+	flag.DurationVar(&Cfg.TierInterval, "ti", Cfg.TierInterval, "Loyalty tiers recalculation interval")
+	flag.StringVar(&Cfg.PasswordHash, "ph", Cfg.PasswordHash, "Password hash algorithm (argon2id, bcrypt)")
+	flag.StringVar(&Cfg.KeyringFile, "kf", Cfg.KeyringFile, "Signing keys file")
+	flag.DurationVar(&Cfg.AccessTokenTTL, "at", Cfg.AccessTokenTTL, "Access token TTL")
+	flag.DurationVar(&Cfg.RefreshTokenTTL, "rt", Cfg.RefreshTokenTTL, "Refresh token TTL")
 	flag.Parse()
-	return env.Parse(&Cfg)
+	if err := parseEnv(&Cfg); err != nil {
+		return err
+	}
+	Cfg.OIDC = Cfg.OIDC.withStubDefaults(Cfg.Host)
+	if err := Cfg.validateTierInterval(); err != nil {
+		return err
+	}
+	if err := Cfg.validateGRPC(); err != nil {
+		return err
+	}
+	return Cfg.Cookies.validate()
+}
+
+// parseEnv применяет к cfg значения заданных переменных окружения; поля, для которых переменная
+// не задана, сохраняют текущие значения, в том числе значения флагов.
+func parseEnv(cfg *HTTPServer) error {
+	var fromEnv HTTPServer
+	set := make(map[string]bool)
+	err := env.Parse(&fromEnv, env.Options{OnSet: func(key string, _ interface{}, _ bool) {
+		if _, ok := os.LookupEnv(key); ok {
+			set[key] = true
+		}
+	}})
+	if err != nil {
+		return err
+	}
+	copyEnvFields(reflect.ValueOf(cfg).Elem(), reflect.ValueOf(&fromEnv).Elem(), set)
+	return nil
+}
+
+// copyEnvFields копирует из src в dst поля, переменные окружения которых входят в set,
+// включая поля вложенных структур.
+func copyEnvFields(dst, src reflect.Value, set map[string]bool) {
+	for i := 0; i < dst.NumField(); i++ {
+		field := dst.Type().Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("env"), ",")
+		switch {
+		case key != "":
+			if set[key] {
+				dst.Field(i).Set(src.Field(i))
+			}
+		case field.IsExported() && field.Type.Kind() == reflect.Struct:
+			copyEnvFields(dst.Field(i), src.Field(i), set)
+		}
+	}
+}
+
+// validateTierInterval проверяет, что период пересчета уровней программы лояльности положителен.
+func (c HTTPServer) validateTierInterval() error {
+	if c.TierInterval <= 0 {
+		return fmt.Errorf("TIER_INTERVAL must be positive, got %s", c.TierInterval)
+	}
+	return nil
+}
+
+// validateGRPC проверяет, что сертификат и ключ TLS сервера gRPC API заданы вместе.
+func (c HTTPServer) validateGRPC() error {
+	if (c.GRPCTLSCert == "") != (c.GRPCTLSKey == "") {
+		return fmt.Errorf("GRPC_TLS_CERT and GRPC_TLS_KEY must be set together")
+	}
+	return nil
 }
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMakeConfigPrecedence(t *testing.T) {
+	args, cfg := os.Args, Cfg
+	t.Cleanup(func() {
+		os.Args, Cfg = args, cfg
+		flag.CommandLine = flag.NewFlagSet(args[0], flag.ExitOnError)
+	})
+	flag.CommandLine = flag.NewFlagSet("gophermart", flag.ContinueOnError)
+	os.Args = []string{"gophermart", "-a", ":9090", "-t", "GOLD:10:2", "-ti", "5m", "-at", "1m"}
+	t.Setenv("RUN_ADDRESS", ":8000")
+	t.Setenv("TIER_INTERVAL", "10m")
+	t.Setenv("REFRESH_TOKEN_TTL", "2h")
+
+	require.NoError(t, MakeConfig())
+	// Переменная окружения важнее флага
+	assert.Equal(t, ":8000", Cfg.Host)
+	assert.Equal(t, 10*time.Minute, Cfg.TierInterval)
+	// Флаг без переменной окружения важнее значения по умолчанию
+	assert.Equal(t, Tiers{{Name: "GOLD", Threshold: 10, Multiplier: 2}}, Cfg.Tiers)
+	assert.Equal(t, time.Minute, Cfg.AccessTokenTTL)
+	// Без флага используется переменная окружения, без нее - значение по умолчанию
+	assert.Equal(t, 2*time.Hour, Cfg.RefreshTokenTTL)
+	assert.Equal(t, 24*time.Hour, Cfg.IdempotencyTTL)
+	assert.Equal(t, 15*time.Second, Cfg.Events.Heartbeat)
+	// Сервер gRPC API запускается, только если его адрес задан явно
+	assert.Empty(t, Cfg.GRPCHost)
+}
+
+func TestMakeConfigTierInterval(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		env     string
+		wantErr bool
+	}{
+		{name: "Default"},
+		{name: "Zero flag", args: []string{"-ti", "0"}, wantErr: true},
+		{name: "Negative variable", env: "-1m", wantErr: true},
+		{name: "Positive variable", args: []string{"-ti", "0"}, env: "30m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, cfg := os.Args, Cfg
+			t.Cleanup(func() {
+				os.Args, Cfg = args, cfg
+				flag.CommandLine = flag.NewFlagSet(args[0], flag.ExitOnError)
+			})
+			flag.CommandLine = flag.NewFlagSet("gophermart", flag.ContinueOnError)
+			os.Args = append([]string{"gophermart"}, tt.args...)
+			if tt.env != "" {
+				t.Setenv("TIER_INTERVAL", tt.env)
+			}
+
+			err := MakeConfig()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Positive(t, Cfg.TierInterval)
+		})
+	}
+}
+
+func TestValidateGRPC(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     HTTPServer
+		wantErr bool
+	}{
+		{
+			name: "Disabled",
+		},
+		{
+			name: "Plaintext",
+			cfg:  HTTPServer{GRPCHost: ":3200"},
+		},
+		{
+			name: "TLS",
+			cfg:  HTTPServer{GRPCHost: ":3200", GRPCTLSCert: "cert.pem", GRPCTLSKey: "key.pem"},
+		},
+		{
+			name:    "Certificate without key",
+			cfg:     HTTPServer{GRPCHost: ":3200", GRPCTLSCert: "cert.pem"},
+			wantErr: true,
+		},
+		{
+			name:    "Key without certificate",
+			cfg:     HTTPServer{GRPCHost: ":3200", GRPCTLSKey: "key.pem"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.validateGRPC()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Cookies содержит атрибуты безопасности кук аутентификации.
+type Cookies struct {
+	Secure   bool     `json:"secure" env:"COOKIE_SECURE" envDefault:"true"`     // Куки отправляются только по HTTPS
+	SameSite SameSite `json:"same_site" env:"COOKIE_SAMESITE" envDefault:"lax"` // Атрибут SameSite: lax, strict или none
+	Domain   string   `json:"domain" env:"COOKIE_DOMAIN"`                       // Домен кук; пустое значение - только текущий хост
+	CSRF     bool     `json:"csrf" env:"CSRF_PROTECTION" envDefault:"true"`     // Проверка CSRF-токена в изменяющих запросах с кукой
+}
+
+// validate проверяет совместимость атрибутов: браузеры отклоняют куки SameSite=None без Secure.
+func (c Cookies) validate() error {
+	if http.SameSite(c.SameSite) == http.SameSiteNoneMode && !c.Secure {
+		return fmt.Errorf("COOKIE_SAMESITE=none requires COOKIE_SECURE=true")
+	}
+	return nil
+}
+
+// SameSite реализует интерфейсы flag.Value и encoding.TextUnmarshaler для атрибута SameSite кук.
+type SameSite http.SameSite
+
+var sameSiteMap = map[string]http.SameSite{
+	"lax":    http.SameSiteLaxMode,
+	"strict": http.SameSiteStrictMode,
+	"none":   http.SameSiteNoneMode,
+}
+
+func (s *SameSite) String() string {
+	if s == nil {
+		return ""
+	}
+	for name, mode := range sameSiteMap {
+		if mode == http.SameSite(*s) {
+			return name
+		}
+	}
+	return ""
+}
+
+func (s *SameSite) Set(value string) error {
+	mode, found := sameSiteMap[strings.ToLower(strings.TrimSpace(value))]
+	if !found {
+		return fmt.Errorf("invalid SameSite mode %q: expected lax, strict or none", value)
+	}
+	*s = SameSite(mode)
+	return nil
+}
+
+func (s *SameSite) UnmarshalText(text []byte) error {
+	return s.Set(string(text))
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BaseTier - уровень пользователя, не достигшего ни одного порога из конфигурации
+const BaseTier = "BASE"
+
+// Tier описывает уровень программы лояльности.
+type Tier struct {
+	Name       string  `json:"name"`
+	Threshold  float32 `json:"threshold"`  // Порог баллов, начисленных за последние 12 месяцев
+	Multiplier float32 `json:"multiplier"` // Множитель начисления
+}
+
+// Tiers реализует интерфейсы flag.Value и encoding.TextUnmarshaler для списка уровней
+// в формате "SILVER:1000:1.05,GOLD:5000:1.1,PLATINUM:20000:1.2".
+type Tiers []Tier
+
+func (t *Tiers) String() string {
+	if t == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(*t))
+	for _, tier := range *t {
+		parts = append(parts, fmt.Sprintf("%s:%s:%s", tier.Name,
+			strconv.FormatFloat(float64(tier.Threshold), 'f', -1, 32),
+			strconv.FormatFloat(float64(tier.Multiplier), 'f', -1, 32)))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (t *Tiers) Set(value string) error {
+	var tiers Tiers
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		fields := strings.Split(part, ":")
+		if len(fields) != 3 || fields[0] == "" {
+			return fmt.Errorf("invalid tier %q: expected NAME:THRESHOLD:MULTIPLIER", part)
+		}
+		threshold, err := strconv.ParseFloat(fields[1], 32)
+		if err != nil || threshold < 0 {
+			return fmt.Errorf("invalid tier threshold %q", fields[1])
+		}
+		multiplier, err := strconv.ParseFloat(fields[2], 32)
+		if err != nil || multiplier <= 0 {
+			return fmt.Errorf("invalid tier multiplier %q", fields[2])
+		}
+		tiers = append(tiers, Tier{
+			Name:       strings.ToUpper(fields[0]),
+			Threshold:  float32(threshold),
+			Multiplier: float32(multiplier),
+		})
+	}
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].Threshold < tiers[j].Threshold })
+	*t = tiers
+	return nil
+}
+
+func (t *Tiers) UnmarshalText(text []byte) error {
+	return t.Set(string(text))
+}
+
+// ForPoints возвращает наивысший уровень, порог которого не превышает количество баллов.
+func (t Tiers) ForPoints(points float32) Tier {
+	current := Tier{Name: BaseTier, Multiplier: 1}
+	for _, tier := range t {
+		if points >= tier.Threshold {
+			current = tier
+		}
+	}
+	return current
+}
+
+// ByName возвращает уровень по имени; неизвестное имя соответствует базовому уровню.
+func (t Tiers) ByName(name string) Tier {
+	for _, tier := range t {
+		if tier.Name == name {
+			return tier
+		}
+	}
+	return Tier{Name: BaseTier, Multiplier: 1}
+}
+
+// Next возвращает уровень, следующий за указанным, и false, если указанный уровень наивысший.
+func (t Tiers) Next(name string) (Tier, bool) {
+	next := 0
+	for i, tier := range t {
+		if tier.Name == name {
+			next = i + 1
+		}
+	}
+	if next >= len(t) {
+		return Tier{}, false
+	}
+	return t[next], true
+}
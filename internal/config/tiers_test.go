@@ -0,0 +1,72 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTiersSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    Tiers
+		wantErr bool
+	}{
+		{
+			name:  "Sorted by threshold",
+			value: "gold:5000:1.1, SILVER:1000:1.05",
+			want:  Tiers{{Name: "SILVER", Threshold: 1000, Multiplier: 1.05}, {Name: "GOLD", Threshold: 5000, Multiplier: 1.1}},
+		},
+		{
+			name:  "Empty",
+			value: "",
+		},
+		{
+			name:    "Missing multiplier",
+			value:   "SILVER:1000",
+			wantErr: true,
+		},
+		{
+			name:    "Negative threshold",
+			value:   "SILVER:-1:1.05",
+			wantErr: true,
+		},
+		{
+			name:    "Zero multiplier",
+			value:   "SILVER:1000:0",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tiers Tiers
+			err := tiers.Set(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, tiers)
+		})
+	}
+}
+
+func TestTiersLookup(t *testing.T) {
+	var tiers Tiers
+	require.NoError(t, tiers.Set("SILVER:1000:1.05,GOLD:5000:1.1"))
+
+	assert.Equal(t, BaseTier, tiers.ForPoints(999).Name)
+	assert.Equal(t, "SILVER", tiers.ForPoints(1000).Name)
+	assert.Equal(t, "GOLD", tiers.ForPoints(20000).Name)
+
+	assert.Equal(t, float32(1.1), tiers.ByName("GOLD").Multiplier)
+	assert.Equal(t, float32(1), tiers.ByName("UNKNOWN").Multiplier)
+
+	next, ok := tiers.Next(BaseTier)
+	assert.True(t, ok)
+	assert.Equal(t, "SILVER", next.Name)
+	_, ok = tiers.Next("GOLD")
+	assert.False(t, ok)
+}
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/nextlag/gomart/internal/mw/auth"
+	"github.com/nextlag/gomart/internal/problem"
+	"github.com/nextlag/gomart/internal/usecase"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+// accountDelete - структура используемая для анализа json-запроса на удаление учетной записи.
+type accountDelete struct {
+	Password string `json:"password"`
+	OTP      string `json:"otp,omitempty"` // Одноразовый код, если подключена двухфакторная аутентификация
+}
+
+// ExportAccount обрабатывает запрос на выгрузку персональных данных пользователя.
+//
+// Этот метод принимает запрос HTTP GET от аутентифицированного пользователя и возвращает все его данные:
+// учетную запись, заказы, списания, корректировки баланса, сеансы и связанные учетные записи поставщиков
+// удостоверений - в виде файла JSON со статусом OK (200).
+// Если пользователь не найден, метод возвращает ошибку NotFound (404).
+// В случае любых других ошибок метод возвращает ошибку InternalServerError (500).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) ExportAccount(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+	// Получаем логин пользователя из контекста запроса
+	user, _ := r.Context().Value(auth.LoginKey).(string)
+
+	export, err := c.uc.DoExportAccount(r.Context(), user)
+	switch {
+	case errors.Is(err, er.ErrUserNotFound):
+		problem.Write(w, r, er.ErrUserNotFound)
+		return
+	case err != nil:
+		log.Error("export account handler", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+	log.Info("account exported", "user", user)
+
+	// Выгрузка сохраняется браузером как файл и не должна сохраняться в кешах
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="gophermart-export-%s.json"`,
+		export.ExportedAt.Format("20060102-150405")))
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, export)
+}
+
+// DeleteAccount обрабатывает запрос на удаление учетной записи пользователя.
+//
+// Этот метод принимает запрос HTTP DELETE с JSON-данными {"password": "...", "otp": "..."}, содержащими текущий
+// пароль и, если подключена двухфакторная аутентификация, одноразовый код. Удаление безвозвратно: логин
+// в финансовых записях обезличивается, учетные данные удаляются, все сеансы и токены пользователя отзываются.
+// При успешном удалении метод удаляет куки аутентификации и возвращает статус NoContent (204).
+// Если JSON некорректен или пароль пуст, метод возвращает ошибку BadRequest (400).
+// Если пароль или одноразовый код неверен либо код не передан, метод возвращает ошибку Forbidden (403),
+// а после превышения количества неверных попыток - TooManyRequests (429) с заголовком Retry-After.
+// В случае любых других ошибок метод возвращает ошибку InternalServerError (500).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+	// Получаем клеймы токена доступа из контекста запроса
+	claims, _ := r.Context().Value(auth.ClaimsKey).(*auth.Claims)
+	if claims == nil {
+		problem.Write(w, r, er.ErrUnAuthUser)
+		return
+	}
+
+	var request accountDelete
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil || request.Password == "" {
+		problem.Write(w, r, er.ErrRequestFormat)
+		return
+	}
+
+	err := c.uc.DoDeleteAccount(r.Context(), claims.Login, request.Password, request.OTP, r)
+	var throttleErr *usecase.ThrottleError
+	switch {
+	case errors.As(err, &throttleErr):
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttleErr.RetryAfter.Seconds()))))
+		problem.Write(w, r, er.ErrTooManyAttempts)
+		return
+	case errors.Is(err, er.ErrPassword), errors.Is(err, er.ErrOTPRequired), errors.Is(err, er.ErrOTPCode):
+		log.Error("delete account confirmation", "user", claims.Login, l.ErrAttr(err))
+		problem.WriteStatus(w, r, http.StatusForbidden, err)
+		return
+	case err != nil:
+		log.Error("delete account handler", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+
+	// Учетная запись уже удалена, поэтому ошибка отзыва токена доступа не отменяет ответ:
+	// токен отклоняется и по отозванному сеансу
+	if err = c.uc.DoRevokeAccessToken(r.Context(), claims.ID, claims.ExpiresAt.Time); err != nil {
+		log.Error("delete account: revoke access token", l.ErrAttr(err))
+	}
+	auth.ClearAuth(w)
+	log.Info("account deleted", "user", claims.Login)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/nextlag/gomart/internal/mw/auth"
+	"github.com/nextlag/gomart/internal/problem"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+// Adjustments обрабатывает запрос на получение истории ручных корректировок и промо-начислений пользователя.
+//
+// Этот метод принимает запрос HTTP GET и возвращает историю корректировок в формате JSON и статус OK (200).
+// Если происходит ошибка при получении истории из UseCase, метод возвращает ошибку InternalServerError (500).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) Adjustments(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+	// Получаем логин пользователя из контекста запроса
+	login, _ := r.Context().Value(auth.LoginKey).(string)
+
+	adjustments, err := c.uc.DoGetAdjustments(r.Context(), login)
+	if err != nil {
+		log.Error("adjustments handler", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, adjustments)
+}
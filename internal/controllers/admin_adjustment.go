@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/nextlag/gomart/internal/entity"
+	"github.com/nextlag/gomart/internal/mw/auth"
+	"github.com/nextlag/gomart/internal/problem"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+// adminActor возвращает автора действия, выполненного через маршруты администратора, - логин из токена.
+func adminActor(r *http.Request) string {
+	if login, ok := r.Context().Value(auth.LoginKey).(string); ok && login != "" {
+		return login
+	}
+	return auth.AdminLogin
+}
+
+// adjustment - структура используемая для анализа json-запроса на ручную корректировку баланса.
+type adjustment struct {
+	Login  string  `json:"login"`
+	Amount float32 `json:"amount"`
+	Reason string  `json:"reason"`
+}
+
+// AdminAdjustBalance обрабатывает запрос администратора на ручное начисление или списание баллов.
+//
+// Этот метод принимает запрос HTTP POST с JSON-данными, содержащими логин пользователя, сумму и причину.
+// Положительная сумма начисляется на баланс, отрицательная - списывается с него. Причина обязательна.
+// При успешной корректировке метод возвращает сохраненную корректировку в формате JSON и статус OK (200).
+// Если JSON некорректен, не указана причина или сумма равна нулю, метод возвращает ошибку BadRequest (400).
+// Если пользователь не найден, метод возвращает ошибку NotFound (404).
+// Если списание превышает баланс пользователя, метод возвращает ошибку PaymentRequired (402).
+// В случае любых других ошибок метод возвращает ошибку InternalServerError (500).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) AdminAdjustBalance(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+
+	var request adjustment
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		log.Error("decode JSON", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrDecodeJSON)
+		return
+	}
+
+	adj, err := c.uc.DoAdjustBalance(r.Context(), entity.Adjustment{
+		Login:     request.Login,
+		Amount:    request.Amount,
+		Reason:    request.Reason,
+		CreatedBy: adminActor(r),
+	})
+	switch {
+	case errors.Is(err, er.ErrReason), errors.Is(err, er.ErrRequestFormat):
+		problem.Write(w, r, err)
+		return
+	case errors.Is(err, er.ErrUserNotFound):
+		problem.Write(w, r, er.ErrUserNotFound)
+		return
+	case errors.Is(err, er.ErrNoBalance):
+		problem.Write(w, r, er.ErrNoBalance)
+		return
+	case err != nil:
+		log.Error("admin adjust balance handler", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+	log.Info("balance adjusted", "login", adj.Login, "amount", adj.Amount, "reason", adj.Reason)
+
+	writeJSON(w, r, http.StatusOK, adj)
+}
+
+// writeJSON кодирует значение в JSON и записывает его в ответ на запрос r с указанным статусом.
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	result, err := json.Marshal(v)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(result)
+}
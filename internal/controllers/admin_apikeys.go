@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/nextlag/gomart/internal/entity"
+	"github.com/nextlag/gomart/internal/problem"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+// apiKeyRequest - структура используемая для анализа json-запроса на создание API-ключа.
+type apiKeyRequest struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	RateLimit int      `json:"rate_limit"`
+}
+
+// AdminCreateAPIKey обрабатывает запрос администратора на создание API-ключа сервисной учетной записи.
+//
+// Этот метод принимает запрос HTTP POST с JSON-данными ключа: name, scopes (orders:write, orders:read, balance:read,
+// balance:withdraw) и rate_limit (запросов в минуту; если не задано, используется значение из конфигурации).
+// При успешном создании метод возвращает ключ в формате JSON и статус Created (201).
+// Ключ целиком возвращается только в этом ответе, в базе данных хранится его хеш.
+// Если данные ключа некорректны или область действия неизвестна, метод возвращает ошибку BadRequest (400).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) AdminCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+
+	var request apiKeyRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		log.Error("decode JSON", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrDecodeJSON)
+		return
+	}
+
+	key, err := c.uc.DoCreateAPIKey(r.Context(), entity.APIKey{
+		Name:      request.Name,
+		Scopes:    request.Scopes,
+		RateLimit: request.RateLimit,
+		CreatedBy: adminActor(r),
+	})
+	switch {
+	case errors.Is(err, er.ErrAPIScope), errors.Is(err, er.ErrRequestFormat):
+		problem.Write(w, r, err)
+		return
+	case err != nil:
+		log.Error("admin create API key handler", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+	log.Info("API key created", "id", key.ID, "name", key.Name, "scopes", key.Scopes, "by", key.CreatedBy)
+
+	// Ответ с ключом не должен сохраняться в кешах
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusCreated, key)
+}
+
+// AdminAPIKeys обрабатывает запрос администратора на получение списка API-ключей.
+//
+// Этот метод принимает запрос HTTP GET и возвращает список ключей, включая отозванные, без самих ключей
+// в формате JSON и статус OK (200).
+// Если происходит ошибка при получении списка, метод возвращает ошибку InternalServerError (500).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) AdminAPIKeys(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+
+	keys, err := c.uc.DoGetAPIKeys(r.Context())
+	if err != nil {
+		log.Error("admin API keys handler", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, keys)
+}
+
+// AdminRevokeAPIKey обрабатывает запрос администратора на отзыв API-ключа.
+//
+// Этот метод принимает запрос HTTP DELETE для ключа с идентификатором из пути запроса.
+// Запросы с отозванным ключом перестают приниматься сразу. При успешном отзыве метод возвращает статус NoContent (204).
+// Если ключ не найден или уже отозван, метод возвращает ошибку NotFound (404).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) AdminRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+	id := chi.URLParam(r, "id")
+
+	err := c.uc.DoRevokeAPIKey(r.Context(), id)
+	switch {
+	case errors.Is(err, er.ErrNoAPIKey):
+		problem.Write(w, r, er.ErrNoAPIKey)
+		return
+	case err != nil:
+		log.Error("admin revoke API key handler", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+	log.Info("API key revoked", "id", id, "by", adminActor(r))
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/nextlag/gomart/internal/entity"
+	"github.com/nextlag/gomart/internal/problem"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+// campaignUpdate - структура используемая для анализа json-запроса на включение или выключение промо-кампании.
+type campaignUpdate struct {
+	Active bool `json:"active"`
+}
+
+// campaignGrant - структура используемая для анализа json-запроса на начисление по промо-кампании.
+type campaignGrant struct {
+	Logins []string `json:"logins"`
+}
+
+// campaignGrantResult - результат начисления по промо-кампании.
+type campaignGrantResult struct {
+	Granted []string `json:"granted"`
+	Skipped int      `json:"skipped"`
+}
+
+// AdminCreateCampaign обрабатывает запрос администратора на создание промо-кампании.
+//
+// Этот метод принимает запрос HTTP POST с JSON-данными кампании: name, kind (welcome или bulk), amount, reason и active.
+// Кампания вида welcome начисляет баллы каждому новому пользователю при регистрации,
+// кампания вида bulk - пользователям из списка, переданного в AdminGrantCampaign.
+// При успешном создании метод возвращает кампанию в формате JSON и статус Created (201).
+// Если данные кампании некорректны, метод возвращает ошибку BadRequest (400).
+// Если кампания с таким именем уже существует, метод возвращает ошибку Conflict (409).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) AdminCreateCampaign(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+
+	var request entity.Campaign
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		log.Error("decode JSON", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrDecodeJSON)
+		return
+	}
+
+	campaign, err := c.uc.DoCreateCampaign(r.Context(), request)
+	switch {
+	case errors.Is(err, er.ErrCampaignKind), errors.Is(err, er.ErrReason), errors.Is(err, er.ErrRequestFormat):
+		problem.Write(w, r, err)
+		return
+	case errors.Is(err, er.ErrCampaignExists):
+		problem.Write(w, r, er.ErrCampaignExists)
+		return
+	case err != nil:
+		log.Error("admin create campaign handler", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+	log.Info("campaign created", "name", campaign.Name, "kind", campaign.Kind, "amount", campaign.Amount)
+
+	writeJSON(w, r, http.StatusCreated, campaign)
+}
+
+// AdminCampaigns обрабатывает запрос администратора на получение списка промо-кампаний.
+//
+// Этот метод принимает запрос HTTP GET и возвращает список кампаний в формате JSON и статус OK (200).
+// Если происходит ошибка при получении списка, метод возвращает ошибку InternalServerError (500).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) AdminCampaigns(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+
+	campaigns, err := c.uc.DoGetCampaigns(r.Context())
+	if err != nil {
+		log.Error("admin campaigns handler", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, campaigns)
+}
+
+// AdminUpdateCampaign обрабатывает запрос администратора на включение или выключение промо-кампании.
+//
+// Этот метод принимает запрос HTTP PATCH с JSON-данными {"active": bool} для кампании из пути запроса.
+// При успешном изменении метод возвращает статус OK (200).
+// Если JSON некорректен, метод возвращает ошибку BadRequest (400), если кампания не найдена - NotFound (404).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) AdminUpdateCampaign(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+	name := chi.URLParam(r, "name")
+
+	var request campaignUpdate
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		problem.Write(w, r, er.ErrDecodeJSON)
+		return
+	}
+
+	err := c.uc.DoSetCampaignActive(r.Context(), name, request.Active)
+	switch {
+	case errors.Is(err, er.ErrNoCampaign):
+		problem.Write(w, r, er.ErrNoCampaign)
+		return
+	case err != nil:
+		log.Error("admin update campaign handler", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+	log.Info("campaign updated", "name", name, "active", request.Active)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// AdminGrantCampaign обрабатывает запрос администратора на начисление баллов промо-кампании списку пользователей.
+//
+// Этот метод принимает запрос HTTP POST с JSON-данными {"logins": [...]} для активной кампании из пути запроса.
+// Каждый пользователь получает начисление по кампании не более одного раза, поэтому запрос можно безопасно повторять.
+// Метод возвращает в формате JSON логины, которым выполнено начисление, и количество пропущенных логинов
+// (уже получивших начисление или несуществующих) со статусом OK (200).
+// Если JSON некорректен, метод возвращает ошибку BadRequest (400),
+// если кампания не найдена или выключена - NotFound (404).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) AdminGrantCampaign(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+	name := chi.URLParam(r, "name")
+
+	var request campaignGrant
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || len(request.Logins) == 0 {
+		problem.Write(w, r, er.ErrRequestFormat)
+		return
+	}
+
+	granted, err := c.uc.DoGrantCampaign(r.Context(), name, request.Logins, adminActor(r))
+	switch {
+	case errors.Is(err, er.ErrNoCampaign):
+		problem.Write(w, r, er.ErrNoCampaign)
+		return
+	case err != nil:
+		log.Error("admin grant campaign handler", "granted", len(granted), l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+	log.Info("campaign granted", "name", name, "granted", len(granted), "requested", len(request.Logins))
+
+	writeJSON(w, r, http.StatusOK, campaignGrantResult{
+		Granted: granted,
+		Skipped: len(request.Logins) - len(granted),
+	})
+}
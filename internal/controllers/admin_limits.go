@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/nextlag/gomart/internal/entity"
+	"github.com/nextlag/gomart/internal/problem"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+// withdrawLimitsUpdate - структура используемая для анализа json-запроса на назначение ограничений на списание.
+type withdrawLimitsUpdate struct {
+	MaxSum         *float32 `json:"max_sum"`
+	MaxDailySum    *float32 `json:"max_daily_sum"`
+	MaxHourlyCount *int     `json:"max_hourly_count"`
+}
+
+// AdminWithdrawLimits обрабатывает запрос администратора на получение ограничений на списание, назначенных пользователю.
+//
+// Этот метод принимает запрос HTTP GET с логином пользователя в пути и возвращает в формате JSON
+// ограничения пользователя и статус OK (200). Отсутствующее поле означает общее ограничение из конфигурации.
+// Если пользователь не найден, метод возвращает ошибку NotFound (404).
+// В случае любых других ошибок метод возвращает ошибку InternalServerError (500).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) AdminWithdrawLimits(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+
+	limits, err := c.uc.DoGetWithdrawLimits(r.Context(), chi.URLParam(r, "login"))
+	switch {
+	case errors.Is(err, er.ErrUserNotFound):
+		problem.Write(w, r, er.ErrUserNotFound)
+		return
+	case err != nil:
+		log.Error("admin withdraw limits handler", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, limits)
+}
+
+// AdminSetWithdrawLimits обрабатывает запрос администратора на назначение пользователю ограничений на списание.
+//
+// Этот метод принимает запрос HTTP PUT с логином пользователя в пути и JSON-данными с полями max_sum,
+// max_daily_sum и max_hourly_count. Назначенные ограничения заменяют общие ограничения из конфигурации:
+// отсутствующее поле или null возвращает пользователю общее ограничение, нулевое значение отключает правило.
+// При успешном назначении метод возвращает сохраненные ограничения и статус OK (200).
+// Если JSON некорректен или значение отрицательное, метод возвращает ошибку BadRequest (400).
+// Если пользователь не найден, метод возвращает ошибку NotFound (404).
+// В случае любых других ошибок метод возвращает ошибку InternalServerError (500).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) AdminSetWithdrawLimits(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+	login := chi.URLParam(r, "login")
+
+	var request withdrawLimitsUpdate
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		log.Error("decode JSON", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrDecodeJSON)
+		return
+	}
+
+	limits, err := c.uc.DoSetWithdrawLimits(r.Context(), entity.WithdrawLimits{
+		Login:          login,
+		MaxSum:         request.MaxSum,
+		MaxDailySum:    request.MaxDailySum,
+		MaxHourlyCount: request.MaxHourlyCount,
+		UpdatedBy:      adminActor(r),
+	})
+	switch {
+	case errors.Is(err, er.ErrRequestFormat):
+		problem.Write(w, r, er.ErrRequestFormat)
+		return
+	case errors.Is(err, er.ErrUserNotFound):
+		problem.Write(w, r, er.ErrUserNotFound)
+		return
+	case err != nil:
+		log.Error("admin set withdraw limits handler", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+	log.Info("withdraw limits changed", "login", login, "by", limits.UpdatedBy)
+
+	writeJSON(w, r, http.StatusOK, limits)
+}
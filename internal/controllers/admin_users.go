@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/nextlag/gomart/internal/problem"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+// roleUpdate - структура используемая для анализа json-запроса на назначение роли.
+type roleUpdate struct {
+	Role string `json:"role"`
+}
+
+// AdminSetRole обрабатывает запрос администратора на назначение роли пользователю.
+//
+// Этот метод принимает запрос HTTP PUT с логином пользователя в пути и JSON-данными с ролью:
+// user, support, admin или service. При изменении роли все токены и сеансы пользователя
+// отзываются, и новая роль попадает в токен при следующем входе.
+// При успешном назначении метод возвращает статус NoContent (204).
+// Если JSON некорректен или роль неизвестна, метод возвращает ошибку BadRequest (400).
+// Если пользователь не найден, метод возвращает ошибку NotFound (404).
+// В случае любых других ошибок метод возвращает ошибку InternalServerError (500).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) AdminSetRole(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+	login := chi.URLParam(r, "login")
+
+	var request roleUpdate
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		log.Error("decode JSON", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrDecodeJSON)
+		return
+	}
+
+	err := c.uc.DoSetRole(r.Context(), login, request.Role)
+	switch {
+	case errors.Is(err, er.ErrRole):
+		problem.Write(w, r, er.ErrRole)
+		return
+	case errors.Is(err, er.ErrUserNotFound):
+		problem.Write(w, r, er.ErrUserNotFound)
+		return
+	case err != nil:
+		log.Error("admin set role handler", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+	log.Info("role changed", "login", login, "role", request.Role, "by", adminActor(r))
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/nextlag/gomart/internal/problem"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+// AdminWithdrawalViolations обрабатывает запрос администратора на получение журнала отклоненных списаний.
+//
+// Этот метод принимает запрос HTTP GET с необязательным параметром login и возвращает в формате JSON
+// последние списания, отклоненные правилами ограничения, с именем нарушенного правила и статус OK (200).
+// Если происходит ошибка при получении журнала, метод возвращает ошибку InternalServerError (500).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) AdminWithdrawalViolations(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+
+	violations, err := c.uc.DoGetWithdrawalViolations(r.Context(), r.URL.Query().Get("login"))
+	if err != nil {
+		log.Error("admin withdrawal violations handler", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, violations)
+}
@@ -2,22 +2,32 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 
-	"github.com/nextlag/gomart/internal/mw/auth"
+	"github.com/nextlag/gomart/internal/entity"
+	"github.com/nextlag/gomart/internal/problem"
+	"github.com/nextlag/gomart/internal/usecase"
 	"github.com/nextlag/gomart/pkg/logger/l"
 )
 
 // Authentication обрабатывает запрос на аутентификацию пользователя.
 //
 // Этот метод принимает запрос HTTP POST, содержащий JSON с данными пользователя.
-// При успешной аутентификации метод устанавливает аутентификационный токен в куки и возвращает
-// статус OK (200) с сообщением об успешной аутентификации.
+// При успешной аутентификации метод устанавливает токен доступа и refresh-токен в куки и возвращает
+// статус OK (200) с токеном доступа в заголовке Authorization и в теле ответа в формате JSON.
 // В случае, если происходит ошибка при декодировании JSON, метод возвращает статус BadRequest (400)
 // с соответствующим сообщением об ошибке.
 // Если логин или пароль пользователя неверны, метод возвращает статус Unauthorized (401)
 // с сообщением об ошибке аутентификации.
+// Если у пользователя подключена двухфакторная аутентификация, запрос должен содержать одноразовый код
+// или код восстановления в поле otp; без кода или с неверным кодом метод возвращает статус Unauthorized (401)
+// с сообщением "one-time code required" или "invalid one-time code".
+// Если после неудачных попыток входа для логина или IP-адреса действует задержка или блокировка, метод возвращает
+// статус TooManyRequests (429) с заголовком Retry-After.
 // При любых других ошибках метод возвращает статус InternalServerError (500)
 // с сообщением об ошибке.
 //
@@ -29,42 +39,54 @@ import (
 //   - нет.
 func (c *Controller) Authentication(w http.ResponseWriter, r *http.Request) {
 	log := l.L(c.ctx)
-	// Получаем данные пользователя из UseCase
-	user := c.uc.Do().GetEntity()
 	// Получаем объект ошибки из UseCase
 	er := c.uc.Do().Err()
 
-	// Декодируем JSON из тела запроса в объект пользователя
+	// Декодируем JSON из тела запроса в новую структуру пользователя: обработчик вызывается параллельно,
+	// поэтому пароль и одноразовый код одного запроса не должны попадать в другой
+	var user entity.User
 	decoder := json.NewDecoder(r.Body)
 	decoder.DisallowUnknownFields()
 	if err := decoder.Decode(&user); err != nil {
 		// Если произошла ошибка при декодировании JSON, возвращаем ошибку BadRequest
 		log.Error("decode JSON", l.ErrAttr(err))
-		http.Error(w, er.ErrDecodeJSON.Error(), http.StatusBadRequest)
+		problem.Write(w, r, er.ErrDecodeJSON)
 		return
 	}
 
 	// Проверяем логин и пароль пользователя
-	if err := c.uc.DoAuth(r.Context(), user.Login, user.Password, r); err != nil {
+	err := c.uc.DoAuth(r.Context(), user.Login, user.Password, user.OTP, r)
+	var throttleErr *usecase.ThrottleError
+	switch {
+	case errors.As(err, &throttleErr):
+		// Если превышено количество неудачных попыток, возвращаем ошибку TooManyRequests с заголовком Retry-After
+		log.Error("login throttled", "login", user.Login, "retry_after", throttleErr.RetryAfter)
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttleErr.RetryAfter.Seconds()))))
+		problem.Write(w, r, er.ErrTooManyAttempts)
+		return
+	case errors.Is(err, er.ErrOTPRequired), errors.Is(err, er.ErrOTPCode):
+		// Если требуется или неверен одноразовый код второго фактора, возвращаем ошибку Unauthorized с пояснением
+		log.Error("second factor", "login", user.Login, l.ErrAttr(err))
+		problem.Write(w, r, err)
+		return
+	case err != nil:
 		// Если логин или пароль неверны, возвращаем ошибку Unauthorized
 		log.Error("incorrect login or password", l.ErrAttr(err))
-		http.Error(w, er.ErrUnauthorized.Error(), http.StatusUnauthorized)
+		problem.Write(w, r, er.ErrUnauthorized)
 		return
 	}
 
-	// Устанавливаем аутентификационный токен в куки
-	jwtToken, err := auth.SetAuth(c.ctx, user.Login, w)
+	// Устанавливаем токен доступа и refresh-токен в куки
+	jwtToken, err := c.setTokens(w, r, user.Login)
 	if err != nil {
 		// Если не удалось установить куки, возвращаем ошибку InternalServerError
 		log.Error("can't set cookie", l.ErrAttr(err))
-		http.Error(w, er.ErrNoCookie.Error(), http.StatusInternalServerError)
+		problem.Write(w, r, er.ErrNoCookie)
 		return
 	}
 	// Логируем успешную аутентификацию
-	a := fmt.Sprintf("[%s] success authenticated", user.Login)
-	log.Debug(a, "token", jwtToken)
+	log.Debug(fmt.Sprintf("[%s] success authenticated", user.Login), "token", jwtToken)
 
-	// Возвращаем успешный статус и сообщение об успешной аутентификации
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(a))
+	// Возвращаем успешный статус и токен доступа
+	writeToken(w, r, jwtToken)
 }
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/nextlag/gomart/internal/mw/auth"
+	"github.com/nextlag/gomart/internal/problem"
 	"github.com/nextlag/gomart/pkg/logger/l"
 )
 
@@ -36,7 +37,7 @@ func (c *Controller) Balance(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		// Если произошла ошибка при получении баланса, логируем её и возвращаем ошибку InternalServerError
 		log.Error("balance handler", "balance", balance, "withdrawn", withdrawn, l.ErrAttr(err))
-		http.Error(w, "error get balance", http.StatusInternalServerError)
+		problem.Write(w, r, err)
 		return
 	}
 
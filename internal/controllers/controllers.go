@@ -6,27 +6,76 @@ package controllers
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 
 	"github.com/nextlag/gomart/internal/config"
+	"github.com/nextlag/gomart/internal/entity"
 	"github.com/nextlag/gomart/internal/mw/auth"
 	"github.com/nextlag/gomart/internal/mw/gzip"
+	"github.com/nextlag/gomart/internal/mw/idempotency"
 	"github.com/nextlag/gomart/internal/mw/logger"
 	"github.com/nextlag/gomart/internal/usecase"
+	"github.com/nextlag/gomart/pkg/events"
 )
 
 //go:generate mockgen -destination=mocks/mocks.go -package=mocks github.com/nextlag/gomart/internal/controllers UseCase
 type UseCase interface {
 	Do() *usecase.UseCase
 	DoRegister(ctx context.Context, login, password string, r *http.Request) error
-	DoAuth(ctx context.Context, login, password string, r *http.Request) error
+	DoAuth(ctx context.Context, login, password, otp string, r *http.Request) error
 	DoInsertOrder(ctx context.Context, user, order string) error
 	DoGetOrders(ctx context.Context, user string) ([]byte, error)
 	DoGetBalance(ctx context.Context, login string) (float32, float32, error)
-	DoDebit(ctx context.Context, user, numOrder string, sum float32) error
+	DoDebit(ctx context.Context, user, numOrder string, sum float32, otp string, r *http.Request) error
 	DoGetWithdrawals(ctx context.Context, user string) ([]byte, error)
+	DoSubscribe(login string, lastID uint64) (*events.Subscription, bool)
+	DoGetOrder(ctx context.Context, user, number string) (entity.OrderDetails, error)
+	DoGetOrdersPage(ctx context.Context, user string, f entity.HistoryFilter) ([]entity.Order, string, error)
+	DoGetWithdrawalsPage(ctx context.Context, user string, f entity.HistoryFilter) ([]entity.Withdrawal, string, error)
+	DoGetTier(ctx context.Context, login string) (entity.TierProgress, error)
+	DoStatement(ctx context.Context, user string, from, to time.Time, w usecase.StatementWriter) error
+	DoAdjustBalance(ctx context.Context, adj entity.Adjustment) (entity.Adjustment, error)
+	DoGetAdjustments(ctx context.Context, login string) ([]entity.Adjustment, error)
+	DoCreateCampaign(ctx context.Context, c entity.Campaign) (entity.Campaign, error)
+	DoGetCampaigns(ctx context.Context) ([]entity.Campaign, error)
+	DoSetCampaignActive(ctx context.Context, name string, active bool) error
+	DoGrantCampaign(ctx context.Context, name string, logins []string, createdBy string) ([]string, error)
+	DoGeneratePassword(login string) (string, error)
+	DoChangePassword(ctx context.Context, login, current, password string, r *http.Request) error
+	DoRequestPasswordReset(ctx context.Context, login string, r *http.Request) error
+	DoResetPassword(ctx context.Context, token, password string) (string, error)
+	DoGetRole(ctx context.Context, login string) (string, error)
+	DoSetRole(ctx context.Context, login, role string) error
+	DoHasAdmin(ctx context.Context) (bool, error)
+	DoCreateAPIKey(ctx context.Context, key entity.APIKey) (entity.APIKey, error)
+	DoGetAPIKeys(ctx context.Context) ([]entity.APIKey, error)
+	DoRevokeAPIKey(ctx context.Context, id string) error
+	DoAuthenticateAPIKey(ctx context.Context, key, login string) (entity.APIKey, error)
+	DoCountAPIKeyRequest(ctx context.Context, id string, limit int) (time.Duration, error)
+	DoGetWithdrawalViolations(ctx context.Context, login string) ([]entity.WithdrawalViolation, error)
+	DoGetWithdrawLimits(ctx context.Context, login string) (entity.WithdrawLimits, error)
+	DoSetWithdrawLimits(ctx context.Context, limits entity.WithdrawLimits) (entity.WithdrawLimits, error)
+	DoIssueRefreshToken(ctx context.Context, login string, r *http.Request) (entity.Session, string, error)
+	DoRotateRefreshToken(ctx context.Context, token string, r *http.Request) (entity.Session, string, error)
+	DoStartSession(ctx context.Context, login string, r *http.Request) (entity.Session, error)
+	DoRevokeRefreshToken(ctx context.Context, token string) error
+	DoRevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+	DoEnrollTOTP(ctx context.Context, login string) (entity.TOTPEnrollment, error)
+	DoConfirmTOTP(ctx context.Context, login, code string) ([]string, error)
+	DoStartOIDCLogin(ctx context.Context, loginHint string) (string, string, error)
+	DoFinishOIDCLogin(ctx context.Context, state, code string) (string, string, error)
+	DoFinishOIDCChallenge(ctx context.Context, challenge, otp string, r *http.Request) (string, error)
+	DoExportAccount(ctx context.Context, login string) (entity.AccountExport, error)
+	DoDeleteAccount(ctx context.Context, login, password, otp string, r *http.Request) error
+	DoGetSessions(ctx context.Context, login string) ([]entity.Session, error)
+	DoRevokeSession(ctx context.Context, login, id string) error
+	DoIsAccessTokenRevoked(ctx context.Context, jti, login, sessionID string, issuedAt time.Time) (bool, error)
+	DoReserveIdempotencyKey(ctx context.Context, login, key, hash string) (*entity.Idempotency, error)
+	DoSaveIdempotencyResponse(ctx context.Context, login, key string, status int, contentType string, body []byte) error
+	DoDeleteIdempotencyKey(ctx context.Context, login, key string) error
 }
 
 type Controller struct {
@@ -62,15 +111,90 @@ func (c *Controller) NewServer(handler *chi.Mux) *http.Server {
 		// Регистрация и аутентификация пользователя
 		r.Post("/api/user/register", c.Register)
 		r.Post("/api/user/login", c.Authentication)
+		// Вход через внешний поставщик удостоверений OpenID Connect
+		r.Get("/api/user/oidc/login", c.OIDCLogin)
+		r.Get("/api/user/oidc/callback", c.OIDCCallback)
+		r.Post("/api/user/oidc/otp", c.OIDCSecondFactor)
+		// Обмен refresh-токена на новую пару токенов; токен доступа к этому моменту может быть уже просрочен
+		r.Post("/api/user/token/refresh", c.RefreshToken)
+		// Сброс забытого пароля по одноразовому токену
+		r.Post("/api/user/password/reset", c.RequestPasswordReset)
+		r.Post("/api/user/password/reset/confirm", c.ConfirmPasswordReset)
 
-		// Группа маршрутов, требующих аутентификации пользователя
-		r.With(auth.CookieAuthentication(c.ctx, c.uc.Do().Err())).Group(func(r chi.Router) {
-			// Маршруты для работы с заказами, балансом и выводом средств
-			r.Post("/api/user/orders", c.PostOrders)
-			r.Post("/api/user/balance/withdraw", c.Withdraw)
-			r.Get("/api/user/withdrawals", c.Withdrawals)
-			r.Get("/api/user/balance", c.Balance)
-			r.Get("/api/user/orders", c.GetOrders)
+		// Группа маршрутов, требующих аутентификации пользователя токеном из заголовка Authorization или куки
+		r.With(auth.Authentication(c.ctx, c.uc, c.uc.Do().Err(), auth.GetBearer, auth.GetCookie)).Group(func(r chi.Router) {
+			// Изменяющие запросы с кукой аутентификации должны повторять CSRF-токен в заголовке X-CSRF-Token
+			r.Use(auth.CSRF(c.ctx, c.uc.Do().Err()))
+			// Повтор изменяющих запросов с тем же Idempotency-Key возвращает сохраненный ответ
+			r.Use(idempotency.New(c.ctx, c.uc, c.uc.Do().Err()))
+
+			r.Get("/api/user/statement", c.Statement)
+			r.Get("/api/user/events", c.Events)
+			r.Get("/api/user/ws", c.WebSocket)
+			r.Get("/api/user/tier", c.Tier)
+			r.Get("/api/user/adjustments", c.Adjustments)
+			r.Post("/api/user/logout", c.Logout)
+			r.Post("/api/user/password", c.ChangePassword)
+			r.Get("/api/user/sessions", c.Sessions)
+			r.Post("/api/user/2fa/totp", c.EnrollTOTP)
+			r.Post("/api/user/2fa/totp/confirm", c.ConfirmTOTP)
+			r.Delete("/api/user/sessions/{id}", c.RevokeSession)
+			r.Get("/api/user/export", c.ExportAccount)
+			r.Delete("/api/user", c.DeleteAccount)
+		})
+
+		// Маршруты для работы с заказами, балансом и выводом средств доступны также сервисам с API-ключом
+		// из заголовка X-API-Key от имени пользователя из заголовка X-On-Behalf-Of в пределах областей действия ключа
+		r.With(auth.Authentication(c.ctx, c.uc, c.uc.Do().Err(), auth.GetAPIKey(c.uc), auth.GetBearer, auth.GetCookie)).Group(func(r chi.Router) {
+			// Изменяющие запросы с кукой аутентификации должны повторять CSRF-токен в заголовке X-CSRF-Token
+			r.Use(auth.CSRF(c.ctx, c.uc.Do().Err()))
+			// Повтор изменяющих запросов с тем же Idempotency-Key возвращает сохраненный ответ
+			r.Use(idempotency.New(c.ctx, c.uc, c.uc.Do().Err()))
+
+			scope := func(scope string) func(http.Handler) http.Handler {
+				return auth.RequireScope(c.ctx, c.uc.Do().Err(), scope)
+			}
+			r.With(scope(entity.ScopeOrdersWrite)).Post("/api/user/orders", c.PostOrders)
+			r.With(scope(entity.ScopeWithdraw)).Post("/api/user/balance/withdraw", c.Withdraw)
+			r.With(scope(entity.ScopeBalanceRead)).Get("/api/user/withdrawals", c.Withdrawals)
+			r.With(scope(entity.ScopeBalanceRead)).Get("/api/user/balance", c.Balance)
+			r.With(scope(entity.ScopeOrdersRead)).Get("/api/user/orders", c.GetOrders)
+			r.With(scope(entity.ScopeOrdersRead)).Get("/api/user/orders/{number}", c.GetOrder)
+		})
+	})
+
+	// Маршруты администратора: доступны пользователям с ролью admin, просмотр - также с ролью support
+	handler.Route("/api/admin", func(r chi.Router) {
+		// Статический токен администратора из заголовка X-Admin-Token принимается только для назначения роли
+		// и только пока в системе нет ни одного администратора
+		r.With(
+			auth.Authentication(c.ctx, c.uc, c.uc.Do().Err(), auth.GetAdminToken(c.uc), auth.GetBearer, auth.GetCookie),
+			auth.CSRF(c.ctx, c.uc.Do().Err()),
+			auth.RequireRole(c.ctx, c.uc.Do().Err(), entity.RoleAdmin),
+		).Put("/users/{login}/role", c.AdminSetRole)
+
+		r.Group(func(r chi.Router) {
+			r.Use(auth.Authentication(c.ctx, c.uc, c.uc.Do().Err(), auth.GetBearer, auth.GetCookie))
+			r.Use(auth.CSRF(c.ctx, c.uc.Do().Err()))
+			// Повтор корректировки баланса или начисления по кампании с тем же Idempotency-Key не начисляет баллы дважды
+			r.Use(idempotency.New(c.ctx, c.uc, c.uc.Do().Err()))
+
+			r.With(auth.RequireRole(c.ctx, c.uc.Do().Err(), entity.RoleAdmin, entity.RoleSupport)).Group(func(r chi.Router) {
+				r.Get("/campaigns", c.AdminCampaigns)
+				r.Get("/withdrawal-violations", c.AdminWithdrawalViolations)
+				r.Get("/users/{login}/withdraw-limits", c.AdminWithdrawLimits)
+				r.Get("/api-keys", c.AdminAPIKeys)
+			})
+
+			r.With(auth.RequireRole(c.ctx, c.uc.Do().Err(), entity.RoleAdmin)).Group(func(r chi.Router) {
+				r.Post("/adjustments", c.AdminAdjustBalance)
+				r.Post("/campaigns", c.AdminCreateCampaign)
+				r.Patch("/campaigns/{name}", c.AdminUpdateCampaign)
+				r.Post("/campaigns/{name}/grants", c.AdminGrantCampaign)
+				r.Put("/users/{login}/withdraw-limits", c.AdminSetWithdrawLimits)
+				r.Post("/api-keys", c.AdminCreateAPIKey)
+				r.Delete("/api-keys/{id}", c.AdminRevokeAPIKey)
+			})
 		})
 	})
 
@@ -2,21 +2,36 @@ package controllers
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/golang/mock/gomock"
 	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
 
 	"github.com/nextlag/gomart/internal/config"
 	"github.com/nextlag/gomart/internal/controllers/mocks"
+	"github.com/nextlag/gomart/internal/entity"
+	"github.com/nextlag/gomart/internal/mw/auth"
+	"github.com/nextlag/gomart/internal/mw/idempotency"
+	"github.com/nextlag/gomart/internal/problem"
 	"github.com/nextlag/gomart/internal/usecase"
+	"github.com/nextlag/gomart/pkg/events"
 	"github.com/nextlag/gomart/pkg/logger/l"
+	"github.com/nextlag/gomart/pkg/passwd"
 )
 
 func controller(t *testing.T) (context.Context, *Controller, *mocks.MockUseCase, *usecase.UseCase) {
@@ -72,6 +87,11 @@ func TestRegistrationHandler(t *testing.T) {
 			want: want{statusCode: http.StatusBadRequest},
 			body: `{"password": "12345"}`,
 		},
+		{
+			name: "Weak password",
+			want: want{statusCode: http.StatusBadRequest},
+			body: `{"login": "one", "password": "qwerty"}`,
+		},
 		{
 			name: "Invalid request",
 			want: want{statusCode: http.StatusBadRequest},
@@ -94,7 +114,13 @@ func TestRegistrationHandler(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			_, ctrl, repo, uc := controller(t)
 			repo.EXPECT().Do().Return(uc).Times(2)
+			repo.EXPECT().DoGetRole(gomock.Any(), gomock.Any()).Return(entity.RoleUser, nil).AnyTimes()
+			repo.EXPECT().DoIssueRefreshToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(entity.Session{ID: "session"}, "refresh", nil).AnyTimes()
+			repo.EXPECT().DoGeneratePassword("one").Return("Generated-Passw0rd", nil).AnyTimes()
 			switch {
+			case tt.name == "Weak password":
+				err := &passwd.PolicyError{Rule: passwd.RuleDenylist, Message: "is too common"}
+				repo.EXPECT().DoRegister(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(err).Times(1)
 			case tt.name == "Internal server error":
 				repo.EXPECT().DoRegister(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("internal server error")).Times(1)
 			case tt.name == "Duplicate login":
@@ -113,10 +139,43 @@ func TestRegistrationHandler(t *testing.T) {
 			handler(w, r)
 			require.NoError(t, err)
 			assert.Equal(t, w.Code, tt.want.statusCode, "Код ответа не совпадает с ожидаемым")
+			switch tt.name {
+			case "Empty password":
+				assert.Contains(t, w.Body.String(), `"password":"Generated-Passw0rd"`)
+			case "Weak password":
+				assert.Contains(t, w.Body.String(), passwd.RuleDenylist)
+			}
 		})
 	}
 }
 
+func TestRegistrationHandlerSequential(t *testing.T) {
+	_, ctrl, repo, uc := controller(t)
+	ttl := config.Cfg.AccessTokenTTL
+	config.Cfg.AccessTokenTTL = time.Minute
+	t.Cleanup(func() { config.Cfg.AccessTokenTTL = ttl })
+
+	repo.EXPECT().Do().Return(uc).AnyTimes()
+	repo.EXPECT().DoGetRole(gomock.Any(), gomock.Any()).Return(entity.RoleUser, nil).AnyTimes()
+	repo.EXPECT().DoIssueRefreshToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(entity.Session{ID: "session"}, "refresh", nil).AnyTimes()
+	// Пароль первого запроса не должен попасть во второй запрос без пароля
+	gomock.InOrder(
+		repo.EXPECT().DoRegister(gomock.Any(), "first", "Fir5t-Passw0rd", gomock.Any()).Return(nil),
+		repo.EXPECT().DoGeneratePassword("second").Return("Generated-Passw0rd", nil),
+		repo.EXPECT().DoRegister(gomock.Any(), "second", "Generated-Passw0rd", gomock.Any()).Return(nil),
+	)
+
+	for _, body := range []string{
+		`{"login": "first", "password": "Fir5t-Passw0rd"}`,
+		`{"login": "second"}`,
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/api/user/register", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		ctrl.Register(w, r)
+		assert.Equal(t, http.StatusOK, w.Code, "Код ответа не совпадает с ожидаемым")
+	}
+}
+
 func TestAuthenticationHandler(t *testing.T) {
 	type want struct {
 		statusCode int
@@ -141,21 +200,1781 @@ func TestAuthenticationHandler(t *testing.T) {
 			want: want{statusCode: http.StatusBadRequest},
 			body: "",
 		},
+		{
+			name: "Too many attempts",
+			want: want{statusCode: http.StatusTooManyRequests},
+			body: `{"login": "test", "password": "guess"}`,
+		},
+		{
+			name: "OTP required",
+			want: want{statusCode: http.StatusUnauthorized},
+			body: `{"login": "test", "password": "12345"}`,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			_, ctrl, repo, uc := controller(t)
 			repo.EXPECT().Do().Return(uc).Times(2)
-			if tt.name == "NoValid auth" {
-				repo.EXPECT().DoAuth(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("unauthorized")).Times(1)
+			repo.EXPECT().DoGetRole(gomock.Any(), gomock.Any()).Return(entity.RoleUser, nil).AnyTimes()
+			repo.EXPECT().DoIssueRefreshToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(entity.Session{ID: "session"}, "refresh", nil).AnyTimes()
+			switch tt.name {
+			case "NoValid auth":
+				repo.EXPECT().DoAuth(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("unauthorized")).Times(1)
+			case "Too many attempts":
+				throttled := &usecase.ThrottleError{RetryAfter: 1500 * time.Millisecond}
+				repo.EXPECT().DoAuth(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(throttled).Times(1)
+			case "OTP required":
+				repo.EXPECT().DoAuth(gomock.Any(), "test", "12345", "", gomock.Any()).Return(usecase.ErrOTPRequired).Times(1)
 			}
-			repo.EXPECT().DoAuth(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			repo.EXPECT().DoAuth(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
 			r, err := http.NewRequest(http.MethodPost, "/api/user/login", bytes.NewBufferString(tt.body))
 			w := httptest.NewRecorder()
 			handler := http.HandlerFunc(ctrl.Authentication)
 			handler(w, r)
 			require.NoError(t, err)
 			assert.Equal(t, w.Code, tt.want.statusCode, "Код ответа не совпадает с ожидаемым")
+			if tt.want.statusCode == http.StatusTooManyRequests {
+				assert.Equal(t, "2", w.Header().Get("Retry-After"))
+			}
+			if tt.name == "OTP required" {
+				assert.Contains(t, w.Body.String(), usecase.ErrOTPRequired.Error())
+			}
+			if tt.want.statusCode == http.StatusOK {
+				var resp tokenResponse
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+				assert.Equal(t, "Bearer "+resp.AccessToken, w.Header().Get(auth.AuthorizationHeader))
+			}
+		})
+	}
+}
+
+func TestRefreshTokenHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		cookie     string
+		err        error
+		statusCode int
+	}{
+		{
+			name:       "Refresh success",
+			cookie:     "valid",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Reused token",
+			cookie:     "revoked",
+			err:        usecase.ErrRefreshToken,
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "No cookie",
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "Internal server error",
+			cookie:     "valid",
+			err:        errors.New("internal server error"),
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ctrl, repo, uc := controller(t)
+			repo.EXPECT().Do().Return(uc).Times(1)
+			repo.EXPECT().DoRotateRefreshToken(gomock.Any(), tt.cookie, gomock.Any()).Return(entity.Session{ID: "session", Login: "test"}, "rotated", tt.err).AnyTimes()
+			repo.EXPECT().DoGetRole(gomock.Any(), "test").Return(entity.RoleUser, nil).AnyTimes()
+			r, err := http.NewRequest(http.MethodPost, "/api/user/token/refresh", nil)
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: auth.RefreshCookie, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			handler := http.HandlerFunc(ctrl.RefreshToken)
+			handler(w, r)
+			require.NoError(t, err)
+			assert.Equal(t, tt.statusCode, w.Code, "Код ответа не совпадает с ожидаемым")
+			if tt.statusCode == http.StatusOK {
+				cookies := map[string]string{}
+				for _, c := range w.Result().Cookies() {
+					cookies[c.Name] = c.Value
+				}
+				assert.Equal(t, "rotated", cookies[auth.RefreshCookie])
+				assert.NotEmpty(t, cookies[auth.Cookie])
+			}
+		})
+	}
+}
+
+func TestAuthenticationHandlerSequential(t *testing.T) {
+	_, ctrl, repo, uc := controller(t)
+	ttl := config.Cfg.AccessTokenTTL
+	config.Cfg.AccessTokenTTL = time.Minute
+	t.Cleanup(func() { config.Cfg.AccessTokenTTL = ttl })
+
+	repo.EXPECT().Do().Return(uc).AnyTimes()
+	repo.EXPECT().DoGetRole(gomock.Any(), gomock.Any()).Return(entity.RoleUser, nil).AnyTimes()
+	repo.EXPECT().DoIssueRefreshToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(entity.Session{ID: "session"}, "refresh", nil).AnyTimes()
+	// Пароль и одноразовый код первого запроса не должны попасть во второй запрос
+	gomock.InOrder(
+		repo.EXPECT().DoAuth(gomock.Any(), "first", "Fir5t-Passw0rd", "123456", gomock.Any()).Return(nil),
+		repo.EXPECT().DoAuth(gomock.Any(), "second", "", "", gomock.Any()).Return(usecase.ErrUnauthorized),
+	)
+
+	for _, tt := range []struct {
+		body       string
+		statusCode int
+	}{
+		{body: `{"login": "first", "password": "Fir5t-Passw0rd", "otp": "123456"}`, statusCode: http.StatusOK},
+		{body: `{"login": "second"}`, statusCode: http.StatusUnauthorized},
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/api/user/login", bytes.NewBufferString(tt.body))
+		w := httptest.NewRecorder()
+		ctrl.Authentication(w, r)
+		assert.Equal(t, tt.statusCode, w.Code, "Код ответа не совпадает с ожидаемым")
+	}
+}
+
+func TestChangePasswordHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		err        error
+		statusCode int
+	}{
+		{
+			name:       "Password changed",
+			body:       `{"current_password": "old", "new_password": "new"}`,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Wrong current password",
+			body:       `{"current_password": "wrong", "new_password": "new"}`,
+			err:        usecase.ErrPassword,
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "Too many attempts",
+			body:       `{"current_password": "guess", "new_password": "new"}`,
+			err:        &usecase.ThrottleError{RetryAfter: 30 * time.Second},
+			statusCode: http.StatusTooManyRequests,
+		},
+		{
+			name:       "Empty new password",
+			body:       `{"current_password": "old", "new_password": ""}`,
+			err:        usecase.ErrRequestFormat,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid JSON",
+			body:       `{"current_password": "old"`,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Internal server error",
+			body:       `{"current_password": "old", "new_password": "new"}`,
+			err:        errors.New("internal server error"),
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ctrl, repo, uc := controller(t)
+			repo.EXPECT().Do().Return(uc).Times(1)
+			repo.EXPECT().DoChangePassword(gomock.Any(), "test", gomock.Any(), gomock.Any(), gomock.Any()).Return(tt.err).AnyTimes()
+			repo.EXPECT().DoIssueRefreshToken(gomock.Any(), "test", gomock.Any()).Return(entity.Session{ID: "session"}, "refresh", nil).AnyTimes()
+			repo.EXPECT().DoGetRole(gomock.Any(), "test").Return(entity.RoleUser, nil).AnyTimes()
+			r, err := http.NewRequest(http.MethodPost, "/api/user/password", bytes.NewBufferString(tt.body))
+			require.NoError(t, err)
+			r = r.WithContext(context.WithValue(r.Context(), auth.LoginKey, "test"))
+			w := httptest.NewRecorder()
+			handler := http.HandlerFunc(ctrl.ChangePassword)
+			handler(w, r)
+			assert.Equal(t, tt.statusCode, w.Code, "Код ответа не совпадает с ожидаемым")
+			if tt.statusCode == http.StatusOK {
+				assert.NotEmpty(t, w.Header().Get(auth.AuthorizationHeader))
+			}
+		})
+	}
+}
+
+func TestRequestPasswordResetHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		err        error
+		statusCode int
+		retryAfter string
+	}{
+		{
+			name:       "Reset requested",
+			body:       `{"login": "test"}`,
+			statusCode: http.StatusAccepted,
+		},
+		{
+			name:       "Too many requests",
+			body:       `{"login": "test"}`,
+			err:        &usecase.ThrottleError{RetryAfter: 1500 * time.Millisecond},
+			statusCode: http.StatusTooManyRequests,
+			retryAfter: "2",
+		},
+		{
+			name:       "Empty login",
+			body:       `{"login": ""}`,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Internal server error",
+			body:       `{"login": "test"}`,
+			err:        errors.New("internal server error"),
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ctrl, repo, uc := controller(t)
+			repo.EXPECT().Do().Return(uc).Times(1)
+			repo.EXPECT().DoRequestPasswordReset(gomock.Any(), "test", gomock.Any()).Return(tt.err).AnyTimes()
+			r, err := http.NewRequest(http.MethodPost, "/api/user/password/reset", bytes.NewBufferString(tt.body))
+			require.NoError(t, err)
+			w := httptest.NewRecorder()
+			handler := http.HandlerFunc(ctrl.RequestPasswordReset)
+			handler(w, r)
+			assert.Equal(t, tt.statusCode, w.Code, "Код ответа не совпадает с ожидаемым")
+			assert.Equal(t, tt.retryAfter, w.Header().Get("Retry-After"))
+		})
+	}
+}
+
+func TestStatementHandler(t *testing.T) {
+	type want struct {
+		statusCode  int
+		contentType string
+		body        string
+	}
+	tests := []struct {
+		name  string
+		query string
+		err   error
+		want  want
+	}{
+		{
+			name:  "CSV statement",
+			query: "?from=2024-01-01&to=2024-01-31&format=csv",
+			want: want{
+				statusCode:  http.StatusOK,
+				contentType: "text/csv; charset=utf-8",
+				body: "time,type,order,reason,amount,balance\n" +
+					"2024-01-01T00:00:00Z,opening_balance,,,,10\n" +
+					"2024-01-02T00:00:00Z,accrual,12345678903,,500,510\n" +
+					"2024-01-03T00:00:00Z,withdrawal,2377225624,,-100.5,409.5\n" +
+					"2024-01-04T00:00:00Z,adjustment,,\"welcome bonus, 2024\",100,509.5\n" +
+					"2024-02-01T00:00:00Z,accrued,,,500,\n" +
+					"2024-02-01T00:00:00Z,withdrawn,,,-100.5,\n" +
+					"2024-02-01T00:00:00Z,adjusted,,,100,\n" +
+					"2024-02-01T00:00:00Z,closing_balance,,,,509.5\n",
+			},
+		},
+		{
+			name:  "JSON statement",
+			query: "?from=2024-01-01T00:00:00Z&to=2024-02-01T00:00:00Z",
+			want: want{
+				statusCode:  http.StatusOK,
+				contentType: "application/json",
+				body: `{"from":"2024-01-01T00:00:00Z","to":"2024-02-01T00:00:00Z","opening_balance":10,"entries":[` +
+					`{"time":"2024-01-02T00:00:00Z","type":"accrual","order":"12345678903","amount":500,"balance":510},` +
+					`{"time":"2024-01-03T00:00:00Z","type":"withdrawal","order":"2377225624","amount":-100.5,"balance":409.5},` +
+					`{"time":"2024-01-04T00:00:00Z","type":"adjustment","reason":"welcome bonus, 2024","amount":100,"balance":509.5}],` +
+					`"accrued":500,"withdrawn":100.5,"adjusted":100,"closing_balance":509.5}`,
+			},
+		},
+		{
+			name:  "Unknown format",
+			query: "?format=xml",
+			want:  want{statusCode: http.StatusBadRequest},
+		},
+		{
+			name:  "Invalid period",
+			query: "?from=2024-02-01&to=2024-01-01",
+			want:  want{statusCode: http.StatusBadRequest},
+		},
+		{
+			name: "Internal server error",
+			err:  errors.New("internal server error"),
+			want: want{statusCode: http.StatusInternalServerError},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ctrl, repo, uc := controller(t)
+			repo.EXPECT().Do().Return(uc).Times(1)
+			repo.EXPECT().DoStatement(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, _ string, _, _ time.Time, sw usecase.StatementWriter) error {
+					if tt.err != nil {
+						return tt.err
+					}
+					day := func(d int) time.Time { return time.Date(2024, time.January, d, 0, 0, 0, 0, time.UTC) }
+					require.NoError(t, sw.Begin(10))
+					require.NoError(t, sw.Entry(entity.StatementEntry{Time: day(2), Type: usecase.EntryAccrual, Order: "12345678903", Amount: 500, Balance: 510}))
+					require.NoError(t, sw.Entry(entity.StatementEntry{Time: day(3), Type: usecase.EntryWithdrawal, Order: "2377225624", Amount: -100.5, Balance: 409.5}))
+					require.NoError(t, sw.Entry(entity.StatementEntry{Time: day(4), Type: usecase.EntryAdjustment, Reason: "welcome bonus, 2024", Amount: 100, Balance: 509.5}))
+					return sw.End(entity.StatementTotals{Opening: 10, Accrued: 500, Withdrawn: 100.5, Adjusted: 100, Closing: 509.5})
+				}).AnyTimes()
+			r, err := http.NewRequest(http.MethodGet, "/api/user/statement"+tt.query, nil)
+			w := httptest.NewRecorder()
+			handler := http.HandlerFunc(ctrl.Statement)
+			handler(w, r)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.statusCode, w.Code, "Код ответа не совпадает с ожидаемым")
+			if tt.want.body != "" {
+				assert.Equal(t, tt.want.contentType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.want.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestPostOrdersHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		err        error
+		statusCode int
+		code       string
+		response   string
+	}{
+		{
+			name:       "Order accepted",
+			body:       "12345678903",
+			statusCode: http.StatusAccepted,
+			response:   `{"number":"12345678903","message":"new order number accepted for processing"}`,
+		},
+		{
+			name:       "Order already uploaded",
+			body:       "12345678903",
+			err:        usecase.ErrThisUser,
+			statusCode: http.StatusOK,
+			response:   `{"number":"12345678903","message":"the order number has already been uploaded by this user"}`,
+		},
+		{
+			name:       "Empty body",
+			statusCode: http.StatusBadRequest,
+			code:       "invalid_request_format",
+		},
+		{
+			name:       "Invalid order number",
+			body:       "123",
+			err:        usecase.ErrOrderFormat,
+			statusCode: http.StatusUnprocessableEntity,
+			code:       "invalid_order_number",
+		},
+		{
+			name:       "Order uploaded by another user",
+			body:       "12345678903",
+			err:        usecase.ErrAnotherUser,
+			statusCode: http.StatusConflict,
+			code:       "order_uploaded_by_another_user",
+		},
+		{
+			name:       "Internal server error",
+			body:       "12345678903",
+			err:        errors.New("connection refused"),
+			statusCode: http.StatusInternalServerError,
+			code:       problem.CodeInternal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ctrl, repo, uc := controller(t)
+			repo.EXPECT().Do().Return(uc).Times(1)
+			if tt.body != "" {
+				repo.EXPECT().DoInsertOrder(gomock.Any(), "test", tt.body).Return(tt.err).Times(1)
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/api/user/orders", strings.NewReader(tt.body))
+			r = r.WithContext(context.WithValue(r.Context(), auth.LoginKey, "test"))
+			w := httptest.NewRecorder()
+			middleware.RequestID(http.HandlerFunc(ctrl.PostOrders)).ServeHTTP(w, r)
+
+			assert.Equal(t, tt.statusCode, w.Code, "Код ответа не совпадает с ожидаемым")
+			if tt.code == "" {
+				assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+				assert.JSONEq(t, tt.response, w.Body.String())
+				return
+			}
+			assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+			var p problem.Problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+			assert.Equal(t, tt.code, p.Code)
+			assert.Equal(t, tt.statusCode, p.Status)
+			assert.Equal(t, "/api/user/orders", p.Instance)
+			assert.NotEmpty(t, p.RequestID)
+			// Текст ошибок вне реестра не передается клиенту
+			assert.NotContains(t, p.Detail, "connection refused")
+		})
+	}
+}
+
+func TestCompressedProblem(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		statusCode int
+	}{
+		{
+			name:       "Invalid JSON",
+			method:     http.MethodPost,
+			path:       "/api/user/login",
+			body:       "{",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Not authenticated",
+			method:     http.MethodGet,
+			path:       "/api/user/orders",
+			statusCode: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ctrl, repo, uc := controller(t)
+			repo.EXPECT().Do().Return(uc).AnyTimes()
+
+			r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			r.Header.Set("Accept-Encoding", "gzip")
+			w := httptest.NewRecorder()
+			ctrl.NewServer(chi.NewRouter()).Handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.statusCode, w.Code, "Код ответа не совпадает с ожидаемым")
+			assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"), "Сжатое тело ответа об ошибке без Content-Encoding")
+			assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+
+			zr, err := gzip.NewReader(w.Body)
+			require.NoError(t, err)
+			var p problem.Problem
+			require.NoError(t, json.NewDecoder(zr).Decode(&p))
+			assert.Equal(t, tt.statusCode, p.Status)
+			assert.Equal(t, tt.path, p.Instance)
+			assert.NotEmpty(t, p.Code)
+		})
+	}
+}
+
+func TestTierHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		progress   entity.TierProgress
+		err        error
+		statusCode int
+		body       string
+	}{
+		{
+			name:       "Tier progress",
+			progress:   entity.TierProgress{Tier: "SILVER", Multiplier: 1.05, Accrued: 1200, NextTier: "GOLD", NextThreshold: 5000, Remaining: 3800},
+			statusCode: http.StatusOK,
+			body:       `{"tier":"SILVER","multiplier":1.05,"accrued":1200,"next_tier":"GOLD","next_threshold":5000,"remaining":3800}`,
+		},
+		{
+			name:       "Highest tier",
+			progress:   entity.TierProgress{Tier: "PLATINUM", Multiplier: 1.2, Accrued: 25000},
+			statusCode: http.StatusOK,
+			body:       `{"tier":"PLATINUM","multiplier":1.2,"accrued":25000}`,
+		},
+		{
+			name:       "Internal server error",
+			err:        errors.New("connection refused"),
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ctrl, repo, uc := controller(t)
+			repo.EXPECT().Do().Return(uc).Times(1)
+			repo.EXPECT().DoGetTier(gomock.Any(), "test").Return(tt.progress, tt.err).Times(1)
+
+			r := httptest.NewRequest(http.MethodGet, "/api/user/tier", nil)
+			r = r.WithContext(context.WithValue(r.Context(), auth.LoginKey, "test"))
+			w := httptest.NewRecorder()
+			ctrl.Tier(w, r)
+
+			assert.Equal(t, tt.statusCode, w.Code, "Код ответа не совпадает с ожидаемым")
+			if tt.body != "" {
+				assert.JSONEq(t, tt.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetOrdersPageHandler(t *testing.T) {
+	uploaded := time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		query      string
+		filter     *entity.HistoryFilter
+		next       string
+		err        error
+		statusCode int
+		body       string
+		link       []string
+	}{
+		{
+			name:       "Without parameters",
+			statusCode: http.StatusOK,
+			body:       `[{"number":"12345678903","status":"NEW","uploaded_at":"2024-01-02T00:00:00Z"}]`,
+		},
+		{
+			name:       "First page",
+			query:      "?limit=1&status=new,processed&sort=desc",
+			filter:     &entity.HistoryFilter{Limit: 1, Status: []string{"NEW", "PROCESSED"}, Desc: true},
+			next:       "abc",
+			statusCode: http.StatusOK,
+			body:       `[{"number":"12345678903","status":"NEW","uploaded_at":"2024-01-02T00:00:00Z"}]`,
+			link: []string{
+				`</api/user/orders?limit=1&sort=desc&status=new%2Cprocessed>; rel="first"`,
+				`</api/user/orders?cursor=abc&limit=1&sort=desc&status=new%2Cprocessed>; rel="next"`,
+			},
+		},
+		{
+			name:       "Last page",
+			query:      "?cursor=abc&from=2024-01-01&to=2024-01-31",
+			filter:     &entity.HistoryFilter{Cursor: "abc", From: uploaded.AddDate(0, 0, -1), To: uploaded.AddDate(0, 0, 30)},
+			statusCode: http.StatusOK,
+			body:       `[{"number":"12345678903","status":"NEW","uploaded_at":"2024-01-02T00:00:00Z"}]`,
+			link:       []string{`</api/user/orders?from=2024-01-01&to=2024-01-31>; rel="first"`},
+		},
+		{
+			name:       "Invalid limit",
+			query:      "?limit=-1",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid cursor",
+			query:      "?cursor=abc",
+			filter:     &entity.HistoryFilter{Cursor: "abc"},
+			err:        usecase.ErrRequestFormat,
+			statusCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ctrl, repo, uc := controller(t)
+			orders := []entity.Order{{Order: "12345678903", Status: "NEW", UploadedAt: uploaded}}
+			repo.EXPECT().Do().Return(uc).Times(1)
+			switch {
+			case tt.query == "":
+				result, err := json.Marshal(orders)
+				require.NoError(t, err)
+				repo.EXPECT().DoGetOrders(gomock.Any(), "test").Return(result, nil).Times(1)
+			case tt.filter != nil:
+				repo.EXPECT().DoGetOrdersPage(gomock.Any(), "test", *tt.filter).Return(orders, tt.next, tt.err).Times(1)
+			}
+
+			r, err := http.NewRequest(http.MethodGet, "/api/user/orders"+tt.query, nil)
+			require.NoError(t, err)
+			r = r.WithContext(context.WithValue(r.Context(), auth.LoginKey, "test"))
+			w := httptest.NewRecorder()
+			ctrl.GetOrders(w, r)
+			assert.Equal(t, tt.statusCode, w.Code, "Код ответа не совпадает с ожидаемым")
+			if tt.body != "" {
+				assert.JSONEq(t, tt.body, w.Body.String())
+			}
+			assert.Equal(t, tt.link, w.Header().Values("Link"))
+		})
+	}
+}
+
+func TestGetOrderHandler(t *testing.T) {
+	order := entity.OrderDetails{
+		Order: entity.Order{Order: "12345678903", Status: "PROCESSED", Accrual: 500,
+			UploadedAt: time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC)},
+		History: []entity.OrderStatusChange{
+			{Status: "NEW", ChangedAt: time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC)},
+			{Status: "PROCESSED", Accrual: 500, ChangedAt: time.Date(2024, time.January, 2, 0, 1, 0, 0, time.UTC)},
+		},
+	}
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		err         error
+		statusCode  int
+		body        string
+	}{
+		{
+			name:       "Order found",
+			statusCode: http.StatusOK,
+			body: `{"number":"12345678903","status":"PROCESSED","accrual":500,"uploaded_at":"2024-01-02T00:00:00Z",` +
+				`"history":[{"status":"NEW","changed_at":"2024-01-02T00:00:00Z"},` +
+				`{"status":"PROCESSED","accrual":500,"changed_at":"2024-01-02T00:01:00Z"}]}`,
+		},
+		{
+			name:        "Order not modified",
+			ifNoneMatch: "current",
+			statusCode:  http.StatusNotModified,
+		},
+		{
+			name:        "Order modified",
+			ifNoneMatch: `W/"stale"`,
+			statusCode:  http.StatusOK,
+		},
+		{
+			name:       "Another user's order",
+			err:        usecase.ErrNoOrder,
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name:       "Internal server error",
+			err:        errors.New("internal server error"),
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ctrl, repo, uc := controller(t)
+			repo.EXPECT().Do().Return(uc).Times(1)
+			repo.EXPECT().DoGetOrder(gomock.Any(), "test", "12345678903").Return(order, tt.err).Times(1)
+
+			router := chi.NewRouter()
+			router.Get("/api/user/orders/{number}", ctrl.GetOrder)
+
+			// Текущий ETag заказа получаем первым запросом без условия
+			current := ""
+			if tt.ifNoneMatch == "current" {
+				repo.EXPECT().Do().Return(uc).Times(1)
+				repo.EXPECT().DoGetOrder(gomock.Any(), "test", "12345678903").Return(order, nil).Times(1)
+				r := httptest.NewRequest(http.MethodGet, "/api/user/orders/12345678903", nil)
+				r = r.WithContext(context.WithValue(r.Context(), auth.LoginKey, "test"))
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, r)
+				current = w.Header().Get("ETag")
+				require.NotEmpty(t, current)
+			}
+
+			r, err := http.NewRequest(http.MethodGet, "/api/user/orders/12345678903", nil)
+			require.NoError(t, err)
+			r = r.WithContext(context.WithValue(r.Context(), auth.LoginKey, "test"))
+			switch tt.ifNoneMatch {
+			case "":
+			case "current":
+				r.Header.Set("If-None-Match", `"other", `+current)
+			default:
+				r.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			assert.Equal(t, tt.statusCode, w.Code, "Код ответа не совпадает с ожидаемым")
+			if tt.body != "" {
+				assert.JSONEq(t, tt.body, w.Body.String())
+				assert.NotEmpty(t, w.Header().Get("ETag"))
+			}
+			if tt.statusCode == http.StatusNotModified {
+				assert.Empty(t, w.Body.String())
+				assert.Equal(t, current, w.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestEventsHandler(t *testing.T) {
+	hub := events.NewHub(10, 10)
+	first, err := hub.Publish("test", usecase.EventOrder, entity.Order{Order: "12345678903", Status: "PROCESSING"})
+	require.NoError(t, err)
+	_, err = hub.Publish("other", usecase.EventBalance, entity.Balance{Current: 1})
+	require.NoError(t, err)
+	second, err := hub.Publish("test", usecase.EventOrder, entity.Order{Order: "12345678903", Status: "PROCESSED", Accrual: 500})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		lastEventID string
+		statusCode  int
+		want        []string
+	}{
+		{
+			name:       "New subscription",
+			statusCode: http.StatusOK,
+			want:       []string{"retry: 3000", "event: balance"},
+		},
+		{
+			name:        "Resumed subscription",
+			lastEventID: strconv.FormatUint(first.ID, 10),
+			statusCode:  http.StatusOK,
+			want: []string{
+				"id: " + strconv.FormatUint(second.ID, 10) + "\nevent: order\n" +
+					`data: {"number":"12345678903","status":"PROCESSED","accrual":500,"uploaded_at":"0001-01-01T00:00:00Z"}`,
+				"event: balance",
+			},
+		},
+		{
+			name:        "Missed events",
+			lastEventID: "1",
+			statusCode:  http.StatusOK,
+			want:        []string{"event: reset\ndata: {}"},
+		},
+		{
+			name:        "Invalid Last-Event-ID",
+			lastEventID: "abc",
+			statusCode:  http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _, repo, uc := controller(t)
+			// Поток событий завершается вместе с контекстом сервера, поэтому он не должен быть отменен
+			ctrl := New(context.WithoutCancel(ctx), repo)
+			repo.EXPECT().Do().Return(uc).Times(1)
+			// После подписки публикуем событие и отменяем подписку: обработчик отправляет события из буфера и завершается
+			repo.EXPECT().DoSubscribe("test", gomock.Any()).DoAndReturn(func(login string, lastID uint64) (*events.Subscription, bool) {
+				sub, complete := hub.Subscribe(login, lastID)
+				_, err := hub.Publish("test", usecase.EventBalance, entity.Balance{Current: 500})
+				require.NoError(t, err)
+				sub.Close()
+				return sub, complete
+			}).MaxTimes(1)
+
+			r, err := http.NewRequest(http.MethodGet, "/api/user/events", nil)
+			require.NoError(t, err)
+			r = r.WithContext(context.WithValue(r.Context(), auth.LoginKey, "test"))
+			if tt.lastEventID != "" {
+				r.Header.Set("Last-Event-ID", tt.lastEventID)
+			}
+			w := httptest.NewRecorder()
+			ctrl.Events(w, r)
+			assert.Equal(t, tt.statusCode, w.Code, "Код ответа не совпадает с ожидаемым")
+			for _, want := range tt.want {
+				assert.Contains(t, w.Body.String(), want)
+			}
+			if tt.statusCode == http.StatusOK {
+				assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+				assert.NotContains(t, w.Body.String(), `"current":1`)
+			}
+		})
+	}
+}
+
+func TestWebSocketHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		cookie  bool
+		origin  string
+		topics  []string
+		buffer  int
+		events  int
+		revoked bool
+		want    []wsMessage
+		err     bool
+	}{
+		{
+			name:   "Subscribed topic",
+			origin: "http://kiosk.example",
+			topics: []string{TopicOrders},
+			buffer: 10,
+			events: 1,
+			want: []wsMessage{
+				{Type: wsSubscribed, Topics: []string{TopicOrders}},
+				{Type: wsEvent, Topic: TopicOrders, Event: usecase.EventOrder},
+				{Type: wsPong},
+			},
+		},
+		{
+			name:   "Slow client",
+			origin: "http://kiosk.example",
+			topics: []string{TopicBalance},
+			buffer: 1,
+			events: 3,
+			want: []wsMessage{
+				{Type: wsSubscribed, Topics: []string{TopicBalance}},
+				{Type: wsError, Error: errSlowClient.Error()},
+			},
+		},
+		{
+			name:    "Revoked token",
+			origin:  "http://kiosk.example",
+			topics:  []string{TopicBalance},
+			buffer:  10,
+			revoked: true,
+			want: []wsMessage{
+				{Type: wsSubscribed, Topics: []string{TopicBalance}},
+				{Type: wsError, Error: errTokenRevoked.Error()},
+			},
+		},
+		{
+			name:   "Cookie from another origin",
+			cookie: true,
+			origin: "http://evil.example",
+			err:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _, repo, uc := controller(t)
+			// Соединение закрывается вместе с контекстом сервера, поэтому он не должен быть отменен
+			ctrl := New(context.WithoutCancel(ctx), repo)
+			hub := events.NewHub(10, tt.buffer)
+			repo.EXPECT().Do().Return(uc).Times(1)
+			repo.EXPECT().DoSubscribe("test", uint64(0)).DoAndReturn(hub.Subscribe).MaxTimes(1)
+
+			claims := &auth.Claims{Login: "test", Cookie: tt.cookie}
+			claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Minute))
+			if tt.revoked {
+				// Токен отзывается после подключения и обнаруживается при очередной проверке
+				revocationCheck := config.Cfg.Events.RevocationCheck
+				config.Cfg.Events.RevocationCheck = 10 * time.Millisecond
+				t.Cleanup(func() { config.Cfg.Events.RevocationCheck = revocationCheck })
+				claims.ID, claims.SessionID = "jti", "sid"
+				claims.IssuedAt = jwt.NewNumericDate(time.Now())
+				repo.EXPECT().DoIsAccessTokenRevoked(gomock.Any(), "jti", "test", "sid", gomock.Any()).Return(false, nil).Times(1)
+				repo.EXPECT().DoIsAccessTokenRevoked(gomock.Any(), "jti", "test", "sid", gomock.Any()).Return(true, nil).Times(1)
+			}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				r = r.WithContext(context.WithValue(r.Context(), auth.LoginKey, "test"))
+				r = r.WithContext(context.WithValue(r.Context(), auth.ClaimsKey, claims))
+				ctrl.WebSocket(w, r)
+			}))
+			defer server.Close()
+
+			ws, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/user/ws", "", tt.origin)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer ws.Close()
+
+			require.NoError(t, websocket.JSON.Send(ws, wsMessage{Type: wsSubscribe, Topics: tt.topics}))
+			var got []wsMessage
+			receive := func() wsMessage {
+				var m wsMessage
+				require.NoError(t, websocket.JSON.Receive(ws, &m))
+				m.ID, m.Data, m.Time = 0, nil, nil
+				got = append(got, m)
+				return m
+			}
+			receive()
+
+			// События по другой теме клиенту не отправляются
+			_, err = hub.Publish("test", usecase.EventBalance, entity.Balance{Current: 500})
+			require.NoError(t, err)
+			for i := 0; i < tt.events; i++ {
+				_, err = hub.Publish("test", usecase.EventOrder, entity.Order{Order: "12345678903", Status: "PROCESSED"})
+				require.NoError(t, err)
+				_, err = hub.Publish("test", usecase.EventBalance, entity.Balance{Current: 500})
+				require.NoError(t, err)
+			}
+
+			if tt.topics[0] == TopicOrders {
+				receive()
+				require.NoError(t, websocket.JSON.Send(ws, wsMessage{Type: wsPing}))
+				receive()
+			} else {
+				// Клиент может успеть получить часть событий до закрытия соединения
+				for m := receive(); m.Type != wsError; m = receive() {
+					got = got[:len(got)-1]
+				}
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAdminAdjustBalanceHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		err        error
+		statusCode int
+	}{
+		{
+			name:       "Credit success",
+			body:       `{"login": "test", "amount": 100, "reason": "compensation"}`,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Empty reason",
+			body:       `{"login": "test", "amount": 100}`,
+			err:        usecase.ErrReason,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Unknown user",
+			body:       `{"login": "nobody", "amount": 100, "reason": "compensation"}`,
+			err:        usecase.ErrUserNotFound,
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name:       "Debit exceeds balance",
+			body:       `{"login": "test", "amount": -1000, "reason": "chargeback"}`,
+			err:        usecase.ErrNoBalance,
+			statusCode: http.StatusPaymentRequired,
+		},
+		{
+			name:       "Invalid request",
+			body:       `{"user": "test"}`,
+			statusCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ctrl, repo, uc := controller(t)
+			repo.EXPECT().Do().Return(uc).Times(1)
+			repo.EXPECT().DoAdjustBalance(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, adj entity.Adjustment) (entity.Adjustment, error) {
+					assert.Equal(t, "admin", adj.CreatedBy)
+					return adj, tt.err
+				}).AnyTimes()
+			r, err := http.NewRequest(http.MethodPost, "/api/admin/adjustments", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			handler := http.HandlerFunc(ctrl.AdminAdjustBalance)
+			handler(w, r)
+			require.NoError(t, err)
+			assert.Equal(t, tt.statusCode, w.Code, "Код ответа не совпадает с ожидаемым")
+		})
+	}
+}
+
+func TestAdminSetRoleHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		claims     *auth.Claims
+		err        error
+		statusCode int
+	}{
+		{
+			name:       "Admin sets role",
+			role:       `{"role": "support"}`,
+			claims:     &auth.Claims{Login: "root", Role: entity.RoleAdmin},
+			statusCode: http.StatusNoContent,
+		},
+		{
+			name:       "Support is forbidden",
+			role:       `{"role": "admin"}`,
+			claims:     &auth.Claims{Login: "helpdesk", Role: entity.RoleSupport},
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "Legacy token without role",
+			role:       `{"role": "admin"}`,
+			claims:     &auth.Claims{Login: "test"},
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "Unknown role",
+			role:       `{"role": "root"}`,
+			claims:     &auth.Claims{Login: "root", Role: entity.RoleAdmin},
+			err:        usecase.ErrRole,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Unknown user",
+			role:       `{"role": "support"}`,
+			claims:     &auth.Claims{Login: "root", Role: entity.RoleAdmin},
+			err:        usecase.ErrUserNotFound,
+			statusCode: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, ctrl, repo, uc := controller(t)
+			repo.EXPECT().Do().Return(uc).AnyTimes()
+			repo.EXPECT().DoSetRole(gomock.Any(), "test", gomock.Any()).Return(tt.err).AnyTimes()
+
+			router := chi.NewRouter()
+			router.With(auth.RequireRole(ctx, uc.Err(), entity.RoleAdmin)).Put("/api/admin/users/{login}/role", ctrl.AdminSetRole)
+
+			r, err := http.NewRequest(http.MethodPut, "/api/admin/users/test/role", bytes.NewBufferString(tt.role))
+			require.NoError(t, err)
+			r = r.WithContext(context.WithValue(r.Context(), auth.ClaimsKey, tt.claims))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			assert.Equal(t, tt.statusCode, w.Code, "Код ответа не совпадает с ожидаемым")
+		})
+	}
+}
+
+func TestAdminTokenRestriction(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured string
+		token      string
+		method     string
+		path       string
+		body       string
+		hasAdmin   bool
+		statusCode int
+	}{
+		{
+			name:       "Role of the first administrator",
+			configured: "bootstrap",
+			token:      "bootstrap",
+			method:     http.MethodPut,
+			path:       "/api/admin/users/test/role",
+			body:       `{"role": "admin"}`,
+			statusCode: http.StatusNoContent,
+		},
+		{
+			name:       "Administrator already assigned",
+			configured: "bootstrap",
+			token:      "bootstrap",
+			method:     http.MethodPut,
+			path:       "/api/admin/users/test/role",
+			body:       `{"role": "admin"}`,
+			hasAdmin:   true,
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "Token not configured",
+			token:      "bootstrap",
+			method:     http.MethodPut,
+			path:       "/api/admin/users/test/role",
+			body:       `{"role": "admin"}`,
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "Wrong token",
+			configured: "bootstrap",
+			token:      "guess",
+			method:     http.MethodPut,
+			path:       "/api/admin/users/test/role",
+			body:       `{"role": "admin"}`,
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "Other admin route",
+			configured: "bootstrap",
+			token:      "bootstrap",
+			method:     http.MethodPost,
+			path:       "/api/admin/adjustments",
+			body:       `{"login": "test", "amount": 100, "reason": "bonus"}`,
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "Admin read route",
+			configured: "bootstrap",
+			token:      "bootstrap",
+			method:     http.MethodGet,
+			path:       "/api/admin/api-keys",
+			statusCode: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ctrl, repo, uc := controller(t)
+			adminToken := config.Cfg.AdminToken
+			config.Cfg.AdminToken = tt.configured
+			t.Cleanup(func() { config.Cfg.AdminToken = adminToken })
+
+			repo.EXPECT().Do().Return(uc).AnyTimes()
+			repo.EXPECT().DoHasAdmin(gomock.Any()).Return(tt.hasAdmin, nil).AnyTimes()
+			repo.EXPECT().DoSetRole(gomock.Any(), "test", entity.RoleAdmin).Return(nil).AnyTimes()
+
+			handler := ctrl.NewServer(chi.NewRouter()).Handler
+			r := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			r.Header.Set(auth.AdminHeader, tt.token)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.statusCode, w.Code, "Код ответа не совпадает с ожидаемым")
+		})
+	}
+}
+
+func TestAdminIdempotency(t *testing.T) {
+	ctx, ctrl, repo, uc := controller(t)
+	ttl := config.Cfg.AccessTokenTTL
+	config.Cfg.AccessTokenTTL = time.Minute
+	t.Cleanup(func() { config.Cfg.AccessTokenTTL = ttl })
+
+	token, err := auth.NewAccessToken(ctx, "root", entity.RoleAdmin, "sid")
+	require.NoError(t, err)
+
+	// Хранилище ответов в памяти: второй запрос с тем же ключом получает сохраненный ответ
+	var stored *entity.Idempotency
+	repo.EXPECT().Do().Return(uc).AnyTimes()
+	repo.EXPECT().DoIsAccessTokenRevoked(gomock.Any(), gomock.Any(), "root", "sid", gomock.Any()).Return(false, nil).AnyTimes()
+	repo.EXPECT().DoReserveIdempotencyKey(gomock.Any(), "root", "adjust-1", gomock.Any()).
+		DoAndReturn(func(_ context.Context, login, key, hash string) (*entity.Idempotency, error) {
+			if stored != nil {
+				return stored, nil
+			}
+			stored = &entity.Idempotency{Login: login, Key: key, RequestHash: hash}
+			return nil, nil
+		}).Times(2)
+	repo.EXPECT().DoSaveIdempotencyResponse(gomock.Any(), "root", "adjust-1", http.StatusOK, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _, _ string, status int, contentType string, body []byte) error {
+			stored.Status, stored.ContentType, stored.Body = status, contentType, body
+			return nil
+		}).Times(1)
+	repo.EXPECT().DoAdjustBalance(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, adj entity.Adjustment) (entity.Adjustment, error) {
+			return adj, nil
+		}).Times(1)
+
+	handler := ctrl.NewServer(chi.NewRouter()).Handler
+	for i, replayed := range []string{"", "true"} {
+		r := httptest.NewRequest(http.MethodPost, "/api/admin/adjustments",
+			bytes.NewBufferString(`{"login": "test", "amount": 100, "reason": "compensation"}`))
+		r.Header.Set(auth.AuthorizationHeader, auth.BearerScheme+" "+token)
+		r.Header.Set(idempotency.Header, "adjust-1")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusOK, w.Code, "Код ответа запроса %d не совпадает с ожидаемым", i+1)
+		assert.Equal(t, replayed, w.Header().Get(idempotency.ReplayedHeader), "Запрос %d", i+1)
+	}
+}
+
+func TestSecretResponsesNotPersisted(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		body    string
+		prepare func(repo *mocks.MockUseCase)
+	}{
+		{
+			name: "Confirm TOTP",
+			path: "/api/user/2fa/totp/confirm",
+			body: `{"code": "123456"}`,
+			prepare: func(repo *mocks.MockUseCase) {
+				repo.EXPECT().DoConfirmTOTP(gomock.Any(), "test", "123456").Return([]string{"recovery-code"}, nil)
+			},
+		},
+		{
+			name: "Change password",
+			path: "/api/user/password",
+			body: `{"current_password": "old-password", "new_password": "new-password"}`,
+			prepare: func(repo *mocks.MockUseCase) {
+				repo.EXPECT().DoChangePassword(gomock.Any(), "test", "old-password", "new-password", gomock.Any()).Return(nil)
+				repo.EXPECT().DoGetRole(gomock.Any(), "test").Return(entity.RoleUser, nil)
+				repo.EXPECT().DoIssueRefreshToken(gomock.Any(), "test", gomock.Any()).Return(entity.Session{ID: "session"}, "refresh", nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, ctrl, repo, uc := controller(t)
+			ttl := config.Cfg.AccessTokenTTL
+			config.Cfg.AccessTokenTTL = time.Minute
+			t.Cleanup(func() { config.Cfg.AccessTokenTTL = ttl })
+
+			token, err := auth.NewAccessToken(ctx, "test", entity.RoleUser, "sid")
+			require.NoError(t, err)
+
+			repo.EXPECT().Do().Return(uc).AnyTimes()
+			repo.EXPECT().DoIsAccessTokenRevoked(gomock.Any(), gomock.Any(), "test", "sid", gomock.Any()).Return(false, nil).AnyTimes()
+			repo.EXPECT().DoReserveIdempotencyKey(gomock.Any(), "test", "key-1", gomock.Any()).Return(nil, nil)
+			// Ответ с секретами не сохраняется, а ключ освобождается
+			repo.EXPECT().DoSaveIdempotencyResponse(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			repo.EXPECT().DoDeleteIdempotencyKey(gomock.Any(), "test", "key-1").Return(nil)
+			tt.prepare(repo)
+
+			r := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewBufferString(tt.body))
+			r.Header.Set(auth.AuthorizationHeader, auth.BearerScheme+" "+token)
+			r.Header.Set(idempotency.Header, "key-1")
+			w := httptest.NewRecorder()
+			ctrl.NewServer(chi.NewRouter()).Handler.ServeHTTP(w, r)
+			assert.Equal(t, http.StatusOK, w.Code, "Код ответа не совпадает с ожидаемым")
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		})
+	}
+}
+
+func TestAdminSetWithdrawLimitsHandler(t *testing.T) {
+	maxSum, negative, hourly := float32(500), float32(-1), 0
+	tests := []struct {
+		name       string
+		body       string
+		limits     entity.WithdrawLimits
+		err        error
+		statusCode int
+	}{
+		{
+			name:       "Limits set",
+			body:       `{"max_sum": 500, "max_hourly_count": 0}`,
+			limits:     entity.WithdrawLimits{Login: "test", MaxSum: &maxSum, MaxHourlyCount: &hourly, UpdatedBy: "root"},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Unknown field",
+			body:       `{"max_weekly_sum": 500}`,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Negative limit",
+			body:       `{"max_sum": -1}`,
+			limits:     entity.WithdrawLimits{Login: "test", MaxSum: &negative, UpdatedBy: "root"},
+			err:        usecase.ErrRequestFormat,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Unknown user",
+			body:       `{}`,
+			limits:     entity.WithdrawLimits{Login: "test", UpdatedBy: "root"},
+			err:        usecase.ErrUserNotFound,
+			statusCode: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ctrl, repo, uc := controller(t)
+			repo.EXPECT().Do().Return(uc).AnyTimes()
+			if tt.limits.Login != "" {
+				// В ограничения передаются значения из запроса, пустые поля остаются пустыми
+				repo.EXPECT().DoSetWithdrawLimits(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, limits entity.WithdrawLimits) (entity.WithdrawLimits, error) {
+						assert.Equal(t, tt.limits.Login, limits.Login)
+						assert.Equal(t, tt.limits.UpdatedBy, limits.UpdatedBy)
+						assert.Equal(t, tt.limits.MaxSum, limits.MaxSum)
+						assert.Equal(t, tt.limits.MaxHourlyCount, limits.MaxHourlyCount)
+						assert.Nil(t, limits.MaxDailySum)
+						return limits, tt.err
+					}).Times(1)
+			}
+
+			router := chi.NewRouter()
+			router.Put("/api/admin/users/{login}/withdraw-limits", ctrl.AdminSetWithdrawLimits)
+
+			r := httptest.NewRequest(http.MethodPut, "/api/admin/users/test/withdraw-limits", bytes.NewBufferString(tt.body))
+			r = r.WithContext(context.WithValue(r.Context(), auth.LoginKey, "root"))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			assert.Equal(t, tt.statusCode, w.Code, "Код ответа не совпадает с ожидаемым")
+		})
+	}
+}
+
+func TestBearerAuthentication(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorization func(token string) string
+		revoked       bool
+		statusCode    int
+		code          string
+	}{
+		{
+			name:          "Bearer token only",
+			authorization: func(token string) string { return auth.BearerScheme + " " + token },
+			statusCode:    http.StatusAccepted,
+		},
+		{
+			name:          "Scheme is case-insensitive",
+			authorization: func(token string) string { return "bearer " + token },
+			statusCode:    http.StatusAccepted,
+		},
+		{
+			name:          "Revoked token",
+			authorization: func(token string) string { return auth.BearerScheme + " " + token },
+			revoked:       true,
+			statusCode:    http.StatusUnauthorized,
+			code:          "token_revoked",
+		},
+		{
+			name:          "Tampered signature",
+			authorization: func(token string) string { return auth.BearerScheme + " " + token[:len(token)-2] + "xx" },
+			statusCode:    http.StatusUnauthorized,
+			code:          "invalid_token",
+		},
+		{
+			name:          "Malformed token",
+			authorization: func(string) string { return auth.BearerScheme + " not-a-jwt" },
+			statusCode:    http.StatusUnauthorized,
+			code:          "invalid_token",
+		},
+		{
+			name:          "Empty token",
+			authorization: func(string) string { return auth.BearerScheme + " " },
+			statusCode:    http.StatusUnauthorized,
+			code:          "invalid_token",
+		},
+		{
+			name:          "Other scheme without cookie",
+			authorization: func(token string) string { return "Basic " + token },
+			statusCode:    http.StatusUnauthorized,
+			code:          "authentication_required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _, repo, uc := controller(t)
+			ttl := config.Cfg.AccessTokenTTL
+			config.Cfg.AccessTokenTTL = time.Minute
+			t.Cleanup(func() { config.Cfg.AccessTokenTTL = ttl })
+
+			token, err := auth.NewAccessToken(ctx, "test", entity.RoleUser, "sid")
+			require.NoError(t, err)
+			repo.EXPECT().DoIsAccessTokenRevoked(gomock.Any(), gomock.Any(), "test", "sid", gomock.Any()).Return(tt.revoked, nil).AnyTimes()
+
+			router := chi.NewRouter()
+			router.With(
+				auth.Authentication(ctx, repo, uc.Err(), auth.GetBearer, auth.GetCookie),
+				auth.CSRF(ctx, uc.Err()),
+			).Post("/api/user/orders", func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "test", r.Context().Value(auth.LoginKey))
+				w.WriteHeader(http.StatusAccepted)
+			})
+
+			// Запрос без куки аутентификации не требует CSRF-токена
+			r := httptest.NewRequest(http.MethodPost, "/api/user/orders", bytes.NewBufferString("12345678903"))
+			r.Header.Set(auth.AuthorizationHeader, tt.authorization(token))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.statusCode, w.Code, "Код ответа не совпадает с ожидаемым")
+			if tt.code != "" {
+				var p problem.Problem
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+				assert.Equal(t, tt.code, p.Code)
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	tests := []struct {
+		name       string
+		key        entity.APIKey
+		onBehalfOf string
+		err        error
+		countErr   error
+		requests   int
+		statusCode int
+	}{
+		{
+			name:       "Key with scope",
+			key:        entity.APIKey{ID: "shop", Scopes: []string{entity.ScopeOrdersWrite}, RateLimit: 10},
+			onBehalfOf: "test",
+			requests:   1,
+			statusCode: http.StatusAccepted,
+		},
+		{
+			name:       "Key without scope",
+			key:        entity.APIKey{ID: "reader", Scopes: []string{entity.ScopeOrdersRead}, RateLimit: 10},
+			onBehalfOf: "test",
+			requests:   1,
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "Revoked key",
+			onBehalfOf: "test",
+			err:        usecase.ErrAPIKey,
+			requests:   1,
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "Unknown user",
+			onBehalfOf: "nobody",
+			err:        usecase.ErrUserNotFound,
+			requests:   1,
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name:       "No X-On-Behalf-Of",
+			requests:   1,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Rate limit exceeded",
+			key:        entity.APIKey{ID: "limited", Scopes: []string{entity.ScopeOrdersWrite}, RateLimit: 1},
+			onBehalfOf: "test",
+			requests:   2,
+			statusCode: http.StatusTooManyRequests,
+		},
+		{
+			name:       "Request counter error",
+			key:        entity.APIKey{ID: "shop", Scopes: []string{entity.ScopeOrdersWrite}, RateLimit: 10},
+			onBehalfOf: "test",
+			countErr:   errors.New("db error"),
+			requests:   1,
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _, repo, uc := controller(t)
+			repo.EXPECT().DoAuthenticateAPIKey(gomock.Any(), "gm_key", tt.onBehalfOf).Return(tt.key, tt.err).AnyTimes()
+			// Счетчик запросов ключа хранится в базе данных; здесь он ведется в памяти теста
+			var counted int
+			repo.EXPECT().DoCountAPIKeyRequest(gomock.Any(), tt.key.ID, tt.key.RateLimit).DoAndReturn(
+				func(context.Context, string, int) (time.Duration, error) {
+					counted++
+					if counted > tt.key.RateLimit {
+						return 30 * time.Second, tt.countErr
+					}
+					return 0, tt.countErr
+				}).AnyTimes()
+
+			router := chi.NewRouter()
+			router.With(
+				auth.Authentication(ctx, repo, uc.Err(), auth.GetAPIKey(repo), auth.GetBearer, auth.GetCookie),
+				auth.RequireScope(ctx, uc.Err(), entity.ScopeOrdersWrite),
+			).Post("/api/user/orders", func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.onBehalfOf, r.Context().Value(auth.LoginKey))
+				w.WriteHeader(http.StatusAccepted)
+			})
+
+			var w *httptest.ResponseRecorder
+			for i := 0; i < tt.requests; i++ {
+				r, err := http.NewRequest(http.MethodPost, "/api/user/orders", bytes.NewBufferString("12345678903"))
+				require.NoError(t, err)
+				r.Header.Set(auth.APIKeyHeader, "gm_key")
+				if tt.onBehalfOf != "" {
+					r.Header.Set(auth.OnBehalfOfHeader, tt.onBehalfOf)
+				}
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, r)
+			}
+			assert.Equal(t, tt.statusCode, w.Code, "Код ответа не совпадает с ожидаемым")
+			if tt.statusCode == http.StatusTooManyRequests {
+				assert.Equal(t, "30", w.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
+
+func TestRevokeSessionHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		statusCode int
+	}{
+		{
+			name:       "Session revoked",
+			statusCode: http.StatusNoContent,
+		},
+		{
+			name:       "Unknown session",
+			err:        usecase.ErrNoSession,
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name:       "Internal server error",
+			err:        errors.New("internal server error"),
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ctrl, repo, uc := controller(t)
+			repo.EXPECT().Do().Return(uc).Times(1)
+			repo.EXPECT().DoRevokeSession(gomock.Any(), "test", "laptop").Return(tt.err).Times(1)
+
+			router := chi.NewRouter()
+			router.Delete("/api/user/sessions/{id}", ctrl.RevokeSession)
+
+			r, err := http.NewRequest(http.MethodDelete, "/api/user/sessions/laptop", nil)
+			require.NoError(t, err)
+			r = r.WithContext(context.WithValue(r.Context(), auth.LoginKey, "test"))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			assert.Equal(t, tt.statusCode, w.Code, "Код ответа не совпадает с ожидаемым")
+		})
+	}
+}
+
+func TestOIDCCallbackHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		cookie     string
+		challenge  string
+		err        error
+		statusCode int
+	}{
+		{
+			name:       "Login success",
+			query:      "?state=xyz&code=abc",
+			cookie:     "xyz",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "State mismatch",
+			query:      "?state=xyz&code=abc",
+			cookie:     "other",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Expired state",
+			query:      "?state=xyz&code=abc",
+			cookie:     "xyz",
+			err:        usecase.ErrOIDCState,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Provider rejected login",
+			query:      "?state=xyz&error=access_denied",
+			cookie:     "xyz",
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "Invalid ID token",
+			query:      "?state=xyz&code=abc",
+			cookie:     "xyz",
+			err:        usecase.ErrOIDCLogin,
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "Second factor required",
+			query:      "?state=xyz&code=abc",
+			cookie:     "xyz",
+			challenge:  "challenge",
+			err:        usecase.ErrOTPRequired,
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "Existing account with unverified login",
+			query:      "?state=xyz&code=abc",
+			cookie:     "xyz",
+			err:        usecase.ErrOIDCAccountExists,
+			statusCode: http.StatusConflict,
+		},
+		{
+			name:       "Single sign-on disabled",
+			query:      "?state=xyz&code=abc",
+			cookie:     "xyz",
+			err:        usecase.ErrOIDCDisabled,
+			statusCode: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ctrl, repo, uc := controller(t)
+			repo.EXPECT().Do().Return(uc).Times(1)
+			repo.EXPECT().DoFinishOIDCLogin(gomock.Any(), "xyz", "abc").Return("alice", tt.challenge, tt.err).AnyTimes()
+			repo.EXPECT().DoGetRole(gomock.Any(), "alice").Return(entity.RoleUser, nil).AnyTimes()
+			repo.EXPECT().DoIssueRefreshToken(gomock.Any(), "alice", gomock.Any()).Return(entity.Session{ID: "session"}, "refresh", nil).AnyTimes()
+
+			r, err := http.NewRequest(http.MethodGet, "/api/user/oidc/callback"+tt.query, nil)
+			require.NoError(t, err)
+			r.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: tt.cookie})
+			w := httptest.NewRecorder()
+			handler := http.HandlerFunc(ctrl.OIDCCallback)
+			handler(w, r)
+			assert.Equal(t, tt.statusCode, w.Code, "Код ответа не совпадает с ожидаемым")
+			if tt.statusCode == http.StatusOK {
+				assert.NotEmpty(t, w.Header().Get(auth.AuthorizationHeader))
+			} else {
+				assert.Empty(t, w.Header().Get(auth.AuthorizationHeader), "Токен выдан без завершения входа")
+			}
+
+			var challenge string
+			for _, cookie := range w.Result().Cookies() {
+				if cookie.Name == oidcChallengeCookie {
+					challenge = cookie.Value
+				}
+			}
+			assert.Equal(t, tt.challenge, challenge, "Кука незавершенного входа не совпадает с ожидаемой")
+		})
+	}
+}
+
+func TestOIDCSecondFactorHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		cookie     string
+		err        error
+		statusCode int
+		code       string
+	}{
+		{
+			name:       "Second factor confirmed",
+			body:       `{"code": "123456"}`,
+			cookie:     "challenge",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Invalid code",
+			body:       `{"code": "000000"}`,
+			cookie:     "challenge",
+			err:        usecase.ErrOTPCode,
+			statusCode: http.StatusUnauthorized,
+			code:       "invalid_otp",
+		},
+		{
+			name:       "Unknown or expired challenge",
+			body:       `{"code": "123456"}`,
+			cookie:     "challenge",
+			err:        usecase.ErrOIDCState,
+			statusCode: http.StatusBadRequest,
+			code:       "invalid_sso_state",
+		},
+		{
+			name:       "Throttled",
+			body:       `{"code": "123456"}`,
+			cookie:     "challenge",
+			err:        &usecase.ThrottleError{RetryAfter: 30 * time.Second},
+			statusCode: http.StatusTooManyRequests,
+		},
+		{
+			name:       "Without challenge cookie",
+			body:       `{"code": "123456"}`,
+			statusCode: http.StatusBadRequest,
+			code:       "invalid_sso_state",
+		},
+		{
+			name:       "Without code",
+			body:       `{}`,
+			cookie:     "challenge",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ctrl, repo, uc := controller(t)
+			repo.EXPECT().Do().Return(uc).AnyTimes()
+			repo.EXPECT().DoFinishOIDCChallenge(gomock.Any(), "challenge", gomock.Any(), gomock.Any()).Return("alice", tt.err).AnyTimes()
+			repo.EXPECT().DoGetRole(gomock.Any(), "alice").Return(entity.RoleUser, nil).AnyTimes()
+			repo.EXPECT().DoIssueRefreshToken(gomock.Any(), "alice", gomock.Any()).Return(entity.Session{ID: "session"}, "refresh", nil).AnyTimes()
+
+			r, err := http.NewRequest(http.MethodPost, "/api/user/oidc/otp", strings.NewReader(tt.body))
+			require.NoError(t, err)
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: oidcChallengeCookie, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			handler := http.HandlerFunc(ctrl.OIDCSecondFactor)
+			handler(w, r)
+			assert.Equal(t, tt.statusCode, w.Code, "Код ответа не совпадает с ожидаемым")
+			if tt.statusCode == http.StatusOK {
+				assert.NotEmpty(t, w.Header().Get(auth.AuthorizationHeader))
+			} else {
+				assert.Empty(t, w.Header().Get(auth.AuthorizationHeader), "Токен выдан без проверки второго фактора")
+			}
+			if tt.statusCode == http.StatusTooManyRequests {
+				assert.Equal(t, "30", w.Header().Get("Retry-After"))
+			}
+			if tt.code != "" {
+				assert.Contains(t, w.Body.String(), tt.code, "Код ошибки не совпадает с ожидаемым")
+			}
+		})
+	}
+}
+
+func TestDeleteAccountHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		err        error
+		statusCode int
+	}{
+		{
+			name:       "Account deleted",
+			body:       `{"password": "12345"}`,
+			statusCode: http.StatusNoContent,
+		},
+		{
+			name:       "Wrong password",
+			body:       `{"password": "guess"}`,
+			err:        usecase.ErrPassword,
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "OTP required",
+			body:       `{"password": "12345"}`,
+			err:        usecase.ErrOTPRequired,
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "Too many attempts",
+			body:       `{"password": "guess"}`,
+			err:        &usecase.ThrottleError{RetryAfter: time.Second},
+			statusCode: http.StatusTooManyRequests,
+		},
+		{
+			name:       "No password",
+			body:       `{}`,
+			statusCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ctrl, repo, uc := controller(t)
+			repo.EXPECT().Do().Return(uc).Times(1)
+			repo.EXPECT().DoDeleteAccount(gomock.Any(), "test", gomock.Any(), "", gomock.Any()).Return(tt.err).AnyTimes()
+			if tt.statusCode == http.StatusNoContent {
+				repo.EXPECT().DoRevokeAccessToken(gomock.Any(), "jti", gomock.Any()).Return(nil).Times(1)
+			}
+
+			claims := &auth.Claims{Login: "test"}
+			claims.ID = "jti"
+			claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Minute))
+			r, err := http.NewRequest(http.MethodDelete, "/api/user", bytes.NewBufferString(tt.body))
+			require.NoError(t, err)
+			r = r.WithContext(context.WithValue(r.Context(), auth.ClaimsKey, claims))
+			w := httptest.NewRecorder()
+			handler := http.HandlerFunc(ctrl.DeleteAccount)
+			handler(w, r)
+			assert.Equal(t, tt.statusCode, w.Code, "Код ответа не совпадает с ожидаемым")
+		})
+	}
+}
+
+func TestCSRFMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		claims     *auth.Claims
+		cookie     string
+		header     string
+		statusCode int
+	}{
+		{
+			name:       "Cookie session with token",
+			method:     http.MethodPost,
+			claims:     &auth.Claims{Login: "test", Cookie: true},
+			cookie:     "csrf",
+			header:     "csrf",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Cookie session without token",
+			method:     http.MethodPost,
+			claims:     &auth.Claims{Login: "test", Cookie: true},
+			cookie:     "csrf",
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "Cookie session with wrong token",
+			method:     http.MethodDelete,
+			claims:     &auth.Claims{Login: "test", Cookie: true},
+			cookie:     "csrf",
+			header:     "forged",
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "Cookie session read",
+			method:     http.MethodGet,
+			claims:     &auth.Claims{Login: "test", Cookie: true},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Bearer token",
+			method:     http.MethodPost,
+			claims:     &auth.Claims{Login: "test"},
+			statusCode: http.StatusOK,
+		},
+	}
+	csrf := config.Cfg.Cookies.CSRF
+	config.Cfg.Cookies.CSRF = true
+	t.Cleanup(func() { config.Cfg.Cookies.CSRF = csrf })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _, _, uc := controller(t)
+			handler := auth.CSRF(ctx, uc.Err())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			r, err := http.NewRequest(tt.method, "/api/user/balance/withdraw", nil)
+			require.NoError(t, err)
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: auth.CSRFCookie, Value: tt.cookie})
+			}
+			if tt.header != "" {
+				r.Header.Set(auth.CSRFHeader, tt.header)
+			}
+			r = r.WithContext(context.WithValue(r.Context(), auth.ClaimsKey, tt.claims))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			assert.Equal(t, tt.statusCode, w.Code, "Код ответа не совпадает с ожидаемым")
 		})
 	}
 }
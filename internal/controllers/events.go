@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nextlag/gomart/internal/config"
+	"github.com/nextlag/gomart/internal/mw/auth"
+	"github.com/nextlag/gomart/internal/problem"
+	"github.com/nextlag/gomart/pkg/events"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+const (
+	// eventReset - событие, после которого клиент должен заново запросить заказы и баланс,
+	// потому что часть событий после Last-Event-ID пропущена
+	eventReset = "reset"
+	// eventRetry - интервал переподключения клиента после обрыва соединения, мс
+	eventRetry = 3000
+)
+
+// Events обрабатывает запрос на подписку на события пользователя в формате Server-Sent Events.
+//
+// Этот метод принимает запрос HTTP GET и держит соединение открытым, отправляя события order об изменении
+// статуса заказа и balance об изменении баланса пользователя. Каждое событие содержит идентификатор: после обрыва
+// соединения клиент передает последний полученный идентификатор в заголовке Last-Event-ID (или в параметре
+// last_event_id) и получает пропущенные события. Если пропущенные события уже не хранятся, первым отправляется
+// событие reset. Раз в config.Cfg.Events.Heartbeat (если он не равен нулю) отправляется комментарий,
+// поддерживающий соединение.
+// Если клиент не успевает читать события, соединение закрывается и клиент переподключается.
+// Если идентификатор последнего события некорректен, метод возвращает ошибку BadRequest (400).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) Events(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+	// Получаем логин пользователя из контекста запроса
+	user, _ := r.Context().Value(auth.LoginKey).(string)
+
+	// Браузерный EventSource передает идентификатор только в заголовке, параметр нужен для первого подключения
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	var lastID uint64
+	if lastEventID != "" {
+		var err error
+		if lastID, err = strconv.ParseUint(lastEventID, 10, 64); err != nil {
+			problem.Write(w, r, er.ErrRequestFormat)
+			return
+		}
+	}
+
+	sub, complete := c.uc.DoSubscribe(user, lastID)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Connection", "keep-alive")
+	// Отключаем буферизацию ответа в обратном прокси nginx
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", eventRetry)
+	if !complete {
+		fmt.Fprintf(w, "event: %s\ndata: {}\n\n", eventReset)
+	}
+	flush(w)
+
+	// Нулевой интервал отключает комментарии, поддерживающие соединение
+	var heartbeat <-chan time.Time
+	if d := config.Cfg.Events.Heartbeat; d > 0 {
+		ticker := time.NewTicker(d)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+	for {
+		select {
+		case e, ok := <-sub.C:
+			if !ok {
+				log.Info("events subscriber dropped", "user", user, "slow", sub.Dropped())
+				return
+			}
+			if _, err := writeEvent(w, e); err != nil {
+				return
+			}
+		case <-heartbeat:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		case <-c.ctx.Done():
+			return
+		}
+		flush(w)
+	}
+}
+
+// writeEvent записывает событие в формате Server-Sent Events.
+func writeEvent(w http.ResponseWriter, e events.Event) (int, error) {
+	return fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, e.Data)
+}
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/nextlag/gomart/internal/mw/auth"
+	"github.com/nextlag/gomart/internal/problem"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+// GetOrder обрабатывает запрос на получение одного заказа пользователя.
+//
+// Этот метод принимает запрос HTTP GET с номером заказа в пути и возвращает статус, начисление,
+// время загрузки заказа и историю изменения его статуса в формате JSON со статусом OK (200).
+// Ответ содержит заголовок ETag: если он совпадает со значением заголовка If-None-Match запроса,
+// метод возвращает статус NotModified (304) без тела, что удешевляет опрос статуса заказа.
+// Если заказ не найден или загружен другим пользователем, метод возвращает ошибку NotFound (404).
+// Если происходит ошибка при получении заказа из UseCase, метод возвращает ошибку InternalServerError (500).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) GetOrder(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+	// Получаем логин пользователя из контекста запроса
+	user, _ := r.Context().Value(auth.LoginKey).(string)
+
+	order, err := c.uc.DoGetOrder(r.Context(), user, chi.URLParam(r, "number"))
+	switch {
+	case errors.Is(err, er.ErrNoOrder):
+		problem.Write(w, r, er.ErrNoOrder)
+		return
+	case err != nil:
+		log.Error("handler GetOrder", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+
+	result, err := json.Marshal(order)
+	if err != nil {
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+	sum := sha256.Sum256(result)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	// Ответ зависит от пользователя, поэтому его можно хранить только в кэше клиента с обязательной проверкой
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if etagMatch(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(result)
+}
+
+// etagMatch сообщает, совпадает ли etag с одним из значений заголовка If-None-Match.
+// Значения сравниваются без учета признака слабого ETag, как того требует RFC 9110.
+func etagMatch(header, etag string) bool {
+	for _, v := range strings.Split(header, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || strings.TrimPrefix(v, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -1,9 +1,11 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/nextlag/gomart/internal/mw/auth"
+	"github.com/nextlag/gomart/internal/problem"
 	"github.com/nextlag/gomart/pkg/logger/l"
 )
 
@@ -11,6 +13,9 @@ import (
 //
 // Этот метод принимает запрос HTTP GET для получения списка заказов пользователя.
 // При успешном выполнении метод возвращает список заказов пользователя в формате JSON и статус OK (200).
+// Если передан хотя бы один из параметров limit, cursor, status, from, to или sort, заказы возвращаются
+// постранично (по умолчанию по 50) с фильтрами по статусу и периоду загрузки, а ссылки на первую и следующую
+// страницу передаются в заголовке Link. Если параметры некорректны, метод возвращает ошибку BadRequest (400).
 // Если происходит ошибка при получении списка заказов из UseCase, метод возвращает ошибку InternalServerError (500)
 // с сообщением "internal server error".
 //
@@ -27,12 +32,33 @@ func (c *Controller) GetOrders(w http.ResponseWriter, r *http.Request) {
 	// Получаем объект ошибки из UseCase
 	er := c.uc.Do().Err()
 
+	// Если переданы параметры страницы, возвращаем заказы постранично
+	filter, paged, err := parseHistoryFilter(r.URL.Query())
+	if err != nil {
+		problem.Write(w, r, er.ErrRequestFormat)
+		return
+	}
+	if paged {
+		orders, next, err := c.uc.DoGetOrdersPage(r.Context(), user, filter)
+		switch {
+		case errors.Is(err, er.ErrRequestFormat):
+			problem.Write(w, r, er.ErrRequestFormat)
+		case err != nil:
+			log.Error("handler GetOrders", l.ErrAttr(err))
+			problem.Write(w, r, er.ErrInternalServer)
+		default:
+			setPageLinks(w, r, next)
+			writeJSON(w, r, http.StatusOK, orders)
+		}
+		return
+	}
+
 	// Получаем список заказов пользователя из UseCase
 	result, err := c.uc.DoGetOrders(r.Context(), user)
 	if err != nil {
 		// Если произошла ошибка при получении списка заказов, логируем её и возвращаем ошибку InternalServerError
 		log.Error("handler GetOrders", l.ErrAttr(err))
-		http.Error(w, er.ErrInternalServer.Error(), http.StatusInternalServerError)
+		problem.Write(w, r, er.ErrInternalServer)
 		return
 	}
 
@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nextlag/gomart/internal/entity"
+	"github.com/nextlag/gomart/internal/usecase"
+)
+
+// historyParams - параметры запроса, включающие постраничную выдачу заказов и списаний.
+var historyParams = []string{"limit", "cursor", "status", "from", "to", "sort"}
+
+// parseHistoryFilter разбирает параметры постраничной выдачи заказов и списаний:
+// limit, cursor, status (через запятую или несколькими параметрами), from и to
+// в формате RFC 3339 или YYYY-MM-DD (день to включается в период) и sort со значениями asc и desc.
+//
+// Параметры:
+//   - query: параметры запроса.
+//
+// Возвращаемые значения:
+//   - entity.HistoryFilter: параметры страницы.
+//   - bool: true, если передан хотя бы один параметр постраничной выдачи.
+//   - error: usecase.ErrRequestFormat, если параметры некорректны.
+func parseHistoryFilter(query url.Values) (entity.HistoryFilter, bool, error) {
+	var f entity.HistoryFilter
+	paged := false
+	for _, p := range historyParams {
+		paged = paged || query.Has(p)
+	}
+	if !paged {
+		return f, false, nil
+	}
+
+	var err error
+	if v := query.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit <= 0 {
+			return f, true, usecase.ErrRequestFormat
+		}
+	}
+	f.Cursor = query.Get("cursor")
+	for _, v := range query["status"] {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.ToUpper(strings.TrimSpace(s)); s != "" {
+				f.Status = append(f.Status, s)
+			}
+		}
+	}
+	if f.From, err = parseStatementTime(query.Get("from"), time.Time{}, false); err != nil {
+		return f, true, usecase.ErrRequestFormat
+	}
+	if f.To, err = parseStatementTime(query.Get("to"), time.Time{}, true); err != nil {
+		return f, true, usecase.ErrRequestFormat
+	}
+	if !f.To.IsZero() && f.To.Before(f.From) {
+		return f, true, usecase.ErrRequestFormat
+	}
+	switch query.Get("sort") {
+	case "", "asc":
+	case "desc":
+		f.Desc = true
+	default:
+		return f, true, usecase.ErrRequestFormat
+	}
+	return f, true, nil
+}
+
+// setPageLinks добавляет в ответ заголовок Link со ссылками на первую и, если она есть, следующую страницу
+// с теми же параметрами запроса.
+func setPageLinks(w http.ResponseWriter, r *http.Request, next string) {
+	link := func(cursor, rel string) string {
+		query := r.URL.Query()
+		query.Del("cursor")
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		u := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+		return "<" + u.String() + `>; rel="` + rel + `"`
+	}
+	w.Header().Add("Link", link("", "first"))
+	if next != "" {
+		w.Header().Add("Link", link(next, "next"))
+	}
+}
@@ -8,9 +8,12 @@ import (
 	context "context"
 	http "net/http"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	entity "github.com/nextlag/gomart/internal/entity"
 	usecase "github.com/nextlag/gomart/internal/usecase"
+	events "github.com/nextlag/gomart/pkg/events"
 )
 
 // MockUseCase is a mock of UseCase interface.
@@ -50,32 +53,270 @@ func (mr *MockUseCaseMockRecorder) Do() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Do", reflect.TypeOf((*MockUseCase)(nil).Do))
 }
 
+// DoAdjustBalance mocks base method.
+func (m *MockUseCase) DoAdjustBalance(arg0 context.Context, arg1 entity.Adjustment) (entity.Adjustment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoAdjustBalance", arg0, arg1)
+	ret0, _ := ret[0].(entity.Adjustment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoAdjustBalance indicates an expected call of DoAdjustBalance.
+func (mr *MockUseCaseMockRecorder) DoAdjustBalance(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoAdjustBalance", reflect.TypeOf((*MockUseCase)(nil).DoAdjustBalance), arg0, arg1)
+}
+
 // DoAuth mocks base method.
-func (m *MockUseCase) DoAuth(arg0 context.Context, arg1, arg2 string, arg3 *http.Request) error {
+func (m *MockUseCase) DoAuth(arg0 context.Context, arg1, arg2, arg3 string, arg4 *http.Request) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DoAuth", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "DoAuth", arg0, arg1, arg2, arg3, arg4)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DoAuth indicates an expected call of DoAuth.
-func (mr *MockUseCaseMockRecorder) DoAuth(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+func (mr *MockUseCaseMockRecorder) DoAuth(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoAuth", reflect.TypeOf((*MockUseCase)(nil).DoAuth), arg0, arg1, arg2, arg3, arg4)
+}
+
+// DoAuthenticateAPIKey mocks base method.
+func (m *MockUseCase) DoAuthenticateAPIKey(arg0 context.Context, arg1, arg2 string) (entity.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoAuthenticateAPIKey", arg0, arg1, arg2)
+	ret0, _ := ret[0].(entity.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoAuthenticateAPIKey indicates an expected call of DoAuthenticateAPIKey.
+func (mr *MockUseCaseMockRecorder) DoAuthenticateAPIKey(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoAuthenticateAPIKey", reflect.TypeOf((*MockUseCase)(nil).DoAuthenticateAPIKey), arg0, arg1, arg2)
+}
+
+// DoChangePassword mocks base method.
+func (m *MockUseCase) DoChangePassword(arg0 context.Context, arg1, arg2, arg3 string, arg4 *http.Request) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoChangePassword", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DoChangePassword indicates an expected call of DoChangePassword.
+func (mr *MockUseCaseMockRecorder) DoChangePassword(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoChangePassword", reflect.TypeOf((*MockUseCase)(nil).DoChangePassword), arg0, arg1, arg2, arg3, arg4)
+}
+
+// DoConfirmTOTP mocks base method.
+func (m *MockUseCase) DoConfirmTOTP(arg0 context.Context, arg1, arg2 string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoConfirmTOTP", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoConfirmTOTP indicates an expected call of DoConfirmTOTP.
+func (mr *MockUseCaseMockRecorder) DoConfirmTOTP(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoConfirmTOTP", reflect.TypeOf((*MockUseCase)(nil).DoConfirmTOTP), arg0, arg1, arg2)
+}
+
+// DoCountAPIKeyRequest mocks base method.
+func (m *MockUseCase) DoCountAPIKeyRequest(arg0 context.Context, arg1 string, arg2 int) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoCountAPIKeyRequest", arg0, arg1, arg2)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoCountAPIKeyRequest indicates an expected call of DoCountAPIKeyRequest.
+func (mr *MockUseCaseMockRecorder) DoCountAPIKeyRequest(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoCountAPIKeyRequest", reflect.TypeOf((*MockUseCase)(nil).DoCountAPIKeyRequest), arg0, arg1, arg2)
+}
+
+// DoCreateAPIKey mocks base method.
+func (m *MockUseCase) DoCreateAPIKey(arg0 context.Context, arg1 entity.APIKey) (entity.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoCreateAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(entity.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoCreateAPIKey indicates an expected call of DoCreateAPIKey.
+func (mr *MockUseCaseMockRecorder) DoCreateAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoCreateAPIKey", reflect.TypeOf((*MockUseCase)(nil).DoCreateAPIKey), arg0, arg1)
+}
+
+// DoCreateCampaign mocks base method.
+func (m *MockUseCase) DoCreateCampaign(arg0 context.Context, arg1 entity.Campaign) (entity.Campaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoCreateCampaign", arg0, arg1)
+	ret0, _ := ret[0].(entity.Campaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoCreateCampaign indicates an expected call of DoCreateCampaign.
+func (mr *MockUseCaseMockRecorder) DoCreateCampaign(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoAuth", reflect.TypeOf((*MockUseCase)(nil).DoAuth), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoCreateCampaign", reflect.TypeOf((*MockUseCase)(nil).DoCreateCampaign), arg0, arg1)
 }
 
 // DoDebit mocks base method.
-func (m *MockUseCase) DoDebit(arg0 context.Context, arg1, arg2 string, arg3 float32) error {
+func (m *MockUseCase) DoDebit(arg0 context.Context, arg1, arg2 string, arg3 float32, arg4 string, arg5 *http.Request) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DoDebit", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "DoDebit", arg0, arg1, arg2, arg3, arg4, arg5)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DoDebit indicates an expected call of DoDebit.
-func (mr *MockUseCaseMockRecorder) DoDebit(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+func (mr *MockUseCaseMockRecorder) DoDebit(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoDebit", reflect.TypeOf((*MockUseCase)(nil).DoDebit), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+// DoDeleteAccount mocks base method.
+func (m *MockUseCase) DoDeleteAccount(arg0 context.Context, arg1, arg2, arg3 string, arg4 *http.Request) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoDeleteAccount", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DoDeleteAccount indicates an expected call of DoDeleteAccount.
+func (mr *MockUseCaseMockRecorder) DoDeleteAccount(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoDeleteAccount", reflect.TypeOf((*MockUseCase)(nil).DoDeleteAccount), arg0, arg1, arg2, arg3, arg4)
+}
+
+// DoDeleteIdempotencyKey mocks base method.
+func (m *MockUseCase) DoDeleteIdempotencyKey(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoDeleteIdempotencyKey", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DoDeleteIdempotencyKey indicates an expected call of DoDeleteIdempotencyKey.
+func (mr *MockUseCaseMockRecorder) DoDeleteIdempotencyKey(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoDeleteIdempotencyKey", reflect.TypeOf((*MockUseCase)(nil).DoDeleteIdempotencyKey), arg0, arg1, arg2)
+}
+
+// DoEnrollTOTP mocks base method.
+func (m *MockUseCase) DoEnrollTOTP(arg0 context.Context, arg1 string) (entity.TOTPEnrollment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoEnrollTOTP", arg0, arg1)
+	ret0, _ := ret[0].(entity.TOTPEnrollment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoEnrollTOTP indicates an expected call of DoEnrollTOTP.
+func (mr *MockUseCaseMockRecorder) DoEnrollTOTP(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoEnrollTOTP", reflect.TypeOf((*MockUseCase)(nil).DoEnrollTOTP), arg0, arg1)
+}
+
+// DoExportAccount mocks base method.
+func (m *MockUseCase) DoExportAccount(arg0 context.Context, arg1 string) (entity.AccountExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoExportAccount", arg0, arg1)
+	ret0, _ := ret[0].(entity.AccountExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoExportAccount indicates an expected call of DoExportAccount.
+func (mr *MockUseCaseMockRecorder) DoExportAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoExportAccount", reflect.TypeOf((*MockUseCase)(nil).DoExportAccount), arg0, arg1)
+}
+
+// DoFinishOIDCChallenge mocks base method.
+func (m *MockUseCase) DoFinishOIDCChallenge(arg0 context.Context, arg1, arg2 string, arg3 *http.Request) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoFinishOIDCChallenge", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoFinishOIDCChallenge indicates an expected call of DoFinishOIDCChallenge.
+func (mr *MockUseCaseMockRecorder) DoFinishOIDCChallenge(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoDebit", reflect.TypeOf((*MockUseCase)(nil).DoDebit), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoFinishOIDCChallenge", reflect.TypeOf((*MockUseCase)(nil).DoFinishOIDCChallenge), arg0, arg1, arg2, arg3)
+}
+
+// DoFinishOIDCLogin mocks base method.
+func (m *MockUseCase) DoFinishOIDCLogin(arg0 context.Context, arg1, arg2 string) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoFinishOIDCLogin", arg0, arg1, arg2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// DoFinishOIDCLogin indicates an expected call of DoFinishOIDCLogin.
+func (mr *MockUseCaseMockRecorder) DoFinishOIDCLogin(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoFinishOIDCLogin", reflect.TypeOf((*MockUseCase)(nil).DoFinishOIDCLogin), arg0, arg1, arg2)
+}
+
+// DoGeneratePassword mocks base method.
+func (m *MockUseCase) DoGeneratePassword(arg0 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoGeneratePassword", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoGeneratePassword indicates an expected call of DoGeneratePassword.
+func (mr *MockUseCaseMockRecorder) DoGeneratePassword(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoGeneratePassword", reflect.TypeOf((*MockUseCase)(nil).DoGeneratePassword), arg0)
+}
+
+// DoGetAPIKeys mocks base method.
+func (m *MockUseCase) DoGetAPIKeys(arg0 context.Context) ([]entity.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoGetAPIKeys", arg0)
+	ret0, _ := ret[0].([]entity.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoGetAPIKeys indicates an expected call of DoGetAPIKeys.
+func (mr *MockUseCaseMockRecorder) DoGetAPIKeys(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoGetAPIKeys", reflect.TypeOf((*MockUseCase)(nil).DoGetAPIKeys), arg0)
+}
+
+// DoGetAdjustments mocks base method.
+func (m *MockUseCase) DoGetAdjustments(arg0 context.Context, arg1 string) ([]entity.Adjustment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoGetAdjustments", arg0, arg1)
+	ret0, _ := ret[0].([]entity.Adjustment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoGetAdjustments indicates an expected call of DoGetAdjustments.
+func (mr *MockUseCaseMockRecorder) DoGetAdjustments(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoGetAdjustments", reflect.TypeOf((*MockUseCase)(nil).DoGetAdjustments), arg0, arg1)
 }
 
 // DoGetBalance mocks base method.
@@ -94,6 +335,36 @@ func (mr *MockUseCaseMockRecorder) DoGetBalance(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoGetBalance", reflect.TypeOf((*MockUseCase)(nil).DoGetBalance), arg0, arg1)
 }
 
+// DoGetCampaigns mocks base method.
+func (m *MockUseCase) DoGetCampaigns(arg0 context.Context) ([]entity.Campaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoGetCampaigns", arg0)
+	ret0, _ := ret[0].([]entity.Campaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoGetCampaigns indicates an expected call of DoGetCampaigns.
+func (mr *MockUseCaseMockRecorder) DoGetCampaigns(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoGetCampaigns", reflect.TypeOf((*MockUseCase)(nil).DoGetCampaigns), arg0)
+}
+
+// DoGetOrder mocks base method.
+func (m *MockUseCase) DoGetOrder(arg0 context.Context, arg1, arg2 string) (entity.OrderDetails, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoGetOrder", arg0, arg1, arg2)
+	ret0, _ := ret[0].(entity.OrderDetails)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoGetOrder indicates an expected call of DoGetOrder.
+func (mr *MockUseCaseMockRecorder) DoGetOrder(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoGetOrder", reflect.TypeOf((*MockUseCase)(nil).DoGetOrder), arg0, arg1, arg2)
+}
+
 // DoGetOrders mocks base method.
 func (m *MockUseCase) DoGetOrders(arg0 context.Context, arg1 string) ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -109,6 +380,97 @@ func (mr *MockUseCaseMockRecorder) DoGetOrders(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoGetOrders", reflect.TypeOf((*MockUseCase)(nil).DoGetOrders), arg0, arg1)
 }
 
+// DoGetOrdersPage mocks base method.
+func (m *MockUseCase) DoGetOrdersPage(arg0 context.Context, arg1 string, arg2 entity.HistoryFilter) ([]entity.Order, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoGetOrdersPage", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]entity.Order)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// DoGetOrdersPage indicates an expected call of DoGetOrdersPage.
+func (mr *MockUseCaseMockRecorder) DoGetOrdersPage(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoGetOrdersPage", reflect.TypeOf((*MockUseCase)(nil).DoGetOrdersPage), arg0, arg1, arg2)
+}
+
+// DoGetRole mocks base method.
+func (m *MockUseCase) DoGetRole(arg0 context.Context, arg1 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoGetRole", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoGetRole indicates an expected call of DoGetRole.
+func (mr *MockUseCaseMockRecorder) DoGetRole(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoGetRole", reflect.TypeOf((*MockUseCase)(nil).DoGetRole), arg0, arg1)
+}
+
+// DoGetSessions mocks base method.
+func (m *MockUseCase) DoGetSessions(arg0 context.Context, arg1 string) ([]entity.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoGetSessions", arg0, arg1)
+	ret0, _ := ret[0].([]entity.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoGetSessions indicates an expected call of DoGetSessions.
+func (mr *MockUseCaseMockRecorder) DoGetSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoGetSessions", reflect.TypeOf((*MockUseCase)(nil).DoGetSessions), arg0, arg1)
+}
+
+// DoGetTier mocks base method.
+func (m *MockUseCase) DoGetTier(arg0 context.Context, arg1 string) (entity.TierProgress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoGetTier", arg0, arg1)
+	ret0, _ := ret[0].(entity.TierProgress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoGetTier indicates an expected call of DoGetTier.
+func (mr *MockUseCaseMockRecorder) DoGetTier(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoGetTier", reflect.TypeOf((*MockUseCase)(nil).DoGetTier), arg0, arg1)
+}
+
+// DoGetWithdrawLimits mocks base method.
+func (m *MockUseCase) DoGetWithdrawLimits(arg0 context.Context, arg1 string) (entity.WithdrawLimits, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoGetWithdrawLimits", arg0, arg1)
+	ret0, _ := ret[0].(entity.WithdrawLimits)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoGetWithdrawLimits indicates an expected call of DoGetWithdrawLimits.
+func (mr *MockUseCaseMockRecorder) DoGetWithdrawLimits(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoGetWithdrawLimits", reflect.TypeOf((*MockUseCase)(nil).DoGetWithdrawLimits), arg0, arg1)
+}
+
+// DoGetWithdrawalViolations mocks base method.
+func (m *MockUseCase) DoGetWithdrawalViolations(arg0 context.Context, arg1 string) ([]entity.WithdrawalViolation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoGetWithdrawalViolations", arg0, arg1)
+	ret0, _ := ret[0].([]entity.WithdrawalViolation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoGetWithdrawalViolations indicates an expected call of DoGetWithdrawalViolations.
+func (mr *MockUseCaseMockRecorder) DoGetWithdrawalViolations(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoGetWithdrawalViolations", reflect.TypeOf((*MockUseCase)(nil).DoGetWithdrawalViolations), arg0, arg1)
+}
+
 // DoGetWithdrawals mocks base method.
 func (m *MockUseCase) DoGetWithdrawals(arg0 context.Context, arg1 string) ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -124,6 +486,52 @@ func (mr *MockUseCaseMockRecorder) DoGetWithdrawals(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoGetWithdrawals", reflect.TypeOf((*MockUseCase)(nil).DoGetWithdrawals), arg0, arg1)
 }
 
+// DoGetWithdrawalsPage mocks base method.
+func (m *MockUseCase) DoGetWithdrawalsPage(arg0 context.Context, arg1 string, arg2 entity.HistoryFilter) ([]entity.Withdrawal, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoGetWithdrawalsPage", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]entity.Withdrawal)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// DoGetWithdrawalsPage indicates an expected call of DoGetWithdrawalsPage.
+func (mr *MockUseCaseMockRecorder) DoGetWithdrawalsPage(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoGetWithdrawalsPage", reflect.TypeOf((*MockUseCase)(nil).DoGetWithdrawalsPage), arg0, arg1, arg2)
+}
+
+// DoGrantCampaign mocks base method.
+func (m *MockUseCase) DoGrantCampaign(arg0 context.Context, arg1 string, arg2 []string, arg3 string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoGrantCampaign", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoGrantCampaign indicates an expected call of DoGrantCampaign.
+func (mr *MockUseCaseMockRecorder) DoGrantCampaign(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoGrantCampaign", reflect.TypeOf((*MockUseCase)(nil).DoGrantCampaign), arg0, arg1, arg2, arg3)
+}
+
+// DoHasAdmin mocks base method.
+func (m *MockUseCase) DoHasAdmin(arg0 context.Context) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoHasAdmin", arg0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoHasAdmin indicates an expected call of DoHasAdmin.
+func (mr *MockUseCaseMockRecorder) DoHasAdmin(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoHasAdmin", reflect.TypeOf((*MockUseCase)(nil).DoHasAdmin), arg0)
+}
+
 // DoInsertOrder mocks base method.
 func (m *MockUseCase) DoInsertOrder(arg0 context.Context, arg1, arg2 string) error {
 	m.ctrl.T.Helper()
@@ -138,6 +546,37 @@ func (mr *MockUseCaseMockRecorder) DoInsertOrder(arg0, arg1, arg2 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoInsertOrder", reflect.TypeOf((*MockUseCase)(nil).DoInsertOrder), arg0, arg1, arg2)
 }
 
+// DoIsAccessTokenRevoked mocks base method.
+func (m *MockUseCase) DoIsAccessTokenRevoked(arg0 context.Context, arg1, arg2, arg3 string, arg4 time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoIsAccessTokenRevoked", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoIsAccessTokenRevoked indicates an expected call of DoIsAccessTokenRevoked.
+func (mr *MockUseCaseMockRecorder) DoIsAccessTokenRevoked(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoIsAccessTokenRevoked", reflect.TypeOf((*MockUseCase)(nil).DoIsAccessTokenRevoked), arg0, arg1, arg2, arg3, arg4)
+}
+
+// DoIssueRefreshToken mocks base method.
+func (m *MockUseCase) DoIssueRefreshToken(arg0 context.Context, arg1 string, arg2 *http.Request) (entity.Session, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoIssueRefreshToken", arg0, arg1, arg2)
+	ret0, _ := ret[0].(entity.Session)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// DoIssueRefreshToken indicates an expected call of DoIssueRefreshToken.
+func (mr *MockUseCaseMockRecorder) DoIssueRefreshToken(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoIssueRefreshToken", reflect.TypeOf((*MockUseCase)(nil).DoIssueRefreshToken), arg0, arg1, arg2)
+}
+
 // DoRegister mocks base method.
 func (m *MockUseCase) DoRegister(arg0 context.Context, arg1, arg2 string, arg3 *http.Request) error {
 	m.ctrl.T.Helper()
@@ -151,3 +590,236 @@ func (mr *MockUseCaseMockRecorder) DoRegister(arg0, arg1, arg2, arg3 interface{}
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoRegister", reflect.TypeOf((*MockUseCase)(nil).DoRegister), arg0, arg1, arg2, arg3)
 }
+
+// DoRequestPasswordReset mocks base method.
+func (m *MockUseCase) DoRequestPasswordReset(arg0 context.Context, arg1 string, arg2 *http.Request) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoRequestPasswordReset", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DoRequestPasswordReset indicates an expected call of DoRequestPasswordReset.
+func (mr *MockUseCaseMockRecorder) DoRequestPasswordReset(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoRequestPasswordReset", reflect.TypeOf((*MockUseCase)(nil).DoRequestPasswordReset), arg0, arg1, arg2)
+}
+
+// DoReserveIdempotencyKey mocks base method.
+func (m *MockUseCase) DoReserveIdempotencyKey(arg0 context.Context, arg1, arg2, arg3 string) (*entity.Idempotency, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoReserveIdempotencyKey", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*entity.Idempotency)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoReserveIdempotencyKey indicates an expected call of DoReserveIdempotencyKey.
+func (mr *MockUseCaseMockRecorder) DoReserveIdempotencyKey(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoReserveIdempotencyKey", reflect.TypeOf((*MockUseCase)(nil).DoReserveIdempotencyKey), arg0, arg1, arg2, arg3)
+}
+
+// DoResetPassword mocks base method.
+func (m *MockUseCase) DoResetPassword(arg0 context.Context, arg1, arg2 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoResetPassword", arg0, arg1, arg2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoResetPassword indicates an expected call of DoResetPassword.
+func (mr *MockUseCaseMockRecorder) DoResetPassword(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoResetPassword", reflect.TypeOf((*MockUseCase)(nil).DoResetPassword), arg0, arg1, arg2)
+}
+
+// DoRevokeAPIKey mocks base method.
+func (m *MockUseCase) DoRevokeAPIKey(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoRevokeAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DoRevokeAPIKey indicates an expected call of DoRevokeAPIKey.
+func (mr *MockUseCaseMockRecorder) DoRevokeAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoRevokeAPIKey", reflect.TypeOf((*MockUseCase)(nil).DoRevokeAPIKey), arg0, arg1)
+}
+
+// DoRevokeAccessToken mocks base method.
+func (m *MockUseCase) DoRevokeAccessToken(arg0 context.Context, arg1 string, arg2 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoRevokeAccessToken", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DoRevokeAccessToken indicates an expected call of DoRevokeAccessToken.
+func (mr *MockUseCaseMockRecorder) DoRevokeAccessToken(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoRevokeAccessToken", reflect.TypeOf((*MockUseCase)(nil).DoRevokeAccessToken), arg0, arg1, arg2)
+}
+
+// DoRevokeRefreshToken mocks base method.
+func (m *MockUseCase) DoRevokeRefreshToken(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoRevokeRefreshToken", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DoRevokeRefreshToken indicates an expected call of DoRevokeRefreshToken.
+func (mr *MockUseCaseMockRecorder) DoRevokeRefreshToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoRevokeRefreshToken", reflect.TypeOf((*MockUseCase)(nil).DoRevokeRefreshToken), arg0, arg1)
+}
+
+// DoRevokeSession mocks base method.
+func (m *MockUseCase) DoRevokeSession(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoRevokeSession", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DoRevokeSession indicates an expected call of DoRevokeSession.
+func (mr *MockUseCaseMockRecorder) DoRevokeSession(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoRevokeSession", reflect.TypeOf((*MockUseCase)(nil).DoRevokeSession), arg0, arg1, arg2)
+}
+
+// DoRotateRefreshToken mocks base method.
+func (m *MockUseCase) DoRotateRefreshToken(arg0 context.Context, arg1 string, arg2 *http.Request) (entity.Session, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoRotateRefreshToken", arg0, arg1, arg2)
+	ret0, _ := ret[0].(entity.Session)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// DoRotateRefreshToken indicates an expected call of DoRotateRefreshToken.
+func (mr *MockUseCaseMockRecorder) DoRotateRefreshToken(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoRotateRefreshToken", reflect.TypeOf((*MockUseCase)(nil).DoRotateRefreshToken), arg0, arg1, arg2)
+}
+
+// DoSaveIdempotencyResponse mocks base method.
+func (m *MockUseCase) DoSaveIdempotencyResponse(arg0 context.Context, arg1, arg2 string, arg3 int, arg4 string, arg5 []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoSaveIdempotencyResponse", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DoSaveIdempotencyResponse indicates an expected call of DoSaveIdempotencyResponse.
+func (mr *MockUseCaseMockRecorder) DoSaveIdempotencyResponse(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoSaveIdempotencyResponse", reflect.TypeOf((*MockUseCase)(nil).DoSaveIdempotencyResponse), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+// DoSetCampaignActive mocks base method.
+func (m *MockUseCase) DoSetCampaignActive(arg0 context.Context, arg1 string, arg2 bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoSetCampaignActive", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DoSetCampaignActive indicates an expected call of DoSetCampaignActive.
+func (mr *MockUseCaseMockRecorder) DoSetCampaignActive(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoSetCampaignActive", reflect.TypeOf((*MockUseCase)(nil).DoSetCampaignActive), arg0, arg1, arg2)
+}
+
+// DoSetRole mocks base method.
+func (m *MockUseCase) DoSetRole(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoSetRole", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DoSetRole indicates an expected call of DoSetRole.
+func (mr *MockUseCaseMockRecorder) DoSetRole(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoSetRole", reflect.TypeOf((*MockUseCase)(nil).DoSetRole), arg0, arg1, arg2)
+}
+
+// DoSetWithdrawLimits mocks base method.
+func (m *MockUseCase) DoSetWithdrawLimits(arg0 context.Context, arg1 entity.WithdrawLimits) (entity.WithdrawLimits, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoSetWithdrawLimits", arg0, arg1)
+	ret0, _ := ret[0].(entity.WithdrawLimits)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoSetWithdrawLimits indicates an expected call of DoSetWithdrawLimits.
+func (mr *MockUseCaseMockRecorder) DoSetWithdrawLimits(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoSetWithdrawLimits", reflect.TypeOf((*MockUseCase)(nil).DoSetWithdrawLimits), arg0, arg1)
+}
+
+// DoStartOIDCLogin mocks base method.
+func (m *MockUseCase) DoStartOIDCLogin(arg0 context.Context, arg1 string) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoStartOIDCLogin", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// DoStartOIDCLogin indicates an expected call of DoStartOIDCLogin.
+func (mr *MockUseCaseMockRecorder) DoStartOIDCLogin(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoStartOIDCLogin", reflect.TypeOf((*MockUseCase)(nil).DoStartOIDCLogin), arg0, arg1)
+}
+
+// DoStartSession mocks base method.
+func (m *MockUseCase) DoStartSession(arg0 context.Context, arg1 string, arg2 *http.Request) (entity.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoStartSession", arg0, arg1, arg2)
+	ret0, _ := ret[0].(entity.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoStartSession indicates an expected call of DoStartSession.
+func (mr *MockUseCaseMockRecorder) DoStartSession(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoStartSession", reflect.TypeOf((*MockUseCase)(nil).DoStartSession), arg0, arg1, arg2)
+}
+
+// DoStatement mocks base method.
+func (m *MockUseCase) DoStatement(arg0 context.Context, arg1 string, arg2, arg3 time.Time, arg4 usecase.StatementWriter) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoStatement", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DoStatement indicates an expected call of DoStatement.
+func (mr *MockUseCaseMockRecorder) DoStatement(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoStatement", reflect.TypeOf((*MockUseCase)(nil).DoStatement), arg0, arg1, arg2, arg3, arg4)
+}
+
+// DoSubscribe mocks base method.
+func (m *MockUseCase) DoSubscribe(arg0 string, arg1 uint64) (*events.Subscription, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoSubscribe", arg0, arg1)
+	ret0, _ := ret[0].(*events.Subscription)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// DoSubscribe indicates an expected call of DoSubscribe.
+func (mr *MockUseCaseMockRecorder) DoSubscribe(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoSubscribe", reflect.TypeOf((*MockUseCase)(nil).DoSubscribe), arg0, arg1)
+}
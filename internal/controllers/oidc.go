@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/nextlag/gomart/internal/mw/auth"
+	"github.com/nextlag/gomart/internal/problem"
+	"github.com/nextlag/gomart/internal/usecase"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+const (
+	// oidcStateCookie - кука, связывающая вход через поставщика удостоверений с браузером, в котором он начат
+	oidcStateCookie = "OIDCState"
+	// oidcCookiePath - маршруты, на которые отправляется кука oidcStateCookie
+	oidcCookiePath = "/api/user/oidc"
+	// oidcCookieMaxAge - время жизни куки oidcStateCookie в секундах
+	oidcCookieMaxAge = 600
+	// oidcChallengeCookie - кука с незавершенным входом пользователя с подключенной двухфакторной аутентификацией
+	oidcChallengeCookie = "OIDCChallenge"
+	// oidcChallengeMaxAge - время жизни куки oidcChallengeCookie в секундах
+	oidcChallengeMaxAge = 300
+)
+
+// OIDCLogin обрабатывает запрос на вход через внешний поставщик удостоверений OpenID Connect.
+//
+// Этот метод принимает запрос HTTP GET, начинает вход по схеме authorization code с PKCE и перенаправляет
+// пользователя на страницу входа поставщика со статусом Found (302). Значение state сохраняется в куке OIDCState,
+// чтобы завершить вход можно было только в том же браузере. Необязательный параметр login_hint передается поставщику.
+// Если вход через поставщика не настроен, метод возвращает ошибку NotFound (404), если поставщик недоступен -
+// BadGateway (502). В случае любых других ошибок метод возвращает ошибку InternalServerError (500).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+
+	state, authURL, err := c.uc.DoStartOIDCLogin(r.Context(), r.URL.Query().Get("login_hint"))
+	switch {
+	case errors.Is(err, er.ErrOIDCDisabled):
+		problem.Write(w, r, er.ErrOIDCDisabled)
+		return
+	case errors.Is(err, er.ErrOIDCLogin):
+		log.Error("identity provider unavailable", l.ErrAttr(err))
+		problem.WriteStatus(w, r, http.StatusBadGateway, er.ErrOIDCLogin)
+		return
+	case err != nil:
+		log.Error("start single sign-on", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+
+	cookie := auth.NewCookie(oidcStateCookie, state, oidcCookiePath, oidcCookieMaxAge, true)
+	if cookie.SameSite != http.SameSiteNoneMode {
+		// Возврат от поставщика - переход с другого сайта, поэтому Strict не подходит
+		cookie.SameSite = http.SameSiteLaxMode
+	}
+	http.SetCookie(w, cookie)
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallback обрабатывает возврат пользователя от внешнего поставщика удостоверений.
+//
+// Этот метод принимает запрос HTTP GET с параметрами code и state, обменивает код авторизации на ID-токен
+// и выполняет вход пользователя, связанного с учетной записью поставщика. При первом входе создается новый
+// пользователь; если пользователь с тем же логином уже есть, а логин взят не из подтвержденного email,
+// метод возвращает ошибку Conflict (409).
+// При успешном входе метод, как и Authentication, устанавливает токен доступа и refresh-токен в куки
+// и возвращает статус OK (200) с токеном доступа в заголовке Authorization и в теле ответа в формате JSON.
+// Если у пользователя подключена двухфакторная аутентификация, токены не выдаются: метод сохраняет незавершенный
+// вход в куке OIDCChallenge и возвращает ошибку Unauthorized (401) с кодом otp_required; вход завершает OIDCSecondFactor.
+// Если state не совпадает с кукой OIDCState, неизвестен или истек, метод возвращает ошибку BadRequest (400).
+// Если поставщик отклонил вход, метод возвращает ошибку Unauthorized (401), если вход через поставщика
+// не настроен - NotFound (404). В случае любых других ошибок метод возвращает ошибку InternalServerError (500).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+	query := r.URL.Query()
+
+	// Кука со state больше не нужна независимо от результата входа
+	http.SetCookie(w, auth.NewCookie(oidcStateCookie, "", oidcCookiePath, -1, true))
+
+	if providerErr := query.Get("error"); providerErr != "" {
+		// Если поставщик отклонил вход, возвращаем ошибку Unauthorized
+		log.Error("identity provider error", "error", providerErr, "description", query.Get("error_description"))
+		problem.Write(w, r, er.ErrOIDCLogin)
+		return
+	}
+
+	state := query.Get("state")
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		// Если вход начат в другом браузере или state подделан, возвращаем ошибку BadRequest
+		problem.Write(w, r, er.ErrOIDCState)
+		return
+	}
+
+	login, challenge, err := c.uc.DoFinishOIDCLogin(r.Context(), state, query.Get("code"))
+	switch {
+	case errors.Is(err, er.ErrOTPRequired):
+		// Если подключена двухфакторная аутентификация, токены выдаются только после проверки одноразового кода
+		http.SetCookie(w, auth.NewCookie(oidcChallengeCookie, challenge, oidcCookiePath, oidcChallengeMaxAge, true))
+		problem.Write(w, r, er.ErrOTPRequired)
+		return
+	case errors.Is(err, er.ErrOIDCDisabled):
+		problem.Write(w, r, er.ErrOIDCDisabled)
+		return
+	case errors.Is(err, er.ErrOIDCState):
+		problem.Write(w, r, er.ErrOIDCState)
+		return
+	case errors.Is(err, er.ErrOIDCAccountExists):
+		// Связать учетную запись поставщика с существующим пользователем по неподтвержденному клейму нельзя
+		log.Error("single sign-on login matches existing user", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrOIDCAccountExists)
+		return
+	case errors.Is(err, er.ErrOIDCLogin):
+		log.Error("single sign-on failed", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrOIDCLogin)
+		return
+	case err != nil:
+		log.Error("finish single sign-on", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+
+	c.finishOIDCLogin(w, r, login)
+}
+
+// OIDCSecondFactor обрабатывает подтверждение входа через внешний поставщик удостоверений вторым фактором.
+//
+// Этот метод принимает запрос HTTP POST с куками OIDCChallenge, установленной OIDCCallback, и JSON вида
+// {"code": "123456"} с одноразовым кодом или кодом восстановления. При верном коде метод, как и Authentication,
+// устанавливает токен доступа и refresh-токен в куки и возвращает статус OK (200) с токеном доступа.
+// Незавершенный вход принимается один раз: при неверном коде метод возвращает ошибку Unauthorized (401),
+// и вход через поставщика нужно начать заново. Если кука отсутствует, вход неизвестен или истек, метод возвращает
+// ошибку BadRequest (400), если в запросе нет кода - BadRequest (400) с ошибкой формата запроса.
+// Если для логина или IP-адреса действует задержка после неудачных попыток входа, метод возвращает
+// статус TooManyRequests (429) с заголовком Retry-After. В случае любых других ошибок метод возвращает
+// ошибку InternalServerError (500).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) OIDCSecondFactor(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+
+	var request totpConfirm
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Code == "" {
+		problem.Write(w, r, er.ErrRequestFormat)
+		return
+	}
+
+	cookie, err := r.Cookie(oidcChallengeCookie)
+	if err != nil || cookie.Value == "" {
+		problem.Write(w, r, er.ErrOIDCState)
+		return
+	}
+	// Незавершенный вход принимается один раз, поэтому кука больше не нужна независимо от результата
+	http.SetCookie(w, auth.NewCookie(oidcChallengeCookie, "", oidcCookiePath, -1, true))
+
+	login, err := c.uc.DoFinishOIDCChallenge(r.Context(), cookie.Value, request.Code, r)
+	var throttleErr *usecase.ThrottleError
+	switch {
+	case errors.As(err, &throttleErr):
+		// Если превышено количество неудачных попыток, возвращаем ошибку TooManyRequests с заголовком Retry-After
+		log.Error("single sign-on second factor throttled", "retry_after", throttleErr.RetryAfter)
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttleErr.RetryAfter.Seconds()))))
+		problem.Write(w, r, er.ErrTooManyAttempts)
+		return
+	case errors.Is(err, er.ErrOIDCState):
+		problem.Write(w, r, er.ErrOIDCState)
+		return
+	case errors.Is(err, er.ErrOTPRequired), errors.Is(err, er.ErrOTPCode):
+		log.Error("single sign-on second factor", l.ErrAttr(err))
+		problem.Write(w, r, err)
+		return
+	case err != nil:
+		log.Error("finish single sign-on second factor", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+
+	c.finishOIDCLogin(w, r, login)
+}
+
+// finishOIDCLogin выдает токены пользователю, выполнившему вход через поставщика удостоверений.
+func (c *Controller) finishOIDCLogin(w http.ResponseWriter, r *http.Request, login string) {
+	// Устанавливаем токен доступа и refresh-токен в куки
+	jwtToken, err := c.setTokens(w, r, login)
+	if err != nil {
+		l.L(c.ctx).Error("can't set cookie", l.ErrAttr(err))
+		problem.Write(w, r, c.uc.Do().Err().ErrNoCookie)
+		return
+	}
+	l.L(c.ctx).Debug("success authenticated via single sign-on", "login", login)
+
+	writeToken(w, r, jwtToken)
+}
@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/nextlag/gomart/internal/mw/auth"
+	"github.com/nextlag/gomart/internal/problem"
+	"github.com/nextlag/gomart/internal/usecase"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+// passwordChange - структура используемая для анализа json-запроса на смену пароля.
+type passwordChange struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// passwordResetRequest - структура используемая для анализа json-запроса на сброс пароля.
+type passwordResetRequest struct {
+	Login string `json:"login"`
+}
+
+// passwordResetConfirm - структура используемая для анализа json-запроса на установку пароля по токену сброса.
+type passwordResetConfirm struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// ChangePassword обрабатывает запрос на смену пароля аутентифицированного пользователя.
+//
+// Этот метод принимает запрос HTTP POST с JSON-данными, содержащими текущий и новый пароль.
+// После смены пароля все токены пользователя отзываются, а текущий сеанс получает новую пару токенов,
+// и метод возвращает токен доступа в заголовке Authorization и в теле ответа в формате JSON со статусом OK (200).
+// Если JSON некорректен, новый пароль пуст или не удовлетворяет политике паролей, метод возвращает ошибку
+// BadRequest (400) с именем нарушенного правила.
+// Если текущий пароль неверен, метод возвращает ошибку Forbidden (403). Неверный текущий пароль учитывается
+// как неудачная попытка входа: если для логина или IP-адреса действует задержка или блокировка, метод возвращает
+// статус TooManyRequests (429) с заголовком Retry-After.
+// В случае любых других ошибок метод возвращает ошибку InternalServerError (500).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+	// Получаем логин пользователя из контекста запроса
+	user, _ := r.Context().Value(auth.LoginKey).(string)
+
+	var request passwordChange
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		log.Error("decode JSON", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrDecodeJSON)
+		return
+	}
+
+	err := c.uc.DoChangePassword(r.Context(), user, request.CurrentPassword, request.NewPassword, r)
+	var throttleErr *usecase.ThrottleError
+	switch {
+	case errors.As(err, &throttleErr):
+		// Если превышено количество неудачных попыток, возвращаем ошибку TooManyRequests с заголовком Retry-After
+		log.Error("change password throttled", "user", user, "retry_after", throttleErr.RetryAfter)
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttleErr.RetryAfter.Seconds()))))
+		problem.Write(w, r, er.ErrTooManyAttempts)
+		return
+	case errors.Is(err, er.ErrRequestFormat), errors.Is(err, er.ErrPasswordPolicy):
+		problem.Write(w, r, err)
+		return
+	case errors.Is(err, er.ErrPassword):
+		log.Error("change password: incorrect current password", "user", user)
+		problem.Write(w, r, er.ErrPassword)
+		return
+	case err != nil:
+		log.Error("change password handler", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+	log.Info("password changed", "user", user)
+
+	// Текущий сеанс продолжает работу с новой парой токенов
+	jwtToken, err := c.setTokens(w, r, user)
+	if err != nil {
+		log.Error("can't set cookie", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrNoCookie)
+		return
+	}
+	writeToken(w, r, jwtToken)
+}
+
+// RequestPasswordReset обрабатывает запрос на сброс забытого пароля.
+//
+// Этот метод принимает запрос HTTP POST с JSON-данными, содержащими логин пользователя.
+// Пользователю отправляется одноразовый токен сброса пароля с ограниченным сроком действия.
+// Чтобы по ответу нельзя было узнать, зарегистрирован ли логин, метод всегда возвращает статус Accepted (202).
+// Если JSON некорректен или логин пуст, метод возвращает ошибку BadRequest (400).
+// Если запросов сброса для логина или с IP-адреса клиента слишком много, метод возвращает ошибку
+// TooManyRequests (429) с заголовком Retry-After.
+// Если происходит ошибка при создании или отправке токена, метод возвращает ошибку InternalServerError (500).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+
+	var request passwordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Login == "" {
+		problem.Write(w, r, er.ErrRequestFormat)
+		return
+	}
+
+	err := c.uc.DoRequestPasswordReset(r.Context(), request.Login, r)
+	var throttleErr *usecase.ThrottleError
+	switch {
+	case errors.As(err, &throttleErr):
+		// Если запросов сброса слишком много, возвращаем ошибку TooManyRequests с заголовком Retry-After
+		log.Error("password reset throttled", "login", request.Login, "retry_after", throttleErr.RetryAfter)
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttleErr.RetryAfter.Seconds()))))
+		problem.Write(w, r, er.ErrTooManyAttempts)
+		return
+	case err != nil:
+		log.Error("password reset request handler", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ConfirmPasswordReset обрабатывает запрос на установку нового пароля по токену сброса.
+//
+// Этот метод принимает запрос HTTP POST с JSON-данными, содержащими токен сброса и новый пароль.
+// Токен можно использовать только один раз; после установки пароля все токены пользователя отзываются,
+// и метод возвращает статус NoContent (204).
+// Если JSON некорректен, новый пароль пуст или не удовлетворяет политике паролей, метод возвращает ошибку
+// BadRequest (400) с именем нарушенного правила.
+// Если токен неизвестен, истек или уже использован, метод возвращает ошибку BadRequest (400).
+// В случае любых других ошибок метод возвращает ошибку InternalServerError (500).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) ConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+
+	var request passwordResetConfirm
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil || request.Token == "" {
+		problem.Write(w, r, er.ErrRequestFormat)
+		return
+	}
+
+	login, err := c.uc.DoResetPassword(r.Context(), request.Token, request.NewPassword)
+	switch {
+	case errors.Is(err, er.ErrResetToken), errors.Is(err, er.ErrRequestFormat), errors.Is(err, er.ErrPasswordPolicy):
+		log.Error("password reset rejected", l.ErrAttr(err))
+		problem.Write(w, r, err)
+		return
+	case err != nil:
+		log.Error("password reset confirm handler", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+	log.Info("password reset", "login", login)
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,25 +2,33 @@ package controllers
 
 import (
 	"errors"
-	"fmt"
 	"io"
 	"net/http"
 
 	"github.com/nextlag/gomart/internal/mw/auth"
+	"github.com/nextlag/gomart/internal/problem"
 	"github.com/nextlag/gomart/pkg/logger/l"
 )
 
+// orderReceived - тело ответа на загрузку номера заказа
+type orderReceived struct {
+	Number  string `json:"number"`
+	Message string `json:"message"`
+}
+
 // PostOrders обрабатывает запрос на создание нового заказа.
 //
 // Этот метод принимает запрос HTTP POST для создания нового заказа пользователя.
-// При успешном выполнении метод возвращает статус Accepted (202) и номер созданного заказа.
+// При успешном выполнении метод возвращает статус Accepted (202) и номер заказа в формате JSON.
 // Если в запросе отсутствует тело или происходит ошибка при чтении тела запроса, метод возвращает
 // ошибку BadRequest (400) с соответствующим сообщением об ошибке.
 // Если происходит ошибка при вставке заказа в базу данных, метод возвращает соответствующий статус и сообщение об ошибке:
 //   - если формат заказа неверен, метод возвращает ошибку UnprocessableEntity (422) с сообщением "order format error";
 //   - если заказ принадлежит другому пользователю, метод возвращает ошибку Conflict (409) с сообщением "order belongs to another user";
-//   - если заказ успешно создан и принадлежит текущему пользователю, метод возвращает статус OK (200) с сообщением "order created";
-//   - в остальных случаях метод возвращает ошибку InternalServerError (500) с сообщением "internal server error".
+//   - если заказ уже загружен текущим пользователем, метод возвращает статус OK (200) и номер заказа в формате JSON;
+//   - в остальных случаях метод возвращает ошибку InternalServerError (500).
+//
+// Ошибки возвращаются в формате application/problem+json с кодом из реестра ошибок пакета problem.
 //
 // Параметры:
 //   - w: http.ResponseWriter - объект для записи HTTP-ответа.
@@ -41,12 +49,12 @@ func (c *Controller) PostOrders(w http.ResponseWriter, r *http.Request) {
 	switch {
 	case order == "":
 		// Если тело запроса отсутствует, возвращаем ошибку BadRequest (400)
-		http.Error(w, er.ErrRequestFormat.Error(), http.StatusBadRequest)
+		problem.Write(w, r, er.ErrRequestFormat)
 		return
 	case err != nil:
 		// Если произошла ошибка при чтении тела запроса, возвращаем ошибку InternalServerError (500)
 		log.Error("body reading error", l.ErrAttr(err))
-		http.Error(w, er.ErrInternalServer.Error(), http.StatusInternalServerError)
+		problem.Write(w, r, er.ErrInternalServer)
 		return
 	}
 
@@ -56,25 +64,26 @@ func (c *Controller) PostOrders(w http.ResponseWriter, r *http.Request) {
 	case errors.Is(err, er.ErrOrderFormat):
 		// Если формат заказа неверен, возвращаем ошибку UnprocessableEntity (422)
 		log.Error("insert Order 422", l.ErrAttr(err))
-		http.Error(w, er.ErrOrderFormat.Error(), http.StatusUnprocessableEntity)
+		problem.Write(w, r, er.ErrOrderFormat)
 		return
 	case errors.Is(err, er.ErrAnotherUser):
 		// Если заказ принадлежит другому пользователю, возвращаем ошибку Conflict (409)
 		log.Error("insert Order 409", l.ErrAttr(err))
-		http.Error(w, er.ErrAnotherUser.Error(), http.StatusConflict)
+		problem.Write(w, r, er.ErrAnotherUser)
 		return
 	case errors.Is(err, er.ErrThisUser):
-		// Если заказ успешно создан и принадлежит текущему пользователю, возвращаем статус OK (200)
-		log.Error("insert Order 200", l.ErrAttr(err))
-		http.Error(w, er.ErrThisUser.Error(), http.StatusOK)
+		// Если заказ уже загружен текущим пользователем, возвращаем статус OK (200)
+		log.Info("order already uploaded", "user", user, "order", order)
+		writeJSON(w, r, http.StatusOK, orderReceived{Number: order, Message: er.ErrThisUser.Error()})
 		return
-	default:
+	case err != nil:
 		// В остальных случаях возвращаем ошибку InternalServerError (500)
-		http.Error(w, er.ErrOrderAccepted.Error(), http.StatusAccepted)
+		log.Error("insert Order", l.ErrAttr(err))
+		problem.Write(w, r, err)
+		return
 	}
 
 	// Логируем успешное получение заказа
 	log.Info("order received", "user", user, "order", order)
-	o := fmt.Sprintf("order number: %s\n", order)
-	w.Write([]byte(o))
+	writeJSON(w, r, http.StatusAccepted, orderReceived{Number: order, Message: er.ErrOrderAccepted.Error()})
 }
@@ -7,17 +7,22 @@ import (
 
 	"github.com/lib/pq"
 
+	"github.com/nextlag/gomart/internal/entity"
 	"github.com/nextlag/gomart/internal/mw/auth"
-	"github.com/nextlag/gomart/pkg/generatestring"
+	"github.com/nextlag/gomart/internal/problem"
 	"github.com/nextlag/gomart/pkg/logger/l"
 )
 
 // Register обрабатывает запрос на регистрацию нового пользователя.
 //
 // Этот метод принимает запрос HTTP POST с JSON-данными, содержащими логин и пароль нового пользователя.
-// При успешной регистрации метод устанавливает аутентификационную куку и возвращает статус OK (200).
+// При успешной регистрации метод устанавливает куки с токеном доступа и refresh-токеном и возвращает статус OK (200)
+// с токеном доступа в заголовке Authorization и в теле ответа в формате JSON.
+// Если пароль не указан, для пользователя генерируется случайный пароль, удовлетворяющий политике паролей;
+// он возвращается один раз в поле password тела ответа и не записывается в журнал.
 // Если происходит ошибка при декодировании JSON или при обработке запроса, метод возвращает ошибку BadRequest (400)
 // с соответствующим сообщением об ошибке.
+// Если пароль не удовлетворяет политике паролей, метод возвращает ошибку BadRequest (400) с именем нарушенного правила.
 // Если указанный логин уже занят другим пользователем, метод возвращает ошибку Conflict (409).
 // В случае любых других ошибок при регистрации, метод возвращает ошибку InternalServerError (500).
 //
@@ -29,60 +34,77 @@ import (
 //   - нет.
 func (c *Controller) Register(w http.ResponseWriter, r *http.Request) {
 	log := l.L(c.ctx)
-	// Получаем объект пользователя из UseCase
-	user := c.uc.Do().GetEntity()
 	// Получаем объект ошибки из UseCase
 	er := c.uc.Do().Err()
 
-	// Декодируем JSON-данные из тела запроса в структуру Credentials
+	// Декодируем JSON-данные из тела запроса в новую структуру пользователя: обработчик вызывается
+	// параллельно, поэтому данные одного запроса не должны попадать в другой
+	var user entity.User
 	decoder := json.NewDecoder(r.Body)
 	decoder.DisallowUnknownFields()
 	err := decoder.Decode(&user)
+	// Сгенерированный пароль возвращается пользователю в ответе
+	var generated string
 	// Проверяем наличие ошибок при декодировании
 	switch {
 	case err != nil:
 		log.Error("failed to process the request", l.ErrAttr(err))
-		http.Error(w, er.ErrDecodeJSON.Error(), http.StatusBadRequest)
+		problem.Write(w, r, er.ErrDecodeJSON)
 		return
 	case len(user.Login) == 0:
 		log.Error("error: empty login", "login", user.Login)
-		http.Error(w, er.ErrRequest.Error(), http.StatusBadRequest)
+		problem.Write(w, r, er.ErrRequest)
 		return
 	case len(user.Password) == 0:
-		user.Password = generatestring.NewRandomString(8)
-		log.Info("generating password", "login", user.Login, "password", user.Password)
+		generated, err = c.uc.DoGeneratePassword(user.Login)
+		if err != nil {
+			log.Error("generating password", l.ErrAttr(err))
+			problem.Write(w, r, er.ErrInternalServer)
+			return
+		}
+		user.Password = generated
+		log.Info("password generated", "login", user.Login)
 	}
 
-	log.Debug("findings", "login", user.Login, "password", user.Password)
-
 	// Вызываем метод DoRegister UseCase для выполнения регистрации
 	if err = c.uc.DoRegister(r.Context(), user.Login, user.Password, r); err != nil {
 		// Обрабатываем ошибку регистрации
 		var pqErr *pq.Error
 		isPGError := errors.As(err, &pqErr)
 		switch {
+		case errors.Is(err, er.ErrPasswordPolicy):
+			log.Error("password rejected by policy", "login", user.Login, l.ErrAttr(err))
+			problem.Write(w, r, err)
 		case isPGError && pqErr.Code == "23505":
 			log.Error("duplicate login", l.ErrAttr(err))
 			// Если дубликат логина - возвращаем конфликт
-			http.Error(w, er.ErrNoLogin.Error(), http.StatusConflict)
+			problem.Write(w, r, er.ErrNoLogin)
 		default:
 			log.Error("register error", l.ErrAttr(err))
 			// В противном случае возвращаем внутреннюю ошибку сервера
-			http.Error(w, er.ErrInternalServer.Error(), http.StatusInternalServerError)
+			problem.Write(w, r, er.ErrInternalServer)
 		}
 		return
 	}
 
-	// Устанавливаем аутентификационную куку после успешной регистрации
-	jwt, err := auth.SetAuth(c.ctx, user.Login, w)
+	// Устанавливаем аутентификационные куки после успешной регистрации
+	jwt, err := c.setTokens(w, r, user.Login)
 	if err != nil {
 		log.Error("can't set cookie: ", l.ErrAttr(err))
-		http.Error(w, er.ErrInternalServer.Error(), http.StatusInternalServerError)
+		problem.Write(w, r, er.ErrInternalServer)
 		return
 	}
-	log.Debug("authentication", "login", user.Login, "password", user.Password, "token", jwt)
+	log.Debug("authentication", "login", user.Login)
 
-	// Возвращаем успешный статус и сообщение об успешной регистрации
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("success"))
+	// Возвращаем успешный статус и токен доступа
+	if generated == "" {
+		writeToken(w, r, jwt)
+		return
+	}
+	// Ответ со сгенерированным паролем не должен сохраняться в кешах
+	response := newTokenResponse(jwt)
+	response.Password = generated
+	auth.SetBearer(w, jwt)
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, response)
 }
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/nextlag/gomart/internal/mw/auth"
+	"github.com/nextlag/gomart/internal/problem"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+// Sessions обрабатывает запрос на получение списка сеансов пользователя.
+//
+// Этот метод принимает запрос HTTP GET от аутентифицированного пользователя и возвращает его действующие сеансы
+// (устройства, на которых выполнен вход) с User-Agent, IP-адресом и временем последней активности в формате JSON
+// и статус OK (200). Сеанс, из которого выполнен запрос, отмечается полем current.
+// Если происходит ошибка при получении списка, метод возвращает ошибку InternalServerError (500).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) Sessions(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+	// Получаем клеймы токена доступа из контекста запроса
+	claims, _ := r.Context().Value(auth.ClaimsKey).(*auth.Claims)
+	if claims == nil {
+		problem.Write(w, r, er.ErrUnAuthUser)
+		return
+	}
+
+	sessions, err := c.uc.DoGetSessions(r.Context(), claims.Login)
+	if err != nil {
+		log.Error("sessions handler", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == claims.SessionID
+	}
+
+	writeJSON(w, r, http.StatusOK, sessions)
+}
+
+// RevokeSession обрабатывает запрос на завершение сеанса пользователя, например на потерянном устройстве.
+//
+// Этот метод принимает запрос HTTP DELETE для сеанса с идентификатором из пути запроса.
+// Refresh-токены сеанса отзываются, а его токены доступа перестают приниматься сразу.
+// При успешном завершении метод возвращает статус NoContent (204).
+// Если сеанс не найден, принадлежит другому пользователю или уже завершен, метод возвращает ошибку NotFound (404).
+// В случае любых других ошибок метод возвращает ошибку InternalServerError (500).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+	// Получаем логин пользователя из контекста запроса
+	user, _ := r.Context().Value(auth.LoginKey).(string)
+	id := chi.URLParam(r, "id")
+
+	err := c.uc.DoRevokeSession(r.Context(), user, id)
+	switch {
+	case errors.Is(err, er.ErrNoSession):
+		problem.Write(w, r, er.ErrNoSession)
+		return
+	case err != nil:
+		log.Error("revoke session handler", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+	log.Info("session revoked", "user", user, "session", id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
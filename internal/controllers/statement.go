@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nextlag/gomart/internal/entity"
+	"github.com/nextlag/gomart/internal/mw/auth"
+	"github.com/nextlag/gomart/internal/problem"
+	"github.com/nextlag/gomart/internal/usecase"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+const (
+	// dateLayout - формат даты без времени в параметрах from и to
+	dateLayout = "2006-01-02"
+	// flushEvery - количество операций, после которого выписка отправляется клиенту
+	flushEvery = 100
+)
+
+// Statement обрабатывает запрос на получение выписки по счету пользователя.
+//
+// Этот метод принимает запрос HTTP GET с параметрами from, to и format.
+// Параметры from и to задаются в формате RFC 3339 или YYYY-MM-DD (в этом случае день to включается в период),
+// по умолчанию выписка формируется за все время до текущего момента.
+// Параметр format принимает значения json (по умолчанию) и csv.
+// Выписка содержит входящий остаток, все начисления, списания и корректировки за период с текущим остатком
+// после каждой операции, а также итоги за период. Операции передаются клиенту потоком по мере чтения из базы данных.
+// Если параметры запроса некорректны, метод возвращает ошибку BadRequest (400).
+// Если ошибка возникает до начала выписки, метод возвращает ошибку InternalServerError (500).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) Statement(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+	// Получаем логин пользователя из контекста запроса
+	user, _ := r.Context().Value(auth.LoginKey).(string)
+
+	// Разбираем период выписки
+	query := r.URL.Query()
+	from, err := parseStatementTime(query.Get("from"), time.Time{}, false)
+	if err != nil {
+		problem.Write(w, r, er.ErrRequestFormat)
+		return
+	}
+	to, err := parseStatementTime(query.Get("to"), time.Now(), true)
+	if err != nil || to.Before(from) {
+		problem.Write(w, r, er.ErrRequestFormat)
+		return
+	}
+
+	var sw statementWriter
+	switch query.Get("format") {
+	case "", "json":
+		sw = &jsonStatement{w: w, from: from, to: to}
+	case "csv":
+		sw = &csvStatement{w: w, cw: csv.NewWriter(w), from: from, to: to}
+	default:
+		problem.Write(w, r, er.ErrRequestFormat)
+		return
+	}
+
+	// Формируем выписку; после начала записи изменить код ответа уже нельзя, поэтому такие ошибки только логируем
+	err = c.uc.DoStatement(r.Context(), user, from, to, sw)
+	switch {
+	case err != nil && !sw.started():
+		log.Error("statement handler", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+	case err != nil:
+		log.Error("statement handler: stream interrupted", l.ErrAttr(err))
+	default:
+		log.Info("statement sent", "user", user, "from", from, "to", to)
+	}
+}
+
+// parseStatementTime разбирает границу периода выписки.
+// Для даты без времени верхняя граница сдвигается на конец дня.
+func parseStatementTime(value string, def time.Time, upper bool) (time.Time, error) {
+	if value == "" {
+		return def, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// statementWriter - выписка в конкретном формате.
+type statementWriter interface {
+	usecase.StatementWriter
+	started() bool
+}
+
+// flush отправляет клиенту накопленную часть ответа.
+func flush(w http.ResponseWriter) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// jsonStatement записывает выписку в виде JSON-объекта с массивом entries.
+type jsonStatement struct {
+	w        http.ResponseWriter
+	from, to time.Time
+	count    int
+}
+
+func (s *jsonStatement) started() bool {
+	return s.count > 0
+}
+
+func (s *jsonStatement) Begin(opening float32) error {
+	s.w.Header().Set("Content-Type", "application/json")
+	s.w.WriteHeader(http.StatusOK)
+	s.count = 1
+	_, err := fmt.Fprintf(s.w, `{"from":%s,"to":%s,"opening_balance":%s,"entries":[`,
+		jsonValue(s.from), jsonValue(s.to), jsonValue(opening))
+	return err
+}
+
+func (s *jsonStatement) Entry(entry entity.StatementEntry) error {
+	if s.count > 1 {
+		if _, err := io.WriteString(s.w, ","); err != nil {
+			return err
+		}
+	}
+	if _, err := s.w.Write(jsonValue(entry)); err != nil {
+		return err
+	}
+	if s.count%flushEvery == 0 {
+		flush(s.w)
+	}
+	s.count++
+	return nil
+}
+
+func (s *jsonStatement) End(totals entity.StatementTotals) error {
+	_, err := fmt.Fprintf(s.w, `],"accrued":%s,"withdrawn":%s,"adjusted":%s,"closing_balance":%s}`,
+		jsonValue(totals.Accrued), jsonValue(totals.Withdrawn), jsonValue(totals.Adjusted), jsonValue(totals.Closing))
+	return err
+}
+
+// jsonValue кодирует значение в JSON; используется только для типов, которые кодируются без ошибок.
+func jsonValue(v any) []byte {
+	b, _ := json.Marshal(v)
+	return b
+}
+
+// csvStatement записывает выписку в CSV: строка входящего остатка, операции и строки итогов.
+type csvStatement struct {
+	w        http.ResponseWriter
+	cw       *csv.Writer
+	from, to time.Time
+	count    int
+}
+
+func (s *csvStatement) started() bool {
+	return s.count > 0
+}
+
+func (s *csvStatement) Begin(opening float32) error {
+	s.w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	s.w.Header().Set("Content-Disposition", `attachment; filename="statement.csv"`)
+	s.w.WriteHeader(http.StatusOK)
+	s.count = 1
+	if err := s.cw.Write([]string{"time", "type", "order", "reason", "amount", "balance"}); err != nil {
+		return err
+	}
+	return s.cw.Write([]string{s.from.Format(time.RFC3339), "opening_balance", "", "", "", formatSum(opening)})
+}
+
+func (s *csvStatement) Entry(entry entity.StatementEntry) error {
+	err := s.cw.Write([]string{
+		entry.Time.Format(time.RFC3339),
+		entry.Type,
+		entry.Order,
+		entry.Reason,
+		formatSum(entry.Amount),
+		formatSum(entry.Balance),
+	})
+	if err != nil {
+		return err
+	}
+	if s.count%flushEvery == 0 {
+		s.cw.Flush()
+		flush(s.w)
+	}
+	s.count++
+	return s.cw.Error()
+}
+
+func (s *csvStatement) End(totals entity.StatementTotals) error {
+	to := s.to.Format(time.RFC3339)
+	s.cw.Write([]string{to, "accrued", "", "", formatSum(totals.Accrued), ""})
+	s.cw.Write([]string{to, "withdrawn", "", "", formatSum(-totals.Withdrawn), ""})
+	s.cw.Write([]string{to, "adjusted", "", "", formatSum(totals.Adjusted), ""})
+	s.cw.Write([]string{to, "closing_balance", "", "", "", formatSum(totals.Closing)})
+	s.cw.Flush()
+	return s.cw.Error()
+}
+
+// formatSum форматирует сумму баллов без лишних нулей.
+func formatSum(sum float32) string {
+	return strconv.FormatFloat(float64(sum), 'f', -1, 32)
+}
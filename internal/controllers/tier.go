@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/nextlag/gomart/internal/mw/auth"
+	"github.com/nextlag/gomart/internal/problem"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+// Tier обрабатывает запрос на получение уровня пользователя в программе лояльности.
+//
+// Этот метод принимает запрос HTTP GET и возвращает в формате JSON текущий уровень пользователя,
+// множитель начислений этого уровня, сумму баллов, начисленных за последние 12 месяцев,
+// а также следующий уровень, его порог и количество баллов, которых не хватает до него.
+// Если происходит ошибка при получении уровня из UseCase, метод возвращает ошибку InternalServerError (500).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) Tier(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+	// Получаем логин пользователя из контекста запроса
+	login, _ := r.Context().Value(auth.LoginKey).(string)
+
+	// Получаем уровень пользователя из UseCase
+	progress, err := c.uc.DoGetTier(r.Context(), login)
+	if err != nil {
+		log.Error("tier handler", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+
+	result, err := json.Marshal(progress)
+	if err != nil {
+		log.Error("tier handler: marshal", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+
+	// Устанавливаем заголовок Content-Type и код статуса OK (200) в ответе
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(result)
+}
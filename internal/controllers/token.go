@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/nextlag/gomart/internal/config"
+	"github.com/nextlag/gomart/internal/mw/auth"
+	"github.com/nextlag/gomart/internal/problem"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+// tokenResponse - тело ответа с токеном доступа
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`         // Время жизни токена в секундах
+	Password    string `json:"password,omitempty"` // Пароль, сгенерированный при регистрации; возвращается один раз
+}
+
+// newTokenResponse возвращает тело ответа с токеном доступа.
+func newTokenResponse(token string) tokenResponse {
+	return tokenResponse{
+		AccessToken: token,
+		TokenType:   auth.BearerScheme,
+		ExpiresIn:   int64(config.Cfg.AccessTokenTTL.Seconds()),
+	}
+}
+
+// writeToken отправляет токен доступа в заголовке Authorization и в теле ответа на запрос r со статусом OK (200).
+// Ответ с токеном не сохраняется ни в кешах, ни в хранилище ответов на идемпотентные запросы.
+func writeToken(w http.ResponseWriter, r *http.Request, token string) {
+	auth.SetBearer(w, token)
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, newTokenResponse(token))
+}
+
+// setTokens начинает новый сеанс пользователя с User-Agent и IP-адресом клиента из запроса r,
+// выдает в нем refresh-токен и токен доступа с текущей ролью и устанавливает их в куки.
+// Возвращает токен доступа.
+func (c *Controller) setTokens(w http.ResponseWriter, r *http.Request, login string) (string, error) {
+	role, err := c.uc.DoGetRole(r.Context(), login)
+	if err != nil {
+		return "", err
+	}
+	session, refresh, err := c.uc.DoIssueRefreshToken(r.Context(), login, r)
+	if err != nil {
+		return "", err
+	}
+	jwtToken, err := auth.SetAuth(c.ctx, login, role, session.ID, w)
+	if err != nil {
+		return "", err
+	}
+	auth.SetRefresh(w, refresh)
+	return jwtToken, nil
+}
+
+// RefreshToken обрабатывает запрос на обновление токена доступа.
+//
+// Этот метод принимает запрос HTTP POST с refresh-токеном в куке RefreshToken.
+// Предъявленный refresh-токен отзывается, а пользователь получает в куках новый токен доступа
+// и новый refresh-токен. Токен доступа также возвращается в заголовке Authorization и в теле ответа в формате JSON
+// со статусом OK (200).
+// Если кука отсутствует, а также если refresh-токен неизвестен, истек или уже был использован,
+// метод возвращает ошибку Unauthorized (401). Повторное использование refresh-токена отзывает
+// все refresh-токены пользователя.
+// При любых других ошибках метод возвращает ошибку InternalServerError (500).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+
+	cookie, err := r.Cookie(auth.RefreshCookie)
+	if err != nil || cookie.Value == "" {
+		problem.Write(w, r, er.ErrRefreshToken)
+		return
+	}
+
+	session, refresh, err := c.uc.DoRotateRefreshToken(r.Context(), cookie.Value, r)
+	switch {
+	case errors.Is(err, er.ErrRefreshToken):
+		log.Error("refresh token rejected", l.ErrAttr(err))
+		auth.ClearAuth(w)
+		problem.Write(w, r, er.ErrRefreshToken)
+		return
+	case err != nil:
+		log.Error("refresh token handler", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+
+	login := session.Login
+	// Роль берется из базы данных, поэтому ее изменение попадает в токен при обновлении
+	role, err := c.uc.DoGetRole(r.Context(), login)
+	if err != nil {
+		log.Error("refresh token handler: get role", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+	jwtToken, err := auth.SetAuth(c.ctx, login, role, session.ID, w)
+	if err != nil {
+		log.Error("can't set cookie", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrNoCookie)
+		return
+	}
+	auth.SetRefresh(w, refresh)
+
+	log.Debug("token refreshed", "login", login)
+	writeToken(w, r, jwtToken)
+}
+
+// Logout обрабатывает запрос на выход пользователя.
+//
+// Этот метод принимает запрос HTTP POST от аутентифицированного пользователя.
+// Текущий токен доступа, refresh-токен из куки RefreshToken и сеанс, в котором выдан токен доступа, отзываются,
+// куки удаляются, и метод возвращает статус OK (200).
+// Если происходит ошибка при отзыве токенов, метод возвращает ошибку InternalServerError (500).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) Logout(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+	// Получаем клеймы токена доступа из контекста запроса
+	claims, _ := r.Context().Value(auth.ClaimsKey).(*auth.Claims)
+	if claims == nil {
+		problem.Write(w, r, er.ErrUnAuthUser)
+		return
+	}
+
+	if cookie, err := r.Cookie(auth.RefreshCookie); err == nil && cookie.Value != "" {
+		if err = c.uc.DoRevokeRefreshToken(r.Context(), cookie.Value); err != nil {
+			log.Error("logout: revoke refresh token", l.ErrAttr(err))
+			problem.Write(w, r, er.ErrInternalServer)
+			return
+		}
+	}
+
+	if claims.SessionID != "" {
+		err := c.uc.DoRevokeSession(r.Context(), claims.Login, claims.SessionID)
+		if err != nil && !errors.Is(err, er.ErrNoSession) {
+			log.Error("logout: revoke session", l.ErrAttr(err))
+			problem.Write(w, r, er.ErrInternalServer)
+			return
+		}
+	}
+
+	// Токен хранится в списке отозванных до истечения его срока действия
+	if err := c.uc.DoRevokeAccessToken(r.Context(), claims.ID, claims.ExpiresAt.Time); err != nil {
+		log.Error("logout: revoke access token", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+
+	auth.ClearAuth(w)
+	log.Info("user logged out", "login", claims.Login)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("logged out"))
+}
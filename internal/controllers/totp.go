@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/nextlag/gomart/internal/mw/auth"
+	"github.com/nextlag/gomart/internal/problem"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+// totpConfirm - структура используемая для анализа json-запроса на подтверждение двухфакторной аутентификации.
+type totpConfirm struct {
+	Code string `json:"code"`
+}
+
+// recoveryCodes - коды восстановления, выдаваемые при подключении двухфакторной аутентификации.
+type recoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// EnrollTOTP обрабатывает запрос на подключение двухфакторной аутентификации.
+//
+// Этот метод принимает запрос HTTP POST от аутентифицированного пользователя и возвращает новый секрет TOTP
+// и ссылку otpauth:// для приложения-аутентификатора в формате JSON со статусом OK (200).
+// Двухфакторная аутентификация начинает действовать после подтверждения кодом в ConfirmTOTP.
+// Если двухфакторная аутентификация уже подключена, метод возвращает ошибку Conflict (409).
+// В случае любых других ошибок метод возвращает ошибку InternalServerError (500).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+	// Получаем логин пользователя из контекста запроса
+	user, _ := r.Context().Value(auth.LoginKey).(string)
+
+	enrollment, err := c.uc.DoEnrollTOTP(r.Context(), user)
+	switch {
+	case errors.Is(err, er.ErrTOTPEnabled):
+		problem.Write(w, r, er.ErrTOTPEnabled)
+		return
+	case err != nil:
+		log.Error("enroll TOTP handler", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+	log.Info("TOTP enrollment started", "user", user)
+
+	// Ответ с секретом не должен сохраняться в кешах
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, enrollment)
+}
+
+// ConfirmTOTP обрабатывает запрос на подтверждение подключения двухфакторной аутентификации.
+//
+// Этот метод принимает запрос HTTP POST с JSON-данными {"code": "123456"}, содержащими код из приложения-аутентификатора.
+// После подтверждения вход и списания выше порога требуют одноразового кода, и метод возвращает коды восстановления
+// в формате JSON со статусом OK (200). Коды восстановления возвращаются только в этом ответе.
+// Если JSON некорректен, метод возвращает ошибку BadRequest (400).
+// Если подключение не начато, метод возвращает ошибку NotFound (404), если уже подтверждено - Conflict (409).
+// Если код неверен, метод возвращает ошибку UnprocessableEntity (422).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	log := l.L(c.ctx)
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+	// Получаем логин пользователя из контекста запроса
+	user, _ := r.Context().Value(auth.LoginKey).(string)
+
+	var request totpConfirm
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Code == "" {
+		problem.Write(w, r, er.ErrRequestFormat)
+		return
+	}
+
+	codes, err := c.uc.DoConfirmTOTP(r.Context(), user, request.Code)
+	switch {
+	case errors.Is(err, er.ErrTOTPNotEnrolled):
+		problem.Write(w, r, er.ErrTOTPNotEnrolled)
+		return
+	case errors.Is(err, er.ErrTOTPEnabled):
+		problem.Write(w, r, er.ErrTOTPEnabled)
+		return
+	case errors.Is(err, er.ErrOTPCode):
+		problem.WriteStatus(w, r, http.StatusUnprocessableEntity, er.ErrOTPCode)
+		return
+	case err != nil:
+		log.Error("confirm TOTP handler", l.ErrAttr(err))
+		problem.Write(w, r, er.ErrInternalServer)
+		return
+	}
+	log.Info("TOTP enabled", "user", user)
+
+	// Ответ с кодами восстановления не должен сохраняться в кешах
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusOK, recoveryCodes{RecoveryCodes: codes})
+}
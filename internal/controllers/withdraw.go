@@ -3,9 +3,13 @@ package controllers
 import (
 	"encoding/json"
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/nextlag/gomart/internal/mw/auth"
+	"github.com/nextlag/gomart/internal/problem"
+	"github.com/nextlag/gomart/internal/usecase"
 	"github.com/nextlag/gomart/pkg/logger/l"
 )
 
@@ -13,6 +17,7 @@ import (
 type debit struct {
 	Order string  `json:"order"`
 	Sum   float32 `json:"sum"`
+	OTP   string  `json:"otp,omitempty"` // Одноразовый код для списаний выше порога подтверждения
 }
 
 // Withdraw обрабатывает запрос на списание средств со счета пользователя.
@@ -20,6 +25,11 @@ type debit struct {
 // Этот метод принимает запрос HTTP POST с JSON-данными, содержащими номер заказа и сумму для списания.
 // При успешном списании средств метод возвращает статус OK (200).
 // Если указанный пользователь не имеет достаточного баланса для списания, метод возвращает ошибку PaymentRequired (402).
+// Если списание превышает лимит на одно списание или на сутки либо выполняется в период запрета после смены пароля,
+// метод возвращает ошибку Forbidden (403), а если превышено количество списаний за час - TooManyRequests (429).
+// Списание суммы выше порога подтверждения пользователем с подключенной двухфакторной аутентификацией требует
+// одноразового кода в поле otp; без кода или с неверным кодом метод возвращает ошибку Forbidden (403),
+// а после превышения количества неверных кодов - TooManyRequests (429) с заголовком Retry-After.
 // Если происходит ошибка при декодировании JSON или при списании средств, метод возвращает ошибку InternalServerError (500)
 // с соответствующим сообщением об ошибке.
 //
@@ -39,35 +49,56 @@ func (c *Controller) Withdraw(w http.ResponseWriter, r *http.Request) {
 	// Декодируем JSON-данные из тела запроса в структуру debit
 	var request debit
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		http.Error(w, "invalid JSON format", http.StatusBadRequest)
+		problem.Write(w, r, er.ErrDecodeJSON)
 		return
 	}
 	// Логируем запрос на списание средств
 	log.Debug("debet request", "user", user, "order", request.Order, "sum", request.Sum)
 
 	// Вызываем метод DoDebit UseCase для списания средств
-	err := c.uc.Do().DoDebit(r.Context(), user, request.Order, request.Sum)
+	err := c.uc.Do().DoDebit(r.Context(), user, request.Order, request.Sum, request.OTP, r)
+	var throttleErr *usecase.ThrottleError
 	switch {
+	case errors.Is(err, er.ErrOTPRequired), errors.Is(err, er.ErrOTPCode):
+		// Если списание требует подтверждения одноразовым кодом, возвращаем ошибку Forbidden (403)
+		log.Error("withdraw step-up", "user", user, l.ErrAttr(err))
+		problem.WriteStatus(w, r, http.StatusForbidden, err)
+		return
+	case errors.As(err, &throttleErr):
+		// Если превышено количество неверных кодов, возвращаем ошибку TooManyRequests с заголовком Retry-After
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttleErr.RetryAfter.Seconds()))))
+		problem.Write(w, r, er.ErrTooManyAttempts)
+		return
 	case errors.Is(err, er.ErrNoBalance):
 		// Если недостаточно средств на счете, возвращаем ошибку PaymentRequired (402)
 		log.Error("there are insufficient funds in the account", l.ErrAttr(err))
-		http.Error(w, er.ErrNoBalance.Error(), http.StatusPaymentRequired)
+		problem.Write(w, r, er.ErrNoBalance)
 		return
 	case errors.Is(err, er.ErrOrderFormat):
 		// Если неверный формат заказа, возвращаем ошибку UnprocessableEntity (422)
 		log.Error("withdraw OrderFormat", l.ErrAttr(err))
-		http.Error(w, er.ErrOrderFormat.Error(), http.StatusUnprocessableEntity)
+		problem.Write(w, r, er.ErrOrderFormat)
+		return
+	case errors.Is(err, er.ErrWithdrawMax), errors.Is(err, er.ErrWithdrawDaily), errors.Is(err, er.ErrCoolingOff):
+		// Если списание нарушает лимиты или выполняется сразу после смены пароля, возвращаем ошибку Forbidden (403)
+		log.Error("withdraw limits", "user", user, l.ErrAttr(err))
+		problem.Write(w, r, err)
+		return
+	case errors.Is(err, er.ErrWithdrawRate):
+		// Если превышено количество списаний за час, возвращаем ошибку TooManyRequests (429)
+		log.Error("withdraw rate", "user", user, l.ErrAttr(err))
+		problem.Write(w, r, er.ErrWithdrawRate)
 		return
 	case errors.Is(err, er.ErrThisUser) || errors.Is(err, er.ErrAnotherUser):
 		// Если заказ уже обработан, возвращаем ошибку Conflict (409)
 		log.Debug("withdraw", "user", user, "order", request.Order)
 		log.Error("withdraw AnotherUser", l.ErrAttr(err))
-		http.Error(w, "order is already loaded", http.StatusConflict)
+		problem.Write(w, r, err)
 		return
 	case err != nil:
 		// Если произошла другая ошибка при списании средств, возвращаем ошибку InternalServerError (500)
 		log.Error("withdraw handler", l.ErrAttr(err))
-		http.Error(w, er.ErrInternalServer.Error(), http.StatusInternalServerError)
+		problem.Write(w, r, er.ErrInternalServer)
 		return
 	}
 	// Возвращаем успешный статус и сообщение об успешном списании средств
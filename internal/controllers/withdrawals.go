@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/nextlag/gomart/internal/mw/auth"
+	"github.com/nextlag/gomart/internal/problem"
 	"github.com/nextlag/gomart/pkg/logger/l"
 )
 
@@ -13,6 +14,10 @@ import (
 // Этот метод принимает запрос HTTP GET для получения истории списаний средств пользователя.
 // При успешном выполнении метод возвращает историю списаний в формате JSON и статус OK (200).
 // Если история списаний пуста, метод возвращает статус NoContent (204).
+// Если передан хотя бы один из параметров limit, cursor, status, from, to или sort, списания возвращаются
+// постранично (по умолчанию по 50) с фильтрами по статусу заказа и периоду, а ссылки на первую и следующую
+// страницу передаются в заголовке Link; пустая страница возвращается со статусом OK (200).
+// Если параметры некорректны, метод возвращает ошибку BadRequest (400).
 // Если происходит ошибка при получении истории списаний из UseCase, метод возвращает ошибку InternalServerError (500)
 // с соответствующим сообщением об ошибке.
 //
@@ -29,18 +34,39 @@ func (c *Controller) Withdrawals(w http.ResponseWriter, r *http.Request) {
 	// Получаем логин пользователя из контекста
 	user, _ := r.Context().Value(auth.LoginKey).(string)
 
+	// Если переданы параметры страницы, возвращаем списания постранично
+	filter, paged, err := parseHistoryFilter(r.URL.Query())
+	if err != nil {
+		problem.Write(w, r, er.ErrRequestFormat)
+		return
+	}
+	if paged {
+		withdrawals, next, err := c.uc.DoGetWithdrawalsPage(r.Context(), user, filter)
+		switch {
+		case errors.Is(err, er.ErrRequestFormat):
+			problem.Write(w, r, er.ErrRequestFormat)
+		case err != nil:
+			log.Error("withdrawals handler", l.ErrAttr(err))
+			problem.Write(w, r, er.ErrInternalServer)
+		default:
+			setPageLinks(w, r, next)
+			writeJSON(w, r, http.StatusOK, withdrawals)
+		}
+		return
+	}
+
 	// Получаем историю списаний средств пользователя из UseCase
 	result, err := c.uc.DoGetWithdrawals(r.Context(), user)
 	switch {
 	case errors.Is(err, er.ErrNoRows):
 		// Если история списаний пуста, возвращаем статус NoContent (204)
 		log.Error("withdrawals handler", l.ErrAttr(err))
-		http.Error(w, "there is no write off", http.StatusNoContent)
+		w.WriteHeader(http.StatusNoContent)
 		return
 	case err != nil:
 		// Если произошла ошибка при получении истории списаний, возвращаем ошибку InternalServerError (500)
 		log.Error("withdrawals handler", l.ErrAttr(err))
-		http.Error(w, er.ErrInternalServer.Error(), http.StatusInternalServerError)
+		problem.Write(w, r, er.ErrInternalServer)
 		return
 	}
 
@@ -0,0 +1,267 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/nextlag/gomart/internal/config"
+	"github.com/nextlag/gomart/internal/mw/auth"
+	"github.com/nextlag/gomart/internal/problem"
+	"github.com/nextlag/gomart/internal/usecase"
+	"github.com/nextlag/gomart/pkg/events"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+// Темы событий, на которые подписывается клиент WebSocket.
+const (
+	TopicOrders  = "orders"  // Изменения статусов заказов
+	TopicBalance = "balance" // Изменения баланса
+)
+
+// Типы сообщений WebSocket.
+const (
+	wsSubscribe   = "subscribe"   // Клиент: подписка на темы topics
+	wsUnsubscribe = "unsubscribe" // Клиент: отписка от тем topics
+	wsAck         = "ack"         // Клиент: подтверждение получения событий до id включительно
+	wsPing        = "ping"        // Клиент: проверка соединения
+	wsPong        = "pong"        // Сервер: ответ на ping
+	wsSubscribed  = "subscribed"  // Сервер: текущий список тем
+	wsEvent       = "event"       // Сервер: событие
+	wsReset       = "reset"       // Сервер: часть событий после last_event_id пропущена
+	wsError       = "error"       // Сервер: ошибка в сообщении клиента или причина закрытия соединения
+)
+
+// wsWriteTimeout - время, за которое сообщение должно быть отправлено клиенту
+const wsWriteTimeout = 10 * time.Second
+
+// eventTopics - тема каждого типа событий
+var eventTopics = map[string]string{
+	usecase.EventOrder:   TopicOrders,
+	usecase.EventBalance: TopicBalance,
+}
+
+// wsMessage - сообщение WebSocket в обе стороны.
+type wsMessage struct {
+	Type   string          `json:"type"`
+	Topics []string        `json:"topics,omitempty"`
+	ID     uint64          `json:"id,omitempty"`
+	Topic  string          `json:"topic,omitempty"`
+	Event  string          `json:"event,omitempty"`
+	Data   json.RawMessage `json:"data,omitempty"`
+	Time   *time.Time      `json:"time,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+var (
+	// errSlowClient - клиент не подтверждает и не читает события
+	errSlowClient = errors.New("client is too slow")
+	// errTokenExpired - токен доступа, с которым установлено соединение, истек
+	errTokenExpired = errors.New("access token expired")
+	// errTokenRevoked - токен доступа, с которым установлено соединение, отозван
+	errTokenRevoked = errors.New("access token revoked")
+)
+
+// WebSocket обрабатывает подключение к каналу уведомлений пользователя по протоколу WebSocket.
+//
+// Клиент аутентифицируется так же, как в остальных запросах пользователя, подписывается на темы orders и balance
+// сообщением {"type":"subscribe","topics":[...]} и получает события {"type":"event","id":...,"topic":...,"data":...}.
+// Получение событий подтверждается сообщением {"type":"ack","id":...}, подтверждающим все события до id включительно.
+// Без подтверждения клиенту отправляется не больше config.Cfg.Events.Buffer событий; если клиент не подтверждает
+// или не читает их, а события продолжают поступать, соединение закрывается. После переподключения с параметром
+// last_event_id клиент получает пропущенные события; если они уже не хранятся, первым приходит сообщение reset.
+// Соединение закрывается, когда истекает токен доступа или когда при периодической проверке с интервалом
+// config.Cfg.Events.RevocationCheck обнаруживается, что он отозван. При аутентификации кукой источник запроса
+// (заголовок Origin) должен совпадать с сервером.
+// Если идентификатор последнего события некорректен, метод возвращает ошибку BadRequest (400).
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - объект HTTP-запроса.
+//
+// Возвращаемые значения:
+//   - нет.
+func (c *Controller) WebSocket(w http.ResponseWriter, r *http.Request) {
+	// Получаем объект ошибки из UseCase
+	er := c.uc.Do().Err()
+	// Получаем логин пользователя и клеймы токена из контекста запроса
+	user, _ := r.Context().Value(auth.LoginKey).(string)
+	claims, _ := r.Context().Value(auth.ClaimsKey).(*auth.Claims)
+
+	var lastID uint64
+	if v := r.URL.Query().Get("last_event_id"); v != "" {
+		var err error
+		if lastID, err = strconv.ParseUint(v, 10, 64); err != nil {
+			problem.Write(w, r, er.ErrRequestFormat)
+			return
+		}
+	}
+
+	websocket.Server{
+		Handshake: func(cfg *websocket.Config, r *http.Request) error {
+			return checkWSOrigin(cfg, r, claims != nil && claims.Cookie)
+		},
+		Handler: func(ws *websocket.Conn) {
+			c.serveWS(ws, user, lastID, claims)
+		},
+	}.ServeHTTP(w, r)
+}
+
+// checkWSOrigin проверяет источник запроса на подключение. Куки отправляются браузером с любого сайта,
+// поэтому при аутентификации кукой подключение принимается только со страниц этого же сервера.
+func checkWSOrigin(cfg *websocket.Config, r *http.Request, cookie bool) error {
+	origin, err := websocket.Origin(cfg, r)
+	if err != nil {
+		return err
+	}
+	cfg.Origin = origin
+	if cookie && (origin == nil || !strings.EqualFold(origin.Host, r.Host)) {
+		return errors.New("cross-origin WebSocket connection")
+	}
+	return nil
+}
+
+// serveWS доставляет клиенту события пользователя и обрабатывает его сообщения до закрытия соединения.
+func (c *Controller) serveWS(ws *websocket.Conn, user string, lastID uint64, claims *auth.Claims) {
+	log := l.L(c.ctx)
+	defer ws.Close()
+
+	sub, complete := c.uc.DoSubscribe(user, lastID)
+	defer sub.Close()
+
+	// Сообщения клиента читаются в отдельной горутине, отправка выполняется только в этой
+	incoming := make(chan wsMessage)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(incoming)
+		for {
+			var m wsMessage
+			if err := websocket.JSON.Receive(ws, &m); err != nil {
+				return
+			}
+			select {
+			case incoming <- m:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	send := func(m wsMessage) error {
+		if err := ws.SetWriteDeadline(time.Now().Add(wsWriteTimeout)); err != nil {
+			return err
+		}
+		return websocket.JSON.Send(ws, m)
+	}
+
+	var expired <-chan time.Time
+	if claims != nil && claims.ExpiresAt != nil {
+		timer := time.NewTimer(time.Until(claims.ExpiresAt.Time))
+		defer timer.Stop()
+		expired = timer.C
+	}
+	// Отзыв токена (выход, смена пароля, завершение сеанса) проверяется периодически, как при каждом запросе
+	// проверяет middleware аутентификации; нулевой интервал отключает проверку
+	var recheck <-chan time.Time
+	if d := config.Cfg.Events.RevocationCheck; d > 0 && claims != nil && claims.ID != "" {
+		ticker := time.NewTicker(d)
+		defer ticker.Stop()
+		recheck = ticker.C
+	}
+
+	if !complete {
+		if err := send(wsMessage{Type: wsReset}); err != nil {
+			return
+		}
+	}
+
+	var (
+		topics   []string
+		inflight []uint64 // Отправленные и не подтвержденные события
+		err      error
+	)
+	window := max(config.Cfg.Events.Buffer, 1)
+	for err == nil {
+		// Пока окно неподтвержденных событий заполнено, события остаются в буфере подписки
+		pending := sub.C
+		if len(inflight) >= window {
+			pending = nil
+		}
+
+		select {
+		case m, ok := <-incoming:
+			if !ok {
+				return
+			}
+			switch m.Type {
+			case wsSubscribe, wsUnsubscribe:
+				if slices.ContainsFunc(m.Topics, func(t string) bool { return t != TopicOrders && t != TopicBalance }) {
+					err = send(wsMessage{Type: wsError, Error: "unknown topic"})
+					continue
+				}
+				topics = slices.DeleteFunc(topics, func(t string) bool { return slices.Contains(m.Topics, t) })
+				if m.Type == wsSubscribe {
+					topics = append(topics, m.Topics...)
+					slices.Sort(topics)
+					topics = slices.Compact(topics)
+				}
+				err = send(wsMessage{Type: wsSubscribed, Topics: topics})
+			case wsAck:
+				inflight = slices.DeleteFunc(inflight, func(id uint64) bool { return id <= m.ID })
+			case wsPing:
+				err = send(wsMessage{Type: wsPong})
+			default:
+				err = send(wsMessage{Type: wsError, Error: "unknown message type"})
+			}
+		case e, ok := <-pending:
+			if !ok {
+				err = errSlowClient
+				continue
+			}
+			err = sendEvent(send, e, topics, &inflight)
+		case <-sub.Done():
+			err = errSlowClient
+		case <-expired:
+			err = errTokenExpired
+		case <-recheck:
+			err = c.checkWSToken(ws.Request(), claims)
+		case <-c.ctx.Done():
+			return
+		}
+	}
+
+	log.Info("websocket closed", "user", user, l.ErrAttr(err))
+	if errors.Is(err, errSlowClient) || errors.Is(err, errTokenExpired) || errors.Is(err, errTokenRevoked) {
+		_ = send(wsMessage{Type: wsError, Error: err.Error()})
+	}
+}
+
+// checkWSToken проверяет, не отозван ли токен доступа, с которым открыто соединение. Если проверить не удалось,
+// соединение закрывается, как middleware аутентификации отклоняет запрос: клиент переподключится с действующим токеном.
+func (c *Controller) checkWSToken(r *http.Request, claims *auth.Claims) error {
+	revoked, err := c.uc.DoIsAccessTokenRevoked(r.Context(), claims.ID, claims.Login, claims.SessionID, claims.IssuedAt.Time)
+	switch {
+	case err != nil:
+		return err
+	case revoked:
+		return errTokenRevoked
+	}
+	return nil
+}
+
+// sendEvent отправляет клиенту событие, если он подписан на его тему, и добавляет его в окно неподтвержденных.
+func sendEvent(send func(wsMessage) error, e events.Event, topics []string, inflight *[]uint64) error {
+	topic := eventTopics[e.Type]
+	if !slices.Contains(topics, topic) {
+		return nil
+	}
+	*inflight = append(*inflight, e.ID)
+	return send(wsMessage{Type: wsEvent, ID: e.ID, Topic: topic, Event: e.Type, Data: e.Data, Time: &e.Time})
+}
@@ -9,8 +9,22 @@ type User struct {
 	Password  string  `json:"password"`
 	Balance   float32 `json:"balance"`
 	Withdrawn float32 `json:"withdrawn"`
+	Tier      string  `json:"tier"`
+	Role      string  `json:"-"`             // Роль задается только администратором
+	OTP       string  `json:"otp,omitempty"` // Одноразовый код второго фактора при входе
 }
 
+// Роли пользователей.
+const (
+	RoleUser    = "user"    // Пользователь программы лояльности
+	RoleSupport = "support" // Служба поддержки: просмотр данных через маршруты администратора
+	RoleAdmin   = "admin"   // Администратор: все маршруты администратора
+	RoleService = "service" // Внутренний сервис
+)
+
+// Roles - список допустимых ролей пользователей.
+var Roles = []string{RoleUser, RoleSupport, RoleAdmin, RoleService}
+
 // Order структура, предназначенная для вставки данных в таблицу заказов.
 type Order struct {
 	UserName         string    `json:"user_name,omitempty"`
@@ -21,6 +35,215 @@ type Order struct {
 	BonusesWithdrawn float32   `json:"bonuses_withdrawn,omitempty"`
 }
 
+// Статусы расчета начислений по заказу.
+const (
+	OrderNew        = "NEW"        // Заказ загружен, но еще не передан в систему расчета начислений
+	OrderProcessing = "PROCESSING" // Начисление по заказу рассчитывается
+	OrderInvalid    = "INVALID"    // Система расчета отказала в начислении
+	OrderProcessed  = "PROCESSED"  // Начисление рассчитано
+)
+
+// OrderStatuses - список допустимых статусов заказа.
+var OrderStatuses = []string{OrderNew, OrderProcessing, OrderInvalid, OrderProcessed}
+
+// Balance структура, описывающая текущий баланс пользователя и сумму списанных баллов.
+type Balance struct {
+	Current   float32 `json:"current"`
+	Withdrawn float32 `json:"withdrawn"`
+}
+
+// OrderStatusChange структура, описывающая изменение статуса расчета начислений по заказу.
+type OrderStatusChange struct {
+	Status    string    `json:"status"`
+	Accrual   float32   `json:"accrual,omitempty"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// OrderDetails структура, описывающая заказ пользователя с историей изменения его статуса.
+type OrderDetails struct {
+	Order
+	History []OrderStatusChange `json:"history"`
+}
+
+// HistoryFilter структура, описывающая параметры постраничного получения заказов и списаний пользователя.
+// Записи упорядочены по времени загрузки заказа; Cursor - непрозрачная позиция, с которой начинается страница.
+type HistoryFilter struct {
+	Limit  int       // Размер страницы; 0 - размер по умолчанию
+	Cursor string    // Позиция из предыдущей страницы; пустая строка - первая страница
+	Status []string  // Статусы заказов; пустой список - любые статусы
+	From   time.Time // Начало периода включительно; нулевое значение - без ограничения
+	To     time.Time // Конец периода не включительно; нулевое значение - без ограничения
+	Desc   bool      // Сначала новые записи
+}
+
+// Idempotency структура, предназначенная для хранения ответа на запрос с заголовком Idempotency-Key.
+type Idempotency struct {
+	Login       string    `json:"login"`
+	Key         string    `json:"key"`
+	RequestHash string    `json:"request_hash"`
+	Status      int       `json:"status"`
+	ContentType string    `json:"content_type"`
+	Body        []byte    `json:"body"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// StatementEntry структура, описывающая одну операцию в выписке по счету.
+type StatementEntry struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	Order   string    `json:"order,omitempty"`
+	Reason  string    `json:"reason,omitempty"`
+	Amount  float32   `json:"amount"`
+	Balance float32   `json:"balance"`
+}
+
+// StatementTotals структура, содержащая итоги выписки по счету за период.
+type StatementTotals struct {
+	Opening   float32 `json:"opening_balance"`
+	Accrued   float32 `json:"accrued"`
+	Withdrawn float32 `json:"withdrawn"`
+	Adjusted  float32 `json:"adjusted"`
+	Closing   float32 `json:"closing_balance"`
+}
+
+// TierProgress структура, описывающая текущий уровень пользователя в программе лояльности и прогресс до следующего.
+type TierProgress struct {
+	Tier          string  `json:"tier"`
+	Multiplier    float32 `json:"multiplier"`
+	Accrued       float32 `json:"accrued"`
+	NextTier      string  `json:"next_tier,omitempty"`
+	NextThreshold float32 `json:"next_threshold,omitempty"`
+	Remaining     float32 `json:"remaining,omitempty"`
+}
+
+// Adjustment структура, описывающая ручное начисление или списание баллов администратором или по промо-кампании.
+type Adjustment struct {
+	ID        int64     `json:"id"`
+	Login     string    `json:"login"`
+	Amount    float32   `json:"amount"`
+	Reason    string    `json:"reason"`
+	Campaign  string    `json:"campaign,omitempty"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Виды промо-кампаний.
+const (
+	CampaignWelcome = "welcome" // Начисление при регистрации пользователя
+	CampaignBulk    = "bulk"    // Начисление по списку логинов
+)
+
+// Campaign структура, описывающая промо-кампанию, начисляющую баллы не более одного раза на пользователя.
+type Campaign struct {
+	Name      string    `json:"name"`
+	Kind      string    `json:"kind"`
+	Amount    float32   `json:"amount"`
+	Reason    string    `json:"reason"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WithdrawalViolation структура, описывающая списание, отклоненное правилами ограничения списаний.
+type WithdrawalViolation struct {
+	ID        int64     `json:"id"`
+	Login     string    `json:"login"`
+	Order     string    `json:"order"`
+	Sum       float32   `json:"sum"`
+	Rule      string    `json:"rule"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WithdrawLimits структура, описывающая ограничения на списание баллов, назначенные пользователю администратором.
+// Пустое поле означает общее ограничение из конфигурации, нулевое значение отключает правило для пользователя.
+type WithdrawLimits struct {
+	Login          string     `json:"login"`
+	MaxSum         *float32   `json:"max_sum,omitempty"`
+	MaxDailySum    *float32   `json:"max_daily_sum,omitempty"`
+	MaxHourlyCount *int       `json:"max_hourly_count,omitempty"`
+	UpdatedBy      string     `json:"updated_by,omitempty"`
+	UpdatedAt      *time.Time `json:"updated_at,omitempty"`
+}
+
+// Области действия API-ключей: каждая открывает сервисной учетной записи набор маршрутов пользователя.
+const (
+	ScopeOrdersWrite = "orders:write"     // Загрузка номеров заказов
+	ScopeOrdersRead  = "orders:read"      // Просмотр загруженных заказов
+	ScopeBalanceRead = "balance:read"     // Просмотр баланса и истории списаний
+	ScopeWithdraw    = "balance:withdraw" // Списание баллов в счет оплаты заказа
+)
+
+// APIScopes - список допустимых областей действия API-ключей.
+var APIScopes = []string{ScopeOrdersWrite, ScopeOrdersRead, ScopeBalanceRead, ScopeWithdraw}
+
+// APIKey структура, описывающая API-ключ сервисной учетной записи, например интернет-магазина,
+// выполняющего запросы от имени покупателей.
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	RateLimit  int        `json:"rate_limit"`    // Запросов в минуту
+	Key        string     `json:"key,omitempty"` // Ключ целиком; возвращается только при создании
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Session структура, описывающая сеанс пользователя на устройстве: вход и все обновления токенов после него.
+type Session struct {
+	ID         string     `json:"id"`
+	Login      string     `json:"-"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Current    bool       `json:"current"` // Сеанс, из которого выполнен запрос
+}
+
+// TOTPEnrollment структура, описывающая подключение двухфакторной аутентификации: секрет и ссылку otpauth://
+// для приложения-аутентификатора.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// Withdrawal структура, описывающая списание баллов в счет оплаты заказа.
+type Withdrawal struct {
+	Order       string    `json:"order"`
+	Sum         float32   `json:"sum"`
+	ProcessedAt time.Time `json:"processed_at"`
+}
+
+// Identity структура, описывающая учетную запись внешнего поставщика удостоверений, связанную с пользователем.
+type Identity struct {
+	Issuer    string    `json:"issuer"`
+	Subject   string    `json:"subject"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AccountProfile структура, описывающая учетную запись пользователя в выгрузке персональных данных.
+type AccountProfile struct {
+	Login             string     `json:"login"`
+	Role              string     `json:"role"`
+	Tier              string     `json:"tier"`
+	Balance           float32    `json:"balance"`
+	Withdrawn         float32    `json:"withdrawn"`
+	PasswordChangedAt *time.Time `json:"password_changed_at,omitempty"`
+	TwoFactor         bool       `json:"two_factor"`
+}
+
+// AccountExport структура, содержащая все данные пользователя для выгрузки персональных данных.
+type AccountExport struct {
+	ExportedAt  time.Time      `json:"exported_at"`
+	Profile     AccountProfile `json:"profile"`
+	Orders      []Order        `json:"orders"`
+	Withdrawals []Withdrawal   `json:"withdrawals"`
+	Adjustments []Adjustment   `json:"adjustments"`
+	Sessions    []Session      `json:"sessions"`
+	Identities  []Identity     `json:"identities"`
+}
+
 type AllEntity struct {
 	*User
 	*Order
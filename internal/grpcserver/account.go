@@ -0,0 +1,102 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lib/pq"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/nextlag/gomart/internal/usecase"
+	"github.com/nextlag/gomart/pkg/logger/l"
+	"github.com/nextlag/gomart/pkg/pb"
+)
+
+// Register регистрирует пользователя и возвращает токен доступа.
+//
+// Если пароль не указан, для пользователя генерируется случайный пароль, удовлетворяющий политике паролей;
+// он возвращается один раз в поле password ответа и не записывается в журнал.
+// Если логин не указан или пароль не удовлетворяет политике паролей, возвращает статус InvalidArgument.
+// Если логин уже занят другим пользователем, возвращает статус AlreadyExists.
+// При любых других ошибках возвращает статус Internal.
+func (s *Server) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.TokenResponse, error) {
+	log := l.L(s.ctx)
+	// Получаем объект ошибки из UseCase
+	er := s.uc.Do().Err()
+
+	if req.GetLogin() == "" {
+		return nil, status.Error(codes.InvalidArgument, er.ErrRequest.Error())
+	}
+	password := req.GetPassword()
+	// Сгенерированный пароль возвращается пользователю в ответе
+	var generated string
+	if password == "" {
+		var err error
+		if generated, err = s.uc.DoGeneratePassword(req.GetLogin()); err != nil {
+			log.Error("generating password", l.ErrAttr(err))
+			return nil, status.Error(codes.Internal, er.ErrInternalServer.Error())
+		}
+		password = generated
+		log.Info("password generated", "login", req.GetLogin())
+	}
+
+	if err := s.uc.DoRegister(ctx, req.GetLogin(), password, clientRequest(ctx)); err != nil {
+		var pqErr *pq.Error
+		switch {
+		case errors.Is(err, er.ErrPasswordPolicy):
+			log.Error("password rejected by policy", "login", req.GetLogin(), l.ErrAttr(err))
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errors.As(err, &pqErr) && pqErr.Code == "23505":
+			log.Error("duplicate login", l.ErrAttr(err))
+			return nil, status.Error(codes.AlreadyExists, er.ErrNoLogin.Error())
+		default:
+			log.Error("register error", l.ErrAttr(err))
+			return nil, status.Error(codes.Internal, er.ErrInternalServer.Error())
+		}
+	}
+
+	resp, err := s.issueToken(ctx, req.GetLogin())
+	if err != nil {
+		log.Error("issuing access token", l.ErrAttr(err))
+		return nil, status.Error(codes.Internal, er.ErrInternalServer.Error())
+	}
+	resp.Password = generated
+	return resp, nil
+}
+
+// Login аутентифицирует пользователя и возвращает токен доступа.
+//
+// Если логин или пароль неверны, возвращает статус Unauthenticated. Если у пользователя подключена двухфакторная
+// аутентификация, запрос должен содержать одноразовый код или код восстановления в поле otp; без кода или с неверным
+// кодом возвращает статус Unauthenticated с сообщением "one-time code required" или "invalid one-time code".
+// Если после неудачных попыток входа для логина или IP-адреса действует задержка или блокировка, возвращает статус
+// ResourceExhausted со временем до повторной попытки в деталях google.rpc.RetryInfo.
+// При ошибке выдачи токена возвращает статус Internal.
+func (s *Server) Login(ctx context.Context, req *pb.LoginRequest) (*pb.TokenResponse, error) {
+	log := l.L(s.ctx)
+	// Получаем объект ошибки из UseCase
+	er := s.uc.Do().Err()
+
+	err := s.uc.DoAuth(ctx, req.GetLogin(), req.GetPassword(), req.GetOtp(), clientRequest(ctx))
+	var throttleErr *usecase.ThrottleError
+	switch {
+	case errors.As(err, &throttleErr):
+		log.Error("login throttled", "login", req.GetLogin(), "retry_after", throttleErr.RetryAfter)
+		return nil, retryError(er.ErrTooManyAttempts.Error(), throttleErr.RetryAfter)
+	case errors.Is(err, er.ErrOTPRequired), errors.Is(err, er.ErrOTPCode):
+		log.Error("second factor", "login", req.GetLogin(), l.ErrAttr(err))
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	case err != nil:
+		log.Error("incorrect login or password", l.ErrAttr(err))
+		return nil, status.Error(codes.Unauthenticated, er.ErrUnauthorized.Error())
+	}
+
+	resp, err := s.issueToken(ctx, req.GetLogin())
+	if err != nil {
+		log.Error("issuing access token", l.ErrAttr(err))
+		return nil, status.Error(codes.Internal, er.ErrInternalServer.Error())
+	}
+	log.Debug("success authenticated", "login", req.GetLogin())
+	return resp, nil
+}
@@ -0,0 +1,108 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/nextlag/gomart/internal/usecase"
+	"github.com/nextlag/gomart/pkg/logger/l"
+	"github.com/nextlag/gomart/pkg/pb"
+)
+
+// GetBalance возвращает текущий баланс пользователя и сумму его списаний.
+// При ошибке получения баланса возвращает статус Internal.
+func (s *Server) GetBalance(ctx context.Context, _ *pb.GetBalanceRequest) (*pb.GetBalanceResponse, error) {
+	log := l.L(s.ctx)
+	// Получаем объект ошибки из UseCase
+	er := s.uc.Do().Err()
+
+	balance, withdrawn, err := s.uc.DoGetBalance(ctx, login(ctx))
+	if err != nil {
+		log.Error("get balance", l.ErrAttr(err))
+		return nil, status.Error(codes.Internal, er.ErrInternalServer.Error())
+	}
+	return &pb.GetBalanceResponse{Current: balance, Withdrawn: withdrawn}, nil
+}
+
+// Withdraw списывает баллы пользователя в счет оплаты заказа.
+//
+// Если номер заказа не проходит проверку алгоритмом Луна, возвращает статус InvalidArgument.
+// Если баллов недостаточно или списание нарушает ограничения на сумму или запрет после смены пароля,
+// возвращает статус FailedPrecondition. Если для списания требуется одноразовый код, а он не указан или неверен,
+// возвращает статус PermissionDenied. Если превышено количество списаний за час или неудачных попыток ввода кода,
+// возвращает статус ResourceExhausted; для попыток ввода кода время до повторной попытки передается в деталях
+// google.rpc.RetryInfo. Если по заказу уже есть списание или начисление, возвращает статус AlreadyExists.
+// При любых других ошибках возвращает статус Internal.
+func (s *Server) Withdraw(ctx context.Context, req *pb.WithdrawRequest) (*pb.WithdrawResponse, error) {
+	log := l.L(s.ctx)
+	// Получаем объект ошибки из UseCase
+	er := s.uc.Do().Err()
+	user := login(ctx)
+
+	err := s.uc.DoDebit(ctx, user, req.GetOrder(), req.GetSum(), req.GetOtp(), clientRequest(ctx))
+	var throttleErr *usecase.ThrottleError
+	switch {
+	case errors.Is(err, er.ErrOTPRequired), errors.Is(err, er.ErrOTPCode):
+		log.Error("withdraw step-up", "user", user, l.ErrAttr(err))
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	case errors.As(err, &throttleErr):
+		return nil, retryError(er.ErrTooManyAttempts.Error(), throttleErr.RetryAfter)
+	case errors.Is(err, er.ErrNoBalance):
+		log.Error("there are insufficient funds in the account", l.ErrAttr(err))
+		return nil, status.Error(codes.FailedPrecondition, er.ErrNoBalance.Error())
+	case errors.Is(err, er.ErrOrderFormat):
+		log.Error("withdraw OrderFormat", l.ErrAttr(err))
+		return nil, status.Error(codes.InvalidArgument, er.ErrOrderFormat.Error())
+	case errors.Is(err, er.ErrWithdrawMax), errors.Is(err, er.ErrWithdrawDaily), errors.Is(err, er.ErrCoolingOff):
+		log.Error("withdraw limits", "user", user, l.ErrAttr(err))
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, er.ErrWithdrawRate):
+		log.Error("withdraw rate", "user", user, l.ErrAttr(err))
+		return nil, status.Error(codes.ResourceExhausted, er.ErrWithdrawRate.Error())
+	case errors.Is(err, er.ErrThisUser), errors.Is(err, er.ErrAnotherUser):
+		log.Error("withdraw AnotherUser", l.ErrAttr(err))
+		return nil, status.Error(codes.AlreadyExists, "order is already loaded")
+	case err != nil:
+		log.Error("withdraw", l.ErrAttr(err))
+		return nil, status.Error(codes.Internal, er.ErrInternalServer.Error())
+	}
+	return &pb.WithdrawResponse{}, nil
+}
+
+// ListWithdrawals возвращает страницу списаний пользователя и курсор следующей страницы.
+//
+// Параметры страницы имеют тот же смысл, что и параметры запроса GET /api/user/withdrawals.
+// Если параметры некорректны, возвращает статус InvalidArgument. При любых других ошибках возвращает статус Internal.
+func (s *Server) ListWithdrawals(ctx context.Context, req *pb.ListWithdrawalsRequest) (*pb.ListWithdrawalsResponse, error) {
+	log := l.L(s.ctx)
+	// Получаем объект ошибки из UseCase
+	er := s.uc.Do().Err()
+
+	f, err := pageFilter(req.GetPage())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, er.ErrRequestFormat.Error())
+	}
+
+	withdrawals, next, err := s.uc.DoGetWithdrawalsPage(ctx, login(ctx), f)
+	switch {
+	case errors.Is(err, er.ErrRequestFormat):
+		return nil, status.Error(codes.InvalidArgument, er.ErrRequestFormat.Error())
+	case err != nil:
+		log.Error("list withdrawals", l.ErrAttr(err))
+		return nil, status.Error(codes.Internal, er.ErrInternalServer.Error())
+	}
+
+	resp := &pb.ListWithdrawalsResponse{Withdrawals: make([]*pb.Withdrawal, 0, len(withdrawals)), NextCursor: next}
+	for _, w := range withdrawals {
+		resp.Withdrawals = append(resp.Withdrawals, &pb.Withdrawal{
+			Order:       w.Order,
+			Sum:         w.Sum,
+			ProcessedAt: timestamppb.New(w.ProcessedAt),
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,113 @@
+// Package grpcserver - сервер gRPC API для внутренних сервисов. Методы API повторяют маршруты HTTP API
+// для регистрации, входа, заказов, баланса и списаний и выполняются теми же операциями controllers.UseCase.
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/nextlag/gomart/internal/config"
+	"github.com/nextlag/gomart/internal/controllers"
+	"github.com/nextlag/gomart/internal/mw/auth"
+	"github.com/nextlag/gomart/pkg/pb"
+)
+
+// Server реализует сервис pb.GophermartServer.
+type Server struct {
+	pb.UnimplementedGophermartServer
+
+	ctx context.Context
+	uc  controllers.UseCase
+}
+
+// New создает реализацию сервиса gRPC API; ctx содержит логгер.
+func New(ctx context.Context, uc controllers.UseCase) *Server {
+	return &Server{ctx: ctx, uc: uc}
+}
+
+// NewServer создает сервер gRPC и регистрирует в нем сервис.
+//
+// Вызовы проходят через перехватчики восстановления после паники, логирования и аутентификации.
+// Методы, кроме Register и Login, требуют токен доступа в метаданных authorization: "Bearer <token>",
+// который проверяется так же, как заголовок Authorization в HTTP API, включая проверку отзыва токена.
+//
+// Параметры:
+//   - opts: дополнительные параметры сервера, например учетные данные TLS из Credentials.
+//
+// Возвращаемые значения:
+//   - *grpc.Server - сервер с зарегистрированным сервисом.
+func (s *Server) NewServer(opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts, grpc.ChainUnaryInterceptor(
+		recoverer(s.ctx),
+		logger(s.ctx),
+		authentication(s.ctx, s.uc, s.uc.Do().Err(), pb.Gophermart_Register_FullMethodName, pb.Gophermart_Login_FullMethodName),
+	))
+	srv := grpc.NewServer(opts...)
+	pb.RegisterGophermartServer(srv, s)
+	return srv
+}
+
+// Credentials загружает сертификат и закрытый ключ TLS сервера gRPC API. Токены доступа и пароли передаются
+// в метаданных и сообщениях вызовов, поэтому без TLS сервер можно открывать только в доверенной сети.
+//
+// Параметры:
+//   - certFile: файл сертификата в формате PEM; может содержать цепочку промежуточных сертификатов.
+//   - keyFile: файл закрытого ключа в формате PEM.
+//
+// Возвращаемые значения:
+//   - grpc.ServerOption: параметр сервера с учетными данными TLS для NewServer.
+//   - error: ошибка чтения или разбора файлов.
+func Credentials(certFile, keyFile string) (grpc.ServerOption, error) {
+	creds, err := credentials.NewServerTLSFromFile(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load gRPC TLS credentials: %w", err)
+	}
+	return grpc.Creds(creds), nil
+}
+
+// login возвращает логин пользователя, установленный перехватчиком аутентификации.
+func login(ctx context.Context) string {
+	user, _ := ctx.Value(auth.LoginKey).(string)
+	return user
+}
+
+// clientRequest возвращает HTTP-запрос с адресом клиента и заголовком User-Agent вызова gRPC:
+// операции UseCase берут из запроса IP-адрес для ограничения попыток входа и описание клиента для сеанса.
+func clientRequest(ctx context.Context) *http.Request {
+	r := &http.Request{Header: make(http.Header)}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		r.RemoteAddr = p.Addr.String()
+	}
+	if ua := metadata.ValueFromIncomingContext(ctx, "user-agent"); len(ua) > 0 {
+		r.Header.Set("User-Agent", ua[0])
+	}
+	return r.WithContext(ctx)
+}
+
+// issueToken начинает новый сеанс пользователя без refresh-токена и выдает в нем токен доступа с текущей ролью:
+// клиент gRPC входит заново, когда истекает токен доступа.
+func (s *Server) issueToken(ctx context.Context, login string) (*pb.TokenResponse, error) {
+	role, err := s.uc.DoGetRole(ctx, login)
+	if err != nil {
+		return nil, err
+	}
+	session, err := s.uc.DoStartSession(ctx, login, clientRequest(ctx))
+	if err != nil {
+		return nil, err
+	}
+	token, err := auth.NewAccessToken(s.ctx, login, role, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.TokenResponse{
+		AccessToken: token,
+		TokenType:   auth.BearerScheme,
+		ExpiresIn:   int64(config.Cfg.AccessTokenTTL.Seconds()),
+	}, nil
+}
@@ -0,0 +1,337 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/nextlag/gomart/internal/config"
+	"github.com/nextlag/gomart/internal/controllers/mocks"
+	"github.com/nextlag/gomart/internal/entity"
+	"github.com/nextlag/gomart/internal/mw/auth"
+	"github.com/nextlag/gomart/internal/usecase"
+	"github.com/nextlag/gomart/pkg/logger/l"
+	"github.com/nextlag/gomart/pkg/pb"
+)
+
+// server запускает сервер gRPC API с моком UseCase на соединении в памяти и возвращает клиента к нему.
+func server(t *testing.T) (context.Context, pb.GophermartClient, *mocks.MockUseCase) {
+	t.Helper()
+	ctx := l.ContextWithLogger(context.Background(), l.LoggerNew(config.Cfg.ProjectRoot))
+	// Токены доступа, выданные с нулевым временем жизни, истекают сразу
+	ttl := config.Cfg.AccessTokenTTL
+	config.Cfg.AccessTokenTTL = time.Minute
+	t.Cleanup(func() { config.Cfg.AccessTokenTTL = ttl })
+
+	var cfg config.HTTPServer
+	mockCtl := gomock.NewController(t)
+	repo := mocks.NewMockUseCase(mockCtl)
+	uc := usecase.New(usecase.NewMockRepository(mockCtl), cfg)
+	repo.EXPECT().Do().Return(uc).AnyTimes()
+
+	listener := bufconn.Listen(1 << 20)
+	srv := New(ctx, repo).NewServer()
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return ctx, pb.NewGophermartClient(conn), repo
+}
+
+func TestGRPCServer(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   bool
+		revoked bool
+		prepare func(repo *mocks.MockUseCase)
+		call    func(ctx context.Context, client pb.GophermartClient) error
+		code    codes.Code
+		retry   bool
+	}{
+		{
+			name: "Login",
+			prepare: func(repo *mocks.MockUseCase) {
+				repo.EXPECT().DoAuth(gomock.Any(), "test", "password", "", gomock.Any()).Return(nil)
+				repo.EXPECT().DoGetRole(gomock.Any(), "test").Return(entity.RoleUser, nil)
+				repo.EXPECT().DoStartSession(gomock.Any(), "test", gomock.Any()).Return(entity.Session{ID: "sid"}, nil)
+			},
+			call: func(ctx context.Context, client pb.GophermartClient) error {
+				resp, err := client.Login(ctx, &pb.LoginRequest{Login: "test", Password: "password"})
+				if err == nil {
+					assert.NotEmpty(t, resp.GetAccessToken())
+					assert.Equal(t, auth.BearerScheme, resp.GetTokenType())
+				}
+				return err
+			},
+			code: codes.OK,
+		},
+		{
+			name: "Login throttled",
+			prepare: func(repo *mocks.MockUseCase) {
+				repo.EXPECT().DoAuth(gomock.Any(), "test", "password", "", gomock.Any()).
+					Return(&usecase.ThrottleError{RetryAfter: time.Minute})
+			},
+			call: func(ctx context.Context, client pb.GophermartClient) error {
+				_, err := client.Login(ctx, &pb.LoginRequest{Login: "test", Password: "password"})
+				return err
+			},
+			code:  codes.ResourceExhausted,
+			retry: true,
+		},
+		{
+			name: "Register duplicate login",
+			prepare: func(repo *mocks.MockUseCase) {
+				repo.EXPECT().DoRegister(gomock.Any(), "test", "password", gomock.Any()).Return(&pq.Error{Code: "23505"})
+			},
+			call: func(ctx context.Context, client pb.GophermartClient) error {
+				_, err := client.Register(ctx, &pb.RegisterRequest{Login: "test", Password: "password"})
+				return err
+			},
+			code: codes.AlreadyExists,
+		},
+		{
+			name: "No access token",
+			call: func(ctx context.Context, client pb.GophermartClient) error {
+				_, err := client.GetBalance(ctx, &pb.GetBalanceRequest{})
+				return err
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name:    "Revoked access token",
+			token:   true,
+			revoked: true,
+			call: func(ctx context.Context, client pb.GophermartClient) error {
+				_, err := client.GetBalance(ctx, &pb.GetBalanceRequest{})
+				return err
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name:  "Balance",
+			token: true,
+			prepare: func(repo *mocks.MockUseCase) {
+				repo.EXPECT().DoGetBalance(gomock.Any(), "test").Return(float32(500), float32(42), nil)
+			},
+			call: func(ctx context.Context, client pb.GophermartClient) error {
+				resp, err := client.GetBalance(ctx, &pb.GetBalanceRequest{})
+				if err == nil {
+					assert.Equal(t, float32(500), resp.GetCurrent())
+					assert.Equal(t, float32(42), resp.GetWithdrawn())
+				}
+				return err
+			},
+			code: codes.OK,
+		},
+		{
+			name:  "Order uploaded by another user",
+			token: true,
+			prepare: func(repo *mocks.MockUseCase) {
+				repo.EXPECT().DoInsertOrder(gomock.Any(), "test", "12345678903").Return(usecase.ErrAnotherUser)
+			},
+			call: func(ctx context.Context, client pb.GophermartClient) error {
+				_, err := client.SubmitOrder(ctx, &pb.SubmitOrderRequest{Number: "12345678903"})
+				return err
+			},
+			code: codes.AlreadyExists,
+		},
+		{
+			name:  "Order already uploaded",
+			token: true,
+			prepare: func(repo *mocks.MockUseCase) {
+				repo.EXPECT().DoInsertOrder(gomock.Any(), "test", "12345678903").Return(usecase.ErrThisUser)
+			},
+			call: func(ctx context.Context, client pb.GophermartClient) error {
+				resp, err := client.SubmitOrder(ctx, &pb.SubmitOrderRequest{Number: "12345678903"})
+				if err == nil {
+					assert.False(t, resp.GetAccepted())
+				}
+				return err
+			},
+			code: codes.OK,
+		},
+		{
+			name:  "Withdraw without funds",
+			token: true,
+			prepare: func(repo *mocks.MockUseCase) {
+				repo.EXPECT().DoDebit(gomock.Any(), "test", "2377225624", float32(751), "", gomock.Any()).Return(usecase.ErrNoBalance)
+			},
+			call: func(ctx context.Context, client pb.GophermartClient) error {
+				_, err := client.Withdraw(ctx, &pb.WithdrawRequest{Order: "2377225624", Sum: 751})
+				return err
+			},
+			code: codes.FailedPrecondition,
+		},
+		{
+			name:  "Invalid page",
+			token: true,
+			call: func(ctx context.Context, client pb.GophermartClient) error {
+				_, err := client.ListOrders(ctx, &pb.ListOrdersRequest{Page: &pb.PageRequest{Limit: -1}})
+				return err
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name:  "Withdrawals page",
+			token: true,
+			prepare: func(repo *mocks.MockUseCase) {
+				repo.EXPECT().DoGetWithdrawalsPage(gomock.Any(), "test", entity.HistoryFilter{Limit: 1, Desc: true}).
+					Return([]entity.Withdrawal{{Order: "2377225624", Sum: 500, ProcessedAt: time.Now()}}, "next", nil)
+			},
+			call: func(ctx context.Context, client pb.GophermartClient) error {
+				resp, err := client.ListWithdrawals(ctx, &pb.ListWithdrawalsRequest{Page: &pb.PageRequest{Limit: 1, Desc: true}})
+				if err == nil {
+					require.Len(t, resp.GetWithdrawals(), 1)
+					assert.Equal(t, "2377225624", resp.GetWithdrawals()[0].GetOrder())
+					assert.Equal(t, "next", resp.GetNextCursor())
+				}
+				return err
+			},
+			code: codes.OK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, client, repo := server(t)
+			if tt.prepare != nil {
+				tt.prepare(repo)
+			}
+
+			callCtx := context.Background()
+			if tt.token {
+				token, err := auth.NewAccessToken(ctx, "test", entity.RoleUser, "sid")
+				require.NoError(t, err)
+				repo.EXPECT().DoIsAccessTokenRevoked(gomock.Any(), gomock.Any(), "test", "sid", gomock.Any()).Return(tt.revoked, nil)
+				callCtx = metadata.AppendToOutgoingContext(callCtx, authorizationMetadata, auth.BearerScheme+" "+token)
+			}
+
+			err := tt.call(callCtx, client)
+			st := status.Convert(err)
+			assert.Equal(t, tt.code, st.Code(), "Код статуса не совпадает с ожидаемым: %v", err)
+			if tt.retry {
+				require.Len(t, st.Details(), 1)
+				assert.IsType(t, &errdetails.RetryInfo{}, st.Details()[0])
+			}
+		})
+	}
+}
+
+// tlsFiles записывает во временный каталог самоподписанный сертификат для localhost и его ключ
+// и возвращает пути к файлам и пул с этим сертификатом для клиента.
+func tlsFiles(t *testing.T) (string, string, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return certFile, keyFile, pool
+}
+
+func TestCredentials(t *testing.T) {
+	certFile, keyFile, pool := tlsFiles(t)
+
+	_, err := Credentials(certFile, filepath.Join(t.TempDir(), "missing.pem"))
+	require.Error(t, err, "Сервер запущен без ключа TLS")
+	_, err = Credentials(keyFile, keyFile)
+	require.Error(t, err, "Ключ принят вместо сертификата")
+
+	creds, err := Credentials(certFile, keyFile)
+	require.NoError(t, err)
+
+	ctx := l.ContextWithLogger(context.Background(), l.LoggerNew(config.Cfg.ProjectRoot))
+	mockCtl := gomock.NewController(t)
+	repo := mocks.NewMockUseCase(mockCtl)
+	repo.EXPECT().Do().Return(usecase.New(usecase.NewMockRepository(mockCtl), config.HTTPServer{})).AnyTimes()
+	repo.EXPECT().DoAuth(gomock.Any(), "test", "password", "", gomock.Any()).Return(usecase.ErrUnauthorized).Times(1)
+
+	listener := bufconn.Listen(1 << 20)
+	srv := New(ctx, repo).NewServer(creds)
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	tests := []struct {
+		name  string
+		creds credentials.TransportCredentials
+		code  codes.Code
+	}{
+		{
+			name:  "TLS client",
+			creds: credentials.NewTLS(&tls.Config{RootCAs: pool, ServerName: "localhost"}),
+			code:  codes.Unauthenticated,
+		},
+		{
+			name:  "Plaintext client",
+			creds: insecure.NewCredentials(),
+			code:  codes.Unavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := grpc.NewClient("passthrough:///bufconn",
+				grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+					return listener.DialContext(ctx)
+				}),
+				grpc.WithTransportCredentials(tt.creds),
+			)
+			require.NoError(t, err)
+			defer conn.Close()
+
+			callCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_, err = pb.NewGophermartClient(conn).Login(callCtx, &pb.LoginRequest{Login: "test", Password: "password"})
+			assert.Equal(t, tt.code, status.Code(err), "Код статуса не совпадает с ожидаемым: %v", err)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"slices"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/nextlag/gomart/internal/mw/auth"
+	"github.com/nextlag/gomart/internal/usecase"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+// authorizationMetadata - ключ метаданных с токеном доступа
+const authorizationMetadata = "authorization"
+
+// authentication возвращает перехватчик, аутентифицирующий вызовы токеном доступа из метаданных authorization.
+//
+// Токен проверяется так же, как заголовок Authorization в HTTP API: подпись, срок действия и отзыв.
+// Если токен отсутствует, некорректен, истек или отозван, вызов завершается со статусом Unauthenticated.
+// Если проверить отзыв токена не удалось, вызов завершается со статусом Internal.
+// После успешной аутентификации логин пользователя и клеймы токена устанавливаются в контекст вызова
+// по ключам auth.LoginKey и auth.ClaimsKey.
+//
+// Параметры:
+//   - ctx: context.Context - контекст с логгером.
+//   - store: auth.TokenStore - хранилище отозванных токенов.
+//   - er: *usecase.ErrAll - объект, содержащий ошибки, используемые в UseCase.
+//   - public: ...string - полные имена методов, не требующих аутентификации.
+//
+// Возвращаемые значения:
+//   - grpc.UnaryServerInterceptor: перехватчик аутентификации.
+func authentication(ctx context.Context, store auth.TokenStore, er *usecase.ErrAll, public ...string) grpc.UnaryServerInterceptor {
+	return func(c context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if slices.Contains(public, info.FullMethod) {
+			return handler(c, req)
+		}
+		log := l.L(ctx)
+
+		var value string
+		if v := metadata.ValueFromIncomingContext(c, authorizationMetadata); len(v) > 0 {
+			value = v[0]
+		}
+		claims, err := auth.ParseBearer(ctx, value)
+		if err == nil && claims.ID != "" {
+			// Проверяем, не был ли токен отозван
+			var revoked bool
+			if revoked, err = store.DoIsAccessTokenRevoked(c, claims.ID, claims.Login, claims.SessionID, claims.IssuedAt.Time); err == nil && revoked {
+				err = er.ErrTokenRevoked
+			}
+		}
+
+		switch {
+		case errors.Is(err, er.ErrAuth):
+			return nil, status.Error(codes.Unauthenticated, er.ErrAuth.Error())
+		case errors.Is(err, er.ErrToken):
+			return nil, status.Error(codes.Unauthenticated, er.ErrToken.Error())
+		case errors.Is(err, er.ErrTokenRevoked):
+			log.Error("revoked token", "login", claims.Login, "method", info.FullMethod)
+			return nil, status.Error(codes.Unauthenticated, er.ErrTokenRevoked.Error())
+		case err != nil:
+			log.Error("authentication error", "method", info.FullMethod, l.ErrAttr(err))
+			return nil, status.Error(codes.Internal, er.ErrInternalServer.Error())
+		}
+
+		c = context.WithValue(c, auth.LoginKey, claims.Login)
+		c = context.WithValue(c, auth.ClaimsKey, claims)
+		return handler(c, req)
+	}
+}
+
+// logger возвращает перехватчик, логирующий метод, адрес клиента, код статуса и продолжительность вызова.
+// Вызовы, завершившиеся со статусами Internal и Unknown, логируются как ошибки.
+func logger(ctx context.Context) grpc.UnaryServerInterceptor {
+	return func(c context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		log := l.L(ctx)
+		t1 := time.Now()
+		resp, err := handler(c, req)
+
+		var remote string
+		if p, ok := peer.FromContext(c); ok && p.Addr != nil {
+			remote = p.Addr.String()
+		}
+		code := status.Code(err)
+		attrs := []any{"method", info.FullMethod, "remote_addr", remote, "code", code.String(), "duration", time.Since(t1).String()}
+		if code == codes.Internal || code == codes.Unknown {
+			log.Error("call completed with error", append(attrs, l.ErrAttr(err))...)
+		} else {
+			log.Info("call", attrs...)
+		}
+		return resp, err
+	}
+}
+
+// recoverer возвращает перехватчик, завершающий вызов со статусом Internal при панике в обработчике.
+func recoverer(ctx context.Context) grpc.UnaryServerInterceptor {
+	return func(c context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if rvr := recover(); rvr != nil {
+				l.L(ctx).Error("panic in gRPC handler", "method", info.FullMethod, "panic", rvr, "stack", string(debug.Stack()))
+				err = status.Error(codes.Internal, usecase.ErrInternalServer.Error())
+			}
+		}()
+		return handler(c, req)
+	}
+}
+
+// retryError возвращает статус ResourceExhausted с сообщением msg и временем, через которое можно повторить вызов,
+// в деталях google.rpc.RetryInfo.
+func retryError(msg string, retryAfter time.Duration) error {
+	st, err := status.New(codes.ResourceExhausted, msg).WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)})
+	if err != nil {
+		return status.Error(codes.ResourceExhausted, msg)
+	}
+	return st.Err()
+}
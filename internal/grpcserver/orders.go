@@ -0,0 +1,113 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/nextlag/gomart/internal/entity"
+	"github.com/nextlag/gomart/internal/usecase"
+	"github.com/nextlag/gomart/pkg/logger/l"
+	"github.com/nextlag/gomart/pkg/pb"
+)
+
+// SubmitOrder загружает номер заказа пользователя для расчета начислений.
+//
+// Возвращает accepted, равный true, если заказ принят в обработку, и false, если он уже был загружен
+// этим пользователем. Если номер заказа не указан или не проходит проверку алгоритмом Луна, возвращает статус
+// InvalidArgument. Если заказ загружен другим пользователем, возвращает статус AlreadyExists.
+// При любых других ошибках возвращает статус Internal.
+func (s *Server) SubmitOrder(ctx context.Context, req *pb.SubmitOrderRequest) (*pb.SubmitOrderResponse, error) {
+	log := l.L(s.ctx)
+	// Получаем объект ошибки из UseCase
+	er := s.uc.Do().Err()
+	user := login(ctx)
+
+	if req.GetNumber() == "" {
+		return nil, status.Error(codes.InvalidArgument, er.ErrRequestFormat.Error())
+	}
+
+	err := s.uc.DoInsertOrder(ctx, user, req.GetNumber())
+	switch {
+	case errors.Is(err, er.ErrOrderFormat):
+		log.Error("insert Order", l.ErrAttr(err))
+		return nil, status.Error(codes.InvalidArgument, er.ErrOrderFormat.Error())
+	case errors.Is(err, er.ErrAnotherUser):
+		log.Error("insert Order", l.ErrAttr(err))
+		return nil, status.Error(codes.AlreadyExists, er.ErrAnotherUser.Error())
+	case errors.Is(err, er.ErrThisUser):
+		return &pb.SubmitOrderResponse{Accepted: false}, nil
+	case err != nil:
+		log.Error("insert Order", l.ErrAttr(err))
+		return nil, status.Error(codes.Internal, er.ErrInternalServer.Error())
+	}
+
+	log.Info("order received", "user", user, "order", req.GetNumber())
+	return &pb.SubmitOrderResponse{Accepted: true}, nil
+}
+
+// ListOrders возвращает страницу заказов пользователя и курсор следующей страницы.
+//
+// Параметры страницы и фильтр по статусам имеют тот же смысл, что и параметры запроса GET /api/user/orders.
+// Если параметры некорректны, возвращает статус InvalidArgument. При любых других ошибках возвращает статус Internal.
+func (s *Server) ListOrders(ctx context.Context, req *pb.ListOrdersRequest) (*pb.ListOrdersResponse, error) {
+	log := l.L(s.ctx)
+	// Получаем объект ошибки из UseCase
+	er := s.uc.Do().Err()
+
+	f, err := pageFilter(req.GetPage())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, er.ErrRequestFormat.Error())
+	}
+	for _, st := range req.GetStatus() {
+		f.Status = append(f.Status, strings.ToUpper(st))
+	}
+
+	orders, next, err := s.uc.DoGetOrdersPage(ctx, login(ctx), f)
+	switch {
+	case errors.Is(err, er.ErrRequestFormat):
+		return nil, status.Error(codes.InvalidArgument, er.ErrRequestFormat.Error())
+	case err != nil:
+		log.Error("list orders", l.ErrAttr(err))
+		return nil, status.Error(codes.Internal, er.ErrInternalServer.Error())
+	}
+
+	resp := &pb.ListOrdersResponse{Orders: make([]*pb.Order, 0, len(orders)), NextCursor: next}
+	for _, o := range orders {
+		resp.Orders = append(resp.Orders, &pb.Order{
+			Number:     o.Order,
+			Status:     o.Status,
+			Accrual:    o.Accrual,
+			UploadedAt: timestamppb.New(o.UploadedAt),
+		})
+	}
+	return resp, nil
+}
+
+// pageFilter возвращает параметры страницы истории; отсутствующие параметры соответствуют первой странице
+// размера по умолчанию за все время.
+//
+// Возвращаемые значения:
+//   - entity.HistoryFilter: параметры страницы.
+//   - error: usecase.ErrRequestFormat, если размер страницы отрицательный или конец периода раньше начала.
+func pageFilter(p *pb.PageRequest) (entity.HistoryFilter, error) {
+	f := entity.HistoryFilter{
+		Limit:  int(p.GetLimit()),
+		Cursor: p.GetCursor(),
+		Desc:   p.GetDesc(),
+	}
+	if p.GetFrom() != nil {
+		f.From = p.GetFrom().AsTime()
+	}
+	if p.GetTo() != nil {
+		f.To = p.GetTo().AsTime()
+	}
+	if f.Limit < 0 || !f.To.IsZero() && f.To.Before(f.From) {
+		return f, usecase.ErrRequestFormat
+	}
+	return f, nil
+}
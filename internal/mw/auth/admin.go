@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/nextlag/gomart/internal/config"
+	"github.com/nextlag/gomart/internal/entity"
+	"github.com/nextlag/gomart/internal/usecase"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+const (
+	// AdminHeader - заголовок с токеном доступа к маршрутам администратора
+	AdminHeader = "X-Admin-Token"
+	// AdminLogin - логин, от имени которого выполняются запросы с токеном администратора
+	AdminLogin = "admin"
+)
+
+// AdminStore - хранилище сведений о назначенных администраторах.
+type AdminStore interface {
+	DoHasAdmin(ctx context.Context) (bool, error)
+}
+
+// GetAdminToken returns an Authenticator for requests carrying the static administrator token from config.Cfg.AdminToken.
+//
+// Токен предназначен только для первоначальной настройки - назначения роли первому администратору - и принимается,
+// только если задан явно и в системе еще нет пользователя с ролью entity.RoleAdmin. Токен сравнивается
+// за постоянное время. Запросу с верным токеном назначаются логин AdminLogin и роль entity.RoleAdmin.
+// Если заголовок отсутствует, возвращается usecase.ErrAuth; если токен не настроен, не совпадает
+// или администратор уже назначен - usecase.ErrToken.
+func GetAdminToken(store AdminStore) Authenticator {
+	return func(ctx context.Context, r *http.Request) (*Claims, error) {
+		token := r.Header.Get(AdminHeader)
+		if token == "" {
+			return nil, usecase.ErrAuth
+		}
+		expected := config.Cfg.AdminToken
+		if expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			return nil, usecase.ErrToken
+		}
+
+		exists, err := store.DoHasAdmin(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			l.L(ctx).Warn("admin token rejected: administrator already assigned")
+			return nil, usecase.ErrToken
+		}
+		return &Claims{Login: AdminLogin, Role: entity.RoleAdmin}, nil
+	}
+}
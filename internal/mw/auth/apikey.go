@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/nextlag/gomart/internal/entity"
+	"github.com/nextlag/gomart/internal/problem"
+	"github.com/nextlag/gomart/internal/usecase"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+const (
+	// APIKeyHeader - заголовок с API-ключом сервисной учетной записи
+	APIKeyHeader = "X-API-Key"
+	// OnBehalfOfHeader - заголовок с логином пользователя, от имени которого сервис выполняет запрос
+	OnBehalfOfHeader = "X-On-Behalf-Of"
+)
+
+// APIKeyStore - хранилище API-ключей и счетчиков их запросов.
+type APIKeyStore interface {
+	DoAuthenticateAPIKey(ctx context.Context, key, login string) (entity.APIKey, error)
+	DoCountAPIKeyRequest(ctx context.Context, id string, limit int) (time.Duration, error)
+}
+
+// RateLimitError - отказ в запросе из-за превышения ограничения запросов API-ключа.
+// Оборачивает usecase.ErrRateLimit и содержит время, через которое можно повторить запрос.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return usecase.ErrRateLimit.Error()
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return usecase.ErrRateLimit
+}
+
+// GetAPIKey возвращает Authenticator для запросов сервисов с API-ключом из заголовка X-API-Key.
+//
+// Сервис выполняет запрос от имени пользователя, логин которого передается в заголовке X-On-Behalf-Of.
+// Запросу назначаются логин этого пользователя, роль entity.RoleService, идентификатор и области действия ключа;
+// доступ к маршрутам ограничивает RequireScope. Количество запросов ключа в минуту ограничено его RateLimit;
+// счетчики хранятся в базе данных, поэтому ограничение общее для всех экземпляров сервиса.
+// Если заголовок X-API-Key отсутствует, возвращается usecase.ErrAuth; если отсутствует X-On-Behalf-Of -
+// usecase.ErrOnBehalfOf; при превышении ограничения - *RateLimitError.
+//
+// Параметры:
+//   - store: APIKeyStore - хранилище API-ключей.
+//
+// Возвращаемые значения:
+//   - Authenticator: способ аутентификации API-ключом.
+func GetAPIKey(store APIKeyStore) Authenticator {
+	return func(ctx context.Context, r *http.Request) (*Claims, error) {
+		key := r.Header.Get(APIKeyHeader)
+		if key == "" {
+			return nil, usecase.ErrAuth
+		}
+		login := r.Header.Get(OnBehalfOfHeader)
+		if login == "" {
+			return nil, usecase.ErrOnBehalfOf
+		}
+
+		apiKey, err := store.DoAuthenticateAPIKey(r.Context(), key, login)
+		if err != nil {
+			return nil, err
+		}
+		retry, err := store.DoCountAPIKeyRequest(r.Context(), apiKey.ID, apiKey.RateLimit)
+		if err != nil {
+			return nil, err
+		}
+		if retry > 0 {
+			return nil, &RateLimitError{RetryAfter: retry}
+		}
+		l.L(ctx).Debug("API key authenticated", "key", apiKey.ID, "name", apiKey.Name, "login", login)
+		return &Claims{Login: login, Role: entity.RoleService, KeyID: apiKey.ID, Scopes: apiKey.Scopes}, nil
+	}
+}
+
+// RequireScope возвращает middleware, пропускающее запросы с API-ключом только при наличии у ключа области действия scope.
+//
+// Middleware подключается после Authentication. Запросы пользователей, аутентифицированных токеном доступа,
+// пропускаются без проверки. Если клеймы отсутствуют, возвращает ошибку Unauthorized (401),
+// если у API-ключа нет нужной области действия - Forbidden (403).
+//
+// Параметры:
+//   - ctx: context.Context - контекст с логгером.
+//   - er: *usecase.ErrAll - объект, содержащий ошибки, используемые в UseCase.
+//   - scope: string - область действия, необходимая для маршрута.
+//
+// Возвращаемые значения:
+//   - func(http.Handler) http.Handler: middleware проверки области действия.
+func RequireScope(ctx context.Context, er *usecase.ErrAll, scope string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := r.Context().Value(ClaimsKey).(*Claims)
+			if !ok {
+				problem.Write(w, r, er.ErrUnAuthUser)
+				return
+			}
+			if claims.KeyID != "" && !slices.Contains(claims.Scopes, scope) {
+				l.L(ctx).Error("API key scope denied", "key", claims.KeyID, "scope", scope, "path", r.URL.Path)
+				problem.Write(w, r, er.ErrForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -3,37 +3,73 @@ package auth
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"net/http"
+	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 
 	"github.com/nextlag/gomart/internal/config"
+	"github.com/nextlag/gomart/internal/entity"
 	"github.com/nextlag/gomart/internal/usecase"
 	"github.com/nextlag/gomart/pkg/logger/l"
 )
 
 const (
 	Cookie = "ErrAuth"
+	// RefreshCookie - кука с refresh-токеном
+	RefreshCookie = "RefreshToken"
+	// refreshCookiePath - путь, для которого браузер отправляет refresh-токен
+	refreshCookiePath = "/api/user"
 )
 
 // Claims introduces a custom claims framework for JWT.
 type Claims struct {
 	jwt.RegisteredClaims
-	Login string `json:"login"`
+	Login     string   `json:"login"`
+	Role      string   `json:"role,omitempty"`
+	SessionID string   `json:"sid,omitempty"` // Идентификатор сеанса, в котором выдан токен
+	KeyID     string   `json:"-"`             // Идентификатор API-ключа, если запрос выполняется сервисом от имени пользователя
+	Scopes    []string `json:"-"`             // Области действия API-ключа
+	Cookie    bool     `json:"-"`             // Токен получен из куки; изменяющие запросы проверяются middleware CSRF
 }
 
-// buildJWTString generates a JWT token with the provided login and signs it using the configured secret key.
-func buildJWTString(ctx context.Context, login string) (string, error) {
+// UserRole возвращает роль из токена; токены, выданные до появления ролей, соответствуют роли entity.RoleUser.
+func (c *Claims) UserRole() string {
+	if c.Role == "" {
+		return entity.RoleUser
+	}
+	return c.Role
+}
+
+// buildJWTString generates a JWT token with the provided login, role and session ID and signs it using the active key of the keyring.
+// Токен содержит идентификатор (jti), время выпуска (iat) и время истечения (exp) через config.Cfg.AccessTokenTTL.
+func buildJWTString(ctx context.Context, login, role, sessionID string) (string, error) {
 	log := l.L(ctx)
-	// Создает новый токен JWT с пользовательскими клеймами и подписывает его с использованием алгоритма HMAC SHA-256.
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
-		RegisteredClaims: jwt.RegisteredClaims{},
-		Login:            login,
+	jti, err := newJTI()
+	if err != nil {
+		log.Error("token id generation error", l.ErrAttr(err))
+		return "", err
+	}
+	now := time.Now()
+	key := currentKeyring().active
+	// Создает новый токен JWT с пользовательскими клеймами и подписывает его активным ключом из набора ключей.
+	jwtToken := jwt.NewWithClaims(key.Method, Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(config.Cfg.AccessTokenTTL)),
+		},
+		Login:     login,
+		Role:      role,
+		SessionID: sessionID,
 	})
-	log.Debug("buildJWTString", "config.Cfg.SecretToken", config.Cfg.SecretToken)
+	jwtToken.Header["kid"] = key.ID
+	log.Debug("buildJWTString", "kid", key.ID)
 
-	// Подписывает токен с использованием секретного ключа и получает строку токена.
-	tokenString, err := jwtToken.SignedString([]byte(config.Cfg.SecretToken))
+	// Подписывает токен и получает строку токена.
+	tokenString, err := jwtToken.SignedString(key.Sign)
 	if err != nil {
 		log.Error("token signing error", l.ErrAttr(err))
 		return "", err
@@ -42,71 +78,92 @@ func buildJWTString(ctx context.Context, login string) (string, error) {
 	return tokenString, nil
 }
 
-// SetAuth creates a new cookie for the provided login and sets it in the HTTP response.
-func SetAuth(ctx context.Context, user string, w http.ResponseWriter) (string, error) {
+// newJTI генерирует случайный идентификатор токена.
+func newJTI() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// SetAuth creates a new cookie for the provided login, role and session ID and sets it in the HTTP response.
+// Кука недоступна из JavaScript, живет столько же, сколько токен доступа, и получает атрибуты из config.Cfg.Cookies;
+// вместе с ней выдается новый CSRF-токен.
+func SetAuth(ctx context.Context, user, role, sessionID string, w http.ResponseWriter) (string, error) {
 	log := l.L(ctx)
 	// Сгенерировать токен JWT для логина.
-	jwtToken, err := buildJWTString(ctx, user)
+	jwtToken, err := buildJWTString(ctx, user, role, sessionID)
 	if err != nil {
 		log.Error("cookie creation error", l.ErrAttr(err))
 		return "", err
 	}
 
 	// Создает новую HTTP-куку с токеном JWT и устанавливает ее в ответе.
-	cookie := http.Cookie{
-		Name:  Cookie,
-		Value: jwtToken,
-		Path:  "/",
+	http.SetCookie(w, NewCookie(Cookie, jwtToken, "/", int(config.Cfg.AccessTokenTTL.Seconds()), true))
+	if config.Cfg.Cookies.CSRF {
+		if err = SetCSRF(w); err != nil {
+			log.Error("CSRF token generation error", l.ErrAttr(err))
+			return "", err
+		}
 	}
-	http.SetCookie(w, &cookie)
 	log.Debug("SetAuth", "received token", jwtToken)
 
 	return jwtToken, nil
 }
 
-// getLogin извлекает логин пользователя из предоставленного токена JWT.
-// A function used to get a user's login using a JWT. It accepts a JWT and returns a login and error.
-func getLogin(ctx context.Context, tokenString string) (string, error) {
+// SetRefresh sets the refresh token cookie in the HTTP response.
+// Кука недоступна из JavaScript и отправляется только на маршруты /api/user.
+func SetRefresh(w http.ResponseWriter, token string) {
+	http.SetCookie(w, NewCookie(RefreshCookie, token, refreshCookiePath, int(config.Cfg.RefreshTokenTTL.Seconds()), true))
+}
+
+// ClearAuth removes the access token, refresh token and CSRF token cookies.
+func ClearAuth(w http.ResponseWriter) {
+	http.SetCookie(w, NewCookie(Cookie, "", "/", -1, true))
+	http.SetCookie(w, NewCookie(RefreshCookie, "", refreshCookiePath, -1, true))
+	http.SetCookie(w, NewCookie(CSRFCookie, "", "/", -1, false))
+}
+
+// getClaims извлекает клеймы пользователя из предоставленного токена JWT.
+// Подпись проверяется ключом из набора ключей по заголовку kid, срок действия токена проверяется; токены без идентификатора (jti),
+// времени выдачи (iat) и времени истечения (exp) не принимаются: по jti и iat проверяется отзыв токена.
+func getClaims(ctx context.Context, tokenString string) (*Claims, error) {
 	log := l.L(ctx)
-	log.Debug("getLogin", "received token", tokenString)
+	log.Debug("getClaims", "received token", tokenString)
 
 	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			log.Error("unexpected signing method", nil)
-			return nil, nil
-		}
-		return []byte(config.Cfg.SecretToken), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, claims, currentKeyring().verificationKey)
 	if err != nil {
 		log.Error("error parsing token", l.ErrAttr(err))
-		return "", usecase.ErrToken
+		return nil, usecase.ErrToken
 	}
-	if !token.Valid {
-		log.Error("token is not valid", nil)
-		return "", err
+	if !token.Valid || claims.ID == "" || claims.IssuedAt == nil || claims.ExpiresAt == nil {
+		log.Error("token is not valid")
+		return nil, usecase.ErrToken
 	}
-	log.Debug("getLogin", "login", claims.Login)
-	return claims.Login, nil
+	log.Debug("getClaims", "login", claims.Login, "jti", claims.ID)
+	return claims, nil
 }
 
-// GetCookie retrieves the user's login from the "ErrAuth" cookie.
-func GetCookie(ctx context.Context, r *http.Request) (string, error) {
+// GetCookie retrieves the user's claims from the "ErrAuth" cookie.
+func GetCookie(ctx context.Context, r *http.Request) (*Claims, error) {
 	log := l.L(ctx)
 	// Извлечь подписанную куку логина из запроса.
 	signedLogin, err := r.Cookie(Cookie)
 	if err != nil {
-		log.Error("error receiving cookie", "error GetCookie", nil)
-		return "", usecase.ErrAuth
+		log.Debug("error receiving cookie", "error GetCookie", err)
+		return nil, usecase.ErrAuth
 	}
 
-	// Извлекает логин из токена JWT в куке.
-	login, err := getLogin(ctx, signedLogin.Value)
+	// Извлекает клеймы из токена JWT в куке.
+	claims, err := getClaims(ctx, signedLogin.Value)
 	if err != nil {
 		log.Error("error reading cookie", l.ErrAttr(err))
-		return "", err
+		return nil, err
 	}
-	log.Debug("GetCookie", "login", login)
+	log.Debug("GetCookie", "login", claims.Login)
+	claims.Cookie = true
 
-	return login, nil
+	return claims, nil
 }
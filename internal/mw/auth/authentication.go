@@ -0,0 +1,128 @@
+// Package auth - middleware authentication
+package auth
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nextlag/gomart/internal/problem"
+	"github.com/nextlag/gomart/internal/usecase"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+// authContextKey - ключ контекста аутентификации
+type authContextKey string
+
+const (
+	// LoginKey - ключ для контекста с логином пользователя
+	LoginKey authContextKey = "login"
+	// ClaimsKey - ключ для контекста с клеймами токена доступа
+	ClaimsKey authContextKey = "claims"
+)
+
+// TokenStore - хранилище отозванных токенов доступа.
+type TokenStore interface {
+	DoIsAccessTokenRevoked(ctx context.Context, jti, login, sessionID string, issuedAt time.Time) (bool, error)
+}
+
+// Authenticator извлекает из запроса клеймы пользователя одним способом (кука, заголовок Authorization и т.д.).
+// Если запрос не содержит учетных данных этого вида, возвращается usecase.ErrAuth, и цепочка переходит
+// к следующему Authenticator.
+type Authenticator func(ctx context.Context, r *http.Request) (*Claims, error)
+
+// Authentication возвращает middleware для аутентификации пользователя цепочкой Authenticator.
+//
+// Authenticator вызываются по порядку до первого, нашедшего в запросе учетные данные.
+// Если учетные данные не найдены ни одним из них, возвращает ошибку Unauthorized (401).
+// Если найденный токен некорректен, истек или отозван (например, после выхода пользователя или завершения сеанса),
+// возвращает ошибку Unauthorized (401) без перехода к следующим Authenticator.
+// Отзыв проверяется только для токенов с идентификатором (jti); клеймы без него выдают GetAdminToken и GetAPIKey.
+// Для API-ключа возвращает ошибку Unauthorized (401), если ключ неизвестен или отозван, BadRequest (400) без заголовка
+// X-On-Behalf-Of, NotFound (404), если пользователь не найден, и TooManyRequests (429) с заголовком Retry-After
+// при превышении ограничения запросов.
+// Если аутентификация прошла успешно, устанавливает логин пользователя и клеймы токена в контекст запроса
+// и передает управление следующему обработчику.
+//
+// Параметры:
+//   - ctx: context.Context - контекст с логгером.
+//   - store: TokenStore - хранилище отозванных токенов.
+//   - er: *usecase.ErrAll - объект, содержащий ошибки, используемые в UseCase.
+//   - chain: ...Authenticator - способы аутентификации в порядке проверки.
+//
+// Возвращаемые значения:
+//   - func(http.Handler) http.Handler: middleware для аутентификации пользователя.
+func Authentication(ctx context.Context, store TokenStore, er *usecase.ErrAll, chain ...Authenticator) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			log := l.L(ctx)
+			// Получаем клеймы пользователя первым подходящим способом
+			claims, err := authenticate(ctx, r, chain)
+			if err == nil && claims.ID != "" {
+				// Проверяем, не был ли токен отозван
+				var revoked bool
+				if revoked, err = store.DoIsAccessTokenRevoked(r.Context(), claims.ID, claims.Login, claims.SessionID, claims.IssuedAt.Time); err == nil && revoked {
+					err = er.ErrTokenRevoked
+				}
+			}
+
+			var rateErr *RateLimitError
+			switch {
+			case errors.As(err, &rateErr):
+				// Если превышено ограничение запросов API-ключа, возвращаем ошибку TooManyRequests с заголовком Retry-After
+				log.Error("API key rate limit exceeded", "retry_after", rateErr.RetryAfter)
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(rateErr.RetryAfter.Seconds()))))
+				problem.Write(w, r, er.ErrRateLimit)
+			case errors.Is(err, er.ErrAPIKey):
+				// Если API-ключ неизвестен или отозван, возвращаем ошибку Unauthorized (401)
+				log.Error("invalid API key", l.ErrAttr(err))
+				problem.Write(w, r, er.ErrAPIKey)
+			case errors.Is(err, er.ErrOnBehalfOf):
+				problem.Write(w, r, er.ErrOnBehalfOf)
+			case errors.Is(err, er.ErrUserNotFound):
+				problem.Write(w, r, er.ErrUserNotFound)
+			case errors.Is(err, er.ErrToken):
+				// Если токен некорректен или истек, возвращаем ошибку Unauthorized (401)
+				problem.Write(w, r, er.ErrToken)
+			case errors.Is(err, er.ErrTokenRevoked):
+				// Если токен отозван, возвращаем ошибку Unauthorized (401)
+				log.Error("revoked token", "login", claims.Login)
+				problem.Write(w, r, er.ErrTokenRevoked)
+			case errors.Is(err, er.ErrAuth):
+				// Если запрос не содержит аутентификационных данных, логируем ошибку и возвращаем ошибку Unauthorized (401)
+				log.Error("error empty login", l.ErrAttr(err))
+				problem.Write(w, r, er.ErrAuth)
+			case err != nil:
+				// Если происходит любая другая ошибка, логируем ошибку и возвращаем ошибку Unauthorized (401)
+				log.Error("authentication error", l.ErrAttr(err))
+				problem.Write(w, r, er.ErrInternalServer)
+			default:
+				// Создаем новый контекст с установленным логином пользователя и клеймами токена
+				login := claims.Login
+				ctx := context.WithValue(r.Context(), LoginKey, login)
+				ctx = context.WithValue(ctx, ClaimsKey, claims)
+				// Обновляем запрос с новым контекстом
+				r = r.WithContext(ctx)
+				log.Debug("Authentication", "context", ctx.Value(LoginKey), "login", login)
+
+				// Передаем управление следующему обработчику
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// authenticate вызывает Authenticator по порядку и возвращает результат первого, нашедшего учетные данные.
+func authenticate(ctx context.Context, r *http.Request, chain []Authenticator) (*Claims, error) {
+	for _, authenticator := range chain {
+		claims, err := authenticator(ctx, r)
+		if errors.Is(err, usecase.ErrAuth) {
+			continue
+		}
+		return claims, err
+	}
+	return nil, usecase.ErrAuth
+}
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/nextlag/gomart/internal/usecase"
+)
+
+const (
+	// AuthorizationHeader - заголовок с токеном доступа
+	AuthorizationHeader = "Authorization"
+	// BearerScheme - схема аутентификации токеном доступа в заголовке Authorization
+	BearerScheme = "Bearer"
+)
+
+// GetBearer retrieves the user's claims from the "Authorization: Bearer <jwt>" header.
+// Если заголовок отсутствует или использует другую схему, возвращается usecase.ErrAuth.
+func GetBearer(ctx context.Context, r *http.Request) (*Claims, error) {
+	return ParseBearer(ctx, r.Header.Get(AuthorizationHeader))
+}
+
+// ParseBearer retrieves the user's claims from the "Bearer <jwt>" value of the Authorization header
+// или метаданных authorization запроса gRPC.
+// Если значение пустое или использует другую схему, возвращается usecase.ErrAuth.
+func ParseBearer(ctx context.Context, value string) (*Claims, error) {
+	scheme, token, ok := strings.Cut(value, " ")
+	if !ok || !strings.EqualFold(scheme, BearerScheme) {
+		return nil, usecase.ErrAuth
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, usecase.ErrToken
+	}
+	return getClaims(ctx, token)
+}
+
+// SetBearer sets the access token in the Authorization response header.
+func SetBearer(w http.ResponseWriter, token string) {
+	w.Header().Set(AuthorizationHeader, BearerScheme+" "+token)
+}
+
+// NewAccessToken issues an access token for the provided login, role and session ID without setting cookies.
+// Используется клиентами, которые передают токен только в заголовке Authorization или метаданных gRPC.
+func NewAccessToken(ctx context.Context, login, role, sessionID string) (string, error) {
+	return buildJWTString(ctx, login, role, sessionID)
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/nextlag/gomart/internal/config"
+	"github.com/nextlag/gomart/internal/problem"
+	"github.com/nextlag/gomart/internal/usecase"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+const (
+	// CSRFCookie - кука с CSRF-токеном; доступна JavaScript веб-интерфейса, чтобы он мог повторить токен в заголовке
+	CSRFCookie = "CSRFToken"
+	// CSRFHeader - заголовок, в котором изменяющие запросы повторяют CSRF-токен из куки
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// NewCookie возвращает куку с атрибутами безопасности из config.Cfg.Cookies и сроком действия maxAge секунд.
+// Отрицательный maxAge удаляет куку.
+func NewCookie(name, value, path string, maxAge int, httpOnly bool) *http.Cookie {
+	cfg := config.Cfg.Cookies
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   cfg.Domain,
+		MaxAge:   maxAge,
+		Secure:   cfg.Secure,
+		HttpOnly: httpOnly,
+		SameSite: http.SameSite(cfg.SameSite),
+	}
+	if maxAge > 0 {
+		// Expires нужен старым браузерам, которые не поддерживают Max-Age
+		cookie.Expires = time.Now().Add(time.Duration(maxAge) * time.Second)
+	}
+	return cookie
+}
+
+// SetCSRF устанавливает новый CSRF-токен в куку CSRFToken и в заголовок X-CSRF-Token ответа.
+// Кука живет столько же, сколько refresh-токен, и обновляется при каждой выдаче токена доступа в куке.
+func SetCSRF(w http.ResponseWriter) error {
+	token, err := newJTI()
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, NewCookie(CSRFCookie, token, "/", int(config.Cfg.RefreshTokenTTL.Seconds()), false))
+	w.Header().Set(CSRFHeader, token)
+	return nil
+}
+
+// CSRF возвращает middleware защиты от подделки межсайтовых запросов по схеме double-submit.
+//
+// Middleware подключается после Authentication и проверяет только изменяющие запросы (POST, PUT, PATCH, DELETE),
+// аутентифицированные кукой ErrAuth: такие запросы должны содержать в заголовке X-CSRF-Token значение куки CSRFToken.
+// Сторонний сайт не может прочитать куку и подставить ее значение в заголовок. Запросы с токеном в заголовке
+// Authorization, API-ключом или токеном администратора не проверяются: браузер не добавляет их автоматически.
+// Если токен отсутствует или не совпадает, возвращает ошибку Forbidden (403).
+// Проверка отключается параметром config.Cfg.Cookies.CSRF.
+//
+// Параметры:
+//   - ctx: context.Context - контекст с логгером.
+//   - er: *usecase.ErrAll - объект, содержащий ошибки, используемые в UseCase.
+//
+// Возвращаемые значения:
+//   - func(http.Handler) http.Handler: middleware защиты от CSRF.
+func CSRF(ctx context.Context, er *usecase.ErrAll) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, _ := r.Context().Value(ClaimsKey).(*Claims)
+			if !config.Cfg.Cookies.CSRF || claims == nil || !claims.Cookie || !isMutating(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cookie, err := r.Cookie(CSRFCookie)
+			header := r.Header.Get(CSRFHeader)
+			if err != nil || cookie.Value == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(header)) != 1 {
+				l.L(ctx).Error("CSRF token mismatch", "login", claims.Login, "path", r.URL.Path)
+				problem.Write(w, r, er.ErrCSRF)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// isMutating сообщает, изменяет ли запрос с указанным методом состояние сервера.
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
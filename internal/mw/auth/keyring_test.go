@@ -0,0 +1,288 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nextlag/gomart/internal/config"
+	"github.com/nextlag/gomart/internal/usecase"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+// edKeys создает пару ключей Ed25519 в формате PEM.
+func edKeys(t *testing.T) (private, public string) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err)
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}))
+}
+
+// keyringJSON формирует содержимое файла ключей.
+func keyringJSON(t *testing.T, active string, keys ...map[string]string) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]interface{}{"active": active, "keys": keys})
+	require.NoError(t, err)
+	return data
+}
+
+// useKeyring делает набор ключей текущим до конца теста.
+func useKeyring(t *testing.T, k *Keyring) {
+	t.Helper()
+	keyring.Store(k)
+	t.Cleanup(func() { keyring.Store(nil) })
+}
+
+// testContext возвращает контекст с логгером.
+func testContext() context.Context {
+	return l.ContextWithLogger(context.Background(), l.LoggerNew(config.Cfg.ProjectRoot))
+}
+
+func TestParseKeyring(t *testing.T) {
+	private, public := edKeys(t)
+	hmac := map[string]string{"kid": "old", "alg": "HS256", "secret": "secret"}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{
+			name: "HMAC and Ed25519 keys",
+			data: keyringJSON(t, "new", map[string]string{"kid": "old", "alg": "HS256", "secret": "secret", "retire_at": "2024-03-01T00:00:00Z"},
+				map[string]string{"kid": "new", "alg": "EdDSA", "private_key": private},
+				map[string]string{"kid": "verify", "alg": "EdDSA", "public_key": public}),
+		},
+		{name: "Invalid JSON", data: []byte("{"), wantErr: "unexpected end of JSON input"},
+		{name: "Key without kid", data: keyringJSON(t, "old", map[string]string{"alg": "HS256", "secret": "secret"}), wantErr: "key without kid"},
+		{name: "Duplicate kid", data: keyringJSON(t, "old", hmac, hmac), wantErr: `duplicate kid "old"`},
+		{name: "Empty secret", data: keyringJSON(t, "old", map[string]string{"kid": "old", "alg": "HS256"}), wantErr: `key "old": empty secret`},
+		{name: "Unsupported alg", data: keyringJSON(t, "old", map[string]string{"kid": "old", "alg": "RS256", "secret": "secret"}), wantErr: `key "old": unsupported alg "RS256"`},
+		{name: "Unknown alg", data: keyringJSON(t, "old", map[string]string{"kid": "old", "alg": "none", "secret": "secret"}), wantErr: `key "old": unsupported alg "none"`},
+		{name: "Invalid private key", data: keyringJSON(t, "new", map[string]string{"kid": "new", "alg": "EdDSA", "private_key": "broken"}), wantErr: `key "new"`},
+		{name: "Invalid public key", data: keyringJSON(t, "new", map[string]string{"kid": "new", "alg": "EdDSA", "public_key": "broken"}), wantErr: `key "new"`},
+		{name: "Active key not found", data: keyringJSON(t, "missing", hmac), wantErr: `active key "missing" not found`},
+		{
+			name:    "Active key without private key",
+			data:    keyringJSON(t, "verify", map[string]string{"kid": "verify", "alg": "EdDSA", "public_key": public}),
+			wantErr: `active key "verify" has no private key`,
+		},
+		{
+			name:    "Active key retires",
+			data:    keyringJSON(t, "old", map[string]string{"kid": "old", "alg": "HS256", "secret": "secret", "retire_at": "2024-03-01T00:00:00Z"}),
+			wantErr: `active key "old" must not retire`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := parseKeyring(tt.data)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "new", k.active.ID)
+			assert.Len(t, k.keys, 3)
+			assert.True(t, k.requireKID)
+			assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), k.keys["old"].RetireAt)
+			assert.Nil(t, k.keys["verify"].Sign, "Ключ с открытым ключом используется только для проверки")
+		})
+	}
+}
+
+func TestVerificationKey(t *testing.T) {
+	private, public := edKeys(t)
+	otherPrivate, _ := edKeys(t)
+	file, err := parseKeyring(keyringJSON(t, "new",
+		map[string]string{"kid": "old", "alg": "HS256", "secret": "old-secret", "retire_at": time.Now().Add(time.Hour).Format(time.RFC3339)},
+		map[string]string{"kid": "retired", "alg": "HS256", "secret": "retired-secret", "retire_at": time.Now().Add(-time.Hour).Format(time.RFC3339)},
+		map[string]string{"kid": "new", "alg": "EdDSA", "private_key": private},
+		map[string]string{"kid": "verify", "alg": "EdDSA", "public_key": public},
+	))
+	require.NoError(t, err)
+	other, err := parseKeyring(keyringJSON(t, "new", map[string]string{"kid": "new", "alg": "EdDSA", "private_key": otherPrivate}))
+	require.NoError(t, err)
+
+	// sign подписывает токен ключом kid из набора ключей; пустой kid не добавляется в заголовок
+	sign := func(k *Keyring, kid string, method jwt.SigningMethod, key interface{}) string {
+		token := jwt.NewWithClaims(method, jwt.RegisteredClaims{
+			ID:        "jti",
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		})
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		if key == nil {
+			key = k.keys[kid].Sign
+		}
+		s, err := token.SignedString(key)
+		require.NoError(t, err)
+		return s
+	}
+
+	tests := []struct {
+		name    string
+		keyring *Keyring
+		token   string
+		valid   bool
+	}{
+		{name: "Active Ed25519 key", keyring: file, token: sign(file, "new", jwt.SigningMethodEdDSA, nil), valid: true},
+		{name: "Old key before retire_at", keyring: file, token: sign(file, "old", jwt.SigningMethodHS256, nil), valid: true},
+		{name: "Verify-only key", keyring: file, token: sign(file, "verify", jwt.SigningMethodEdDSA, file.keys["new"].Sign), valid: true},
+		{name: "Retired key", keyring: file, token: sign(file, "retired", jwt.SigningMethodHS256, nil)},
+		{name: "Unknown kid", keyring: file, token: sign(file, "missing", jwt.SigningMethodHS256, []byte("old-secret"))},
+		{name: "Signing method differs from key", keyring: file, token: sign(file, "old", jwt.SigningMethodHS384, []byte("old-secret"))},
+		{name: "Signed with another Ed25519 key", keyring: file, token: sign(other, "new", jwt.SigningMethodEdDSA, nil)},
+		{name: "Without kid when keyring file is configured", keyring: file, token: sign(file, "", jwt.SigningMethodHS256, []byte("old-secret"))},
+		{name: "Without kid when keyring file is not configured", keyring: secretKeyring("secret"), token: sign(nil, "", jwt.SigningMethodHS256, []byte("secret")), valid: true},
+		{name: "Default kid when keyring file is not configured", keyring: secretKeyring("secret"), token: sign(secretKeyring("secret"), DefaultKeyID, jwt.SigningMethodHS256, nil), valid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useKeyring(t, tt.keyring)
+			claims, err := getClaims(testContext(), tt.token)
+			if !tt.valid {
+				assert.ErrorIs(t, err, usecase.ErrToken)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "jti", claims.ID)
+		})
+	}
+}
+
+func TestGetClaimsRequiredClaims(t *testing.T) {
+	useKeyring(t, secretKeyring("secret"))
+	now := time.Now()
+	tests := []struct {
+		name   string
+		claims jwt.RegisteredClaims
+		valid  bool
+	}{
+		{
+			name:   "All claims",
+			claims: jwt.RegisteredClaims{ID: "jti", IssuedAt: jwt.NewNumericDate(now), ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute))},
+			valid:  true,
+		},
+		{
+			name:   "Without jti",
+			claims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(now), ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute))},
+		},
+		{
+			name:   "Without iat",
+			claims: jwt.RegisteredClaims{ID: "jti", ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute))},
+		},
+		{
+			name:   "Without exp",
+			claims: jwt.RegisteredClaims{ID: "jti", IssuedAt: jwt.NewNumericDate(now)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, tt.claims).SignedString([]byte("secret"))
+			require.NoError(t, err)
+
+			claims, err := getClaims(testContext(), token)
+			if !tt.valid {
+				assert.ErrorIs(t, err, usecase.ErrToken)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, claims.IssuedAt)
+		})
+	}
+}
+
+func TestBuildJWTStringUsesActiveKey(t *testing.T) {
+	ttl := config.Cfg.AccessTokenTTL
+	config.Cfg.AccessTokenTTL = time.Minute
+	t.Cleanup(func() { config.Cfg.AccessTokenTTL = ttl })
+
+	private, _ := edKeys(t)
+	k, err := parseKeyring(keyringJSON(t, "new", map[string]string{"kid": "new", "alg": "EdDSA", "private_key": private}))
+	require.NoError(t, err)
+	useKeyring(t, k)
+
+	ctx := testContext()
+	token, err := buildJWTString(ctx, "test", "user", "sid")
+	require.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, "new", parsed.Header["kid"])
+	assert.Equal(t, jwt.SigningMethodEdDSA.Alg(), parsed.Method.Alg())
+
+	claims, err := getClaims(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, "test", claims.Login)
+	assert.Equal(t, "sid", claims.SessionID)
+}
+
+func TestWatchKeyring(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keyring.json")
+	file := config.Cfg.KeyringFile
+	config.Cfg.KeyringFile = path
+	t.Cleanup(func() {
+		config.Cfg.KeyringFile = file
+		keyring.Store(nil)
+	})
+
+	write := func(data []byte) {
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+	}
+	write(keyringJSON(t, "first", map[string]string{"kid": "first", "alg": "HS256", "secret": "first"}))
+
+	ctx, cancel := context.WithCancel(testContext())
+	require.NoError(t, LoadKeyring(ctx))
+	assert.Equal(t, "first", currentKeyring().active.ID)
+
+	signals := make(chan os.Signal)
+	done := make(chan struct{})
+	go func() {
+		WatchKeyring(ctx, signals)
+		close(done)
+	}()
+
+	// Канал без буфера: повторная отправка дожидается окончания обработки предыдущего сигнала
+	reload := func() {
+		signals <- os.Interrupt
+		signals <- os.Interrupt
+	}
+
+	write(keyringJSON(t, "second", map[string]string{"kid": "second", "alg": "HS256", "secret": "second"}))
+	reload()
+	assert.Equal(t, "second", currentKeyring().active.ID, "Файл перечитан по сигналу")
+
+	write([]byte("{broken"))
+	reload()
+	assert.Equal(t, "second", currentKeyring().active.ID, "При ошибке разбора действует прежний набор ключей")
+
+	require.NoError(t, os.Remove(path))
+	reload()
+	assert.Equal(t, "second", currentKeyring().active.ID, "При ошибке чтения действует прежний набор ключей")
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("WatchKeyring не завершился после отмены контекста")
+	}
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/nextlag/gomart/internal/problem"
+	"github.com/nextlag/gomart/internal/usecase"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+// RequireRole возвращает middleware, пропускающее только пользователей с одной из указанных ролей.
+//
+// Middleware подключается после Authentication и берет роль из клеймов токена в контексте запроса.
+// Если клеймы отсутствуют, возвращает ошибку Unauthorized (401), если роль не подходит - Forbidden (403).
+//
+// Параметры:
+//   - ctx: context.Context - контекст с логгером.
+//   - er: *usecase.ErrAll - объект, содержащий ошибки, используемые в UseCase.
+//   - roles: ...string - допустимые роли.
+//
+// Возвращаемые значения:
+//   - func(http.Handler) http.Handler: middleware проверки роли.
+func RequireRole(ctx context.Context, er *usecase.ErrAll, roles ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := r.Context().Value(ClaimsKey).(*Claims)
+			if !ok {
+				problem.Write(w, r, er.ErrUnAuthUser)
+				return
+			}
+			if !slices.Contains(roles, claims.UserRole()) {
+				l.L(ctx).Error("access denied", "login", claims.Login, "role", claims.UserRole(), "path", r.URL.Path)
+				problem.Write(w, r, er.ErrForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
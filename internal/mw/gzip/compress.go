@@ -8,8 +8,9 @@ import (
 )
 
 type CompressWriter struct {
-	w  http.ResponseWriter
-	zw *gzip.Writer
+	w           http.ResponseWriter
+	zw          *gzip.Writer
+	wroteHeader bool // Статус ответа уже отправлен
 }
 
 func NewCompressWriter(w http.ResponseWriter) *CompressWriter {
@@ -24,17 +25,39 @@ func (c *CompressWriter) Header() http.Header {
 }
 
 func (c *CompressWriter) Write(p []byte) (int, error) {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
 	return c.zw.Write(p)
 }
 
+// WriteHeader отправляет статус ответа. Тело сжимается при любом статусе, в том числе в ответах об ошибках,
+// поэтому заголовок Content-Encoding устанавливается всегда, а длина несжатого тела удаляется.
 func (c *CompressWriter) WriteHeader(statusCode int) {
-	if statusCode < 300 {
+	if !c.wroteHeader {
+		c.wroteHeader = true
 		c.w.Header().Set("Content-Encoding", "gzip")
+		c.w.Header().Del("Content-Length")
 	}
 	c.w.WriteHeader(statusCode)
 }
 
+// Flush отправляет клиенту уже сжатые данные, что нужно для потоковых ответов.
+func (c *CompressWriter) Flush() {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+	c.zw.Flush()
+	if f, ok := c.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close завершает сжатый поток. Если обработчик ничего не записал, ответ остается пустым и не сжимается.
 func (c *CompressWriter) Close() error {
+	if !c.wroteHeader {
+		return nil
+	}
 	return c.zw.Close()
 }
 
@@ -27,6 +27,12 @@ func New() func(next http.Handler) http.Handler {
 			// Проверяем поддержку сжатия gzip. Если заголовок "Accept-Encoding" содержит "gzip", устанавливаем флаг supportGzip.
 			supportGzip := strings.Contains(acceptEncoding, "gzip")
 
+			// Запросы на переключение протокола (WebSocket) не сжимаются: обработчику нужен исходный
+			// rest.ResponseWriter, чтобы перехватить соединение.
+			if r.Header.Get("Upgrade") != "" {
+				supportGzip = false
+			}
+
 			// Если поддержка gzip обнаружена, создаем новый gzip.Writer (cw) и устанавливаем ow на него.
 			if supportGzip {
 				cw := NewCompressWriter(w)
@@ -0,0 +1,195 @@
+// Package idempotency - middleware обработки заголовка Idempotency-Key
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/nextlag/gomart/internal/entity"
+	"github.com/nextlag/gomart/internal/mw/auth"
+	"github.com/nextlag/gomart/internal/problem"
+	"github.com/nextlag/gomart/internal/usecase"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+const (
+	// Header - заголовок с ключом идемпотентности
+	Header = "Idempotency-Key"
+	// ReplayedHeader - заголовок, которым помечается повторно отданный сохраненный ответ
+	ReplayedHeader = "Idempotent-Replayed"
+	// maxKeyLength - максимальная длина ключа идемпотентности
+	maxKeyLength = 255
+)
+
+// Store - хранилище ответов на идемпотентные запросы.
+type Store interface {
+	DoReserveIdempotencyKey(ctx context.Context, login, key, hash string) (*entity.Idempotency, error)
+	DoSaveIdempotencyResponse(ctx context.Context, login, key string, status int, contentType string, body []byte) error
+	DoDeleteIdempotencyKey(ctx context.Context, login, key string) error
+}
+
+// responseRecorder дублирует ответ обработчика в буфер, чтобы его можно было сохранить.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(statusCode int) {
+	if rec.status == 0 {
+		rec.status = statusCode
+	}
+	rec.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rec *responseRecorder) Write(p []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(p)
+	return rec.ResponseWriter.Write(p)
+}
+
+// New возвращает middleware, обеспечивающее идемпотентность изменяющих запросов.
+//
+// Middleware применяется к запросам с методами POST, PUT, PATCH и DELETE, содержащим заголовок Idempotency-Key.
+// Ключ хранится отдельно для каждого пользователя, поэтому middleware должно подключаться после аутентификации.
+// При первом запросе ключ резервируется, а ответ обработчика сохраняется в хранилище.
+// Повторный запрос с тем же ключом и тем же телом получает сохраненный ответ без повторного выполнения обработчика.
+// Если ключ уже использовался с другим телом запроса или первый запрос еще выполняется, возвращается Conflict (409).
+// Ответы с ошибкой сервера (>= 500) не сохраняются, чтобы клиент мог повторить запрос с тем же ключом;
+// по той же причине ключ освобождается, если обработчик запаниковал.
+// Ответы с заголовком Cache-Control: no-store содержат секреты (токены, секрет TOTP, коды восстановления)
+// и тоже не сохраняются: ключ освобождается, и повтор выполняет запрос заново.
+//
+// Параметры:
+//   - ctx: context.Context - контекст с логгером.
+//   - store: Store - хранилище ответов.
+//   - er: *usecase.ErrAll - объект, содержащий ошибки, используемые в UseCase.
+//
+// Возвращаемые значения:
+//   - func(http.Handler) http.Handler: middleware идемпотентности.
+func New(ctx context.Context, store Store, er *usecase.ErrAll) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			log := l.L(ctx)
+			key := r.Header.Get(Header)
+			if key == "" || !isMutating(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxKeyLength {
+				problem.Write(w, r, er.ErrIdempotencyKey)
+				return
+			}
+			login, _ := r.Context().Value(auth.LoginKey).(string)
+
+			// Читаем тело запроса, чтобы посчитать его хеш, и возвращаем его обратно для обработчика
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				log.Error("idempotency: body reading error", l.ErrAttr(err))
+				problem.Write(w, r, er.ErrInternalServer)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			hash := requestHash(r, body)
+
+			stored, err := store.DoReserveIdempotencyKey(r.Context(), login, key, hash)
+			if err != nil {
+				log.Error("idempotency: reserve key", l.ErrAttr(err))
+				problem.Write(w, r, er.ErrInternalServer)
+				return
+			}
+
+			switch {
+			case stored == nil:
+				// Новый ключ - выполняем запрос и сохраняем ответ
+			case stored.RequestHash != hash:
+				log.Error("idempotency: key reused with a different request", "login", login, "key", key)
+				problem.Write(w, r, er.ErrIdempotency)
+				return
+			case stored.Status == 0:
+				problem.Write(w, r, er.ErrInProgress)
+				return
+			default:
+				// Повторяем сохраненный ответ
+				log.Debug("idempotency: replay", "login", login, "key", key, "status", stored.Status)
+				if stored.ContentType != "" {
+					w.Header().Set("Content-Type", stored.ContentType)
+				}
+				w.Header().Set(ReplayedHeader, "true")
+				w.WriteHeader(stored.Status)
+				w.Write(stored.Body)
+				return
+			}
+
+			rec := &responseRecorder{ResponseWriter: w}
+			defer func() {
+				// Если обработчик запаниковал, освобождаем ключ, иначе он остался бы зарезервированным до истечения TTL,
+				// и повторы получали бы Conflict (409). Панику передаем дальше в middleware.Recoverer.
+				if rvr := recover(); rvr != nil {
+					if err := store.DoDeleteIdempotencyKey(ctx, login, key); err != nil {
+						log.Error("idempotency: delete key", l.ErrAttr(err))
+					}
+					panic(rvr)
+				}
+			}()
+			next.ServeHTTP(rec, r)
+
+			if rec.status == 0 {
+				// Обработчик ничего не записал - net/http отправит 200
+				rec.status = http.StatusOK
+			}
+			// Контекст запроса может быть уже отменен, поэтому сохраняем ответ с контекстом сервера
+			if rec.status >= http.StatusInternalServerError || noStore(rec.Header()) {
+				if err = store.DoDeleteIdempotencyKey(ctx, login, key); err != nil {
+					log.Error("idempotency: delete key", l.ErrAttr(err))
+				}
+				return
+			}
+			err = store.DoSaveIdempotencyResponse(ctx, login, key, rec.status, rec.Header().Get("Content-Type"), rec.body.Bytes())
+			if err != nil {
+				log.Error("idempotency: save response", l.ErrAttr(err))
+			}
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// isMutating сообщает, изменяет ли запрос с указанным методом состояние сервера.
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// noStore сообщает, запрещает ли заголовок Cache-Control ответа его сохранение.
+func noStore(h http.Header) bool {
+	for _, value := range h.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(directive), "no-store") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// requestHash вычисляет хеш запроса по методу, пути и телу.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.Path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
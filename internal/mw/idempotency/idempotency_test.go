@@ -0,0 +1,188 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nextlag/gomart/internal/config"
+	"github.com/nextlag/gomart/internal/controllers/mocks"
+	"github.com/nextlag/gomart/internal/entity"
+	"github.com/nextlag/gomart/internal/mw/auth"
+	"github.com/nextlag/gomart/internal/usecase"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+func TestIdempotency(t *testing.T) {
+	const body = `{"order":"2377225624","sum":751}`
+	hash := requestHash(httptest.NewRequest(http.MethodPost, "/api/user/balance/withdraw", nil), []byte(body))
+
+	tests := []struct {
+		name       string
+		method     string
+		key        string
+		prepare    func(store *mocks.MockUseCase)
+		handler    http.HandlerFunc
+		statusCode int
+		response   string
+		replayed   bool
+		called     bool
+	}{
+		{
+			name:       "Without key",
+			method:     http.MethodPost,
+			statusCode: http.StatusOK,
+			response:   "done",
+			called:     true,
+		},
+		{
+			name:       "Safe method",
+			method:     http.MethodGet,
+			key:        "key",
+			statusCode: http.StatusOK,
+			response:   "done",
+			called:     true,
+		},
+		{
+			name:       "Key too long",
+			method:     http.MethodPost,
+			key:        strings.Repeat("k", maxKeyLength+1),
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:   "First request",
+			method: http.MethodPost,
+			key:    "key",
+			prepare: func(store *mocks.MockUseCase) {
+				store.EXPECT().DoReserveIdempotencyKey(gomock.Any(), "test", "key", hash).Return(nil, nil)
+				store.EXPECT().DoSaveIdempotencyResponse(gomock.Any(), "test", "key", http.StatusOK, "text/plain", []byte("done")).Return(nil)
+			},
+			statusCode: http.StatusOK,
+			response:   "done",
+			called:     true,
+		},
+		{
+			name:   "Replay",
+			method: http.MethodPost,
+			key:    "key",
+			prepare: func(store *mocks.MockUseCase) {
+				store.EXPECT().DoReserveIdempotencyKey(gomock.Any(), "test", "key", hash).Return(&entity.Idempotency{
+					RequestHash: hash, Status: http.StatusOK, ContentType: "text/plain", Body: []byte("stored"),
+				}, nil)
+			},
+			statusCode: http.StatusOK,
+			response:   "stored",
+			replayed:   true,
+		},
+		{
+			name:   "Key reused with another body",
+			method: http.MethodPost,
+			key:    "key",
+			prepare: func(store *mocks.MockUseCase) {
+				store.EXPECT().DoReserveIdempotencyKey(gomock.Any(), "test", "key", hash).Return(&entity.Idempotency{
+					RequestHash: "other", Status: http.StatusOK,
+				}, nil)
+			},
+			statusCode: http.StatusConflict,
+		},
+		{
+			name:   "First request in progress",
+			method: http.MethodPost,
+			key:    "key",
+			prepare: func(store *mocks.MockUseCase) {
+				store.EXPECT().DoReserveIdempotencyKey(gomock.Any(), "test", "key", hash).Return(&entity.Idempotency{
+					RequestHash: hash,
+				}, nil)
+			},
+			statusCode: http.StatusConflict,
+		},
+		{
+			name:   "Server error releases key",
+			method: http.MethodPost,
+			key:    "key",
+			prepare: func(store *mocks.MockUseCase) {
+				store.EXPECT().DoReserveIdempotencyKey(gomock.Any(), "test", "key", hash).Return(nil, nil)
+				store.EXPECT().DoDeleteIdempotencyKey(gomock.Any(), "test", "key").Return(nil)
+			},
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			statusCode: http.StatusInternalServerError,
+			called:     true,
+		},
+		{
+			name:   "No-store response is not saved",
+			method: http.MethodPost,
+			key:    "key",
+			prepare: func(store *mocks.MockUseCase) {
+				store.EXPECT().DoReserveIdempotencyKey(gomock.Any(), "test", "key", hash).Return(nil, nil)
+				store.EXPECT().DoDeleteIdempotencyKey(gomock.Any(), "test", "key").Return(nil)
+			},
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Cache-Control", "private, No-Store")
+				w.Write([]byte("secret"))
+			},
+			statusCode: http.StatusOK,
+			response:   "secret",
+			called:     true,
+		},
+		{
+			name:   "Panic releases key",
+			method: http.MethodPost,
+			key:    "key",
+			prepare: func(store *mocks.MockUseCase) {
+				store.EXPECT().DoReserveIdempotencyKey(gomock.Any(), "test", "key", hash).Return(nil, nil)
+				store.EXPECT().DoDeleteIdempotencyKey(gomock.Any(), "test", "key").Return(nil)
+			},
+			handler: func(http.ResponseWriter, *http.Request) {
+				panic("handler failed")
+			},
+			statusCode: http.StatusInternalServerError,
+			called:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := l.ContextWithLogger(context.Background(), l.LoggerNew(config.Cfg.ProjectRoot))
+			store := mocks.NewMockUseCase(gomock.NewController(t))
+			if tt.prepare != nil {
+				tt.prepare(store)
+			}
+
+			called := false
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				if tt.handler != nil {
+					tt.handler(w, r)
+					return
+				}
+				w.Header().Set("Content-Type", "text/plain")
+				w.Write([]byte("done"))
+			}
+			h := middleware.Recoverer(New(ctx, store, usecase.New(nil, config.HTTPServer{}).Err())(http.HandlerFunc(handler)))
+
+			r := httptest.NewRequest(tt.method, "/api/user/balance/withdraw", strings.NewReader(body))
+			r = r.WithContext(context.WithValue(r.Context(), auth.LoginKey, "test"))
+			if tt.key != "" {
+				r.Header.Set(Header, tt.key)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.statusCode, w.Code, "Код ответа не совпадает с ожидаемым")
+			assert.Equal(t, tt.called, called, "Обработчик вызван неожиданно")
+			if tt.response != "" {
+				assert.Equal(t, tt.response, w.Body.String())
+			}
+			if tt.replayed {
+				assert.Equal(t, "true", w.Header().Get(ReplayedHeader))
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// Package problem - ответы об ошибках API в формате RFC 7807 (application/problem+json)
+// и реестр, сопоставляющий ошибкам бизнес-логики HTTP-статус и стабильный код.
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/nextlag/gomart/internal/usecase"
+)
+
+// ContentType - тип содержимого ответа об ошибке
+const ContentType = "application/problem+json"
+
+// CodeInternal - код ошибок, отсутствующих в реестре
+const CodeInternal = "internal_error"
+
+// Problem - тело ответа об ошибке по RFC 7807, дополненное стабильным кодом ошибки и идентификатором запроса.
+// Клиенты различают ошибки по полю code: в отличие от detail, оно не меняется при изменении текста сообщения.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// entry - запись реестра ошибок
+type entry struct {
+	err    error
+	status int
+	code   string
+}
+
+// registry - реестр ошибок бизнес-логики, которые передаются клиентам API. Ошибка сопоставляется записи
+// через errors.Is, поэтому обернутые ошибки (например, нарушение политики паролей с именем правила) получают
+// статус и код исходной ошибки. Коды стабильны: изменение кода ломает клиентов.
+var registry = []entry{
+	// Запрос
+	{usecase.ErrRequest, http.StatusBadRequest, "invalid_request"},
+	{usecase.ErrRequestFormat, http.StatusBadRequest, "invalid_request_format"},
+	{usecase.ErrDecodeJSON, http.StatusBadRequest, "invalid_json"},
+	{usecase.ErrIdempotencyKey, http.StatusBadRequest, "invalid_idempotency_key"},
+	{usecase.ErrIdempotency, http.StatusConflict, "idempotency_key_reused"},
+	{usecase.ErrInProgress, http.StatusConflict, "idempotency_key_in_progress"},
+	{usecase.ErrCSRF, http.StatusForbidden, "csrf_token_invalid"},
+
+	// Аутентификация и доступ
+	{usecase.ErrAuth, http.StatusUnauthorized, "authentication_required"},
+	{usecase.ErrUnAuthUser, http.StatusUnauthorized, "not_authenticated"},
+	{usecase.ErrToken, http.StatusUnauthorized, "invalid_token"},
+	{usecase.ErrTokenRevoked, http.StatusUnauthorized, "token_revoked"},
+	{usecase.ErrRefreshToken, http.StatusUnauthorized, "invalid_refresh_token"},
+	{usecase.ErrUnauthorized, http.StatusUnauthorized, "invalid_credentials"},
+	{usecase.ErrOTPRequired, http.StatusUnauthorized, "otp_required"},
+	{usecase.ErrOTPCode, http.StatusUnauthorized, "invalid_otp"},
+	{usecase.ErrTooManyAttempts, http.StatusTooManyRequests, "too_many_attempts"},
+	{usecase.ErrForbidden, http.StatusForbidden, "forbidden"},
+	{usecase.ErrAPIKey, http.StatusUnauthorized, "invalid_api_key"},
+	{usecase.ErrOnBehalfOf, http.StatusBadRequest, "on_behalf_of_required"},
+	{usecase.ErrRateLimit, http.StatusTooManyRequests, "rate_limited"},
+	{usecase.ErrNoCookie, http.StatusInternalServerError, "token_issue_failed"},
+
+	// Учетная запись
+	{usecase.ErrNoLogin, http.StatusConflict, "login_taken"},
+	{usecase.ErrUserNotFound, http.StatusNotFound, "user_not_found"},
+	{usecase.ErrPassword, http.StatusForbidden, "invalid_password"},
+	{usecase.ErrPasswordPolicy, http.StatusBadRequest, "password_policy"},
+	{usecase.ErrResetToken, http.StatusBadRequest, "invalid_reset_token"},
+	{usecase.ErrNoSession, http.StatusNotFound, "session_not_found"},
+	{usecase.ErrTOTPEnabled, http.StatusConflict, "totp_already_enabled"},
+	{usecase.ErrTOTPNotEnrolled, http.StatusNotFound, "totp_not_enrolled"},
+	{usecase.ErrOIDCDisabled, http.StatusNotFound, "sso_disabled"},
+	{usecase.ErrOIDCState, http.StatusBadRequest, "invalid_sso_state"},
+	{usecase.ErrOIDCLogin, http.StatusUnauthorized, "sso_failed"},
+	{usecase.ErrOIDCAccountExists, http.StatusConflict, "sso_account_exists"},
+
+	// Заказы и списания
+	{usecase.ErrOrderFormat, http.StatusUnprocessableEntity, "invalid_order_number"},
+	{usecase.ErrThisUser, http.StatusConflict, "order_already_uploaded"},
+	{usecase.ErrAnotherUser, http.StatusConflict, "order_uploaded_by_another_user"},
+	{usecase.ErrNoOrder, http.StatusNotFound, "order_not_found"},
+	{usecase.ErrNoBalance, http.StatusPaymentRequired, "insufficient_balance"},
+	{usecase.ErrWithdrawMax, http.StatusForbidden, "withdraw_limit_exceeded"},
+	{usecase.ErrWithdrawDaily, http.StatusForbidden, "withdraw_daily_limit_exceeded"},
+	{usecase.ErrWithdrawRate, http.StatusTooManyRequests, "withdraw_rate_exceeded"},
+	{usecase.ErrCoolingOff, http.StatusForbidden, "withdraw_cooling_off"},
+
+	// Администрирование
+	{usecase.ErrRole, http.StatusBadRequest, "invalid_role"},
+	{usecase.ErrReason, http.StatusBadRequest, "reason_required"},
+	{usecase.ErrCampaignExists, http.StatusConflict, "campaign_exists"},
+	{usecase.ErrNoCampaign, http.StatusNotFound, "campaign_not_found"},
+	{usecase.ErrCampaignKind, http.StatusBadRequest, "invalid_campaign_kind"},
+	{usecase.ErrAPIScope, http.StatusBadRequest, "invalid_api_scope"},
+	{usecase.ErrNoAPIKey, http.StatusNotFound, "api_key_not_found"},
+
+	{usecase.ErrInternalServer, http.StatusInternalServerError, CodeInternal},
+}
+
+// Lookup возвращает HTTP-статус и код ошибки err по реестру.
+//
+// Возвращаемые значения:
+//   - int: HTTP-статус ошибки; InternalServerError (500) для ошибок вне реестра.
+//   - string: код ошибки; CodeInternal для ошибок вне реестра.
+//   - bool: false, если ошибка отсутствует в реестре.
+func Lookup(err error) (int, string, bool) {
+	for _, e := range registry {
+		if errors.Is(err, e.err) {
+			return e.status, e.code, true
+		}
+	}
+	return http.StatusInternalServerError, CodeInternal, false
+}
+
+// Write отправляет ответ об ошибке err со статусом и кодом из реестра.
+// Текст ошибок вне реестра не передается клиенту: они описываются как usecase.ErrInternalServer.
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - запрос, путь и идентификатор которого указываются в ответе.
+//   - err: error - ошибка.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	status, _, _ := Lookup(err)
+	WriteStatus(w, r, status, err)
+}
+
+// WriteStatus отправляет ответ об ошибке err с кодом из реестра и статусом status. Используется там, где одна
+// и та же ошибка означает для маршрута другое, например неверный одноразовый код при подтверждении списания.
+//
+// Параметры:
+//   - w: http.ResponseWriter - объект для записи HTTP-ответа.
+//   - r: *http.Request - запрос, путь и идентификатор которого указываются в ответе.
+//   - status: int - HTTP-статус ответа.
+//   - err: error - ошибка.
+func WriteStatus(w http.ResponseWriter, r *http.Request, status int, err error) {
+	_, code, ok := Lookup(err)
+	detail := usecase.ErrInternalServer.Error()
+	if ok {
+		detail = err.Error()
+	}
+	p := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Code:      code,
+		RequestID: middleware.GetReqID(r.Context()),
+	}
+	body, _ := json.Marshal(p)
+
+	// Как и http.Error, удаляем длину, подготовленную для успешного ответа
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Type", ContentType)
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	w.Write(body)
+}
@@ -0,0 +1,227 @@
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nextlag/gomart/internal/usecase"
+)
+
+// registered - ожидаемые статус и код каждой ошибки реестра. Коды стабильны для клиентов API,
+// поэтому таблица задана явно, а не строится по реестру.
+var registered = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{err: usecase.ErrRequest, status: http.StatusBadRequest, code: "invalid_request"},
+	{err: usecase.ErrRequestFormat, status: http.StatusBadRequest, code: "invalid_request_format"},
+	{err: usecase.ErrDecodeJSON, status: http.StatusBadRequest, code: "invalid_json"},
+	{err: usecase.ErrIdempotencyKey, status: http.StatusBadRequest, code: "invalid_idempotency_key"},
+	{err: usecase.ErrIdempotency, status: http.StatusConflict, code: "idempotency_key_reused"},
+	{err: usecase.ErrInProgress, status: http.StatusConflict, code: "idempotency_key_in_progress"},
+	{err: usecase.ErrCSRF, status: http.StatusForbidden, code: "csrf_token_invalid"},
+	{err: usecase.ErrAuth, status: http.StatusUnauthorized, code: "authentication_required"},
+	{err: usecase.ErrUnAuthUser, status: http.StatusUnauthorized, code: "not_authenticated"},
+	{err: usecase.ErrToken, status: http.StatusUnauthorized, code: "invalid_token"},
+	{err: usecase.ErrTokenRevoked, status: http.StatusUnauthorized, code: "token_revoked"},
+	{err: usecase.ErrRefreshToken, status: http.StatusUnauthorized, code: "invalid_refresh_token"},
+	{err: usecase.ErrUnauthorized, status: http.StatusUnauthorized, code: "invalid_credentials"},
+	{err: usecase.ErrOTPRequired, status: http.StatusUnauthorized, code: "otp_required"},
+	{err: usecase.ErrOTPCode, status: http.StatusUnauthorized, code: "invalid_otp"},
+	{err: usecase.ErrTooManyAttempts, status: http.StatusTooManyRequests, code: "too_many_attempts"},
+	{err: usecase.ErrForbidden, status: http.StatusForbidden, code: "forbidden"},
+	{err: usecase.ErrAPIKey, status: http.StatusUnauthorized, code: "invalid_api_key"},
+	{err: usecase.ErrOnBehalfOf, status: http.StatusBadRequest, code: "on_behalf_of_required"},
+	{err: usecase.ErrRateLimit, status: http.StatusTooManyRequests, code: "rate_limited"},
+	{err: usecase.ErrNoCookie, status: http.StatusInternalServerError, code: "token_issue_failed"},
+	{err: usecase.ErrNoLogin, status: http.StatusConflict, code: "login_taken"},
+	{err: usecase.ErrUserNotFound, status: http.StatusNotFound, code: "user_not_found"},
+	{err: usecase.ErrPassword, status: http.StatusForbidden, code: "invalid_password"},
+	{err: usecase.ErrPasswordPolicy, status: http.StatusBadRequest, code: "password_policy"},
+	{err: usecase.ErrResetToken, status: http.StatusBadRequest, code: "invalid_reset_token"},
+	{err: usecase.ErrNoSession, status: http.StatusNotFound, code: "session_not_found"},
+	{err: usecase.ErrTOTPEnabled, status: http.StatusConflict, code: "totp_already_enabled"},
+	{err: usecase.ErrTOTPNotEnrolled, status: http.StatusNotFound, code: "totp_not_enrolled"},
+	{err: usecase.ErrOIDCDisabled, status: http.StatusNotFound, code: "sso_disabled"},
+	{err: usecase.ErrOIDCState, status: http.StatusBadRequest, code: "invalid_sso_state"},
+	{err: usecase.ErrOIDCLogin, status: http.StatusUnauthorized, code: "sso_failed"},
+	{err: usecase.ErrOIDCAccountExists, status: http.StatusConflict, code: "sso_account_exists"},
+	{err: usecase.ErrOrderFormat, status: http.StatusUnprocessableEntity, code: "invalid_order_number"},
+	{err: usecase.ErrThisUser, status: http.StatusConflict, code: "order_already_uploaded"},
+	{err: usecase.ErrAnotherUser, status: http.StatusConflict, code: "order_uploaded_by_another_user"},
+	{err: usecase.ErrNoOrder, status: http.StatusNotFound, code: "order_not_found"},
+	{err: usecase.ErrNoBalance, status: http.StatusPaymentRequired, code: "insufficient_balance"},
+	{err: usecase.ErrWithdrawMax, status: http.StatusForbidden, code: "withdraw_limit_exceeded"},
+	{err: usecase.ErrWithdrawDaily, status: http.StatusForbidden, code: "withdraw_daily_limit_exceeded"},
+	{err: usecase.ErrWithdrawRate, status: http.StatusTooManyRequests, code: "withdraw_rate_exceeded"},
+	{err: usecase.ErrCoolingOff, status: http.StatusForbidden, code: "withdraw_cooling_off"},
+	{err: usecase.ErrRole, status: http.StatusBadRequest, code: "invalid_role"},
+	{err: usecase.ErrReason, status: http.StatusBadRequest, code: "reason_required"},
+	{err: usecase.ErrCampaignExists, status: http.StatusConflict, code: "campaign_exists"},
+	{err: usecase.ErrNoCampaign, status: http.StatusNotFound, code: "campaign_not_found"},
+	{err: usecase.ErrCampaignKind, status: http.StatusBadRequest, code: "invalid_campaign_kind"},
+	{err: usecase.ErrAPIScope, status: http.StatusBadRequest, code: "invalid_api_scope"},
+	{err: usecase.ErrNoAPIKey, status: http.StatusNotFound, code: "api_key_not_found"},
+	{err: usecase.ErrInternalServer, status: http.StatusInternalServerError, code: CodeInternal},
+}
+
+func TestLookupRegistered(t *testing.T) {
+	for _, tt := range registered {
+		t.Run(tt.code+"/"+tt.err.Error(), func(t *testing.T) {
+			status, code, ok := Lookup(tt.err)
+			assert.True(t, ok, "Ошибка отсутствует в реестре")
+			assert.Equal(t, tt.status, status, "Статус не совпадает с ожидаемым")
+			assert.Equal(t, tt.code, code, "Код не совпадает с ожидаемым")
+		})
+	}
+}
+
+func TestRegistryCovered(t *testing.T) {
+	// Каждая запись реестра должна быть проверена в таблице registered, а коды не должны повторяться
+	require.Len(t, registered, len(registry), "Таблица registered не совпадает с реестром")
+	codes := make(map[string]error, len(registry))
+	for i, e := range registry {
+		assert.Equal(t, registered[i].err, e.err, "Запись реестра %d не проверена", i)
+		if prev, ok := codes[e.code]; ok {
+			t.Errorf("code %q is used by %q and %q", e.code, prev, e.err)
+		}
+		codes[e.code] = e.err
+	}
+}
+
+func TestLookupWrapped(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		ok     bool
+	}{
+		{
+			name:   "Wrapped with rule",
+			err:    fmt.Errorf("%w: minimum length is 12", usecase.ErrPasswordPolicy),
+			status: http.StatusBadRequest,
+			code:   "password_policy",
+			ok:     true,
+		},
+		{
+			name:   "Wrapped twice",
+			err:    fmt.Errorf("withdraw: %w", fmt.Errorf("%w: 1000", usecase.ErrWithdrawMax)),
+			status: http.StatusForbidden,
+			code:   "withdraw_limit_exceeded",
+			ok:     true,
+		},
+		{
+			name:   "Joined with unregistered error",
+			err:    errors.Join(errors.New("db error"), usecase.ErrNoBalance),
+			status: http.StatusPaymentRequired,
+			code:   "insufficient_balance",
+			ok:     true,
+		},
+		{
+			name:   "Throttle error",
+			err:    &usecase.ThrottleError{},
+			status: http.StatusTooManyRequests,
+			code:   "too_many_attempts",
+			ok:     true,
+		},
+		{
+			name:   "Unregistered error",
+			err:    usecase.ErrNoRows,
+			status: http.StatusInternalServerError,
+			code:   CodeInternal,
+		},
+		{
+			name:   "Wrapped unregistered error",
+			err:    fmt.Errorf("select user: %w", errors.New("connection refused")),
+			status: http.StatusInternalServerError,
+			code:   CodeInternal,
+		},
+		{
+			name:   "Nil",
+			status: http.StatusInternalServerError,
+			code:   CodeInternal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, code, ok := Lookup(tt.err)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.status, status, "Статус не совпадает с ожидаемым")
+			assert.Equal(t, tt.code, code, "Код не совпадает с ожидаемым")
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		err    error
+		want   Problem
+	}{
+		{
+			name: "Registered error",
+			err:  fmt.Errorf("%w: minimum length is 12", usecase.ErrPasswordPolicy),
+			want: Problem{
+				Status: http.StatusBadRequest,
+				Detail: "password does not meet policy: minimum length is 12",
+				Code:   "password_policy",
+			},
+		},
+		{
+			name:   "Status override",
+			status: http.StatusForbidden,
+			err:    usecase.ErrOTPCode,
+			want: Problem{
+				Status: http.StatusForbidden,
+				Detail: usecase.ErrOTPCode.Error(),
+				Code:   "invalid_otp",
+			},
+		},
+		{
+			name: "Unregistered error is not disclosed",
+			err:  errors.New("pq: password authentication failed for user postgres"),
+			want: Problem{
+				Status: http.StatusInternalServerError,
+				Detail: usecase.ErrInternalServer.Error(),
+				Code:   CodeInternal,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/user/password", nil)
+			r = r.WithContext(context.WithValue(r.Context(), middleware.RequestIDKey, "req-1"))
+			w := httptest.NewRecorder()
+			w.Header().Set("Content-Length", "2")
+			if tt.status != 0 {
+				WriteStatus(w, r, tt.status, tt.err)
+			} else {
+				Write(w, r, tt.err)
+			}
+
+			assert.Equal(t, tt.want.Status, w.Code, "Код ответа не совпадает с ожидаемым")
+			assert.Equal(t, ContentType, w.Header().Get("Content-Type"))
+			assert.Empty(t, w.Header().Get("Content-Length"))
+
+			var got Problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+			tt.want.Type = "about:blank"
+			tt.want.Title = http.StatusText(tt.want.Status)
+			tt.want.Instance = "/api/user/password"
+			tt.want.RequestID = "req-1"
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -6,8 +6,13 @@ import (
 	"database/sql"
 	"time"
 
+	"github.com/nextlag/gomart/internal/config"
 	"github.com/nextlag/gomart/internal/usecase"
+	"github.com/nextlag/gomart/pkg/events"
 	"github.com/nextlag/gomart/pkg/logger/l"
+	"github.com/nextlag/gomart/pkg/notify"
+	"github.com/nextlag/gomart/pkg/oidc"
+	"github.com/nextlag/gomart/pkg/passwd"
 )
 
 const createTablesTimeout = time.Second * 5
@@ -29,8 +34,42 @@ func New(ctx context.Context, cfg string) (*usecase.UseCase, error) {
 		return nil, err
 	}
 
+	hasher, err := passwd.New(config.Cfg.PasswordHash)
+	if err != nil {
+		log.Error("error initializing password hasher", l.ErrAttr(err))
+		return nil, err
+	}
+
+	policy, err := newPasswordPolicy(config.Cfg.PasswordPolicy)
+	if err != nil {
+		log.Error("error initializing password policy", l.ErrAttr(err))
+		return nil, err
+	}
+
+	notifier, err := notify.New(config.Cfg.Notifier, config.Cfg.NotifyFile)
+	if err != nil {
+		log.Error("error initializing notifier", l.ErrAttr(err))
+		return nil, err
+	}
+	if notifier != nil {
+		log.Warn("notifier writes password reset tokens in plain text; use it only for development", "notifier", config.Cfg.Notifier)
+	}
+
 	storage := &usecase.UseCase{
-		DB: db,
+		DB:       db,
+		Hasher:   hasher,
+		Policy:   policy,
+		Notifier: notifier,
+		Events:   events.NewHub(config.Cfg.Events.History, config.Cfg.Events.Buffer),
+	}
+	// Вход через поставщика удостоверений доступен, только если он настроен
+	if cfg := config.Cfg.OIDC; cfg.Enabled() {
+		storage.IdP = oidc.New(oidc.Config{
+			Issuer:       cfg.Issuer,
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+		})
 	}
 
 	if err = storage.CreateTable(ctx); err != nil {
@@ -42,3 +81,20 @@ func New(ctx context.Context, cfg string) (*usecase.UseCase, error) {
 
 	return storage, nil
 }
+
+// newPasswordPolicy создает политику паролей из конфигурации и загружает список запрещенных паролей.
+func newPasswordPolicy(cfg config.PasswordPolicy) (*passwd.Policy, error) {
+	policy := &passwd.Policy{
+		MinLength:       cfg.MinLength,
+		MaxLength:       cfg.MaxLength,
+		RequireUpper:    cfg.RequireUpper,
+		RequireLower:    cfg.RequireLower,
+		RequireDigit:    cfg.RequireDigit,
+		RequireSymbol:   cfg.RequireSymbol,
+		GeneratedLength: cfg.GeneratedLength,
+	}
+	if err := policy.LoadDenylist(cfg.DenylistFile); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
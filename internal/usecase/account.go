@@ -0,0 +1,268 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nextlag/gomart/internal/entity"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+// deletedLoginPrefix - префикс логина, которым заменяется логин удаленного пользователя в финансовых записях
+const deletedLoginPrefix = "deleted-"
+
+const (
+	selectProfile = `
+		SELECT u.login, u.role, u.tier, u.balance, u.withdrawn, u.password_changed_at,
+			EXISTS (SELECT 1 FROM totp_secrets t WHERE t.login = u.login AND t.confirmed_at IS NOT NULL)
+		FROM users u
+		WHERE u.login = $1
+	`
+	selectAllSessions = `
+		SELECT id, user_agent, ip, created_at, last_seen_at, revoked_at
+		FROM sessions
+		WHERE login = $1
+		ORDER BY created_at ASC
+	`
+	selectIdentities = `
+		SELECT issuer, subject, created_at
+		FROM user_identities
+		WHERE login = $1
+		ORDER BY created_at ASC
+	`
+	deleteUser = `
+		UPDATE users
+		SET login = $2, password = NULL, role = 'user', deleted_at = $3
+		WHERE login = $1
+	`
+	scrubSessions = `
+		UPDATE sessions
+		SET login = $2, user_agent = '', ip = ''
+		WHERE login = $1
+	`
+)
+
+// anonymizeQueries - запросы, заменяющие логин удаленного пользователя в записях, которые необходимо хранить.
+// Записи сеансов сохраняются без User-Agent и IP-адреса, чтобы выданные в них токены доступа оставались отозванными.
+var anonymizeQueries = []struct {
+	name  string
+	query string
+}{
+	{"orders", `UPDATE orders SET user_name = $2 WHERE user_name = $1`},
+	{"balance adjustments", `UPDATE balance_adjustments SET login = $2 WHERE login = $1`},
+	{"campaign grants", `UPDATE campaign_grants SET login = $2 WHERE login = $1`},
+	{"withdrawal violations", `UPDATE withdrawal_violations SET login = $2 WHERE login = $1`},
+	{"sessions", scrubSessions},
+}
+
+// deleteQueries - запросы, удаляющие учетные данные и остальные данные удаленного пользователя.
+var deleteQueries = []struct {
+	name  string
+	query string
+}{
+	{"refresh tokens", `DELETE FROM refresh_tokens WHERE login = $1`},
+	{"password resets", `DELETE FROM password_resets WHERE login = $1`},
+	{"TOTP secret", `DELETE FROM totp_secrets WHERE login = $1`},
+	{"recovery codes", `DELETE FROM recovery_codes WHERE login = $1`},
+	{"identities", `DELETE FROM user_identities WHERE login = $1`},
+	{"idempotency keys", `DELETE FROM idempotency_keys WHERE login = $1`},
+}
+
+// ExportAccount собирает все данные пользователя: учетную запись, заказы, списания, корректировки баланса,
+// сеансы и связанные учетные записи поставщиков удостоверений.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - login: логин пользователя.
+//
+// Возвращаемые значения:
+//   - entity.AccountExport: данные пользователя.
+//   - error: ErrUserNotFound, если пользователь не найден, ошибка базы данных в остальных случаях.
+func (uc *UseCase) ExportAccount(ctx context.Context, login string) (entity.AccountExport, error) {
+	export := entity.AccountExport{
+		ExportedAt:  time.Now(),
+		Orders:      make([]entity.Order, 0),
+		Withdrawals: make([]entity.Withdrawal, 0),
+		Sessions:    make([]entity.Session, 0),
+		Identities:  make([]entity.Identity, 0),
+	}
+
+	var (
+		p                 = &export.Profile
+		passwordChangedAt sql.NullTime
+	)
+	err := uc.DB.QueryRowContext(ctx, selectProfile, login).Scan(&p.Login, &p.Role, &p.Tier, &p.Balance, &p.Withdrawn,
+		&passwordChangedAt, &p.TwoFactor)
+	if errors.Is(err, sql.ErrNoRows) {
+		return export, ErrUserNotFound
+	}
+	if err != nil {
+		return export, fmt.Errorf("error selecting user: %v", err)
+	}
+	if passwordChangedAt.Valid {
+		p.PasswordChangedAt = &passwordChangedAt.Time
+	}
+
+	if err = uc.exportOrders(ctx, login, &export); err != nil {
+		return export, err
+	}
+	if export.Adjustments, err = uc.GetAdjustments(ctx, login); err != nil {
+		return export, err
+	}
+	if err = uc.exportSessions(ctx, login, &export); err != nil {
+		return export, err
+	}
+	if err = uc.exportIdentities(ctx, login, &export); err != nil {
+		return export, err
+	}
+	return export, nil
+}
+
+// exportOrders добавляет в выгрузку заказы и списания пользователя.
+func (uc *UseCase) exportOrders(ctx context.Context, login string, export *entity.AccountExport) error {
+	rows, err := uc.DB.QueryContext(ctx, selectOrders, login)
+	if err != nil {
+		return fmt.Errorf("error selecting orders: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var order entity.Order
+		if err = rows.Scan(&order.Order, &order.Status, &order.Accrual, &order.UploadedAt); err != nil {
+			return err
+		}
+		export.Orders = append(export.Orders, order)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = uc.DB.QueryContext(ctx, selectOrderWithdrawals, login)
+	if err != nil {
+		return fmt.Errorf("error selecting withdrawals: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var w entity.Withdrawal
+		if err = rows.Scan(&w.Order, &w.Sum, &w.ProcessedAt); err != nil {
+			return err
+		}
+		export.Withdrawals = append(export.Withdrawals, w)
+	}
+	return rows.Err()
+}
+
+// exportSessions добавляет в выгрузку все сеансы пользователя, включая завершенные.
+func (uc *UseCase) exportSessions(ctx context.Context, login string, export *entity.AccountExport) error {
+	rows, err := uc.DB.QueryContext(ctx, selectAllSessions, login)
+	if err != nil {
+		return fmt.Errorf("error selecting sessions: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			s         entity.Session
+			revokedAt sql.NullTime
+		)
+		if err = rows.Scan(&s.ID, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastSeenAt, &revokedAt); err != nil {
+			return err
+		}
+		if revokedAt.Valid {
+			s.RevokedAt = &revokedAt.Time
+		}
+		export.Sessions = append(export.Sessions, s)
+	}
+	return rows.Err()
+}
+
+// exportIdentities добавляет в выгрузку связанные учетные записи поставщиков удостоверений.
+func (uc *UseCase) exportIdentities(ctx context.Context, login string, export *entity.AccountExport) error {
+	rows, err := uc.DB.QueryContext(ctx, selectIdentities, login)
+	if err != nil {
+		return fmt.Errorf("error selecting identities: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var identity entity.Identity
+		if err = rows.Scan(&identity.Issuer, &identity.Subject, &identity.CreatedAt); err != nil {
+			return err
+		}
+		export.Identities = append(export.Identities, identity)
+	}
+	return rows.Err()
+}
+
+// DeleteAccount безвозвратно удаляет учетную запись пользователя после проверки пароля. Логин пользователя
+// в заказах, корректировках баланса и других финансовых записях заменяется случайным логином удаленного
+// пользователя, не связанным с исходным, а пароль, секрет двухфакторной аутентификации, коды восстановления,
+// токены и связанные учетные записи поставщиков удостоверений удаляются. Все сеансы и токены пользователя
+// отзываются, а освободившийся логин можно зарегистрировать снова.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - login: логин пользователя.
+//   - password: текущий пароль пользователя.
+//
+// Возвращаемое значение:
+//   - error: ErrUserNotFound, если пользователь не найден, ErrPassword, если пароль неверен,
+//     ошибка базы данных в остальных случаях.
+func (uc *UseCase) DeleteAccount(ctx context.Context, login, password string) error {
+	tx, err := uc.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var stored sql.NullString
+	err = tx.QueryRowContext(ctx, selectUserPasswordForUpdate, login).Scan(&stored)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error selecting password: %v", err)
+	}
+	ok, _, err := uc.Hasher.Verify(password, stored.String)
+	if err != nil {
+		return err
+	}
+	if !ok || !stored.Valid {
+		return ErrPassword
+	}
+
+	b := make([]byte, 8)
+	if _, err = rand.Read(b); err != nil {
+		return err
+	}
+	anonymous := deletedLoginPrefix + hex.EncodeToString(b)
+	now := time.Now()
+
+	if err = revokeUserTokens(ctx, tx, login, now); err != nil {
+		return err
+	}
+	for _, q := range anonymizeQueries {
+		if _, err = tx.ExecContext(ctx, q.query, login, anonymous); err != nil {
+			return fmt.Errorf("error anonymizing %s: %v", q.name, err)
+		}
+	}
+	for _, q := range deleteQueries {
+		if _, err = tx.ExecContext(ctx, q.query, login); err != nil {
+			return fmt.Errorf("error deleting %s: %v", q.name, err)
+		}
+	}
+	if _, err = tx.ExecContext(ctx, deleteLoginAttempts, "login:"+login); err != nil {
+		return fmt.Errorf("error deleting login attempts: %v", err)
+	}
+	if _, err = tx.ExecContext(ctx, deleteUser, login, anonymous, now); err != nil {
+		return fmt.Errorf("error deleting user: %v", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	l.L(ctx).Info("account deleted", "login", login)
+	return nil
+}
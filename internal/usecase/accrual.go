@@ -71,13 +71,13 @@ func GetAccrual(ctx context.Context, order entity.Order) (OrderResponse, error)
 			case 200:
 				switch orderUpdate.Status {
 				case "INVALID", "PROCESSED":
-					log.Info("Exiting the loop. Order status: %s", orderUpdate.Status)
+					log.Info("Exiting the loop", "status", orderUpdate.Status)
 					return orderUpdate, nil
 				case "PROCESSING":
 					log.Info("Order status is PROCESSING. Sleeping for 1 second before the next request.")
 					time.Sleep(1 * time.Second)
 				default:
-					log.Info("Unknown order status: %s. Sleeping for 1 second before the next request.", orderUpdate.Status)
+					log.Info("Unknown order status. Sleeping for 1 second before the next request.", "status", orderUpdate.Status)
 					time.Sleep(1 * time.Second)
 				}
 			case 429:
@@ -105,6 +105,7 @@ func GetAccrual(ctx context.Context, order entity.Order) (OrderResponse, error)
 // Затем она запускает цикл обработки этих заказов, вызывая функцию GetAccrual для каждого заказа
 // и обновляя статусы заказов в базе данных согласно полученной информации. Функция продолжает
 // работу до получения сигнала остановки из канала stop.
+// После фиксации транзакции пользователям отправляются события об изменившихся заказах и начислениях.
 func (uc *UseCase) Sync(ctx context.Context) error {
 	log := l.L(ctx)
 	ticker := time.NewTicker(tick)
@@ -158,6 +159,8 @@ func (uc *UseCase) Sync(ctx context.Context) error {
 				continue
 			}
 
+			// Заказы, статус которых изменился; события о них отправляются после фиксации транзакции
+			var changed []entity.Order
 			for _, unfinishedOrder := range allOrders {
 				select {
 				case <-ctx.Done():
@@ -169,13 +172,21 @@ func (uc *UseCase) Sync(ctx context.Context) error {
 						tx.Rollback()
 						continue // Пропустить текущую итерацию цикла и перейти к следующей итерации
 					}
-					log.Debug("finished", finishedOrder)
+					log.Debug("finished", "order", finishedOrder)
 					err = uc.UpdateStatus(ctx, finishedOrder, unfinishedOrder.UserName, tx)
 					if err != nil {
 						log.Error("error updating status", l.ErrAttr(err))
 						tx.Rollback()
 						continue // Пропустить текущую итерацию цикла и перейти к следующей итерации
 					}
+					if finishedOrder.Status != unfinishedOrder.Status {
+						changed = append(changed, entity.Order{
+							UserName: unfinishedOrder.UserName,
+							Order:    unfinishedOrder.Order,
+							Status:   finishedOrder.Status,
+							Accrual:  finishedOrder.Accrual,
+						})
+					}
 				}
 			}
 
@@ -183,6 +194,12 @@ func (uc *UseCase) Sync(ctx context.Context) error {
 				log.Error("error committing transaction", l.ErrAttr(err))
 				continue
 			}
+			for _, order := range changed {
+				uc.publishOrder(ctx, order.UserName, order.Order)
+				if order.Status == entity.OrderProcessed && order.Accrual > 0 {
+					uc.publishBalance(ctx, order.UserName)
+				}
+			}
 		case <-ctx.Done():
 			return nil // В случае получения сигнала остановки, завершаем выполнение без ошибок
 		}
@@ -201,7 +218,11 @@ func (uc *UseCase) Sync(ctx context.Context) error {
 //   - error: в случае возникновения ошибки при выполнении запроса к базе данных.
 //
 // Функция выполняет два отдельных запроса к базе данных для обновления статуса заказа и баланса пользователя.
-// Сначала она обновляет статус заказа и начисление в таблице заказов, а затем обновляет баланс пользователя
+// Перед этим начисление по обработанному заказу умножается на множитель текущего уровня пользователя
+// из config.Cfg.Tiers, и в заказ и на баланс записывается уже увеличенная сумма. Исходное начисление
+// сохраняется в base_accrual: по нему, а не по увеличенной сумме, пересчитываются уровни.
+// Сначала она записывает изменившийся статус в историю заказа, обновляет статус заказа и начисление
+// в таблице заказов, а затем обновляет баланс пользователя
 // в соответствии с начисленной суммой. Если произошла ошибка при выполнении запросов к базе данных,
 // функция возвращает ошибку.
 func (uc *UseCase) UpdateStatus(ctx context.Context, orderAccrual OrderResponse, login string, tx bun.Tx) error {
@@ -209,10 +230,35 @@ func (uc *UseCase) UpdateStatus(ctx context.Context, orderAccrual OrderResponse,
 	orderModel := &entity.Order{}
 	userModel := &entity.User{}
 
+	// Применяем множитель уровня программы лояльности к начислению системы расчета
+	baseAccrual := orderAccrual.Accrual
+	if orderAccrual.Status == "PROCESSED" && orderAccrual.Accrual > 0 {
+		var tier string
+		err := tx.NewSelect().
+			Model(userModel).
+			Column("tier").
+			Where("login = ?", login).
+			Scan(ctx, &tier)
+		if err != nil {
+			log.Error("error selecting user tier", l.ErrAttr(err))
+			return err
+		}
+		orderAccrual.Accrual = tierAccrual(config.Cfg.Tiers, tier, baseAccrual)
+		log.Debug("tier multiplier applied", "login", login, "tier", tier, "base_accrual", baseAccrual, "accrual", orderAccrual.Accrual)
+	}
+
+	// Записываем изменение статуса в историю заказа
+	_, err := tx.ExecContext(ctx, insertOrderStatusChange,
+		orderAccrual.Status, orderAccrual.Accrual, time.Now(), orderAccrual.Order, orderAccrual.Status)
+	if err != nil {
+		log.Error("error inserting order status", l.ErrAttr(err))
+		return err
+	}
+
 	// Используем tx для создания запроса обновления
-	_, err := tx.NewUpdate().
+	_, err = tx.NewUpdate().
 		Model(orderModel).
-		Set("status = ?, accrual = ?", orderAccrual.Status, orderAccrual.Accrual).
+		Set("status = ?, accrual = ?, base_accrual = ?", orderAccrual.Status, orderAccrual.Accrual, baseAccrual).
 		Where(`"order" = ?`, orderAccrual.Order).
 		Exec(ctx)
 	if err != nil {
@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nextlag/gomart/internal/entity"
+)
+
+const (
+	selectBalanceForUpdate = `
+		SELECT balance
+		FROM users
+		WHERE login = $1
+		FOR UPDATE
+	`
+	updateUserBalance = `
+		UPDATE users
+		SET balance = balance + $1
+		WHERE login = $2
+	`
+	insertAdjustment = `
+		INSERT INTO balance_adjustments (login, amount, reason, campaign, created_by, created_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
+		RETURNING id
+	`
+	selectAdjustments = `
+		SELECT id, login, amount, reason, COALESCE(campaign, ''), created_by, created_at
+		FROM balance_adjustments
+		WHERE login = $1
+		ORDER BY created_at ASC
+	`
+)
+
+// adjust изменяет баланс пользователя на сумму корректировки и сохраняет ее в истории в рамках транзакции tx.
+// Списание, превышающее текущий баланс, отклоняется с ошибкой ErrNoBalance.
+func adjust(ctx context.Context, tx *sql.Tx, adj *entity.Adjustment) error {
+	var balance float32
+	err := tx.QueryRowContext(ctx, selectBalanceForUpdate, adj.Login).Scan(&balance)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error selecting user balance: %v", err)
+	}
+	if balance+adj.Amount < 0 {
+		return ErrNoBalance
+	}
+
+	if _, err = tx.ExecContext(ctx, updateUserBalance, adj.Amount, adj.Login); err != nil {
+		return fmt.Errorf("error updating user balance: %v", err)
+	}
+
+	adj.CreatedAt = time.Now()
+	err = tx.QueryRowContext(ctx, insertAdjustment,
+		adj.Login, adj.Amount, adj.Reason, adj.Campaign, adj.CreatedBy, adj.CreatedAt).Scan(&adj.ID)
+	if err != nil {
+		return fmt.Errorf("error inserting balance adjustment: %v", err)
+	}
+	return nil
+}
+
+// AdjustBalance выполняет ручное начисление (положительная сумма) или списание (отрицательная сумма) баллов.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - adj: корректировка с логином пользователя, суммой, обязательной причиной и автором.
+//
+// Возвращаемые значения:
+//   - entity.Adjustment: сохраненная корректировка с идентификатором и временем.
+//   - error: ErrReason, если причина не указана; ErrUserNotFound, если пользователь не найден;
+//     ErrNoBalance, если списание превышает баланс; ошибка базы данных в остальных случаях.
+func (uc *UseCase) AdjustBalance(ctx context.Context, adj entity.Adjustment) (entity.Adjustment, error) {
+	if adj.Reason == "" {
+		return adj, ErrReason
+	}
+	if adj.Amount == 0 {
+		return adj, ErrRequestFormat
+	}
+
+	tx, err := uc.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return adj, fmt.Errorf("error beginning transaction AdjustBalance method: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err = adjust(ctx, tx, &adj); err != nil {
+		return adj, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return adj, fmt.Errorf("error committing transaction AdjustBalance method: %v", err)
+	}
+	uc.publishBalance(ctx, adj.Login)
+	return adj, nil
+}
+
+// GetAdjustments возвращает историю ручных корректировок и промо-начислений пользователя.
+func (uc *UseCase) GetAdjustments(ctx context.Context, login string) ([]entity.Adjustment, error) {
+	adjustments := make([]entity.Adjustment, 0)
+
+	rows, err := uc.DB.QueryContext(ctx, selectAdjustments, login)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var adj entity.Adjustment
+		err = rows.Scan(&adj.ID, &adj.Login, &adj.Amount, &adj.Reason, &adj.Campaign, &adj.CreatedBy, &adj.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		adjustments = append(adjustments, adj)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return adjustments, nil
+}
@@ -0,0 +1,234 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/nextlag/gomart/internal/config"
+	"github.com/nextlag/gomart/internal/entity"
+)
+
+const (
+	// apiKeyPrefix - префикс API-ключей, упрощающий их поиск в журналах и репозиториях кода
+	apiKeyPrefix = "gm_"
+	// apiKeyIDLength - длина идентификатора API-ключа в байтах до кодирования
+	apiKeyIDLength = 8
+	// apiKeyUsageInterval - минимальный интервал обновления времени последнего использования API-ключа
+	apiKeyUsageInterval = time.Minute
+	// apiKeyRateWindow - окно, для которого задается ограничение запросов API-ключа
+	apiKeyRateWindow = time.Minute
+)
+
+const (
+	insertAPIKey = `
+		INSERT INTO api_keys (id, key_hash, name, scopes, rate_limit, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	selectAPIKeys = `
+		SELECT id, name, scopes, rate_limit, created_by, created_at, last_used_at, revoked_at
+		FROM api_keys
+		ORDER BY created_at ASC
+	`
+	selectActiveAPIKey = `
+		SELECT id, name, scopes, rate_limit, created_by, created_at, last_used_at, revoked_at
+		FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL
+	`
+	updateAPIKeyUsage = `
+		UPDATE api_keys
+		SET last_used_at = $2
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $3)
+	`
+	upsertAPIKeyRequest = `
+		INSERT INTO api_key_requests AS r (key_id, window_start, requests)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (key_id) DO UPDATE
+		SET window_start = CASE WHEN r.window_start <= $3 THEN $2 ELSE r.window_start END,
+		    requests = CASE WHEN r.window_start <= $3 THEN 1 ELSE r.requests + 1 END
+		RETURNING window_start, requests
+	`
+	revokeAPIKey = `
+		UPDATE api_keys
+		SET revoked_at = $2
+		WHERE id = $1 AND revoked_at IS NULL
+	`
+)
+
+// scanAPIKey считывает API-ключ из строки результата запроса.
+func scanAPIKey(row interface{ Scan(...any) error }) (entity.APIKey, error) {
+	var (
+		key        entity.APIKey
+		lastUsedAt sql.NullTime
+		revokedAt  sql.NullTime
+	)
+	err := row.Scan(&key.ID, &key.Name, pq.Array(&key.Scopes), &key.RateLimit, &key.CreatedBy, &key.CreatedAt,
+		&lastUsedAt, &revokedAt)
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return key, err
+}
+
+// CreateAPIKey создает API-ключ сервисной учетной записи с указанными областями действия.
+// Если ограничение запросов не задано, используется config.Cfg.APIKeyRateLimit.
+// В базе данных сохраняется только хеш ключа, поэтому ключ целиком возвращается один раз в поле Key.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - key: имя, области действия и ограничение запросов в минуту нового ключа и логин его создателя.
+//
+// Возвращаемые значения:
+//   - entity.APIKey: созданный ключ.
+//   - error: ErrRequestFormat при пустом имени, отрицательном ограничении или без областей действия,
+//     ErrAPIScope при неизвестной области действия, ошибка базы данных в остальных случаях.
+func (uc *UseCase) CreateAPIKey(ctx context.Context, key entity.APIKey) (entity.APIKey, error) {
+	switch {
+	case key.Name == "" || len(key.Scopes) == 0 || key.RateLimit < 0:
+		return key, ErrRequestFormat
+	case slices.ContainsFunc(key.Scopes, func(s string) bool { return !slices.Contains(entity.APIScopes, s) }):
+		return key, ErrAPIScope
+	}
+	if key.RateLimit == 0 {
+		key.RateLimit = config.Cfg.APIKeyRateLimit
+	}
+
+	id := make([]byte, apiKeyIDLength)
+	if _, err := rand.Read(id); err != nil {
+		return key, err
+	}
+	secret, _, err := newRefreshToken()
+	if err != nil {
+		return key, err
+	}
+	key.ID = hex.EncodeToString(id)
+	key.Key = apiKeyPrefix + key.ID + "_" + secret
+	key.CreatedAt = time.Now()
+
+	_, err = uc.DB.ExecContext(ctx, insertAPIKey, key.ID, hashToken(key.Key), key.Name, pq.Array(key.Scopes),
+		key.RateLimit, key.CreatedBy, key.CreatedAt)
+	if err != nil {
+		return key, fmt.Errorf("error inserting API key: %v", err)
+	}
+	return key, nil
+}
+
+// GetAPIKeys возвращает все API-ключи, включая отозванные, без самих ключей.
+func (uc *UseCase) GetAPIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	rows, err := uc.DB.QueryContext(ctx, selectAPIKeys)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting API keys: %v", err)
+	}
+	defer rows.Close()
+
+	keys := make([]entity.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey отзывает API-ключ; запросы с ним перестают приниматься сразу.
+// Возвращает ErrNoAPIKey, если ключ не найден или уже отозван.
+func (uc *UseCase) RevokeAPIKey(ctx context.Context, id string) error {
+	res, err := uc.DB.ExecContext(ctx, revokeAPIKey, id, time.Now())
+	if err != nil {
+		return fmt.Errorf("error revoking API key: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNoAPIKey
+	}
+	return nil
+}
+
+// AuthenticateAPIKey проверяет API-ключ и существование пользователя, от имени которого выполняется запрос.
+// Время последнего использования ключа обновляется не чаще раза в минуту.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - key: предъявленный API-ключ.
+//   - login: логин пользователя, от имени которого выполняется запрос.
+//
+// Возвращаемые значения:
+//   - entity.APIKey: действующий API-ключ без самого ключа.
+//   - error: ErrAPIKey, если ключ неизвестен или отозван, ErrUserNotFound, если пользователь не найден,
+//     ошибка базы данных в остальных случаях.
+func (uc *UseCase) AuthenticateAPIKey(ctx context.Context, key, login string) (entity.APIKey, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return entity.APIKey{}, ErrAPIKey
+	}
+	apiKey, err := scanAPIKey(uc.DB.QueryRowContext(ctx, selectActiveAPIKey, hashToken(key)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return apiKey, ErrAPIKey
+	}
+	if err != nil {
+		return apiKey, fmt.Errorf("error selecting API key: %v", err)
+	}
+
+	var exists bool
+	if err = uc.DB.QueryRowContext(ctx, selectUserExists, login).Scan(&exists); err != nil {
+		return apiKey, fmt.Errorf("error selecting user: %v", err)
+	}
+	if !exists {
+		return apiKey, ErrUserNotFound
+	}
+
+	now := time.Now()
+	if _, err = uc.DB.ExecContext(ctx, updateAPIKeyUsage, apiKey.ID, now, now.Add(-apiKeyUsageInterval)); err != nil {
+		return apiKey, fmt.Errorf("error updating API key usage: %v", err)
+	}
+	return apiKey, nil
+}
+
+// rateLimitRetry возвращает время до начала следующего окна, если количество запросов requests в окне,
+// начавшемся в windowStart, превышает ограничение limit, и 0, если запрос разрешен.
+func rateLimitRetry(windowStart time.Time, requests, limit int, now time.Time) time.Duration {
+	if requests <= limit {
+		return 0
+	}
+	return windowStart.Add(apiKeyRateWindow).Sub(now)
+}
+
+// CountAPIKeyRequest учитывает запрос API-ключа в фиксированном окне длиной в минуту и проверяет ограничение запросов.
+// Счетчик хранится в базе данных и увеличивается одним запросом, поэтому ограничение действует для всех экземпляров
+// сервиса, а на каждый ключ приходится одна строка, заменяемая с началом нового окна.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - id: идентификатор API-ключа.
+//   - limit: количество запросов в минуту.
+//
+// Возвращаемые значения:
+//   - time.Duration: время, через которое можно повторить запрос, если ограничение исчерпано; 0, если запрос разрешен.
+//   - error: ошибка базы данных.
+func (uc *UseCase) CountAPIKeyRequest(ctx context.Context, id string, limit int) (time.Duration, error) {
+	now := time.Now()
+	var (
+		windowStart time.Time
+		requests    int
+	)
+	err := uc.DB.QueryRowContext(ctx, upsertAPIKeyRequest, id, now, now.Add(-apiKeyRateWindow)).Scan(&windowStart, &requests)
+	if err != nil {
+		return 0, fmt.Errorf("error counting API key request: %v", err)
+	}
+	return rateLimitRetry(windowStart, requests, limit, now), nil
+}
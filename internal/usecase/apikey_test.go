@@ -0,0 +1,29 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitRetry(t *testing.T) {
+	now := time.Date(2024, time.January, 2, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		windowStart time.Time
+		requests    int
+		limit       int
+		want        time.Duration
+	}{
+		{name: "First request in window", windowStart: now, requests: 1, limit: 10},
+		{name: "Last allowed request", windowStart: now.Add(-30 * time.Second), requests: 10, limit: 10},
+		{name: "Limit exceeded", windowStart: now.Add(-20 * time.Second), requests: 11, limit: 10, want: 40 * time.Second},
+		{name: "Limit exceeded at window start", windowStart: now, requests: 2, limit: 1, want: time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, rateLimitRetry(tt.windowStart, tt.requests, tt.limit, now))
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/nextlag/gomart/internal/entity"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+const (
+	insertCampaign = `
+		INSERT INTO campaigns (name, kind, amount, reason, active, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	selectCampaigns = `
+		SELECT name, kind, amount, reason, active, created_at
+		FROM campaigns
+		ORDER BY created_at ASC
+	`
+	selectActiveCampaign = `
+		SELECT name, kind, amount, reason, active, created_at
+		FROM campaigns
+		WHERE name = $1 AND active
+	`
+	selectWelcomeCampaigns = `
+		SELECT name, kind, amount, reason, active, created_at
+		FROM campaigns
+		WHERE kind = 'welcome' AND active
+		ORDER BY created_at ASC
+	`
+	updateCampaignActive = `
+		UPDATE campaigns
+		SET active = $2
+		WHERE name = $1
+	`
+	insertCampaignGrant = `
+		INSERT INTO campaign_grants (campaign, login, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (campaign, login) DO NOTHING
+	`
+)
+
+// CampaignSystem - автор промо-начислений, выполненных автоматически при регистрации
+const CampaignSystem = "system"
+
+// CreateCampaign создает промо-кампанию.
+// Поддерживаются кампании вида welcome (начисление при регистрации) и bulk (начисление по списку логинов).
+// Возвращает ErrCampaignKind при неизвестном виде, ErrReason без причины, ErrRequestFormat при пустом имени
+// или неположительной сумме и ErrCampaignExists, если кампания с таким именем уже есть.
+func (uc *UseCase) CreateCampaign(ctx context.Context, c entity.Campaign) (entity.Campaign, error) {
+	switch {
+	case c.Kind != entity.CampaignWelcome && c.Kind != entity.CampaignBulk:
+		return c, ErrCampaignKind
+	case c.Reason == "":
+		return c, ErrReason
+	case c.Name == "" || c.Amount <= 0:
+		return c, ErrRequestFormat
+	}
+
+	c.CreatedAt = time.Now()
+	_, err := uc.DB.ExecContext(ctx, insertCampaign, c.Name, c.Kind, c.Amount, c.Reason, c.Active, c.CreatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return c, ErrCampaignExists
+	}
+	if err != nil {
+		return c, fmt.Errorf("error inserting campaign: %v", err)
+	}
+	return c, nil
+}
+
+// GetCampaigns возвращает список всех промо-кампаний.
+func (uc *UseCase) GetCampaigns(ctx context.Context) ([]entity.Campaign, error) {
+	rows, err := uc.DB.QueryContext(ctx, selectCampaigns)
+	if err != nil {
+		return nil, err
+	}
+	return scanCampaigns(rows)
+}
+
+// SetCampaignActive включает или выключает промо-кампанию.
+func (uc *UseCase) SetCampaignActive(ctx context.Context, name string, active bool) error {
+	res, err := uc.DB.ExecContext(ctx, updateCampaignActive, name, active)
+	if err != nil {
+		return fmt.Errorf("error updating campaign: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNoCampaign
+	}
+	return nil
+}
+
+// GrantCampaign начисляет баллы активной промо-кампании пользователям из списка.
+// Каждый пользователь получает начисление по кампании не более одного раза: повторные и несуществующие
+// логины пропускаются.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - name: имя кампании.
+//   - logins: логины пользователей.
+//   - createdBy: автор начисления.
+//
+// Возвращаемые значения:
+//   - []string: логины, которым начисление выполнено.
+//   - error: ErrNoCampaign, если кампания не найдена или выключена; ошибка базы данных в остальных случаях.
+func (uc *UseCase) GrantCampaign(ctx context.Context, name string, logins []string, createdBy string) ([]string, error) {
+	var c entity.Campaign
+	err := uc.DB.QueryRowContext(ctx, selectActiveCampaign, name).Scan(&c.Name, &c.Kind, &c.Amount, &c.Reason, &c.Active, &c.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoCampaign
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	granted := make([]string, 0, len(logins))
+	for _, login := range logins {
+		ok, err := uc.grant(ctx, c, login, createdBy)
+		if err != nil {
+			return granted, err
+		}
+		if ok {
+			granted = append(granted, login)
+		}
+	}
+	return granted, nil
+}
+
+// applyWelcomeCampaigns начисляет новому пользователю баллы всех активных кампаний вида welcome в транзакции tx,
+// в которой создается пользователь, поэтому пользователь не может остаться без приветственного бонуса.
+func applyWelcomeCampaigns(ctx context.Context, tx *sql.Tx, login string) error {
+	rows, err := tx.QueryContext(ctx, selectWelcomeCampaigns)
+	if err != nil {
+		return fmt.Errorf("error selecting welcome campaigns: %v", err)
+	}
+	campaigns, err := scanCampaigns(rows)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range campaigns {
+		ok, err := grantTx(ctx, tx, c, login, CampaignSystem)
+		if err != nil {
+			return err
+		}
+		if ok {
+			l.L(ctx).Info("welcome bonus granted", "login", login, "campaign", c.Name, "amount", c.Amount)
+		}
+	}
+	return nil
+}
+
+// grant выполняет начисление по кампании одному пользователю в отдельной транзакции
+// и отправляет пользователю событие об изменении баланса.
+func (uc *UseCase) grant(ctx context.Context, c entity.Campaign, login, createdBy string) (bool, error) {
+	tx, err := uc.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("error beginning transaction grant method: %v", err)
+	}
+	defer tx.Rollback()
+
+	ok, err := grantTx(ctx, tx, c, login, createdBy)
+	if err != nil || !ok {
+		return false, err
+	}
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("error committing transaction grant method: %v", err)
+	}
+	uc.publishBalance(ctx, login)
+	return true, nil
+}
+
+// grantTx выполняет начисление по кампании одному пользователю в транзакции tx.
+// Отметка о начислении и изменение баланса выполняются в одной транзакции, поэтому начисление происходит ровно один раз.
+// Возвращает false, если пользователь уже получил начисление по кампании или не найден.
+func grantTx(ctx context.Context, tx *sql.Tx, c entity.Campaign, login, createdBy string) (bool, error) {
+	res, err := tx.ExecContext(ctx, insertCampaignGrant, c.Name, login, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("error inserting campaign grant: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		// Пользователь уже получил начисление по этой кампании
+		return false, nil
+	}
+
+	adj := entity.Adjustment{
+		Login:     login,
+		Amount:    c.Amount,
+		Reason:    c.Reason,
+		Campaign:  c.Name,
+		CreatedBy: createdBy,
+	}
+	err = adjust(ctx, tx, &adj)
+	if errors.Is(err, ErrUserNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// scanCampaigns читает промо-кампании из результата запроса и закрывает его.
+func scanCampaigns(rows *sql.Rows) ([]entity.Campaign, error) {
+	defer rows.Close()
+
+	campaigns := make([]entity.Campaign, 0)
+	for rows.Next() {
+		var c entity.Campaign
+		if err := rows.Scan(&c.Name, &c.Kind, &c.Amount, &c.Reason, &c.Active, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		campaigns = append(campaigns, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return campaigns, nil
+}
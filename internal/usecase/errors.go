@@ -3,72 +3,182 @@ package usecase
 
 import (
 	"errors"
+
+	"github.com/nextlag/gomart/pkg/passwd"
 )
 
 type ErrAll struct {
-	ErrNoLogin        error
-	ErrAuth           error
-	ErrToken          error
-	ErrInternalServer error
-	ErrRequest        error
-	ErrDecodeJSON     error
-	ErrUnauthorized   error
-	ErrNoCookie       error
-	ErrOrderNotFound  error
-	ErrThisUser       error
-	ErrAnotherUser    error
-	ErrOrderAccepted  error
-	ErrRequestFormat  error
-	ErrUnAuthUser     error
-	ErrOrderFormat    error
-	ErrGetOrders      error
-	ErrNoContent      error
-	ErrNoBalance      error
-	ErrNoRows         error
+	ErrNoLogin           error
+	ErrAuth              error
+	ErrToken             error
+	ErrInternalServer    error
+	ErrRequest           error
+	ErrDecodeJSON        error
+	ErrUnauthorized      error
+	ErrNoCookie          error
+	ErrOrderNotFound     error
+	ErrThisUser          error
+	ErrAnotherUser       error
+	ErrOrderAccepted     error
+	ErrRequestFormat     error
+	ErrUnAuthUser        error
+	ErrOrderFormat       error
+	ErrGetOrders         error
+	ErrNoContent         error
+	ErrNoBalance         error
+	ErrNoRows            error
+	ErrIdempotencyKey    error
+	ErrIdempotency       error
+	ErrInProgress        error
+	ErrUserNotFound      error
+	ErrForbidden         error
+	ErrReason            error
+	ErrCampaignExists    error
+	ErrNoCampaign        error
+	ErrCampaignKind      error
+	ErrWithdrawMax       error
+	ErrWithdrawDaily     error
+	ErrWithdrawRate      error
+	ErrCoolingOff        error
+	ErrRefreshToken      error
+	ErrTokenRevoked      error
+	ErrTooManyAttempts   error
+	ErrRole              error
+	ErrPassword          error
+	ErrResetToken        error
+	ErrPasswordPolicy    error
+	ErrAPIKey            error
+	ErrAPIScope          error
+	ErrNoAPIKey          error
+	ErrRateLimit         error
+	ErrOnBehalfOf        error
+	ErrNoSession         error
+	ErrOTPRequired       error
+	ErrOTPCode           error
+	ErrTOTPEnabled       error
+	ErrTOTPNotEnrolled   error
+	ErrOIDCDisabled      error
+	ErrOIDCState         error
+	ErrOIDCLogin         error
+	ErrOIDCAccountExists error
+	ErrCSRF              error
+	ErrNoOrder           error
 }
 
 var (
-	ErrNoLogin        = errors.New("login is already taken")
-	ErrAuth           = errors.New("authentication error")
-	ErrToken          = errors.New("signature is invalid")
-	ErrInternalServer = errors.New("insert server error")
-	ErrRequest        = errors.New("error request")
-	ErrDecodeJSON     = errors.New("failed to decode json")
-	ErrUnauthorized   = errors.New("incorrect login or password")
-	ErrNoCookie       = errors.New("can't set cookie")
-	ErrOrderNotFound  = errors.New("no such order exists")
-	ErrThisUser       = errors.New("the order number has already been uploaded by this user")
-	ErrAnotherUser    = errors.New("the order number has already been uploaded by another user")
-	ErrOrderAccepted  = errors.New("new order number accepted for processing")
-	ErrRequestFormat  = errors.New("invalid request format")
-	ErrUnAuthUser     = errors.New("user is not authenticated")
-	ErrOrderFormat    = errors.New("invalid order format")
-	ErrGetOrders      = errors.New("error getting orders")
-	ErrNoContent      = errors.New("no information to answer")
-	ErrNoBalance      = errors.New("not enough balance")
-	ErrNoRows         = errors.New("no rows were found")
+	ErrNoLogin           = errors.New("login is already taken")
+	ErrAuth              = errors.New("authentication error")
+	ErrToken             = errors.New("signature is invalid")
+	ErrInternalServer    = errors.New("internal server error")
+	ErrRequest           = errors.New("error request")
+	ErrDecodeJSON        = errors.New("failed to decode json")
+	ErrUnauthorized      = errors.New("incorrect login or password")
+	ErrNoCookie          = errors.New("can't set cookie")
+	ErrOrderNotFound     = errors.New("no such order exists")
+	ErrThisUser          = errors.New("the order number has already been uploaded by this user")
+	ErrAnotherUser       = errors.New("the order number has already been uploaded by another user")
+	ErrOrderAccepted     = errors.New("new order number accepted for processing")
+	ErrRequestFormat     = errors.New("invalid request format")
+	ErrUnAuthUser        = errors.New("user is not authenticated")
+	ErrOrderFormat       = errors.New("invalid order format")
+	ErrGetOrders         = errors.New("error getting orders")
+	ErrNoContent         = errors.New("no information to answer")
+	ErrNoBalance         = errors.New("not enough balance")
+	ErrNoRows            = errors.New("no rows were found")
+	ErrIdempotencyKey    = errors.New("invalid idempotency key")
+	ErrIdempotency       = errors.New("idempotency key has already been used with a different request")
+	ErrInProgress        = errors.New("a request with this idempotency key is still in progress")
+	ErrUserNotFound      = errors.New("user not found")
+	ErrForbidden         = errors.New("access denied")
+	ErrReason            = errors.New("reason is required")
+	ErrCampaignExists    = errors.New("campaign already exists")
+	ErrNoCampaign        = errors.New("campaign not found or inactive")
+	ErrCampaignKind      = errors.New("invalid campaign kind")
+	ErrWithdrawMax       = errors.New("withdrawal exceeds the per-withdrawal limit")
+	ErrWithdrawDaily     = errors.New("withdrawal exceeds the daily limit")
+	ErrWithdrawRate      = errors.New("too many withdrawals in the last hour")
+	ErrCoolingOff        = errors.New("withdrawals are blocked after a recent password change")
+	ErrRefreshToken      = errors.New("refresh token is invalid or expired")
+	ErrTokenRevoked      = errors.New("token has been revoked")
+	ErrTooManyAttempts   = errors.New("too many failed login attempts, try again later")
+	ErrRole              = errors.New("unknown role")
+	ErrPassword          = errors.New("current password is incorrect")
+	ErrResetToken        = errors.New("password reset token is invalid or expired")
+	ErrPasswordPolicy    = passwd.ErrPolicy
+	ErrAPIKey            = errors.New("invalid or revoked API key")
+	ErrAPIScope          = errors.New("unknown API key scope")
+	ErrNoAPIKey          = errors.New("no such API key")
+	ErrRateLimit         = errors.New("API key rate limit exceeded, try again later")
+	ErrOnBehalfOf        = errors.New("X-On-Behalf-Of header is required for API key requests")
+	ErrNoSession         = errors.New("no such session")
+	ErrOTPRequired       = errors.New("one-time code required")
+	ErrOTPCode           = errors.New("invalid one-time code")
+	ErrTOTPEnabled       = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotEnrolled   = errors.New("two-factor authentication enrollment has not been started")
+	ErrOIDCDisabled      = errors.New("single sign-on is not configured")
+	ErrOIDCState         = errors.New("invalid or expired single sign-on state")
+	ErrOIDCLogin         = errors.New("single sign-on login failed")
+	ErrOIDCAccountExists = errors.New("an account with this login already exists; sign in with your password")
+	ErrCSRF              = errors.New("missing or invalid CSRF token")
+	ErrNoOrder           = errors.New("order not found")
 )
 
 func (uc *UseCase) Err() *ErrAll {
 	return &ErrAll{
-		ErrNoLogin:        ErrNoLogin,
-		ErrAuth:           ErrAuth,
-		ErrToken:          ErrToken,
-		ErrInternalServer: ErrInternalServer,
-		ErrRequest:        ErrRequest,
-		ErrDecodeJSON:     ErrDecodeJSON,
-		ErrUnauthorized:   ErrUnauthorized,
-		ErrNoCookie:       ErrNoCookie,
-		ErrOrderNotFound:  ErrOrderNotFound,
-		ErrThisUser:       ErrThisUser,
-		ErrAnotherUser:    ErrAnotherUser,
-		ErrOrderAccepted:  ErrOrderAccepted,
-		ErrRequestFormat:  ErrRequestFormat,
-		ErrUnAuthUser:     ErrUnAuthUser,
-		ErrOrderFormat:    ErrOrderFormat,
-		ErrGetOrders:      ErrGetOrders,
-		ErrNoContent:      ErrNoContent,
-		ErrNoBalance:      ErrNoBalance,
-		ErrNoRows:         ErrNoRows,
+		ErrNoLogin:           ErrNoLogin,
+		ErrAuth:              ErrAuth,
+		ErrToken:             ErrToken,
+		ErrInternalServer:    ErrInternalServer,
+		ErrRequest:           ErrRequest,
+		ErrDecodeJSON:        ErrDecodeJSON,
+		ErrUnauthorized:      ErrUnauthorized,
+		ErrNoCookie:          ErrNoCookie,
+		ErrOrderNotFound:     ErrOrderNotFound,
+		ErrThisUser:          ErrThisUser,
+		ErrAnotherUser:       ErrAnotherUser,
+		ErrOrderAccepted:     ErrOrderAccepted,
+		ErrRequestFormat:     ErrRequestFormat,
+		ErrUnAuthUser:        ErrUnAuthUser,
+		ErrOrderFormat:       ErrOrderFormat,
+		ErrGetOrders:         ErrGetOrders,
+		ErrNoContent:         ErrNoContent,
+		ErrNoBalance:         ErrNoBalance,
+		ErrNoRows:            ErrNoRows,
+		ErrIdempotencyKey:    ErrIdempotencyKey,
+		ErrIdempotency:       ErrIdempotency,
+		ErrInProgress:        ErrInProgress,
+		ErrUserNotFound:      ErrUserNotFound,
+		ErrForbidden:         ErrForbidden,
+		ErrReason:            ErrReason,
+		ErrCampaignExists:    ErrCampaignExists,
+		ErrNoCampaign:        ErrNoCampaign,
+		ErrCampaignKind:      ErrCampaignKind,
+		ErrWithdrawMax:       ErrWithdrawMax,
+		ErrWithdrawDaily:     ErrWithdrawDaily,
+		ErrWithdrawRate:      ErrWithdrawRate,
+		ErrCoolingOff:        ErrCoolingOff,
+		ErrRefreshToken:      ErrRefreshToken,
+		ErrTokenRevoked:      ErrTokenRevoked,
+		ErrTooManyAttempts:   ErrTooManyAttempts,
+		ErrRole:              ErrRole,
+		ErrPassword:          ErrPassword,
+		ErrResetToken:        ErrResetToken,
+		ErrPasswordPolicy:    ErrPasswordPolicy,
+		ErrAPIKey:            ErrAPIKey,
+		ErrAPIScope:          ErrAPIScope,
+		ErrNoAPIKey:          ErrNoAPIKey,
+		ErrRateLimit:         ErrRateLimit,
+		ErrOnBehalfOf:        ErrOnBehalfOf,
+		ErrNoSession:         ErrNoSession,
+		ErrOTPRequired:       ErrOTPRequired,
+		ErrOTPCode:           ErrOTPCode,
+		ErrTOTPEnabled:       ErrTOTPEnabled,
+		ErrTOTPNotEnrolled:   ErrTOTPNotEnrolled,
+		ErrOIDCDisabled:      ErrOIDCDisabled,
+		ErrOIDCState:         ErrOIDCState,
+		ErrOIDCLogin:         ErrOIDCLogin,
+		ErrOIDCAccountExists: ErrOIDCAccountExists,
+		ErrCSRF:              ErrCSRF,
+		ErrNoOrder:           ErrNoOrder,
 	}
 }
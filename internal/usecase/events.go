@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/nextlag/gomart/internal/entity"
+	"github.com/nextlag/gomart/pkg/events"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+// Типы событий, доставляемых пользователям в реальном времени.
+const (
+	EventOrder   = "order"   // Изменился статус расчета начислений по заказу
+	EventBalance = "balance" // Изменился баланс пользователя
+)
+
+const selectUserBalance = `
+	SELECT balance, withdrawn
+	FROM users
+	WHERE login = $1
+`
+
+// Subscribe подписывает на события пользователя login, начиная с события после lastID.
+// Возвращает false, если часть событий после lastID уже не хранится.
+func (uc *UseCase) Subscribe(login string, lastID uint64) (*events.Subscription, bool) {
+	return uc.Events.Subscribe(login, lastID)
+}
+
+// publishOrder отправляет пользователю событие с текущим состоянием заказа после фиксации его изменения.
+// Ошибки только логируются: событие не должно влиять на уже выполненную операцию.
+func (uc *UseCase) publishOrder(ctx context.Context, login, number string) {
+	if uc.Events == nil {
+		return
+	}
+	var order entity.Order
+	err := uc.DB.QueryRowContext(ctx, selectUserOrder, number, login).
+		Scan(&order.Order, &order.Status, &order.Accrual, &order.UploadedAt)
+	if err == nil {
+		_, err = uc.Events.Publish(login, EventOrder, order)
+	}
+	if err != nil {
+		l.L(ctx).Error("error publishing order event", "login", login, "order", number, l.ErrAttr(err))
+	}
+}
+
+// publishBalance отправляет пользователю событие с текущим балансом после фиксации его изменения.
+// Ошибки только логируются: событие не должно влиять на уже выполненную операцию.
+func (uc *UseCase) publishBalance(ctx context.Context, login string) {
+	if uc.Events == nil {
+		return
+	}
+	var balance entity.Balance
+	err := uc.DB.QueryRowContext(ctx, selectUserBalance, login).Scan(&balance.Current, &balance.Withdrawn)
+	if err == nil {
+		_, err = uc.Events.Publish(login, EventBalance, balance)
+	}
+	if err != nil {
+		l.L(ctx).Error("error publishing balance event", "login", login, l.ErrAttr(err))
+	}
+}
@@ -0,0 +1,208 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/nextlag/gomart/internal/entity"
+)
+
+const (
+	// historyPageLimit - размер страницы заказов и списаний по умолчанию
+	historyPageLimit = 50
+	// historyMaxLimit - наибольший допустимый размер страницы заказов и списаний
+	historyMaxLimit = 1000
+	// cursorTimeLayout - формат времени загрузки заказа в курсоре страницы
+	cursorTimeLayout = "2006-01-02T15:04:05.999999999"
+)
+
+const (
+	selectOrdersPage = `
+		SELECT "order", status, accrual, uploaded_at
+		FROM orders
+		WHERE user_name = $1
+	`
+	selectWithdrawalsPage = `
+		SELECT "order", bonuses_withdrawn, uploaded_at
+		FROM orders
+		WHERE user_name = $1 AND bonuses_withdrawn != 0
+	`
+)
+
+// historyCursor - позиция записи в истории заказов и списаний: время загрузки и номер заказа.
+type historyCursor struct {
+	uploadedAt time.Time
+	order      string
+}
+
+// encode возвращает курсор в виде строки для передачи клиенту.
+func (c historyCursor) encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.uploadedAt.Format(cursorTimeLayout) + "," + c.order))
+}
+
+// decodeHistoryCursor разбирает курсор, полученный от клиента.
+func decodeHistoryCursor(s string) (historyCursor, error) {
+	var c historyCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrRequestFormat
+	}
+	uploadedAt, order, ok := strings.Cut(string(b), ",")
+	if !ok || order == "" {
+		return c, ErrRequestFormat
+	}
+	if c.uploadedAt, err = time.Parse(cursorTimeLayout, uploadedAt); err != nil {
+		return c, ErrRequestFormat
+	}
+	c.order = order
+	return c, nil
+}
+
+// historyQuery дополняет запрос base условиями фильтра f, порядком сортировки и ограничением
+// на одну запись больше размера страницы, чтобы определить, есть ли следующая страница.
+//
+// Параметры:
+//   - base: запрос с условием на пользователя в параметре $1.
+//   - user: логин пользователя.
+//   - f: параметры страницы.
+//
+// Возвращаемые значения:
+//   - string: текст запроса.
+//   - []any: параметры запроса.
+//   - int: размер страницы.
+//   - error: ErrRequestFormat при некорректном размере страницы, курсоре или статусе.
+func historyQuery(base, user string, f entity.HistoryFilter) (string, []any, int, error) {
+	limit := f.Limit
+	switch {
+	case limit == 0:
+		limit = historyPageLimit
+	case limit < 0 || limit > historyMaxLimit:
+		return "", nil, 0, ErrRequestFormat
+	case slices.ContainsFunc(f.Status, func(s string) bool { return !slices.Contains(entity.OrderStatuses, s) }):
+		return "", nil, 0, ErrRequestFormat
+	}
+
+	var q strings.Builder
+	q.WriteString(base)
+	args := []any{user}
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if len(f.Status) > 0 {
+		q.WriteString(" AND status = ANY(" + arg(pq.Array(f.Status)) + ")")
+	}
+	if !f.From.IsZero() {
+		q.WriteString(" AND uploaded_at >= " + arg(f.From))
+	}
+	if !f.To.IsZero() {
+		q.WriteString(" AND uploaded_at < " + arg(f.To))
+	}
+
+	order, cmp := "ASC", ">"
+	if f.Desc {
+		order, cmp = "DESC", "<"
+	}
+	if f.Cursor != "" {
+		c, err := decodeHistoryCursor(f.Cursor)
+		if err != nil {
+			return "", nil, 0, err
+		}
+		q.WriteString(fmt.Sprintf(` AND (uploaded_at, "order") %s (%s, %s)`, cmp, arg(c.uploadedAt), arg(c.order)))
+	}
+	q.WriteString(fmt.Sprintf(` ORDER BY uploaded_at %s, "order" %s LIMIT %s`, order, order, arg(limit+1)))
+	return q.String(), args, limit, nil
+}
+
+// GetOrdersPage возвращает страницу заказов пользователя, упорядоченных по времени загрузки.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - user: логин пользователя.
+//   - f: размер страницы, курсор, фильтры по статусу и периоду и направление сортировки.
+//
+// Возвращаемые значения:
+//   - []entity.Order: заказы страницы.
+//   - string: курсор следующей страницы; пустая строка, если страница последняя.
+//   - error: ErrRequestFormat при некорректных параметрах страницы, ошибка базы данных в остальных случаях.
+func (uc *UseCase) GetOrdersPage(ctx context.Context, user string, f entity.HistoryFilter) ([]entity.Order, string, error) {
+	query, args, limit, err := historyQuery(selectOrdersPage, user, f)
+	if err != nil {
+		return nil, "", err
+	}
+	rows, err := uc.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("error selecting orders: %v", err)
+	}
+	defer rows.Close()
+
+	orders := make([]entity.Order, 0, limit)
+	for rows.Next() {
+		var order entity.Order
+		if err = rows.Scan(&order.Order, &order.Status, &order.Accrual, &order.UploadedAt); err != nil {
+			return nil, "", err
+		}
+		orders = append(orders, order)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	if len(orders) <= limit {
+		return orders, "", nil
+	}
+	orders = orders[:limit]
+	last := orders[limit-1]
+	return orders, historyCursor{uploadedAt: last.UploadedAt, order: last.Order}.encode(), nil
+}
+
+// GetWithdrawalsPage возвращает страницу списаний пользователя, упорядоченных по времени списания.
+// Фильтр по статусу заказа к списаниям также применяется.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - user: логин пользователя.
+//   - f: размер страницы, курсор, фильтры по статусу и периоду и направление сортировки.
+//
+// Возвращаемые значения:
+//   - []entity.Withdrawal: списания страницы.
+//   - string: курсор следующей страницы; пустая строка, если страница последняя.
+//   - error: ErrRequestFormat при некорректных параметрах страницы, ошибка базы данных в остальных случаях.
+func (uc *UseCase) GetWithdrawalsPage(ctx context.Context, user string, f entity.HistoryFilter) ([]entity.Withdrawal, string, error) {
+	query, args, limit, err := historyQuery(selectWithdrawalsPage, user, f)
+	if err != nil {
+		return nil, "", err
+	}
+	rows, err := uc.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("error selecting withdrawals: %v", err)
+	}
+	defer rows.Close()
+
+	withdrawals := make([]entity.Withdrawal, 0, limit)
+	for rows.Next() {
+		var w entity.Withdrawal
+		if err = rows.Scan(&w.Order, &w.Sum, &w.ProcessedAt); err != nil {
+			return nil, "", err
+		}
+		withdrawals = append(withdrawals, w)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	if len(withdrawals) <= limit {
+		return withdrawals, "", nil
+	}
+	withdrawals = withdrawals[:limit]
+	last := withdrawals[limit-1]
+	return withdrawals, historyCursor{uploadedAt: last.ProcessedAt, order: last.Order}.encode(), nil
+}
@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nextlag/gomart/internal/config"
+	"github.com/nextlag/gomart/internal/entity"
+)
+
+const (
+	deleteExpiredIdempotency = `
+		DELETE FROM idempotency_keys
+		WHERE login = $1 AND key = $2 AND created_at < $3
+	`
+	insertIdempotency = `
+		INSERT INTO idempotency_keys (login, key, request_hash, status, created_at)
+		VALUES ($1, $2, $3, 0, $4)
+		ON CONFLICT (login, key) DO NOTHING
+	`
+	selectIdempotency = `
+		SELECT login, key, request_hash, status, COALESCE(content_type, ''), body, created_at
+		FROM idempotency_keys
+		WHERE login = $1 AND key = $2
+	`
+	updateIdempotency = `
+		UPDATE idempotency_keys
+		SET status = $3, content_type = $4, body = $5
+		WHERE login = $1 AND key = $2
+	`
+	deleteIdempotency = `
+		DELETE FROM idempotency_keys
+		WHERE login = $1 AND key = $2
+	`
+)
+
+// ReserveIdempotencyKey резервирует ключ идемпотентности пользователя под новый запрос.
+// Записи старше config.Cfg.IdempotencyTTL предварительно удаляются, поэтому ключ после истечения TTL
+// можно использовать повторно.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - login: логин пользователя.
+//   - key: значение заголовка Idempotency-Key.
+//   - hash: хеш тела запроса.
+//
+// Возвращаемые значения:
+//   - *entity.Idempotency: nil, если ключ успешно зарезервирован, иначе ранее сохраненная запись.
+//   - error: ошибка при выполнении запроса к базе данных.
+func (uc *UseCase) ReserveIdempotencyKey(ctx context.Context, login, key, hash string) (*entity.Idempotency, error) {
+	now := time.Now()
+
+	_, err := uc.DB.ExecContext(ctx, deleteExpiredIdempotency, login, key, now.Add(-config.Cfg.IdempotencyTTL))
+	if err != nil {
+		return nil, fmt.Errorf("error deleting expired idempotency key: %v", err)
+	}
+
+	res, err := uc.DB.ExecContext(ctx, insertIdempotency, login, key, hash, now)
+	if err != nil {
+		return nil, fmt.Errorf("error reserving idempotency key: %v", err)
+	}
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if inserted == 1 {
+		// Ключ новый - запрос можно выполнять
+		return nil, nil
+	}
+
+	// Ключ уже использовался - возвращаем сохраненную запись
+	var record entity.Idempotency
+	err = uc.DB.QueryRowContext(ctx, selectIdempotency, login, key).Scan(
+		&record.Login,
+		&record.Key,
+		&record.RequestHash,
+		&record.Status,
+		&record.ContentType,
+		&record.Body,
+		&record.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Запись успели удалить между вставкой и чтением - считаем, что запрос еще выполняется
+		return &entity.Idempotency{Login: login, Key: key, RequestHash: hash}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// SaveIdempotencyResponse сохраняет ответ на запрос, выполненный с ключом идемпотентности.
+func (uc *UseCase) SaveIdempotencyResponse(ctx context.Context, login, key string, status int, contentType string, body []byte) error {
+	_, err := uc.DB.ExecContext(ctx, updateIdempotency, login, key, status, contentType, body)
+	if err != nil {
+		return fmt.Errorf("error saving idempotent response: %v", err)
+	}
+	return nil
+}
+
+// DeleteIdempotencyKey освобождает ключ идемпотентности, например, если запрос завершился ошибкой сервера.
+func (uc *UseCase) DeleteIdempotencyKey(ctx context.Context, login, key string) error {
+	_, err := uc.DB.ExecContext(ctx, deleteIdempotency, login, key)
+	if err != nil {
+		return fmt.Errorf("error deleting idempotency key: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nextlag/gomart/internal/config"
+	"github.com/nextlag/gomart/internal/entity"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+// Правила ограничения списаний, сохраняемые в журнале нарушений.
+const (
+	RuleMaxSum          = "max_per_withdrawal"
+	RuleMaxDailySum     = "max_per_day"
+	RuleMaxHourlyCount  = "max_per_hour"
+	RulePasswordCooloff = "password_cooling_off"
+)
+
+// ruleErrors сопоставляет ошибку правила с его именем в журнале нарушений.
+var ruleErrors = map[error]string{
+	ErrWithdrawMax:   RuleMaxSum,
+	ErrWithdrawDaily: RuleMaxDailySum,
+	ErrWithdrawRate:  RuleMaxHourlyCount,
+	ErrCoolingOff:    RulePasswordCooloff,
+}
+
+const (
+	selectRecentWithdrawals = `
+		SELECT COALESCE(SUM(bonuses_withdrawn), 0), COUNT(*) FILTER (WHERE uploaded_at >= $3)
+		FROM orders
+		WHERE user_name = $1 AND bonuses_withdrawn != 0 AND uploaded_at >= $2
+	`
+	selectPasswordChangedAt = `
+		SELECT password_changed_at
+		FROM users
+		WHERE login = $1
+	`
+	selectUserWithdrawLimits = `
+		SELECT max_sum, max_daily_sum, max_hourly_count, updated_by, updated_at
+		FROM withdraw_limits
+		WHERE login = $1
+	`
+	upsertWithdrawLimits = `
+		INSERT INTO withdraw_limits (login, max_sum, max_daily_sum, max_hourly_count, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (login) DO UPDATE
+		SET max_sum = $2, max_daily_sum = $3, max_hourly_count = $4, updated_by = $5, updated_at = $6
+	`
+	insertViolation = `
+		INSERT INTO withdrawal_violations (login, "order", sum, rule, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	selectViolations = `
+		SELECT id, login, "order", sum, rule, created_at
+		FROM withdrawal_violations
+		WHERE $1 = '' OR login = $1
+		ORDER BY created_at DESC
+		LIMIT 1000
+	`
+)
+
+// withdrawUsage описывает предыдущие списания пользователя, по которым проверяются правила ограничения.
+type withdrawUsage struct {
+	daily             float32   // Сумма списаний за последние 24 часа
+	hourly            int       // Количество списаний за последний час
+	passwordChangedAt time.Time // Время последней смены пароля; нулевое, если пароль не менялся
+}
+
+// checkWithdrawalLimits проверяет правила config.Cfg.WithdrawLimits с учетом ограничений пользователя
+// перед списанием суммы sum в рамках транзакции tx.
+// Строка пользователя должна быть заблокирована вызывающей стороной, чтобы параллельные списания
+// не обошли суточный и часовой лимиты.
+//
+// Возвращаемое значение:
+//   - error: ErrWithdrawMax, ErrWithdrawDaily, ErrWithdrawRate или ErrCoolingOff при нарушении правила,
+//     ошибка базы данных в остальных случаях.
+func checkWithdrawalLimits(ctx context.Context, tx *sql.Tx, user string, sum float32, now time.Time) error {
+	userLimits, err := selectWithdrawLimits(ctx, tx, user)
+	if err != nil {
+		return err
+	}
+	limits := effectiveLimits(config.Cfg.WithdrawLimits, userLimits)
+
+	var usage withdrawUsage
+	if limits.PasswordCooloff > 0 {
+		var changedAt sql.NullTime
+		if err = tx.QueryRowContext(ctx, selectPasswordChangedAt, user).Scan(&changedAt); err != nil {
+			return fmt.Errorf("error selecting password change time: %v", err)
+		}
+		usage.passwordChangedAt = changedAt.Time
+	}
+	if limits.MaxDailySum > 0 || limits.MaxHourlyCount > 0 {
+		err = tx.QueryRowContext(ctx, selectRecentWithdrawals, user, now.Add(-24*time.Hour), now.Add(-time.Hour)).
+			Scan(&usage.daily, &usage.hourly)
+		if err != nil {
+			return fmt.Errorf("error selecting recent withdrawals: %v", err)
+		}
+	}
+	return checkLimits(limits, sum, usage, now)
+}
+
+// checkLimits проверяет списание суммы sum в момент now по правилам limits и предыдущим списаниям usage.
+func checkLimits(limits config.WithdrawLimits, sum float32, usage withdrawUsage, now time.Time) error {
+	switch {
+	case limits.MaxSum > 0 && sum > limits.MaxSum:
+		return ErrWithdrawMax
+	case limits.PasswordCooloff > 0 && !usage.passwordChangedAt.IsZero() &&
+		now.Sub(usage.passwordChangedAt) < limits.PasswordCooloff:
+		return ErrCoolingOff
+	case limits.MaxDailySum > 0 && usage.daily+sum > limits.MaxDailySum:
+		return ErrWithdrawDaily
+	case limits.MaxHourlyCount > 0 && usage.hourly >= limits.MaxHourlyCount:
+		return ErrWithdrawRate
+	}
+	return nil
+}
+
+// effectiveLimits возвращает общие ограничения global, замененные ограничениями, назначенными пользователю.
+func effectiveLimits(global config.WithdrawLimits, user entity.WithdrawLimits) config.WithdrawLimits {
+	if user.MaxSum != nil {
+		global.MaxSum = *user.MaxSum
+	}
+	if user.MaxDailySum != nil {
+		global.MaxDailySum = *user.MaxDailySum
+	}
+	if user.MaxHourlyCount != nil {
+		global.MaxHourlyCount = *user.MaxHourlyCount
+	}
+	return global
+}
+
+// queryRower - база данных или транзакция, в которой выполняется запрос одной строки.
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// selectWithdrawLimits возвращает ограничения, назначенные пользователю; если их нет, поля ограничений пустые.
+func selectWithdrawLimits(ctx context.Context, q queryRower, login string) (entity.WithdrawLimits, error) {
+	var (
+		limits    = entity.WithdrawLimits{Login: login}
+		maxSum    sql.NullFloat64
+		maxDaily  sql.NullFloat64
+		maxHourly sql.NullInt64
+		updatedAt time.Time
+	)
+	err := q.QueryRowContext(ctx, selectUserWithdrawLimits, login).Scan(&maxSum, &maxDaily, &maxHourly, &limits.UpdatedBy, &updatedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return limits, nil
+	case err != nil:
+		return limits, fmt.Errorf("error selecting user withdraw limits: %v", err)
+	}
+	if maxSum.Valid {
+		v := float32(maxSum.Float64)
+		limits.MaxSum = &v
+	}
+	if maxDaily.Valid {
+		v := float32(maxDaily.Float64)
+		limits.MaxDailySum = &v
+	}
+	if maxHourly.Valid {
+		v := int(maxHourly.Int64)
+		limits.MaxHourlyCount = &v
+	}
+	limits.UpdatedAt = &updatedAt
+	return limits, nil
+}
+
+// GetWithdrawLimits возвращает ограничения на списание, назначенные пользователю.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - login: логин пользователя.
+//
+// Возвращаемые значения:
+//   - entity.WithdrawLimits: ограничения пользователя; пустые поля означают общие ограничения из конфигурации.
+//   - error: ErrUserNotFound, если пользователь не найден; ошибка базы данных в остальных случаях.
+func (uc *UseCase) GetWithdrawLimits(ctx context.Context, login string) (entity.WithdrawLimits, error) {
+	if err := uc.checkUserExists(ctx, login); err != nil {
+		return entity.WithdrawLimits{}, err
+	}
+	return selectWithdrawLimits(ctx, uc.DB, login)
+}
+
+// checkUserExists возвращает ErrUserNotFound, если пользователя login нет.
+func (uc *UseCase) checkUserExists(ctx context.Context, login string) error {
+	var exists bool
+	if err := uc.DB.QueryRowContext(ctx, selectUserExists, login).Scan(&exists); err != nil {
+		return fmt.Errorf("error selecting user: %v", err)
+	}
+	if !exists {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// SetWithdrawLimits назначает пользователю ограничения на списание вместо общих ограничений из конфигурации.
+// Пустое поле возвращает пользователю общее ограничение, нулевое значение отключает правило для пользователя.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - limits: ограничения с логином пользователя и автором изменения.
+//
+// Возвращаемые значения:
+//   - entity.WithdrawLimits: сохраненные ограничения.
+//   - error: ErrRequestFormat при отрицательном значении, ErrUserNotFound, если пользователь не найден;
+//     ошибка базы данных в остальных случаях.
+func (uc *UseCase) SetWithdrawLimits(ctx context.Context, limits entity.WithdrawLimits) (entity.WithdrawLimits, error) {
+	if limits.MaxSum != nil && *limits.MaxSum < 0 ||
+		limits.MaxDailySum != nil && *limits.MaxDailySum < 0 ||
+		limits.MaxHourlyCount != nil && *limits.MaxHourlyCount < 0 {
+		return limits, ErrRequestFormat
+	}
+
+	if err := uc.checkUserExists(ctx, limits.Login); err != nil {
+		return limits, err
+	}
+
+	now := time.Now()
+	limits.UpdatedAt = &now
+	_, err := uc.DB.ExecContext(ctx, upsertWithdrawLimits,
+		limits.Login, limits.MaxSum, limits.MaxDailySum, limits.MaxHourlyCount, limits.UpdatedBy, now)
+	if err != nil {
+		return limits, fmt.Errorf("error updating user withdraw limits: %v", err)
+	}
+	return limits, nil
+}
+
+// recordViolation сохраняет в журнале отклоненное правилами списание, если ошибка относится к правилам ограничения.
+// Запись выполняется вне транзакции списания, которая после нарушения откатывается.
+func (uc *UseCase) recordViolation(ctx context.Context, user, order string, sum float32, err error) {
+	var rule string
+	for ruleErr, name := range ruleErrors {
+		if errors.Is(err, ruleErr) {
+			rule = name
+		}
+	}
+	if rule == "" {
+		return
+	}
+
+	l.L(ctx).Info("withdrawal rejected by limits", "user", user, "order", order, "sum", sum, "rule", rule)
+	if _, dbErr := uc.DB.ExecContext(ctx, insertViolation, user, order, sum, rule, time.Now()); dbErr != nil {
+		l.L(ctx).Error("error recording withdrawal violation", l.ErrAttr(dbErr))
+	}
+}
+
+// GetWithdrawalViolations возвращает последние отклоненные правилами списания для проверки.
+// Пустой login возвращает нарушения всех пользователей.
+func (uc *UseCase) GetWithdrawalViolations(ctx context.Context, login string) ([]entity.WithdrawalViolation, error) {
+	violations := make([]entity.WithdrawalViolation, 0)
+
+	rows, err := uc.DB.QueryContext(ctx, selectViolations, login)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v entity.WithdrawalViolation
+		if err = rows.Scan(&v.ID, &v.Login, &v.Order, &v.Sum, &v.Rule, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		violations = append(violations, v)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return violations, nil
+}
@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nextlag/gomart/internal/config"
+	"github.com/nextlag/gomart/internal/entity"
+)
+
+func TestEffectiveLimits(t *testing.T) {
+	global := config.WithdrawLimits{MaxSum: 1000, MaxDailySum: 5000, MaxHourlyCount: 3, PasswordCooloff: time.Hour}
+	maxSum, disabled := float32(100), 0
+
+	// Пустые поля оставляют общие ограничения
+	assert.Equal(t, global, effectiveLimits(global, entity.WithdrawLimits{Login: "test"}))
+
+	got := effectiveLimits(global, entity.WithdrawLimits{Login: "test", MaxSum: &maxSum, MaxHourlyCount: &disabled})
+	assert.Equal(t, config.WithdrawLimits{MaxSum: 100, MaxDailySum: 5000, MaxHourlyCount: 0, PasswordCooloff: time.Hour}, got)
+}
+
+func TestCheckLimits(t *testing.T) {
+	now := time.Date(2024, time.January, 2, 12, 0, 0, 0, time.UTC)
+	limits := config.WithdrawLimits{MaxSum: 1000, MaxDailySum: 1500, MaxHourlyCount: 2, PasswordCooloff: 24 * time.Hour}
+
+	tests := []struct {
+		name   string
+		limits config.WithdrawLimits
+		sum    float32
+		usage  withdrawUsage
+		want   error
+	}{
+		{
+			name:   "Within limits",
+			limits: limits,
+			sum:    500,
+			usage:  withdrawUsage{daily: 500, hourly: 1, passwordChangedAt: now.Add(-48 * time.Hour)},
+		},
+		{
+			name:   "Single withdrawal too large",
+			limits: limits,
+			sum:    1001,
+			want:   ErrWithdrawMax,
+		},
+		{
+			name:   "Password changed recently",
+			limits: limits,
+			sum:    100,
+			usage:  withdrawUsage{passwordChangedAt: now.Add(-time.Hour)},
+			want:   ErrCoolingOff,
+		},
+		{
+			name:   "Daily sum exceeded",
+			limits: limits,
+			sum:    600,
+			usage:  withdrawUsage{daily: 1000},
+			want:   ErrWithdrawDaily,
+		},
+		{
+			name:   "Too many withdrawals per hour",
+			limits: limits,
+			sum:    100,
+			usage:  withdrawUsage{hourly: 2},
+			want:   ErrWithdrawRate,
+		},
+		{
+			name:  "Rules disabled",
+			sum:   100000,
+			usage: withdrawUsage{daily: 100000, hourly: 100, passwordChangedAt: now},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, checkLimits(tt.limits, tt.sum, tt.usage, now), tt.want)
+		})
+	}
+}
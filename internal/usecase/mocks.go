@@ -7,8 +7,11 @@ package usecase
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	entity "github.com/nextlag/gomart/internal/entity"
+	events "github.com/nextlag/gomart/pkg/events"
 )
 
 // MockRepository is a mock of Repository interface.
@@ -34,6 +37,21 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AdjustBalance mocks base method.
+func (m *MockRepository) AdjustBalance(arg0 context.Context, arg1 entity.Adjustment) (entity.Adjustment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdjustBalance", arg0, arg1)
+	ret0, _ := ret[0].(entity.Adjustment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdjustBalance indicates an expected call of AdjustBalance.
+func (mr *MockRepositoryMockRecorder) AdjustBalance(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdjustBalance", reflect.TypeOf((*MockRepository)(nil).AdjustBalance), arg0, arg1)
+}
+
 // Auth mocks base method.
 func (m *MockRepository) Auth(arg0 context.Context, arg1, arg2 string) error {
 	m.ctrl.T.Helper()
@@ -48,6 +66,125 @@ func (mr *MockRepositoryMockRecorder) Auth(arg0, arg1, arg2 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Auth", reflect.TypeOf((*MockRepository)(nil).Auth), arg0, arg1, arg2)
 }
 
+// AuthenticateAPIKey mocks base method.
+func (m *MockRepository) AuthenticateAPIKey(arg0 context.Context, arg1, arg2 string) (entity.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateAPIKey", arg0, arg1, arg2)
+	ret0, _ := ret[0].(entity.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticateAPIKey indicates an expected call of AuthenticateAPIKey.
+func (mr *MockRepositoryMockRecorder) AuthenticateAPIKey(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateAPIKey", reflect.TypeOf((*MockRepository)(nil).AuthenticateAPIKey), arg0, arg1, arg2)
+}
+
+// ChangePassword mocks base method.
+func (m *MockRepository) ChangePassword(arg0 context.Context, arg1, arg2, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockRepositoryMockRecorder) ChangePassword(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockRepository)(nil).ChangePassword), arg0, arg1, arg2, arg3)
+}
+
+// ConfirmTOTP mocks base method.
+func (m *MockRepository) ConfirmTOTP(arg0 context.Context, arg1, arg2 string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTOTP", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmTOTP indicates an expected call of ConfirmTOTP.
+func (mr *MockRepositoryMockRecorder) ConfirmTOTP(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTOTP", reflect.TypeOf((*MockRepository)(nil).ConfirmTOTP), arg0, arg1, arg2)
+}
+
+// ConsumeOIDCChallenge mocks base method.
+func (m *MockRepository) ConsumeOIDCChallenge(arg0 context.Context, arg1 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeOIDCChallenge", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeOIDCChallenge indicates an expected call of ConsumeOIDCChallenge.
+func (mr *MockRepositoryMockRecorder) ConsumeOIDCChallenge(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeOIDCChallenge", reflect.TypeOf((*MockRepository)(nil).ConsumeOIDCChallenge), arg0, arg1)
+}
+
+// CountAPIKeyRequest mocks base method.
+func (m *MockRepository) CountAPIKeyRequest(arg0 context.Context, arg1 string, arg2 int) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountAPIKeyRequest", arg0, arg1, arg2)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountAPIKeyRequest indicates an expected call of CountAPIKeyRequest.
+func (mr *MockRepositoryMockRecorder) CountAPIKeyRequest(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountAPIKeyRequest", reflect.TypeOf((*MockRepository)(nil).CountAPIKeyRequest), arg0, arg1, arg2)
+}
+
+// CreateAPIKey mocks base method.
+func (m *MockRepository) CreateAPIKey(arg0 context.Context, arg1 entity.APIKey) (entity.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(entity.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockRepositoryMockRecorder) CreateAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockRepository)(nil).CreateAPIKey), arg0, arg1)
+}
+
+// CreateCampaign mocks base method.
+func (m *MockRepository) CreateCampaign(arg0 context.Context, arg1 entity.Campaign) (entity.Campaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCampaign", arg0, arg1)
+	ret0, _ := ret[0].(entity.Campaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCampaign indicates an expected call of CreateCampaign.
+func (mr *MockRepositoryMockRecorder) CreateCampaign(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCampaign", reflect.TypeOf((*MockRepository)(nil).CreateCampaign), arg0, arg1)
+}
+
+// CreateOIDCChallenge mocks base method.
+func (m *MockRepository) CreateOIDCChallenge(arg0 context.Context, arg1 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOIDCChallenge", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOIDCChallenge indicates an expected call of CreateOIDCChallenge.
+func (mr *MockRepositoryMockRecorder) CreateOIDCChallenge(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOIDCChallenge", reflect.TypeOf((*MockRepository)(nil).CreateOIDCChallenge), arg0, arg1)
+}
+
 // Debit mocks base method.
 func (m *MockRepository) Debit(arg0 context.Context, arg1, arg2 string, arg3 float32) error {
 	m.ctrl.T.Helper()
@@ -62,6 +199,125 @@ func (mr *MockRepositoryMockRecorder) Debit(arg0, arg1, arg2, arg3 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Debit", reflect.TypeOf((*MockRepository)(nil).Debit), arg0, arg1, arg2, arg3)
 }
 
+// DeleteAccount mocks base method.
+func (m *MockRepository) DeleteAccount(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccount", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccount indicates an expected call of DeleteAccount.
+func (mr *MockRepositoryMockRecorder) DeleteAccount(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockRepository)(nil).DeleteAccount), arg0, arg1, arg2)
+}
+
+// DeleteIdempotencyKey mocks base method.
+func (m *MockRepository) DeleteIdempotencyKey(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdempotencyKey", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIdempotencyKey indicates an expected call of DeleteIdempotencyKey.
+func (mr *MockRepositoryMockRecorder) DeleteIdempotencyKey(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdempotencyKey", reflect.TypeOf((*MockRepository)(nil).DeleteIdempotencyKey), arg0, arg1, arg2)
+}
+
+// EnrollTOTP mocks base method.
+func (m *MockRepository) EnrollTOTP(arg0 context.Context, arg1 string) (entity.TOTPEnrollment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollTOTP", arg0, arg1)
+	ret0, _ := ret[0].(entity.TOTPEnrollment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollTOTP indicates an expected call of EnrollTOTP.
+func (mr *MockRepositoryMockRecorder) EnrollTOTP(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollTOTP", reflect.TypeOf((*MockRepository)(nil).EnrollTOTP), arg0, arg1)
+}
+
+// ExportAccount mocks base method.
+func (m *MockRepository) ExportAccount(arg0 context.Context, arg1 string) (entity.AccountExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportAccount", arg0, arg1)
+	ret0, _ := ret[0].(entity.AccountExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportAccount indicates an expected call of ExportAccount.
+func (mr *MockRepositoryMockRecorder) ExportAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportAccount", reflect.TypeOf((*MockRepository)(nil).ExportAccount), arg0, arg1)
+}
+
+// FinishOIDCLogin mocks base method.
+func (m *MockRepository) FinishOIDCLogin(arg0 context.Context, arg1, arg2 string) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishOIDCLogin", arg0, arg1, arg2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FinishOIDCLogin indicates an expected call of FinishOIDCLogin.
+func (mr *MockRepositoryMockRecorder) FinishOIDCLogin(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishOIDCLogin", reflect.TypeOf((*MockRepository)(nil).FinishOIDCLogin), arg0, arg1, arg2)
+}
+
+// GeneratePassword mocks base method.
+func (m *MockRepository) GeneratePassword(arg0 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GeneratePassword", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GeneratePassword indicates an expected call of GeneratePassword.
+func (mr *MockRepositoryMockRecorder) GeneratePassword(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GeneratePassword", reflect.TypeOf((*MockRepository)(nil).GeneratePassword), arg0)
+}
+
+// GetAPIKeys mocks base method.
+func (m *MockRepository) GetAPIKeys(arg0 context.Context) ([]entity.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeys", arg0)
+	ret0, _ := ret[0].([]entity.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeys indicates an expected call of GetAPIKeys.
+func (mr *MockRepositoryMockRecorder) GetAPIKeys(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeys", reflect.TypeOf((*MockRepository)(nil).GetAPIKeys), arg0)
+}
+
+// GetAdjustments mocks base method.
+func (m *MockRepository) GetAdjustments(arg0 context.Context, arg1 string) ([]entity.Adjustment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAdjustments", arg0, arg1)
+	ret0, _ := ret[0].([]entity.Adjustment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAdjustments indicates an expected call of GetAdjustments.
+func (mr *MockRepositoryMockRecorder) GetAdjustments(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAdjustments", reflect.TypeOf((*MockRepository)(nil).GetAdjustments), arg0, arg1)
+}
+
 // GetBalance mocks base method.
 func (m *MockRepository) GetBalance(arg0 context.Context, arg1 string) (float32, float32, error) {
 	m.ctrl.T.Helper()
@@ -78,6 +334,36 @@ func (mr *MockRepositoryMockRecorder) GetBalance(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalance", reflect.TypeOf((*MockRepository)(nil).GetBalance), arg0, arg1)
 }
 
+// GetCampaigns mocks base method.
+func (m *MockRepository) GetCampaigns(arg0 context.Context) ([]entity.Campaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCampaigns", arg0)
+	ret0, _ := ret[0].([]entity.Campaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCampaigns indicates an expected call of GetCampaigns.
+func (mr *MockRepositoryMockRecorder) GetCampaigns(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCampaigns", reflect.TypeOf((*MockRepository)(nil).GetCampaigns), arg0)
+}
+
+// GetOrder mocks base method.
+func (m *MockRepository) GetOrder(arg0 context.Context, arg1, arg2 string) (entity.OrderDetails, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrder", arg0, arg1, arg2)
+	ret0, _ := ret[0].(entity.OrderDetails)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrder indicates an expected call of GetOrder.
+func (mr *MockRepositoryMockRecorder) GetOrder(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrder", reflect.TypeOf((*MockRepository)(nil).GetOrder), arg0, arg1, arg2)
+}
+
 // GetOrders mocks base method.
 func (m *MockRepository) GetOrders(arg0 context.Context, arg1 string) ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -93,6 +379,97 @@ func (mr *MockRepositoryMockRecorder) GetOrders(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrders", reflect.TypeOf((*MockRepository)(nil).GetOrders), arg0, arg1)
 }
 
+// GetOrdersPage mocks base method.
+func (m *MockRepository) GetOrdersPage(arg0 context.Context, arg1 string, arg2 entity.HistoryFilter) ([]entity.Order, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrdersPage", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]entity.Order)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetOrdersPage indicates an expected call of GetOrdersPage.
+func (mr *MockRepositoryMockRecorder) GetOrdersPage(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersPage", reflect.TypeOf((*MockRepository)(nil).GetOrdersPage), arg0, arg1, arg2)
+}
+
+// GetRole mocks base method.
+func (m *MockRepository) GetRole(arg0 context.Context, arg1 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRole", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRole indicates an expected call of GetRole.
+func (mr *MockRepositoryMockRecorder) GetRole(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRole", reflect.TypeOf((*MockRepository)(nil).GetRole), arg0, arg1)
+}
+
+// GetSessions mocks base method.
+func (m *MockRepository) GetSessions(arg0 context.Context, arg1 string) ([]entity.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessions", arg0, arg1)
+	ret0, _ := ret[0].([]entity.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessions indicates an expected call of GetSessions.
+func (mr *MockRepositoryMockRecorder) GetSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessions", reflect.TypeOf((*MockRepository)(nil).GetSessions), arg0, arg1)
+}
+
+// GetTier mocks base method.
+func (m *MockRepository) GetTier(arg0 context.Context, arg1 string) (entity.TierProgress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTier", arg0, arg1)
+	ret0, _ := ret[0].(entity.TierProgress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTier indicates an expected call of GetTier.
+func (mr *MockRepositoryMockRecorder) GetTier(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTier", reflect.TypeOf((*MockRepository)(nil).GetTier), arg0, arg1)
+}
+
+// GetWithdrawLimits mocks base method.
+func (m *MockRepository) GetWithdrawLimits(arg0 context.Context, arg1 string) (entity.WithdrawLimits, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWithdrawLimits", arg0, arg1)
+	ret0, _ := ret[0].(entity.WithdrawLimits)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWithdrawLimits indicates an expected call of GetWithdrawLimits.
+func (mr *MockRepositoryMockRecorder) GetWithdrawLimits(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithdrawLimits", reflect.TypeOf((*MockRepository)(nil).GetWithdrawLimits), arg0, arg1)
+}
+
+// GetWithdrawalViolations mocks base method.
+func (m *MockRepository) GetWithdrawalViolations(arg0 context.Context, arg1 string) ([]entity.WithdrawalViolation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWithdrawalViolations", arg0, arg1)
+	ret0, _ := ret[0].([]entity.WithdrawalViolation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWithdrawalViolations indicates an expected call of GetWithdrawalViolations.
+func (mr *MockRepositoryMockRecorder) GetWithdrawalViolations(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithdrawalViolations", reflect.TypeOf((*MockRepository)(nil).GetWithdrawalViolations), arg0, arg1)
+}
+
 // GetWithdrawals mocks base method.
 func (m *MockRepository) GetWithdrawals(arg0 context.Context, arg1 string) ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -108,6 +485,52 @@ func (mr *MockRepositoryMockRecorder) GetWithdrawals(arg0, arg1 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithdrawals", reflect.TypeOf((*MockRepository)(nil).GetWithdrawals), arg0, arg1)
 }
 
+// GetWithdrawalsPage mocks base method.
+func (m *MockRepository) GetWithdrawalsPage(arg0 context.Context, arg1 string, arg2 entity.HistoryFilter) ([]entity.Withdrawal, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWithdrawalsPage", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]entity.Withdrawal)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetWithdrawalsPage indicates an expected call of GetWithdrawalsPage.
+func (mr *MockRepositoryMockRecorder) GetWithdrawalsPage(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithdrawalsPage", reflect.TypeOf((*MockRepository)(nil).GetWithdrawalsPage), arg0, arg1, arg2)
+}
+
+// GrantCampaign mocks base method.
+func (m *MockRepository) GrantCampaign(arg0 context.Context, arg1 string, arg2 []string, arg3 string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GrantCampaign", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GrantCampaign indicates an expected call of GrantCampaign.
+func (mr *MockRepositoryMockRecorder) GrantCampaign(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GrantCampaign", reflect.TypeOf((*MockRepository)(nil).GrantCampaign), arg0, arg1, arg2, arg3)
+}
+
+// HasAdmin mocks base method.
+func (m *MockRepository) HasAdmin(arg0 context.Context) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasAdmin", arg0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasAdmin indicates an expected call of HasAdmin.
+func (mr *MockRepositoryMockRecorder) HasAdmin(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasAdmin", reflect.TypeOf((*MockRepository)(nil).HasAdmin), arg0)
+}
+
 // InsertOrder mocks base method.
 func (m *MockRepository) InsertOrder(arg0 context.Context, arg1, arg2 string) error {
 	m.ctrl.T.Helper()
@@ -122,6 +545,37 @@ func (mr *MockRepositoryMockRecorder) InsertOrder(arg0, arg1, arg2 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertOrder", reflect.TypeOf((*MockRepository)(nil).InsertOrder), arg0, arg1, arg2)
 }
 
+// IsAccessTokenRevoked mocks base method.
+func (m *MockRepository) IsAccessTokenRevoked(arg0 context.Context, arg1, arg2, arg3 string, arg4 time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsAccessTokenRevoked", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsAccessTokenRevoked indicates an expected call of IsAccessTokenRevoked.
+func (mr *MockRepositoryMockRecorder) IsAccessTokenRevoked(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAccessTokenRevoked", reflect.TypeOf((*MockRepository)(nil).IsAccessTokenRevoked), arg0, arg1, arg2, arg3, arg4)
+}
+
+// IssueRefreshToken mocks base method.
+func (m *MockRepository) IssueRefreshToken(arg0 context.Context, arg1 entity.Session) (entity.Session, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueRefreshToken", arg0, arg1)
+	ret0, _ := ret[0].(entity.Session)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// IssueRefreshToken indicates an expected call of IssueRefreshToken.
+func (mr *MockRepositoryMockRecorder) IssueRefreshToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueRefreshToken", reflect.TypeOf((*MockRepository)(nil).IssueRefreshToken), arg0, arg1)
+}
+
 // Register mocks base method.
 func (m *MockRepository) Register(arg0 context.Context, arg1, arg2 string) error {
 	m.ctrl.T.Helper()
@@ -135,3 +589,306 @@ func (mr *MockRepositoryMockRecorder) Register(arg0, arg1, arg2 interface{}) *go
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockRepository)(nil).Register), arg0, arg1, arg2)
 }
+
+// ReleaseLoginAttempt mocks base method.
+func (m *MockRepository) ReleaseLoginAttempt(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseLoginAttempt", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseLoginAttempt indicates an expected call of ReleaseLoginAttempt.
+func (mr *MockRepositoryMockRecorder) ReleaseLoginAttempt(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseLoginAttempt", reflect.TypeOf((*MockRepository)(nil).ReleaseLoginAttempt), arg0, arg1, arg2)
+}
+
+// RequestPasswordReset mocks base method.
+func (m *MockRepository) RequestPasswordReset(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestPasswordReset", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequestPasswordReset indicates an expected call of RequestPasswordReset.
+func (mr *MockRepositoryMockRecorder) RequestPasswordReset(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestPasswordReset", reflect.TypeOf((*MockRepository)(nil).RequestPasswordReset), arg0, arg1)
+}
+
+// ReserveIdempotencyKey mocks base method.
+func (m *MockRepository) ReserveIdempotencyKey(arg0 context.Context, arg1, arg2, arg3 string) (*entity.Idempotency, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReserveIdempotencyKey", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*entity.Idempotency)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReserveIdempotencyKey indicates an expected call of ReserveIdempotencyKey.
+func (mr *MockRepositoryMockRecorder) ReserveIdempotencyKey(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveIdempotencyKey", reflect.TypeOf((*MockRepository)(nil).ReserveIdempotencyKey), arg0, arg1, arg2, arg3)
+}
+
+// ReserveLoginAttempt mocks base method.
+func (m *MockRepository) ReserveLoginAttempt(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReserveLoginAttempt", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReserveLoginAttempt indicates an expected call of ReserveLoginAttempt.
+func (mr *MockRepositoryMockRecorder) ReserveLoginAttempt(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveLoginAttempt", reflect.TypeOf((*MockRepository)(nil).ReserveLoginAttempt), arg0, arg1, arg2)
+}
+
+// ReservePasswordReset mocks base method.
+func (m *MockRepository) ReservePasswordReset(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReservePasswordReset", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReservePasswordReset indicates an expected call of ReservePasswordReset.
+func (mr *MockRepositoryMockRecorder) ReservePasswordReset(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReservePasswordReset", reflect.TypeOf((*MockRepository)(nil).ReservePasswordReset), arg0, arg1, arg2)
+}
+
+// ResetLoginAttempts mocks base method.
+func (m *MockRepository) ResetLoginAttempts(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetLoginAttempts", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetLoginAttempts indicates an expected call of ResetLoginAttempts.
+func (mr *MockRepositoryMockRecorder) ResetLoginAttempts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetLoginAttempts", reflect.TypeOf((*MockRepository)(nil).ResetLoginAttempts), arg0, arg1)
+}
+
+// ResetPassword mocks base method.
+func (m *MockRepository) ResetPassword(arg0 context.Context, arg1, arg2 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", arg0, arg1, arg2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockRepositoryMockRecorder) ResetPassword(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockRepository)(nil).ResetPassword), arg0, arg1, arg2)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockRepository) RevokeAPIKey(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockRepositoryMockRecorder) RevokeAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockRepository)(nil).RevokeAPIKey), arg0, arg1)
+}
+
+// RevokeAccessToken mocks base method.
+func (m *MockRepository) RevokeAccessToken(arg0 context.Context, arg1 string, arg2 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAccessToken", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAccessToken indicates an expected call of RevokeAccessToken.
+func (mr *MockRepositoryMockRecorder) RevokeAccessToken(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAccessToken", reflect.TypeOf((*MockRepository)(nil).RevokeAccessToken), arg0, arg1, arg2)
+}
+
+// RevokeRefreshToken mocks base method.
+func (m *MockRepository) RevokeRefreshToken(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshToken", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshToken indicates an expected call of RevokeRefreshToken.
+func (mr *MockRepositoryMockRecorder) RevokeRefreshToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshToken", reflect.TypeOf((*MockRepository)(nil).RevokeRefreshToken), arg0, arg1)
+}
+
+// RevokeSession mocks base method.
+func (m *MockRepository) RevokeSession(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockRepositoryMockRecorder) RevokeSession(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockRepository)(nil).RevokeSession), arg0, arg1, arg2)
+}
+
+// RotateRefreshToken mocks base method.
+func (m *MockRepository) RotateRefreshToken(arg0 context.Context, arg1 string, arg2 entity.Session) (entity.Session, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateRefreshToken", arg0, arg1, arg2)
+	ret0, _ := ret[0].(entity.Session)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RotateRefreshToken indicates an expected call of RotateRefreshToken.
+func (mr *MockRepositoryMockRecorder) RotateRefreshToken(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateRefreshToken", reflect.TypeOf((*MockRepository)(nil).RotateRefreshToken), arg0, arg1, arg2)
+}
+
+// SaveIdempotencyResponse mocks base method.
+func (m *MockRepository) SaveIdempotencyResponse(arg0 context.Context, arg1, arg2 string, arg3 int, arg4 string, arg5 []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveIdempotencyResponse", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveIdempotencyResponse indicates an expected call of SaveIdempotencyResponse.
+func (mr *MockRepositoryMockRecorder) SaveIdempotencyResponse(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveIdempotencyResponse", reflect.TypeOf((*MockRepository)(nil).SaveIdempotencyResponse), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+// SetCampaignActive mocks base method.
+func (m *MockRepository) SetCampaignActive(arg0 context.Context, arg1 string, arg2 bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCampaignActive", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetCampaignActive indicates an expected call of SetCampaignActive.
+func (mr *MockRepositoryMockRecorder) SetCampaignActive(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCampaignActive", reflect.TypeOf((*MockRepository)(nil).SetCampaignActive), arg0, arg1, arg2)
+}
+
+// SetRole mocks base method.
+func (m *MockRepository) SetRole(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRole", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRole indicates an expected call of SetRole.
+func (mr *MockRepositoryMockRecorder) SetRole(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRole", reflect.TypeOf((*MockRepository)(nil).SetRole), arg0, arg1, arg2)
+}
+
+// SetWithdrawLimits mocks base method.
+func (m *MockRepository) SetWithdrawLimits(arg0 context.Context, arg1 entity.WithdrawLimits) (entity.WithdrawLimits, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWithdrawLimits", arg0, arg1)
+	ret0, _ := ret[0].(entity.WithdrawLimits)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetWithdrawLimits indicates an expected call of SetWithdrawLimits.
+func (mr *MockRepositoryMockRecorder) SetWithdrawLimits(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWithdrawLimits", reflect.TypeOf((*MockRepository)(nil).SetWithdrawLimits), arg0, arg1)
+}
+
+// StartOIDCLogin mocks base method.
+func (m *MockRepository) StartOIDCLogin(arg0 context.Context, arg1 string) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartOIDCLogin", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// StartOIDCLogin indicates an expected call of StartOIDCLogin.
+func (mr *MockRepositoryMockRecorder) StartOIDCLogin(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartOIDCLogin", reflect.TypeOf((*MockRepository)(nil).StartOIDCLogin), arg0, arg1)
+}
+
+// StartSession mocks base method.
+func (m *MockRepository) StartSession(arg0 context.Context, arg1 entity.Session) (entity.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartSession", arg0, arg1)
+	ret0, _ := ret[0].(entity.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartSession indicates an expected call of StartSession.
+func (mr *MockRepositoryMockRecorder) StartSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartSession", reflect.TypeOf((*MockRepository)(nil).StartSession), arg0, arg1)
+}
+
+// Statement mocks base method.
+func (m *MockRepository) Statement(arg0 context.Context, arg1 string, arg2, arg3 time.Time, arg4 StatementWriter) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Statement", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Statement indicates an expected call of Statement.
+func (mr *MockRepositoryMockRecorder) Statement(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Statement", reflect.TypeOf((*MockRepository)(nil).Statement), arg0, arg1, arg2, arg3, arg4)
+}
+
+// Subscribe mocks base method.
+func (m *MockRepository) Subscribe(arg0 string, arg1 uint64) (*events.Subscription, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", arg0, arg1)
+	ret0, _ := ret[0].(*events.Subscription)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockRepositoryMockRecorder) Subscribe(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockRepository)(nil).Subscribe), arg0, arg1)
+}
+
+// VerifySecondFactor mocks base method.
+func (m *MockRepository) VerifySecondFactor(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifySecondFactor", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifySecondFactor indicates an expected call of VerifySecondFactor.
+func (mr *MockRepositoryMockRecorder) VerifySecondFactor(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifySecondFactor", reflect.TypeOf((*MockRepository)(nil).VerifySecondFactor), arg0, arg1, arg2)
+}
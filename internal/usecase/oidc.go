@@ -0,0 +1,270 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nextlag/gomart/internal/config"
+	"github.com/nextlag/gomart/pkg/logger/l"
+	"github.com/nextlag/gomart/pkg/oidc"
+)
+
+const (
+	// oidcStateTTL - время, за которое пользователь должен завершить вход у поставщика удостоверений
+	oidcStateTTL = 10 * time.Minute
+	// oidcChallengeTTL - время, за которое пользователь должен подтвердить вход через поставщика вторым фактором
+	oidcChallengeTTL = 5 * time.Minute
+)
+
+const (
+	insertOIDCState = `
+		INSERT INTO oidc_states (state_hash, nonce, verifier, created_at)
+		VALUES ($1, $2, $3, $4)
+	`
+	deleteExpiredOIDCStates = `
+		DELETE FROM oidc_states
+		WHERE created_at < $1
+	`
+	consumeOIDCState = `
+		DELETE FROM oidc_states
+		WHERE state_hash = $1
+		RETURNING nonce, verifier, created_at
+	`
+	selectIdentityLogin = `
+		SELECT login
+		FROM user_identities
+		WHERE issuer = $1 AND subject = $2
+	`
+	insertIdentity = `
+		INSERT INTO user_identities (issuer, subject, login, created_at)
+		VALUES ($1, $2, $3, $4)
+	`
+	insertOIDCChallenge = `
+		INSERT INTO oidc_challenges (challenge_hash, login, created_at)
+		VALUES ($1, $2, $3)
+	`
+	deleteExpiredOIDCChallenges = `
+		DELETE FROM oidc_challenges
+		WHERE created_at < $1
+	`
+	consumeOIDCChallenge = `
+		DELETE FROM oidc_challenges
+		WHERE challenge_hash = $1
+		RETURNING login, created_at
+	`
+)
+
+// StartOIDCLogin начинает вход через поставщика удостоверений: сохраняет state, nonce и code_verifier PKCE
+// и возвращает адрес страницы входа поставщика. Незавершенные входы старше 10 минут при этом удаляются.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - loginHint: подсказка поставщику, каким пользователем выполнить вход; может быть пустой.
+//
+// Возвращаемые значения:
+//   - string: значение state, которое поставщик вернет вместе с кодом авторизации.
+//   - string: адрес страницы входа поставщика.
+//   - error: ErrOIDCDisabled, если вход через поставщика не настроен, ErrOIDCLogin, если поставщик недоступен,
+//     ошибка базы данных в остальных случаях.
+func (uc *UseCase) StartOIDCLogin(ctx context.Context, loginHint string) (string, string, error) {
+	if uc.IdP == nil {
+		return "", "", ErrOIDCDisabled
+	}
+
+	state, stateHash, err := newRefreshToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := oidc.NewVerifier()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := uc.IdP.AuthCodeURL(ctx, state, nonce, verifier, loginHint)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrOIDCLogin, err)
+	}
+
+	now := time.Now()
+	if _, err = uc.DB.ExecContext(ctx, deleteExpiredOIDCStates, now.Add(-oidcStateTTL)); err != nil {
+		return "", "", fmt.Errorf("error deleting expired single sign-on states: %v", err)
+	}
+	if _, err = uc.DB.ExecContext(ctx, insertOIDCState, stateHash, nonce, verifier, now); err != nil {
+		return "", "", fmt.Errorf("error inserting single sign-on state: %v", err)
+	}
+	return state, authURL, nil
+}
+
+// FinishOIDCLogin завершает вход через поставщика удостоверений: обменивает код авторизации на ID-токен
+// и возвращает логин пользователя, связанного с учетной записью поставщика. При первом входе создается новый
+// пользователь со случайным паролем и логином из клейма config.Cfg.OIDC.LoginClaim. С существующим пользователем
+// с тем же логином учетная запись поставщика связывается, только если логин взят из подтвержденного email:
+// неподтвержденные клеймы, например preferred_username, владелец учетной записи поставщика может задать сам.
+// Каждое значение state принимается один раз.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - state: значение state из ответа поставщика.
+//   - code: код авторизации из ответа поставщика.
+//
+// Возвращаемые значения:
+//   - string: логин пользователя.
+//   - bool: true, если пользователь создан при этом входе.
+//   - error: ErrOIDCDisabled, если вход через поставщика не настроен, ErrOIDCState, если state неизвестен
+//     или истек, ErrOIDCLogin, если поставщик отклонил вход или в ID-токене нет логина, ErrOIDCAccountExists,
+//     если пользователь с таким логином уже есть, а логин взят не из подтвержденного email,
+//     ошибка базы данных в остальных случаях.
+func (uc *UseCase) FinishOIDCLogin(ctx context.Context, state, code string) (string, bool, error) {
+	if uc.IdP == nil {
+		return "", false, ErrOIDCDisabled
+	}
+
+	var (
+		nonce, verifier string
+		createdAt       time.Time
+	)
+	err := uc.DB.QueryRowContext(ctx, consumeOIDCState, hashToken(state)).Scan(&nonce, &verifier, &createdAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return "", false, ErrOIDCState
+	case err != nil:
+		return "", false, fmt.Errorf("error selecting single sign-on state: %v", err)
+	case time.Since(createdAt) > oidcStateTTL:
+		return "", false, ErrOIDCState
+	}
+
+	claims, err := uc.IdP.Exchange(ctx, code, verifier, nonce)
+	if err != nil {
+		return "", false, fmt.Errorf("%w: %v", ErrOIDCLogin, err)
+	}
+	return uc.linkIdentity(ctx, uc.IdP.Issuer(), claims)
+}
+
+// linkIdentity возвращает логин пользователя, связанного с учетной записью поставщика, связывая ее
+// с новым пользователем или, если allowLinkExisting разрешает, с существующим пользователем при первом входе.
+func (uc *UseCase) linkIdentity(ctx context.Context, issuer string, claims oidc.Claims) (string, bool, error) {
+	tx, err := uc.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return "", false, err
+	}
+	defer tx.Rollback()
+
+	var login string
+	err = tx.QueryRowContext(ctx, selectIdentityLogin, issuer, claims.Subject).Scan(&login)
+	switch {
+	case err == nil:
+		return login, false, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return "", false, fmt.Errorf("error selecting identity: %v", err)
+	}
+
+	login = claims.Claim(config.Cfg.OIDC.LoginClaim)
+	if login == "" {
+		return "", false, fmt.Errorf("%w: no %s claim in ID token", ErrOIDCLogin, config.Cfg.OIDC.LoginClaim)
+	}
+
+	var exists bool
+	if err = tx.QueryRowContext(ctx, selectUserExists, login).Scan(&exists); err != nil {
+		return "", false, fmt.Errorf("error selecting user: %v", err)
+	}
+	if exists && !allowLinkExisting(config.Cfg.OIDC.LoginClaim) {
+		l.L(ctx).Warn("identity not linked to existing user", "login", login, "issuer", issuer,
+			"subject", claims.Subject, "claim", config.Cfg.OIDC.LoginClaim)
+		return "", false, ErrOIDCAccountExists
+	}
+	if !exists {
+		// Пароль пользователю не сообщается: войти по паролю можно после его сброса
+		password, err := uc.Policy.Generate(login)
+		if err != nil {
+			return "", false, err
+		}
+		hash, err := uc.Hasher.Hash(password)
+		if err != nil {
+			return "", false, err
+		}
+		if _, err = tx.ExecContext(ctx, insertUser, login, hash); err != nil {
+			return "", false, fmt.Errorf("error inserting user: %v", err)
+		}
+		// Пользователю, созданному при первом входе, начисляются приветственные бонусы, как при регистрации
+		if err = applyWelcomeCampaigns(ctx, tx, login); err != nil {
+			return "", false, err
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, insertIdentity, issuer, claims.Subject, login, time.Now()); err != nil {
+		return "", false, fmt.Errorf("error inserting identity: %v", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return "", false, err
+	}
+	l.L(ctx).Info("identity linked", "login", login, "issuer", issuer, "subject", claims.Subject, "created", !exists)
+	return login, !exists, nil
+}
+
+// allowLinkExisting сообщает, можно ли при первом входе связать учетную запись поставщика с существующим
+// пользователем по логину из клейма loginClaim. Это допустимо только для email: oidc.Claims.Claim возвращает его,
+// только если поставщик подтвердил адрес. Остальные клеймы не доказывают владение локальной учетной записью.
+func allowLinkExisting(loginClaim string) bool {
+	return loginClaim == "email"
+}
+
+// CreateOIDCChallenge сохраняет незавершенный вход через поставщика удостоверений пользователя с подключенной
+// двухфакторной аутентификацией. Вход завершается после проверки второго фактора в течение 5 минут;
+// просроченные входы при этом удаляются.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - login: логин пользователя, выполнившего вход через поставщика.
+//
+// Возвращаемые значения:
+//   - string: одноразовый идентификатор незавершенного входа.
+//   - error: ошибка базы данных.
+func (uc *UseCase) CreateOIDCChallenge(ctx context.Context, login string) (string, error) {
+	challenge, challengeHash, err := newRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	if _, err = uc.DB.ExecContext(ctx, deleteExpiredOIDCChallenges, now.Add(-oidcChallengeTTL)); err != nil {
+		return "", fmt.Errorf("error deleting expired single sign-on challenges: %v", err)
+	}
+	if _, err = uc.DB.ExecContext(ctx, insertOIDCChallenge, challengeHash, login, now); err != nil {
+		return "", fmt.Errorf("error inserting single sign-on challenge: %v", err)
+	}
+	return challenge, nil
+}
+
+// ConsumeOIDCChallenge возвращает логин пользователя незавершенного входа через поставщика удостоверений
+// и удаляет вход: каждый идентификатор принимается один раз, поэтому после неверного кода вход нужно начать заново.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - challenge: идентификатор незавершенного входа из CreateOIDCChallenge.
+//
+// Возвращаемые значения:
+//   - string: логин пользователя.
+//   - error: ErrOIDCState, если вход неизвестен или истек, ошибка базы данных в остальных случаях.
+func (uc *UseCase) ConsumeOIDCChallenge(ctx context.Context, challenge string) (string, error) {
+	var (
+		login     string
+		createdAt time.Time
+	)
+	err := uc.DB.QueryRowContext(ctx, consumeOIDCChallenge, hashToken(challenge)).Scan(&login, &createdAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return "", ErrOIDCState
+	case err != nil:
+		return "", fmt.Errorf("error selecting single sign-on challenge: %v", err)
+	case time.Since(createdAt) > oidcChallengeTTL:
+		return "", ErrOIDCState
+	}
+	return login, nil
+}
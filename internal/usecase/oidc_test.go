@@ -0,0 +1,153 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nextlag/gomart/internal/config"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+func TestAllowLinkExisting(t *testing.T) {
+	tests := []struct {
+		claim string
+		want  bool
+	}{
+		{claim: "email", want: true},
+		{claim: "preferred_username", want: false},
+		{claim: "sub", want: false},
+		{claim: "name", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.claim, func(t *testing.T) {
+			assert.Equal(t, tt.want, allowLinkExisting(tt.claim))
+		})
+	}
+}
+
+func TestDoFinishOIDCLogin(t *testing.T) {
+	errDB := errors.New("db error")
+	tests := []struct {
+		name      string
+		prepare   func(repo *MockRepository)
+		login     string
+		challenge string
+		want      error
+	}{
+		{
+			name: "Without second factor",
+			prepare: func(repo *MockRepository) {
+				repo.EXPECT().FinishOIDCLogin(gomock.Any(), "state", "code").Return("alice", false, nil)
+				repo.EXPECT().VerifySecondFactor(gomock.Any(), "alice", "").Return(nil)
+			},
+			login: "alice",
+		},
+		{
+			name: "Second factor required",
+			prepare: func(repo *MockRepository) {
+				repo.EXPECT().FinishOIDCLogin(gomock.Any(), "state", "code").Return("alice", false, nil)
+				repo.EXPECT().VerifySecondFactor(gomock.Any(), "alice", "").Return(ErrOTPRequired)
+				repo.EXPECT().CreateOIDCChallenge(gomock.Any(), "alice").Return("challenge", nil)
+			},
+			login:     "alice",
+			challenge: "challenge",
+			want:      ErrOTPRequired,
+		},
+		{
+			name: "Existing account",
+			prepare: func(repo *MockRepository) {
+				repo.EXPECT().FinishOIDCLogin(gomock.Any(), "state", "code").Return("", false, ErrOIDCAccountExists)
+			},
+			want: ErrOIDCAccountExists,
+		},
+		{
+			name: "Second factor check failed",
+			prepare: func(repo *MockRepository) {
+				repo.EXPECT().FinishOIDCLogin(gomock.Any(), "state", "code").Return("alice", false, nil)
+				repo.EXPECT().VerifySecondFactor(gomock.Any(), "alice", "").Return(errDB)
+			},
+			want: errDB,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := l.ContextWithLogger(context.Background(), l.LoggerNew(config.Cfg.ProjectRoot))
+			repo := NewMockRepository(gomock.NewController(t))
+			tt.prepare(repo)
+
+			login, challenge, err := New(repo, config.HTTPServer{}).DoFinishOIDCLogin(ctx, "state", "code")
+			assert.ErrorIs(t, err, tt.want)
+			if tt.want == nil {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.login, login)
+			assert.Equal(t, tt.challenge, challenge)
+		})
+	}
+}
+
+func TestDoFinishOIDCChallenge(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(repo *MockRepository)
+		want    error
+	}{
+		{
+			name: "Unknown challenge",
+			prepare: func(repo *MockRepository) {
+				repo.EXPECT().ConsumeOIDCChallenge(gomock.Any(), "challenge").Return("", ErrOIDCState)
+			},
+			want: ErrOIDCState,
+		},
+		{
+			name: "Throttled",
+			prepare: func(repo *MockRepository) {
+				repo.EXPECT().ConsumeOIDCChallenge(gomock.Any(), "challenge").Return("alice", nil)
+				repo.EXPECT().ReserveLoginAttempt(gomock.Any(), "alice", "192.0.2.1").Return(ErrTooManyAttempts)
+			},
+			want: ErrTooManyAttempts,
+		},
+		{
+			name: "Wrong one-time code stays counted",
+			prepare: func(repo *MockRepository) {
+				repo.EXPECT().ConsumeOIDCChallenge(gomock.Any(), "challenge").Return("alice", nil)
+				repo.EXPECT().ReserveLoginAttempt(gomock.Any(), "alice", "192.0.2.1").Return(nil)
+				repo.EXPECT().VerifySecondFactor(gomock.Any(), "alice", "123456").Return(ErrOTPCode)
+			},
+			want: ErrOTPCode,
+		},
+		{
+			name: "Success releases attempt and resets login counter",
+			prepare: func(repo *MockRepository) {
+				repo.EXPECT().ConsumeOIDCChallenge(gomock.Any(), "challenge").Return("alice", nil)
+				repo.EXPECT().ReserveLoginAttempt(gomock.Any(), "alice", "192.0.2.1").Return(nil)
+				repo.EXPECT().VerifySecondFactor(gomock.Any(), "alice", "123456").Return(nil)
+				repo.EXPECT().ReleaseLoginAttempt(gomock.Any(), "alice", "192.0.2.1").Return(nil)
+				repo.EXPECT().ResetLoginAttempts(gomock.Any(), "alice").Return(nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := l.ContextWithLogger(context.Background(), l.LoggerNew(config.Cfg.ProjectRoot))
+			repo := NewMockRepository(gomock.NewController(t))
+			tt.prepare(repo)
+
+			r := httptest.NewRequest("POST", "/api/user/oidc/otp", nil)
+			r.RemoteAddr = "192.0.2.1:1234"
+			login, err := New(repo, config.HTTPServer{}).DoFinishOIDCChallenge(ctx, "challenge", "123456", r)
+			if tt.want == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, "alice", login)
+				return
+			}
+			assert.ErrorIs(t, err, tt.want)
+			assert.Empty(t, login)
+		})
+	}
+}
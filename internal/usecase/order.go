@@ -0,0 +1,75 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/nextlag/gomart/internal/entity"
+)
+
+const (
+	selectUserOrder = `
+		SELECT "order", status, accrual, uploaded_at
+		FROM orders
+		WHERE "order" = $1 AND user_name = $2
+	`
+	selectOrderStatusHistory = `
+		SELECT status, accrual, changed_at
+		FROM order_status_history
+		WHERE "order" = $1
+		ORDER BY id ASC
+	`
+	insertOrderStatus = `
+		INSERT INTO order_status_history ("order", status, accrual, changed_at)
+		VALUES ($1, $2, $3, $4)
+	`
+	// insertOrderStatusChange записывает новый статус заказа, только если он отличается от текущего;
+	// запрос выполняется через bun и поэтому использует его формат параметров
+	insertOrderStatusChange = `
+		INSERT INTO order_status_history ("order", status, accrual, changed_at)
+		SELECT "order", ?, ?, ?
+		FROM orders
+		WHERE "order" = ? AND status != ?
+	`
+)
+
+// GetOrder возвращает заказ пользователя с историей изменения статуса расчета начислений.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - user: логин пользователя.
+//   - number: номер заказа.
+//
+// Возвращаемые значения:
+//   - entity.OrderDetails: заказ и изменения его статуса в порядке их записи.
+//   - error: ErrNoOrder, если заказ не найден или загружен другим пользователем,
+//     ошибка базы данных в остальных случаях.
+func (uc *UseCase) GetOrder(ctx context.Context, user, number string) (entity.OrderDetails, error) {
+	var order entity.OrderDetails
+	err := uc.DB.QueryRowContext(ctx, selectUserOrder, number, user).
+		Scan(&order.Order.Order, &order.Status, &order.Accrual, &order.UploadedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return order, ErrNoOrder
+	case err != nil:
+		return order, fmt.Errorf("error selecting order: %v", err)
+	}
+
+	rows, err := uc.DB.QueryContext(ctx, selectOrderStatusHistory, number)
+	if err != nil {
+		return order, fmt.Errorf("error selecting order status history: %v", err)
+	}
+	defer rows.Close()
+
+	order.History = make([]entity.OrderStatusChange, 0)
+	for rows.Next() {
+		var change entity.OrderStatusChange
+		if err = rows.Scan(&change.Status, &change.Accrual, &change.ChangedAt); err != nil {
+			return order, err
+		}
+		order.History = append(order.History, change)
+	}
+	return order, rows.Err()
+}
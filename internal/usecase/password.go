@@ -0,0 +1,200 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nextlag/gomart/internal/config"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+// resetSubject - тема уведомления со ссылкой на сброс пароля
+const resetSubject = "Password reset"
+
+const (
+	selectUserPasswordForUpdate = `
+		SELECT password
+		FROM users
+		WHERE login = $1
+		FOR UPDATE
+	`
+	updatePassword = `
+		UPDATE users
+		SET password = $1, password_changed_at = $2
+		WHERE login = $3
+	`
+	selectUserExists = `
+		SELECT EXISTS (SELECT 1 FROM users WHERE login = $1)
+	`
+	insertPasswordReset = `
+		INSERT INTO password_resets (token_hash, login, expires_at, created_at)
+		VALUES ($1, $2, $3, $4)
+	`
+	usePasswordReset = `
+		UPDATE password_resets
+		SET used_at = $2
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2
+		RETURNING login
+	`
+	deleteExpiredPasswordResets = `
+		DELETE FROM password_resets
+		WHERE expires_at < $1
+	`
+)
+
+// GeneratePassword генерирует случайный пароль, удовлетворяющий политике паролей,
+// с помощью криптографически стойкого генератора.
+//
+// Параметры:
+//   - login: логин пользователя, для которого генерируется пароль.
+//
+// Возвращаемые значения:
+//   - string: сгенерированный пароль.
+//   - error: ошибка генерации пароля.
+func (uc *UseCase) GeneratePassword(login string) (string, error) {
+	return uc.Policy.Generate(login)
+}
+
+// setPassword проверяет новый пароль по политике паролей, сохраняет его хеш в рамках транзакции tx,
+// запоминает время смены пароля и отзывает все токены пользователя.
+func (uc *UseCase) setPassword(ctx context.Context, tx *sql.Tx, login, password string, now time.Time) error {
+	if password == "" {
+		return ErrRequestFormat
+	}
+	if err := uc.Policy.Validate(login, password); err != nil {
+		return err
+	}
+	hash, err := uc.Hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, updatePassword, hash, now, login); err != nil {
+		return fmt.Errorf("error updating password: %v", err)
+	}
+	return revokeUserTokens(ctx, tx, login, now)
+}
+
+// ChangePassword меняет пароль пользователя после проверки текущего пароля.
+// Все refresh-токены и токены доступа пользователя, выданные до смены пароля, отзываются.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - login: логин пользователя.
+//   - current: текущий пароль.
+//   - password: новый пароль.
+//
+// Возвращаемое значение:
+//   - error: ErrPassword, если текущий пароль неверен, ErrRequestFormat, если новый пароль пуст,
+//     *passwd.PolicyError, если новый пароль не удовлетворяет политике паролей, ErrUserNotFound, если пользователь не найден, ошибка базы данных или хеширования в остальных случаях.
+func (uc *UseCase) ChangePassword(ctx context.Context, login, current, password string) error {
+	tx, err := uc.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var stored string
+	err = tx.QueryRowContext(ctx, selectUserPasswordForUpdate, login).Scan(&stored)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error selecting password: %v", err)
+	}
+
+	ok, _, err := uc.Hasher.Verify(current, stored)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPassword
+	}
+
+	if err = uc.setPassword(ctx, tx, login, password, time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RequestPasswordReset создает одноразовый токен сброса пароля со сроком действия config.Cfg.PasswordResetTTL
+// и отправляет его пользователю через Notifier. Чтобы по ответу нельзя было узнать, зарегистрирован ли логин,
+// для неизвестного логина метод ничего не отправляет и также возвращает nil. Если Notifier не задан,
+// сброс пароля отключен: токен не создается, и метод также возвращает nil.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - login: логин пользователя.
+//
+// Возвращаемое значение:
+//   - error: ошибка генерации токена, выполнения запроса к базе данных или отправки уведомления.
+func (uc *UseCase) RequestPasswordReset(ctx context.Context, login string) error {
+	now := time.Now()
+	if uc.Notifier == nil {
+		l.L(ctx).Warn("password reset requested, but notifier is not configured", "login", login)
+		return nil
+	}
+
+	var exists bool
+	if err := uc.DB.QueryRowContext(ctx, selectUserExists, login).Scan(&exists); err != nil {
+		return fmt.Errorf("error selecting user: %v", err)
+	}
+	if !exists {
+		l.L(ctx).Info("password reset requested for unknown login", "login", login)
+		return nil
+	}
+
+	if _, err := uc.DB.ExecContext(ctx, deleteExpiredPasswordResets, now); err != nil {
+		return fmt.Errorf("error deleting expired password resets: %v", err)
+	}
+	token, hash, err := newRefreshToken()
+	if err != nil {
+		return err
+	}
+	ttl := config.Cfg.PasswordResetTTL
+	if _, err = uc.DB.ExecContext(ctx, insertPasswordReset, hash, login, now.Add(ttl), now); err != nil {
+		return fmt.Errorf("error inserting password reset: %v", err)
+	}
+
+	body := fmt.Sprintf("Use this token to reset your password within %s: %s\n"+
+		"If you did not request a password reset, ignore this message.", ttl, token)
+	return uc.Notifier.Notify(ctx, login, resetSubject, body)
+}
+
+// ResetPassword устанавливает новый пароль по токену сброса. Токен можно использовать только один раз
+// до истечения срока действия. Все refresh-токены и токены доступа пользователя отзываются.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - token: токен сброса пароля из уведомления.
+//   - password: новый пароль.
+//
+// Возвращаемые значения:
+//   - string: логин пользователя, пароль которого изменен.
+//   - error: ErrResetToken, если токен неизвестен, истек или уже использован, ErrRequestFormat, если пароль пуст,
+//     *passwd.PolicyError, если пароль не удовлетворяет политике паролей, ошибка базы данных или хеширования в остальных случаях.
+func (uc *UseCase) ResetPassword(ctx context.Context, token, password string) (string, error) {
+	now := time.Now()
+
+	tx, err := uc.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var login string
+	err = tx.QueryRowContext(ctx, usePasswordReset, hashToken(token), now).Scan(&login)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrResetToken
+	}
+	if err != nil {
+		return "", fmt.Errorf("error using password reset: %v", err)
+	}
+
+	if err = uc.setPassword(ctx, tx, login, password, now); err != nil {
+		return "", err
+	}
+	return login, tx.Commit()
+}
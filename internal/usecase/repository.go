@@ -33,7 +33,12 @@ const (
 	selectUser = `
 		SELECT login, password, balance, withdrawn
 		FROM users
-		WHERE login = $1 AND password = $2
+		WHERE login = $1
+	`
+	updateUserPassword = `
+		UPDATE users
+		SET password = $1
+		WHERE login = $2
 	`
 	selectOrder = `
 		SELECT user_name, "order", status, accrual, uploaded_at, bonuses_withdrawn
@@ -65,9 +70,9 @@ const (
 )
 
 // Register регистрирует нового пользователя с предоставленным логином и паролем.
-// Метод начинает транзакцию с базой данных, создает нового пользователя с указанными
-// данными и вставляет его в базу данных. После успешной вставки, транзакция фиксируется,
-// а если происходит ошибка, транзакция откатывается.
+// Метод проверяет пароль по политике паролей, хеширует его, начинает транзакцию с базой данных, создает нового пользователя с указанными
+// данными и вставляет его в базу данных, а затем в той же транзакции начисляет приветственные бонусы активных
+// кампаний. После успешного начисления транзакция фиксируется, а если происходит ошибка, транзакция откатывается.
 //
 // Параметры:
 //   - ctx: контекст выполнения запроса.
@@ -76,37 +81,35 @@ const (
 //
 // Возвращаемое значение:
 //
-//   - error: если произошла ошибка во время выполнения запроса или транзакции,
-//     возвращается ошибка, в противном случае nil.
+//   - error: *passwd.PolicyError, если пароль не удовлетворяет политике паролей; если произошла ошибка
+//     во время выполнения запроса или транзакции, возвращается ошибка, в противном случае nil.
 func (uc *UseCase) Register(ctx context.Context, login, password string) error {
 	// Создание переменной для хранения данных о пользователе
 	var eUsers entity.User
 
+	// Пароль должен удовлетворять политике паролей
+	if err := uc.Policy.Validate(login, password); err != nil {
+		return err
+	}
+
+	// В базе данных хранится только хеш пароля
+	hash, err := uc.Hasher.Hash(password)
+	if err != nil {
+		l.L(ctx).Error("password hashing", l.ErrAttr(err))
+		return err
+	}
+
 	// Начало транзакции
 	tx, err := uc.DB.BeginTx(ctx, nil)
 	if err != nil {
 		l.L(ctx).Error("begin transaction", l.ErrAttr(err))
 		return err
 	}
-
-	// Отложенный вызов функции, выполняющей откат транзакции в случае ошибки
-	defer func() {
-		if err != nil {
-			// Если произошла ошибка, откатываем транзакцию
-			tx.Rollback()
-			return
-		}
-
-		// Если все прошло успешно, фиксируем транзакцию
-		err = tx.Commit()
-		if err != nil {
-			// Если при фиксации транзакции произошла ошибка, создаем ошибку и возвращаем ее
-			err = fmt.Errorf("commit transaction: %v", err)
-		}
-	}()
+	// Откат транзакции не выполняется, если она уже зафиксирована
+	defer tx.Rollback()
 
 	// Вставляем данные пользователя в базу данных
-	err = uc.DB.QueryRowContext(ctx, insertUser, login, password).Scan(
+	err = tx.QueryRowContext(ctx, insertUser, login, hash).Scan(
 		&eUsers.Login,
 		&eUsers.Password,
 		&eUsers.Balance,
@@ -119,15 +122,23 @@ func (uc *UseCase) Register(ctx context.Context, login, password string) error {
 		return err
 	}
 
-	// Возвращаем nil, если все прошло успешно
+	// Начисляем приветственные бонусы в той же транзакции: регистрация без бонуса не фиксируется
+	if err = applyWelcomeCampaigns(ctx, tx, login); err != nil {
+		l.L(ctx).Error("error applying welcome campaigns", "login", login, l.ErrAttr(err))
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %v", err)
+	}
 	return nil
 }
 
 // Auth выполняет аутентификацию пользователя по указанному логину и паролю.
-// Метод выполняет запрос к базе данных для поиска пользователя с указанными
-// учетными данными. Если пользователь существует и его учетные данные совпадают
-// с переданными в метод логином и паролем, метод завершается успешно. В противном
-// случае возвращается ошибка.
+// Метод выполняет запрос к базе данных для поиска пользователя с указанным логином
+// и проверяет пароль по сохраненному хешу. Если хеш создан другим алгоритмом или с другими
+// параметрами либо пароль хранится в открытом виде, после успешной проверки он пересчитывается
+// текущим алгоритмом; ошибка пересчета только логируется.
 //
 // Параметры:
 //   - ctx: контекст выполнения запроса.
@@ -135,24 +146,69 @@ func (uc *UseCase) Register(ctx context.Context, login, password string) error {
 //   - password: пароль пользователя.
 //
 // Возвращаемое значение:
-//   - error: в случае успешной аутентификации возвращается nil, в противном случае
-//     возвращается ошибка.
+//   - error: в случае успешной аутентификации возвращается nil, ErrUnauthorized, если пользователь
+//     не найден или пароль неверен, ошибка базы данных или хеширования в остальных случаях.
 func (uc *UseCase) Auth(ctx context.Context, login, password string) error {
 	var user entity.User
 
-	err := uc.DB.QueryRowContext(ctx, selectUser, login, password).Scan(
+	err := uc.DB.QueryRowContext(ctx, selectUser, login).Scan(
 		&user.Login,
 		&user.Password,
 		&user.Balance,
 		&user.Withdrawn,
 	)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Пользователь с таким логином не найден. Пароль все равно проверяется по фиктивному хешу,
+		// чтобы по времени ответа нельзя было узнать, существует ли логин
+		uc.Hasher.Verify(password, uc.dummyHash(ctx))
+		return ErrUnauthorized
+	}
 	if err != nil {
-		return err // Пользователь с таким логином и паролем не найден
+		return err
+	}
+
+	ok, rehash, err := uc.Hasher.Verify(password, user.Password)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrUnauthorized // Пароль не совпадает
+	}
+
+	if rehash {
+		if err = uc.rehashPassword(ctx, login, password); err != nil {
+			l.L(ctx).Error("password rehashing", "login", login, l.ErrAttr(err))
+		}
 	}
 
 	return nil // Пользователь успешно аутентифицирован
 }
 
+// dummyHash возвращает хеш случайного пароля, вычисленный текущим алгоритмом с текущими параметрами,
+// поэтому его проверка занимает столько же времени, сколько проверка пароля существующего пользователя.
+func (uc *UseCase) dummyHash(ctx context.Context) string {
+	uc.dummyOnce.Do(func() {
+		password, _, err := newRefreshToken()
+		if err == nil {
+			uc.dummy, err = uc.Hasher.Hash(password)
+		}
+		if err != nil {
+			l.L(ctx).Error("dummy password hashing", l.ErrAttr(err))
+		}
+	})
+	return uc.dummy
+}
+
+// rehashPassword сохраняет хеш пароля, вычисленный текущим алгоритмом.
+func (uc *UseCase) rehashPassword(ctx context.Context, login, password string) error {
+	hash, err := uc.Hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+	_, err = uc.DB.ExecContext(ctx, updateUserPassword, hash, login)
+	return err
+}
+
 // InsertOrder осуществляет вставку нового заказа в базу данных.
 // Метод принимает контекст ctx типа context.Context, имя пользователя user и описание заказа order.
 // Контекст ctx используется для управления временем жизни операции и для передачи значения времени выполнения, которое должно учитываться при выполнении операции.
@@ -231,6 +287,11 @@ func (uc *UseCase) InsertOrder(ctx context.Context, user, order string) error {
 	if err != nil {
 		return err
 	}
+
+	// Начинаем историю статусов заказа.
+	if _, err = tx.ExecContext(ctx, insertOrderStatus, order, userOrder.Status, 0, now); err != nil {
+		return fmt.Errorf("error inserting order status: %v", err)
+	}
 	return nil
 }
 
@@ -325,6 +386,8 @@ func (uc *UseCase) GetBalance(ctx context.Context, login string) (float32, float
 //   - ErrCommittingTransaction: ошибка при коммите транзакции.
 //   - ErrAnotherUser: заказ существует и принадлежит другому пользователю.
 //   - ErrThisUser: заказ существует и принадлежит текущему пользователю.
+//   - ErrWithdrawMax, ErrWithdrawDaily, ErrWithdrawRate, ErrCoolingOff: нарушено правило ограничения списаний
+//     из config.Cfg.WithdrawLimits; нарушение сохраняется в журнале withdrawal_violations.
 func (uc *UseCase) Debit(ctx context.Context, user, order string, sum float32) error {
 	// Проверка корректности номера заказа
 	validOrder := luna.CheckValidOrder(order)
@@ -333,10 +396,17 @@ func (uc *UseCase) Debit(ctx context.Context, user, order string, sum float32) e
 		return uc.Err().ErrOrderFormat
 	}
 
-	// Получение текущего баланса пользователя
-	balance, _, err := uc.GetBalance(ctx, user)
+	tx, err := uc.DB.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return fmt.Errorf("error beginning transaction Debit method: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Получение текущего баланса пользователя; строка блокируется до конца транзакции,
+	// чтобы параллельные списания не обошли проверку баланса и лимитов
+	var balance float32
+	if err = tx.QueryRowContext(ctx, selectBalanceForUpdate, user).Scan(&balance); err != nil {
+		return fmt.Errorf("error selecting user balance: %v", err)
 	}
 
 	// Если на счету пользователя недостаточно средств, возвращает ошибку
@@ -344,15 +414,16 @@ func (uc *UseCase) Debit(ctx context.Context, user, order string, sum float32) e
 		return uc.Err().ErrNoBalance
 	}
 
-	tx, err := uc.DB.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("error beginning transaction Debit method: %v", err)
-	}
-	defer tx.Rollback()
-
 	// Проверка существования заказа в базе данных
 	var existingOrder entity.Order
-	err = uc.DB.QueryRowContext(ctx, selectOrder, order).Scan(&existingOrder.UserName, &existingOrder.Order)
+	err = tx.QueryRowContext(ctx, selectOrder, order).Scan(
+		&existingOrder.UserName,
+		&existingOrder.Order,
+		&existingOrder.Status,
+		&existingOrder.Accrual,
+		&existingOrder.UploadedAt,
+		&existingOrder.BonusesWithdrawn,
+	)
 
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return fmt.Errorf("error checking order existence: %v", err)
@@ -366,22 +437,35 @@ func (uc *UseCase) Debit(ctx context.Context, user, order string, sum float32) e
 		return uc.Err().ErrThisUser
 	}
 
-	// Обновляем баланс пользователя и добавляем запись о списании в базу данных.
+	// Проверка правил ограничения списаний; нарушение сохраняется в журнале после отката транзакции
 	now := time.Now()
-	_, err = uc.DB.ExecContext(ctx, updateUser, sum, user)
+	if err = checkWithdrawalLimits(ctx, tx, user, sum, now); err != nil {
+		tx.Rollback()
+		uc.recordViolation(ctx, user, order, sum, err)
+		return err
+	}
+
+	// Обновляем баланс пользователя и добавляем запись о списании в базу данных.
+	_, err = tx.ExecContext(ctx, updateUser, sum, user)
 	if err != nil {
 		return fmt.Errorf("error updating user balance: %v", err)
 	}
 
-	_, err = uc.DB.ExecContext(ctx, insertOrderWithdrawn, user, order, now, sum)
+	_, err = tx.ExecContext(ctx, insertOrderWithdrawn, user, order, now, sum)
 
 	if err != nil {
 		return fmt.Errorf("error inserting order: %v", err)
 	}
 
+	_, err = tx.ExecContext(ctx, insertOrderStatus, order, entity.OrderNew, 0, now)
+	if err != nil {
+		return fmt.Errorf("error inserting order status: %v", err)
+	}
+
 	if err = tx.Commit(); err != nil {
 		return fmt.Errorf("error committing transaction Debit method: %v", err)
 	}
+	uc.publishBalance(ctx, user)
 	return nil
 }
 
@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/nextlag/gomart/internal/entity"
+)
+
+const (
+	selectUserRole = `
+		SELECT role
+		FROM users
+		WHERE login = $1
+	`
+	selectAdminExists = `
+		SELECT EXISTS (SELECT 1 FROM users WHERE role = $1)
+	`
+	selectUserRoleForUpdate = `
+		SELECT role
+		FROM users
+		WHERE login = $1
+		FOR UPDATE
+	`
+	updateUserRole = `
+		UPDATE users
+		SET role = $1
+		WHERE login = $2
+	`
+)
+
+// GetRole возвращает роль пользователя.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - login: логин пользователя.
+//
+// Возвращаемые значения:
+//   - string: роль пользователя.
+//   - error: ErrUserNotFound, если пользователь не найден, ошибка базы данных в остальных случаях.
+func (uc *UseCase) GetRole(ctx context.Context, login string) (string, error) {
+	var role string
+	err := uc.DB.QueryRowContext(ctx, selectUserRole, login).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrUserNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("error selecting user role: %v", err)
+	}
+	return role, nil
+}
+
+// HasAdmin сообщает, есть ли пользователь с ролью entity.RoleAdmin.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//
+// Возвращаемые значения:
+//   - bool: true, если администратор уже назначен.
+//   - error: ошибка базы данных.
+func (uc *UseCase) HasAdmin(ctx context.Context) (bool, error) {
+	var exists bool
+	if err := uc.DB.QueryRowContext(ctx, selectAdminExists, entity.RoleAdmin).Scan(&exists); err != nil {
+		return false, fmt.Errorf("error selecting admin: %v", err)
+	}
+	return exists, nil
+}
+
+// SetRole назначает пользователю роль. При изменении роли в той же транзакции отзываются все refresh-токены,
+// сеансы и токены доступа пользователя: роль записана в токене доступа, и без отзыва пониженный администратор
+// сохранял бы права до истечения токена. Новая роль попадает в токен доступа при следующем входе.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - login: логин пользователя.
+//   - role: одна из ролей entity.Roles.
+//
+// Возвращаемое значение:
+//   - error: ErrRole, если роль неизвестна, ErrUserNotFound, если пользователь не найден,
+//     ошибка базы данных в остальных случаях.
+func (uc *UseCase) SetRole(ctx context.Context, login, role string) error {
+	if !slices.Contains(entity.Roles, role) {
+		return ErrRole
+	}
+
+	tx, err := uc.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = setRole(ctx, txRoleStore{tx: tx}, login, role, time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// roleStore - операции назначения роли в рамках одной транзакции.
+type roleStore interface {
+	// role блокирует и возвращает роль пользователя или sql.ErrNoRows
+	role(ctx context.Context, login string) (string, error)
+	// setRole сохраняет роль пользователя
+	setRole(ctx context.Context, login, role string) error
+	// revokeTokens отзывает refresh-токены, сеансы и токены доступа пользователя, выданные до now
+	revokeTokens(ctx context.Context, login string, now time.Time) error
+}
+
+// txRoleStore - roleStore на основе транзакции базы данных.
+type txRoleStore struct {
+	tx *sql.Tx
+}
+
+func (s txRoleStore) role(ctx context.Context, login string) (string, error) {
+	var role string
+	err := s.tx.QueryRowContext(ctx, selectUserRoleForUpdate, login).Scan(&role)
+	return role, err
+}
+
+func (s txRoleStore) setRole(ctx context.Context, login, role string) error {
+	if _, err := s.tx.ExecContext(ctx, updateUserRole, role, login); err != nil {
+		return fmt.Errorf("error updating user role: %v", err)
+	}
+	return nil
+}
+
+func (s txRoleStore) revokeTokens(ctx context.Context, login string, now time.Time) error {
+	return revokeUserTokens(ctx, s.tx, login, now)
+}
+
+// setRole назначает пользователю роль и, если она изменилась, отзывает его токены.
+func setRole(ctx context.Context, store roleStore, login, role string, now time.Time) error {
+	current, err := store.role(ctx, login)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrUserNotFound
+	case err != nil:
+		return fmt.Errorf("error selecting user role: %v", err)
+	case current == role:
+		return nil
+	}
+
+	if err = store.setRole(ctx, login, role); err != nil {
+		return err
+	}
+	return store.revokeTokens(ctx, login, now)
+}
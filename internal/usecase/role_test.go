@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nextlag/gomart/internal/entity"
+)
+
+// memoryRoleStore - roleStore в памяти для тестов назначения роли.
+type memoryRoleStore struct {
+	roles   map[string]string
+	revoked map[string]time.Time
+}
+
+func (s *memoryRoleStore) role(_ context.Context, login string) (string, error) {
+	role, ok := s.roles[login]
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return role, nil
+}
+
+func (s *memoryRoleStore) setRole(_ context.Context, login, role string) error {
+	s.roles[login] = role
+	return nil
+}
+
+func (s *memoryRoleStore) revokeTokens(_ context.Context, login string, now time.Time) error {
+	s.revoked[login] = now
+	return nil
+}
+
+func TestSetRole(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		login   string
+		role    string
+		want    string
+		revoked bool
+		err     error
+	}{
+		{name: "Admin demoted", login: "root", role: entity.RoleUser, want: entity.RoleUser, revoked: true},
+		{name: "Admin demoted to support", login: "root", role: entity.RoleSupport, want: entity.RoleSupport, revoked: true},
+		{name: "User promoted", login: "test", role: entity.RoleAdmin, want: entity.RoleAdmin, revoked: true},
+		{name: "Same role keeps tokens", login: "root", role: entity.RoleAdmin, want: entity.RoleAdmin},
+		{name: "Unknown user", login: "nobody", role: entity.RoleUser, err: ErrUserNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memoryRoleStore{
+				roles:   map[string]string{"root": entity.RoleAdmin, "test": entity.RoleUser},
+				revoked: map[string]time.Time{},
+			}
+			err := setRole(context.Background(), store, tt.login, tt.role, now)
+			assert.ErrorIs(t, err, tt.err)
+			if tt.err != nil {
+				assert.Empty(t, store.revoked)
+				return
+			}
+			assert.Equal(t, tt.want, store.roles[tt.login])
+			revokedAt, revoked := store.revoked[tt.login]
+			assert.Equal(t, tt.revoked, revoked, "Токены пользователя не отозваны при смене роли")
+			if tt.revoked {
+				assert.Equal(t, now, revokedAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/nextlag/gomart/internal/config"
+	"github.com/nextlag/gomart/internal/entity"
+)
+
+const (
+	// sessionIDLength - длина идентификатора сеанса в байтах до кодирования
+	sessionIDLength = 16
+	// sessionSeenInterval - минимальный интервал обновления времени последней активности сеанса
+	sessionSeenInterval = time.Minute
+)
+
+const (
+	insertSession = `
+		INSERT INTO sessions (id, login, user_agent, ip, created_at, last_seen_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+	`
+	updateSessionClient = `
+		UPDATE sessions
+		SET user_agent = $2, ip = $3, last_seen_at = $4
+		WHERE id = $1
+		RETURNING created_at
+	`
+	updateSessionLastSeen = `
+		UPDATE sessions
+		SET last_seen_at = $2
+		WHERE id = $1 AND last_seen_at < $3
+	`
+	selectSessions = `
+		SELECT id, login, user_agent, ip, created_at, last_seen_at
+		FROM sessions
+		WHERE login = $1 AND revoked_at IS NULL AND last_seen_at > $2
+		ORDER BY last_seen_at DESC
+	`
+	revokeSession = `
+		UPDATE sessions
+		SET revoked_at = $3
+		WHERE id = $1 AND login = $2 AND revoked_at IS NULL
+	`
+	revokeUserSessions = `
+		UPDATE sessions
+		SET revoked_at = $2
+		WHERE login = $1 AND revoked_at IS NULL
+	`
+	revokeSessionRefreshTokens = `
+		UPDATE refresh_tokens
+		SET revoked_at = $2
+		WHERE session_id = $1 AND revoked_at IS NULL
+	`
+)
+
+// createSession создает в рамках транзакции tx сеанс пользователя session.Login с User-Agent и IP-адресом клиента.
+func createSession(ctx context.Context, tx *sql.Tx, session entity.Session, now time.Time) (entity.Session, error) {
+	id := make([]byte, sessionIDLength)
+	if _, err := rand.Read(id); err != nil {
+		return session, err
+	}
+	session.ID = hex.EncodeToString(id)
+	session.CreatedAt, session.LastSeenAt = now, now
+	_, err := tx.ExecContext(ctx, insertSession, session.ID, session.Login, session.UserAgent, session.IP, now)
+	if err != nil {
+		return session, fmt.Errorf("error inserting session: %v", err)
+	}
+	return session, nil
+}
+
+// touchSession обновляет в рамках транзакции tx User-Agent, IP-адрес клиента и время последней активности сеанса id.
+func touchSession(ctx context.Context, tx *sql.Tx, id string, session entity.Session, now time.Time) (entity.Session, error) {
+	session.ID, session.LastSeenAt = id, now
+	err := tx.QueryRowContext(ctx, updateSessionClient, id, session.UserAgent, session.IP, now).Scan(&session.CreatedAt)
+	if err != nil {
+		return session, fmt.Errorf("error updating session: %v", err)
+	}
+	return session, nil
+}
+
+// StartSession начинает новый сеанс пользователя без refresh-токена: клиент получает только токены доступа
+// и входит заново, когда они истекают.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - session: логин пользователя, User-Agent и IP-адрес клиента.
+//
+// Возвращаемые значения:
+//   - entity.Session: созданный сеанс.
+//   - error: ошибка выполнения запроса к базе данных.
+func (uc *UseCase) StartSession(ctx context.Context, session entity.Session) (entity.Session, error) {
+	tx, err := uc.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return session, err
+	}
+	defer tx.Rollback()
+
+	if session, err = createSession(ctx, tx, session, time.Now()); err != nil {
+		return session, err
+	}
+	return session, tx.Commit()
+}
+
+// GetSessions возвращает действующие сеансы пользователя, начиная с последнего активного.
+// Сеанс без активности дольше config.Cfg.RefreshTokenTTL считается завершенным: его refresh-токен истек.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - login: логин пользователя.
+//
+// Возвращаемые значения:
+//   - []entity.Session: сеансы пользователя.
+//   - error: ошибка при выполнении запроса к базе данных.
+func (uc *UseCase) GetSessions(ctx context.Context, login string) ([]entity.Session, error) {
+	rows, err := uc.DB.QueryContext(ctx, selectSessions, login, time.Now().Add(-config.Cfg.RefreshTokenTTL))
+	if err != nil {
+		return nil, fmt.Errorf("error selecting sessions: %v", err)
+	}
+	defer rows.Close()
+
+	sessions := make([]entity.Session, 0)
+	for rows.Next() {
+		var s entity.Session
+		if err = rows.Scan(&s.ID, &s.Login, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastSeenAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// RevokeSession завершает сеанс пользователя: отзывает его refresh-токены, а токены доступа сеанса
+// перестают приниматься сразу.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - login: логин владельца сеанса.
+//   - id: идентификатор сеанса.
+//
+// Возвращаемое значение:
+//   - error: ErrNoSession, если сеанс не найден, принадлежит другому пользователю или уже завершен,
+//     ошибка базы данных в остальных случаях.
+func (uc *UseCase) RevokeSession(ctx context.Context, login, id string) error {
+	now := time.Now()
+
+	tx, err := uc.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, revokeSession, id, login, now)
+	if err != nil {
+		return fmt.Errorf("error revoking session: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNoSession
+	}
+	if _, err = tx.ExecContext(ctx, revokeSessionRefreshTokens, id, now); err != nil {
+		return fmt.Errorf("error revoking refresh tokens: %v", err)
+	}
+	return tx.Commit()
+}
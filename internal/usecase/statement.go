@@ -0,0 +1,130 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nextlag/gomart/internal/entity"
+)
+
+// Типы операций в выписке по счету.
+const (
+	EntryAccrual    = "accrual"
+	EntryWithdrawal = "withdrawal"
+	EntryAdjustment = "adjustment"
+)
+
+const (
+	selectOpeningBalance = `
+		SELECT
+			COALESCE(SUM(CASE WHEN status = 'PROCESSED' THEN accrual ELSE 0 END), 0),
+			COALESCE(SUM(bonuses_withdrawn), 0),
+			(SELECT COALESCE(SUM(amount), 0) FROM balance_adjustments WHERE login = $1 AND created_at < $2)
+		FROM orders
+		WHERE user_name = $1 AND uploaded_at < $2
+	`
+	selectStatement = `
+		SELECT "order", status, accrual, COALESCE(bonuses_withdrawn, 0), 0, '', uploaded_at AS at
+		FROM orders
+		WHERE user_name = $1 AND uploaded_at >= $2 AND uploaded_at < $3
+			AND ((status = 'PROCESSED' AND accrual != 0) OR bonuses_withdrawn != 0)
+		UNION ALL
+		SELECT '', '', 0, 0, amount, reason, created_at AS at
+		FROM balance_adjustments
+		WHERE login = $1 AND created_at >= $2 AND created_at < $3
+		ORDER BY at ASC, 1 ASC
+	`
+)
+
+// StatementWriter принимает выписку по счету по мере чтения операций из базы данных.
+type StatementWriter interface {
+	// Begin вызывается один раз перед первой операцией с входящим остатком на начало периода.
+	Begin(opening float32) error
+	// Entry вызывается для каждой операции за период в хронологическом порядке.
+	Entry(entry entity.StatementEntry) error
+	// End вызывается один раз после последней операции с итогами за период.
+	End(totals entity.StatementTotals) error
+}
+
+// Statement формирует выписку по счету пользователя за период [from, to).
+// Начисления и списания по заказам, а также ручные корректировки и промо-начисления читаются построчно и сразу передаются в StatementWriter
+// вместе с текущим остатком, поэтому история любого размера не накапливается в памяти.
+// Входящий остаток и операции читаются в одной транзакции, чтобы итоги сходились.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - user: логин пользователя.
+//   - from, to: границы периода.
+//   - w: получатель выписки.
+//
+// Возвращаемое значение:
+//   - error: ошибка при выполнении запроса к базе данных или при записи выписки.
+func (uc *UseCase) Statement(ctx context.Context, user string, from, to time.Time, w StatementWriter) error {
+	tx, err := uc.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("error beginning transaction Statement method: %v", err)
+	}
+	defer tx.Rollback()
+
+	var accrued, withdrawn, adjusted float32
+	if err = tx.QueryRowContext(ctx, selectOpeningBalance, user, from).Scan(&accrued, &withdrawn, &adjusted); err != nil {
+		return fmt.Errorf("error calculating opening balance: %v", err)
+	}
+	totals := entity.StatementTotals{Opening: accrued - withdrawn + adjusted}
+	if err = w.Begin(totals.Opening); err != nil {
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, selectStatement, user, from, to)
+	if err != nil {
+		return fmt.Errorf("error selecting statement: %v", err)
+	}
+	defer rows.Close()
+
+	balance := totals.Opening
+	for rows.Next() {
+		var (
+			order, status, reason string
+			accrual, sum, amount  float32
+			uploadedAt            time.Time
+		)
+		if err = rows.Scan(&order, &status, &accrual, &sum, &amount, &reason, &uploadedAt); err != nil {
+			return err
+		}
+
+		// Ручная корректировка или промо-начисление
+		if amount != 0 {
+			balance += amount
+			totals.Adjusted += amount
+			entry := entity.StatementEntry{Time: uploadedAt, Type: EntryAdjustment, Reason: reason, Amount: amount, Balance: balance}
+			if err = w.Entry(entry); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Заказ, оплаченный баллами, может и сам получить начисление - тогда в выписке будут обе операции
+		if sum != 0 {
+			balance -= sum
+			totals.Withdrawn += sum
+			if err = w.Entry(entity.StatementEntry{Time: uploadedAt, Type: EntryWithdrawal, Order: order, Amount: -sum, Balance: balance}); err != nil {
+				return err
+			}
+		}
+		if status == "PROCESSED" && accrual != 0 {
+			balance += accrual
+			totals.Accrued += accrual
+			if err = w.Entry(entity.StatementEntry{Time: uploadedAt, Type: EntryAccrual, Order: order, Amount: accrual, Balance: balance}); err != nil {
+				return err
+			}
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	totals.Closing = balance
+	return w.End(totals)
+}
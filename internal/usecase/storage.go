@@ -21,20 +21,197 @@ const (
 		uploaded_at TIMESTAMP,
 		bonuses_withdrawn FLOAT
 	);`
+	usersTierColumn            = `ALTER TABLE users ADD COLUMN IF NOT EXISTS tier VARCHAR(32) NOT NULL DEFAULT 'BASE';`
+	usersTokensColumn          = `ALTER TABLE users ADD COLUMN IF NOT EXISTS tokens_valid_after TIMESTAMP;`
+	usersRoleColumn            = `ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(32) NOT NULL DEFAULT 'user';`
+	usersPasswordChangedColumn = `ALTER TABLE users ADD COLUMN IF NOT EXISTS password_changed_at TIMESTAMP;`
+	usersDeletedColumn         = `ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;`
+	ordersBaseAccrualColumn    = `ALTER TABLE orders ADD COLUMN IF NOT EXISTS base_accrual FLOAT;`
+	ordersHistoryIndex         = `CREATE INDEX IF NOT EXISTS orders_user_uploaded_idx ON orders (user_name, uploaded_at, "order");`
+	idempotencyTable           = `CREATE TABLE IF NOT EXISTS idempotency_keys (
+		login VARCHAR(255) NOT NULL,
+		key VARCHAR(255) NOT NULL,
+		request_hash VARCHAR(64) NOT NULL,
+		status INTEGER NOT NULL DEFAULT 0,
+		content_type VARCHAR(255),
+		body BYTEA,
+		created_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (login, key)
+	);`
+	withdrawLimitsTable = `CREATE TABLE IF NOT EXISTS withdraw_limits (
+		login VARCHAR(255) PRIMARY KEY,
+		max_sum FLOAT,
+		max_daily_sum FLOAT,
+		max_hourly_count INT,
+		updated_by VARCHAR(255) NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);`
+	adjustmentsTable = `CREATE TABLE IF NOT EXISTS balance_adjustments (
+		id BIGSERIAL PRIMARY KEY,
+		login VARCHAR(255) NOT NULL,
+		amount FLOAT NOT NULL,
+		reason TEXT NOT NULL,
+		campaign VARCHAR(255),
+		created_by VARCHAR(255) NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	);`
+	campaignsTable = `CREATE TABLE IF NOT EXISTS campaigns (
+		name VARCHAR(255) PRIMARY KEY,
+		kind VARCHAR(32) NOT NULL,
+		amount FLOAT NOT NULL,
+		reason TEXT NOT NULL,
+		active BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at TIMESTAMP NOT NULL
+	);`
+	campaignGrantsTable = `CREATE TABLE IF NOT EXISTS campaign_grants (
+		campaign VARCHAR(255) NOT NULL,
+		login VARCHAR(255) NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (campaign, login)
+	);`
+	violationsTable = `CREATE TABLE IF NOT EXISTS withdrawal_violations (
+		id BIGSERIAL PRIMARY KEY,
+		login VARCHAR(255) NOT NULL,
+		"order" VARCHAR(255) NOT NULL,
+		sum FLOAT NOT NULL,
+		rule VARCHAR(64) NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	);`
+	refreshTokensTable = `CREATE TABLE IF NOT EXISTS refresh_tokens (
+		token_hash VARCHAR(64) PRIMARY KEY,
+		login VARCHAR(255) NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		revoked_at TIMESTAMP
+	);`
+	revokedTokensTable = `CREATE TABLE IF NOT EXISTS revoked_tokens (
+		jti VARCHAR(64) PRIMARY KEY,
+		expires_at TIMESTAMP NOT NULL
+	);`
+	loginAttemptsTable = `CREATE TABLE IF NOT EXISTS login_attempts (
+		key VARCHAR(300) PRIMARY KEY,
+		failures INT NOT NULL,
+		last_failure TIMESTAMP NOT NULL,
+		locked_until TIMESTAMP
+	);`
+	passwordResetsTable = `CREATE TABLE IF NOT EXISTS password_resets (
+		token_hash VARCHAR(64) PRIMARY KEY,
+		login VARCHAR(255) NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		used_at TIMESTAMP
+	);`
+	sessionsTable = `CREATE TABLE IF NOT EXISTS sessions (
+		id VARCHAR(32) PRIMARY KEY,
+		login VARCHAR(255) NOT NULL,
+		user_agent TEXT NOT NULL,
+		ip VARCHAR(64) NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		last_seen_at TIMESTAMP NOT NULL,
+		revoked_at TIMESTAMP
+	);`
+	totpSecretsTable = `CREATE TABLE IF NOT EXISTS totp_secrets (
+		login VARCHAR(255) PRIMARY KEY,
+		secret VARCHAR(64) NOT NULL,
+		last_counter BIGINT NOT NULL DEFAULT 0,
+		created_at TIMESTAMP NOT NULL,
+		confirmed_at TIMESTAMP
+	);`
+	recoveryCodesTable = `CREATE TABLE IF NOT EXISTS recovery_codes (
+		login VARCHAR(255) NOT NULL,
+		code_hash VARCHAR(64) NOT NULL,
+		used_at TIMESTAMP,
+		PRIMARY KEY (login, code_hash)
+	);`
+	oidcStatesTable = `CREATE TABLE IF NOT EXISTS oidc_states (
+		state_hash VARCHAR(64) PRIMARY KEY,
+		nonce VARCHAR(64) NOT NULL,
+		verifier VARCHAR(64) NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	);`
+	orderStatusHistoryTable = `CREATE TABLE IF NOT EXISTS order_status_history (
+		id BIGSERIAL PRIMARY KEY,
+		"order" VARCHAR(255) NOT NULL,
+		status VARCHAR(255) NOT NULL,
+		accrual FLOAT NOT NULL,
+		changed_at TIMESTAMP NOT NULL
+	);`
+	orderStatusHistoryIndex = `CREATE INDEX IF NOT EXISTS order_status_history_order_idx ON order_status_history ("order", id);`
+	userIdentitiesTable     = `CREATE TABLE IF NOT EXISTS user_identities (
+		issuer VARCHAR(255) NOT NULL,
+		subject VARCHAR(255) NOT NULL,
+		login VARCHAR(255) NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (issuer, subject)
+	);`
+	refreshTokensSessionColumn = `ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS session_id VARCHAR(32);`
+	apiKeysTable               = `CREATE TABLE IF NOT EXISTS api_keys (
+		id VARCHAR(32) PRIMARY KEY,
+		key_hash VARCHAR(64) NOT NULL UNIQUE,
+		name VARCHAR(255) NOT NULL,
+		scopes TEXT[] NOT NULL,
+		rate_limit INT NOT NULL,
+		created_by VARCHAR(255) NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		last_used_at TIMESTAMP,
+		revoked_at TIMESTAMP
+	);`
+	apiKeyRequestsTable = `CREATE TABLE IF NOT EXISTS api_key_requests (
+		key_id VARCHAR(32) PRIMARY KEY,
+		window_start TIMESTAMP NOT NULL,
+		requests INT NOT NULL
+	);`
+	oidcChallengesTable = `CREATE TABLE IF NOT EXISTS oidc_challenges (
+		challenge_hash VARCHAR(64) PRIMARY KEY,
+		login VARCHAR(255) NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	);`
 )
 
+// migrations - запросы создания и изменения таблиц в порядке их выполнения
+var migrations = []struct {
+	name  string
+	query string
+}{
+	{"create users table", usersTable},
+	{"create orders table", ordersTable},
+	{"add users tier column", usersTierColumn},
+	{"create idempotency_keys table", idempotencyTable},
+	{"create balance_adjustments table", adjustmentsTable},
+	{"create campaigns table", campaignsTable},
+	{"create campaign_grants table", campaignGrantsTable},
+	{"add users password_changed_at column", usersPasswordChangedColumn},
+	{"create withdrawal_violations table", violationsTable},
+	{"create refresh_tokens table", refreshTokensTable},
+	{"create revoked_tokens table", revokedTokensTable},
+	{"create login_attempts table", loginAttemptsTable},
+	{"add users role column", usersRoleColumn},
+	{"add users tokens_valid_after column", usersTokensColumn},
+	{"create password_resets table", passwordResetsTable},
+	{"create api_keys table", apiKeysTable},
+	{"create sessions table", sessionsTable},
+	{"add refresh_tokens session_id column", refreshTokensSessionColumn},
+	{"create totp_secrets table", totpSecretsTable},
+	{"create recovery_codes table", recoveryCodesTable},
+	{"create oidc_states table", oidcStatesTable},
+	{"create user_identities table", userIdentitiesTable},
+	{"add users deleted_at column", usersDeletedColumn},
+	{"create orders history index", ordersHistoryIndex},
+	{"create order_status_history table", orderStatusHistoryTable},
+	{"create order_status_history index", orderStatusHistoryIndex},
+	{"add orders base_accrual column", ordersBaseAccrualColumn},
+	{"create withdraw_limits table", withdrawLimitsTable},
+	{"create api_key_requests table", apiKeyRequestsTable},
+	{"create oidc_challenges table", oidcChallengesTable},
+}
+
 // CreateTable - creating tables in the database
 func (uc *UseCase) CreateTable(ctx context.Context) error {
-	_, err := uc.DB.ExecContext(ctx, usersTable)
-	if err != nil {
-		return fmt.Errorf("exec create users table query: %v", err.Error())
+	for _, m := range migrations {
+		if _, err := uc.DB.ExecContext(ctx, m.query); err != nil {
+			return fmt.Errorf("exec %s query: %v", m.name, err.Error())
+		}
 	}
-
-	_, err = uc.DB.ExecContext(ctx, ordersTable)
-	if err != nil {
-		return fmt.Errorf("exec create orders table query: %v", err.Error())
-	}
-
 	return nil
 }
 
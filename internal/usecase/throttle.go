@@ -0,0 +1,233 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nextlag/gomart/internal/config"
+)
+
+// ThrottleError - отказ во входе из-за превышения количества неудачных попыток.
+// Оборачивает ErrTooManyAttempts и содержит время, через которое можно повторить попытку.
+type ThrottleError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottleError) Error() string {
+	return ErrTooManyAttempts.Error()
+}
+
+func (e *ThrottleError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+const (
+	insertLoginAttempts = `
+		INSERT INTO login_attempts (key, failures, last_failure)
+		VALUES ($1, 0, $2)
+		ON CONFLICT (key) DO NOTHING
+	`
+	selectLoginAttemptsForUpdate = `
+		SELECT failures, last_failure, locked_until
+		FROM login_attempts
+		WHERE key = $1
+		FOR UPDATE
+	`
+	updateLoginAttempts = `
+		UPDATE login_attempts
+		SET failures = $2, last_failure = $3, locked_until = $4
+		WHERE key = $1
+	`
+	releaseLoginAttempt = `
+		UPDATE login_attempts
+		SET failures = GREATEST(failures - 1, 0),
+		    locked_until = CASE WHEN $2 > 0 AND failures - 1 >= $2 THEN locked_until END
+		WHERE key = $1
+	`
+	deleteLoginAttempts = `
+		DELETE FROM login_attempts
+		WHERE key = $1
+	`
+)
+
+// attemptCounter - счетчик неудачных попыток входа с порогом блокировки.
+type attemptCounter struct {
+	key         string
+	maxFailures int
+}
+
+// attemptState - состояние счетчика неудачных попыток входа.
+type attemptState struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil sql.NullTime
+}
+
+// attemptCounters возвращает счетчики неудачных попыток для логина и IP-адреса клиента.
+// Порядок счетчиков постоянный, поэтому параллельные резервирования блокируют строки в одном порядке.
+func attemptCounters(login, ip string) []attemptCounter {
+	throttle := config.Cfg.LoginThrottle
+	counters := []attemptCounter{{key: "login:" + login, maxFailures: throttle.MaxFailures}}
+	if ip != "" {
+		counters = append(counters, attemptCounter{key: "ip:" + ip, maxFailures: throttle.IPMaxFailures})
+	}
+	return counters
+}
+
+// loginDelay возвращает задержку после указанного количества неудачных попыток:
+// config.Cfg.LoginThrottle.BaseDelay, удваиваемая с каждой попыткой, но не больше времени блокировки.
+func loginDelay(failures int) time.Duration {
+	throttle := config.Cfg.LoginThrottle
+	delay := throttle.BaseDelay
+	for i := 1; i < failures && delay < throttle.Lockout; i++ {
+		delay *= 2
+	}
+	if delay > throttle.Lockout {
+		delay = throttle.Lockout
+	}
+	return delay
+}
+
+// reserveAttempt проверяет, разрешена ли попытка в момент now, и возвращает состояние счетчика,
+// в котором попытка уже учтена как неудачная. Если попытка не разрешена, возвращается время до следующей попытки.
+func reserveAttempt(counter attemptCounter, state attemptState, now time.Time) (attemptState, time.Duration) {
+	throttle := config.Cfg.LoginThrottle
+	switch {
+	case state.lockedUntil.Valid && now.Before(state.lockedUntil.Time):
+		return state, state.lockedUntil.Time.Sub(now)
+	case state.failures > 0 && now.Sub(state.lastFailure) >= throttle.Window:
+		// Счетчик, не увеличивавшийся дольше окна, начинается заново
+		state.failures = 0
+	case state.failures > 0:
+		if wait := state.lastFailure.Add(loginDelay(state.failures)).Sub(now); wait > 0 {
+			return state, wait
+		}
+	}
+
+	state.failures++
+	state.lastFailure = now
+	state.lockedUntil = sql.NullTime{}
+	if counter.maxFailures > 0 && state.failures >= counter.maxFailures {
+		state.lockedUntil = sql.NullTime{Time: now.Add(throttle.Lockout), Valid: true}
+	}
+	return state, 0
+}
+
+// resetCounters возвращает счетчики запросов сброса пароля для логина и IP-адреса клиента.
+// Они ведутся отдельно от счетчиков входа, чтобы запросы сброса не блокировали вход пользователя.
+func resetCounters(login, ip string) []attemptCounter {
+	counters := attemptCounters(login, ip)
+	for i := range counters {
+		counters[i].key = "reset:" + counters[i].key
+	}
+	return counters
+}
+
+// ReserveLoginAttempt проверяет, разрешена ли сейчас попытка входа для логина и IP-адреса клиента, и сразу учитывает ее
+// как неудачную. Проверка и учет выполняются в одной транзакции с блокировкой строк счетчиков, поэтому параллельные
+// попытки не обходят задержку: каждая следующая видит попытки, зарезервированные до нее. Попытку, которая не оказалась
+// неудачной, нужно вернуть через ReleaseLoginAttempt.
+// Счетчики хранятся в базе данных, поэтому ограничения действуют для всех экземпляров сервиса.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - login: логин, под которым выполняется вход.
+//   - ip: IP-адрес клиента; пустое значение отключает проверку по адресу.
+//
+// Возвращаемое значение:
+//   - error: *ThrottleError, если логин или адрес заблокирован или не истекла задержка после предыдущей попытки,
+//     ошибка базы данных в остальных случаях.
+func (uc *UseCase) ReserveLoginAttempt(ctx context.Context, login, ip string) error {
+	return uc.reserveAttempts(ctx, attemptCounters(login, ip))
+}
+
+// ReservePasswordReset ограничивает запросы сброса пароля так же, как попытки входа: каждый запрос учитывается
+// в счетчиках логина и IP-адреса клиента, следующий разрешается через удваивающуюся задержку, а после достижения
+// порога логин или адрес блокируется. Запрос учитывается независимо от того, зарегистрирован ли логин.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - login: логин, для которого запрошен сброс пароля.
+//   - ip: IP-адрес клиента; пустое значение отключает проверку по адресу.
+//
+// Возвращаемое значение:
+//   - error: *ThrottleError, если не истекла задержка после предыдущего запроса или счетчик заблокирован,
+//     ошибка базы данных в остальных случаях.
+func (uc *UseCase) ReservePasswordReset(ctx context.Context, login, ip string) error {
+	return uc.reserveAttempts(ctx, resetCounters(login, ip))
+}
+
+// reserveAttempts атомарно проверяет счетчики и учитывает в них попытку.
+func (uc *UseCase) reserveAttempts(ctx context.Context, counters []attemptCounter) error {
+	now := time.Now()
+	tx, err := uc.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	states := make([]attemptState, len(counters))
+	var retry time.Duration
+	for i, counter := range counters {
+		if _, err = tx.ExecContext(ctx, insertLoginAttempts, counter.key, now); err != nil {
+			return fmt.Errorf("error inserting login attempts: %v", err)
+		}
+		var state attemptState
+		err = tx.QueryRowContext(ctx, selectLoginAttemptsForUpdate, counter.key).Scan(&state.failures, &state.lastFailure, &state.lockedUntil)
+		if err != nil {
+			return fmt.Errorf("error selecting login attempts: %v", err)
+		}
+
+		var wait time.Duration
+		states[i], wait = reserveAttempt(counter, state, now)
+		if wait > retry {
+			retry = wait
+		}
+	}
+	if retry > 0 {
+		return &ThrottleError{RetryAfter: retry}
+	}
+
+	for i, counter := range counters {
+		state := states[i]
+		if _, err = tx.ExecContext(ctx, updateLoginAttempts, counter.key, state.failures, state.lastFailure, state.lockedUntil); err != nil {
+			return fmt.Errorf("error recording login attempt: %v", err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing login attempt: %v", err)
+	}
+	return nil
+}
+
+// ReleaseLoginAttempt возвращает попытку, зарезервированную ReserveLoginAttempt, если она не оказалась неудачной
+// (пароль и код верны или проверка не выполнялась из-за внутренней ошибки). Счетчики логина и IP-адреса уменьшаются,
+// блокировка снимается, если счетчик опустился ниже порога. Время последней попытки не восстанавливается.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - login: логин, под которым выполнялся вход.
+//   - ip: IP-адрес клиента; пустое значение отключает учет по адресу.
+//
+// Возвращаемое значение:
+//   - error: ошибка при выполнении запроса к базе данных.
+func (uc *UseCase) ReleaseLoginAttempt(ctx context.Context, login, ip string) error {
+	for _, counter := range attemptCounters(login, ip) {
+		if _, err := uc.DB.ExecContext(ctx, releaseLoginAttempt, counter.key, counter.maxFailures); err != nil {
+			return fmt.Errorf("error releasing login attempt: %v", err)
+		}
+	}
+	return nil
+}
+
+// ResetLoginAttempts сбрасывает счетчик неудачных попыток входа для логина после успешного входа.
+// Счетчик IP-адреса не сбрасывается, чтобы успешный вход в свою учетную запись не снимал ограничения
+// на подбор паролей к чужим.
+func (uc *UseCase) ResetLoginAttempts(ctx context.Context, login string) error {
+	if _, err := uc.DB.ExecContext(ctx, deleteLoginAttempts, "login:"+login); err != nil {
+		return fmt.Errorf("error resetting login attempts: %v", err)
+	}
+	return nil
+}
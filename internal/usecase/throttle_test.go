@@ -0,0 +1,312 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nextlag/gomart/internal/config"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+// useLoginThrottle задает параметры защиты входа до конца теста.
+func useLoginThrottle(t *testing.T) {
+	t.Helper()
+	throttle := config.Cfg.LoginThrottle
+	config.Cfg.LoginThrottle = config.LoginThrottle{
+		MaxFailures:   3,
+		IPMaxFailures: 10,
+		BaseDelay:     time.Second,
+		Lockout:       time.Minute,
+		Window:        time.Hour,
+	}
+	t.Cleanup(func() { config.Cfg.LoginThrottle = throttle })
+}
+
+func TestLoginDelay(t *testing.T) {
+	useLoginThrottle(t)
+	for failures, want := range map[int]time.Duration{
+		1:  time.Second,
+		2:  2 * time.Second,
+		3:  4 * time.Second,
+		6:  32 * time.Second,
+		7:  time.Minute,
+		20: time.Minute,
+	} {
+		assert.Equal(t, want, loginDelay(failures), "failures %d", failures)
+	}
+}
+
+func TestReserveAttempt(t *testing.T) {
+	useLoginThrottle(t)
+	now := time.Date(2024, time.January, 2, 12, 0, 0, 0, time.UTC)
+	counter := attemptCounter{key: "login:test", maxFailures: 3}
+	locked := func(at time.Time) sql.NullTime { return sql.NullTime{Time: at, Valid: true} }
+
+	tests := []struct {
+		name  string
+		state attemptState
+		want  attemptState
+		wait  time.Duration
+	}{
+		{
+			name:  "First attempt",
+			state: attemptState{lastFailure: now},
+			want:  attemptState{failures: 1, lastFailure: now},
+		},
+		{
+			name:  "Delay after failure not expired",
+			state: attemptState{failures: 2, lastFailure: now.Add(-time.Second)},
+			want:  attemptState{failures: 2, lastFailure: now.Add(-time.Second)},
+			wait:  time.Second,
+		},
+		{
+			name:  "Delay after failure expired",
+			state: attemptState{failures: 1, lastFailure: now.Add(-time.Second)},
+			want:  attemptState{failures: 2, lastFailure: now},
+		},
+		{
+			name:  "Threshold reached locks counter",
+			state: attemptState{failures: 2, lastFailure: now.Add(-time.Minute)},
+			want:  attemptState{failures: 3, lastFailure: now, lockedUntil: locked(now.Add(time.Minute))},
+		},
+		{
+			name:  "Locked",
+			state: attemptState{failures: 3, lastFailure: now.Add(-10 * time.Second), lockedUntil: locked(now.Add(50 * time.Second))},
+			want:  attemptState{failures: 3, lastFailure: now.Add(-10 * time.Second), lockedUntil: locked(now.Add(50 * time.Second))},
+			wait:  50 * time.Second,
+		},
+		{
+			name:  "Lockout expired",
+			state: attemptState{failures: 3, lastFailure: now.Add(-time.Minute), lockedUntil: locked(now.Add(-time.Second))},
+			want:  attemptState{failures: 4, lastFailure: now, lockedUntil: locked(now.Add(time.Minute))},
+		},
+		{
+			name:  "Window expired resets counter",
+			state: attemptState{failures: 2, lastFailure: now.Add(-2 * time.Hour)},
+			want:  attemptState{failures: 1, lastFailure: now},
+		},
+		{
+			name:  "Released attempts have no delay",
+			state: attemptState{lastFailure: now.Add(-100 * time.Millisecond)},
+			want:  attemptState{failures: 1, lastFailure: now},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, wait := reserveAttempt(counter, tt.state, now)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wait, wait)
+		})
+	}
+}
+
+func TestReserveAttemptParallel(t *testing.T) {
+	useLoginThrottle(t)
+	now := time.Date(2024, time.January, 2, 12, 0, 0, 0, time.UTC)
+	counter := attemptCounter{key: "login:test", maxFailures: 3}
+
+	// Строка счетчика блокируется на время проверки, поэтому параллельные попытки видят результат друг друга
+	state, wait := reserveAttempt(counter, attemptState{lastFailure: now}, now)
+	require.Zero(t, wait)
+	_, wait = reserveAttempt(counter, state, now)
+	assert.Equal(t, time.Second, wait, "Вторая одновременная попытка ждет задержку после первой")
+}
+
+func TestDoAuthLoginAttempts(t *testing.T) {
+	errDB := errors.New("db error")
+	tests := []struct {
+		name    string
+		prepare func(repo *MockRepository)
+		want    error
+	}{
+		{
+			name: "Throttled",
+			prepare: func(repo *MockRepository) {
+				repo.EXPECT().ReserveLoginAttempt(gomock.Any(), "test", "192.0.2.1").Return(&ThrottleError{RetryAfter: time.Second})
+			},
+			want: ErrTooManyAttempts,
+		},
+		{
+			name: "Wrong password stays counted",
+			prepare: func(repo *MockRepository) {
+				repo.EXPECT().ReserveLoginAttempt(gomock.Any(), "test", "192.0.2.1").Return(nil)
+				repo.EXPECT().Auth(gomock.Any(), "test", "password").Return(ErrUnauthorized)
+			},
+			want: ErrUnauthorized,
+		},
+		{
+			name: "Internal error releases attempt",
+			prepare: func(repo *MockRepository) {
+				repo.EXPECT().ReserveLoginAttempt(gomock.Any(), "test", "192.0.2.1").Return(nil)
+				repo.EXPECT().Auth(gomock.Any(), "test", "password").Return(errDB)
+				repo.EXPECT().ReleaseLoginAttempt(gomock.Any(), "test", "192.0.2.1").Return(nil)
+			},
+			want: errDB,
+		},
+		{
+			name: "Wrong one-time code stays counted",
+			prepare: func(repo *MockRepository) {
+				repo.EXPECT().ReserveLoginAttempt(gomock.Any(), "test", "192.0.2.1").Return(nil)
+				repo.EXPECT().Auth(gomock.Any(), "test", "password").Return(nil)
+				repo.EXPECT().VerifySecondFactor(gomock.Any(), "test", "123456").Return(ErrOTPCode)
+			},
+			want: ErrOTPCode,
+		},
+		{
+			name: "Missing one-time code releases attempt",
+			prepare: func(repo *MockRepository) {
+				repo.EXPECT().ReserveLoginAttempt(gomock.Any(), "test", "192.0.2.1").Return(nil)
+				repo.EXPECT().Auth(gomock.Any(), "test", "password").Return(nil)
+				repo.EXPECT().VerifySecondFactor(gomock.Any(), "test", "123456").Return(ErrOTPRequired)
+				repo.EXPECT().ReleaseLoginAttempt(gomock.Any(), "test", "192.0.2.1").Return(nil)
+			},
+			want: ErrOTPRequired,
+		},
+		{
+			name: "Success releases attempt and resets login counter",
+			prepare: func(repo *MockRepository) {
+				repo.EXPECT().ReserveLoginAttempt(gomock.Any(), "test", "192.0.2.1").Return(nil)
+				repo.EXPECT().Auth(gomock.Any(), "test", "password").Return(nil)
+				repo.EXPECT().VerifySecondFactor(gomock.Any(), "test", "123456").Return(nil)
+				repo.EXPECT().ReleaseLoginAttempt(gomock.Any(), "test", "192.0.2.1").Return(nil)
+				repo.EXPECT().ResetLoginAttempts(gomock.Any(), "test").Return(nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := l.ContextWithLogger(context.Background(), l.LoggerNew(config.Cfg.ProjectRoot))
+			repo := NewMockRepository(gomock.NewController(t))
+			tt.prepare(repo)
+
+			r := httptest.NewRequest("POST", "/api/user/login", nil)
+			r.RemoteAddr = "192.0.2.1:1234"
+			err := New(repo, config.HTTPServer{}).DoAuth(ctx, "test", "password", "123456", r)
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestResetCounters(t *testing.T) {
+	useLoginThrottle(t)
+	assert.Equal(t, []attemptCounter{
+		{key: "reset:login:test", maxFailures: 3},
+		{key: "reset:ip:192.0.2.1", maxFailures: 10},
+	}, resetCounters("test", "192.0.2.1"))
+	assert.Equal(t, []attemptCounter{{key: "reset:login:test", maxFailures: 3}}, resetCounters("test", ""))
+}
+
+func TestDoRequestPasswordReset(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(repo *MockRepository)
+		want    error
+	}{
+		{
+			name: "Throttled",
+			prepare: func(repo *MockRepository) {
+				repo.EXPECT().ReservePasswordReset(gomock.Any(), "test", "192.0.2.1").Return(&ThrottleError{RetryAfter: time.Second})
+			},
+			want: ErrTooManyAttempts,
+		},
+		{
+			name: "Allowed",
+			prepare: func(repo *MockRepository) {
+				repo.EXPECT().ReservePasswordReset(gomock.Any(), "test", "192.0.2.1").Return(nil)
+				repo.EXPECT().RequestPasswordReset(gomock.Any(), "test").Return(nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := l.ContextWithLogger(context.Background(), l.LoggerNew(config.Cfg.ProjectRoot))
+			repo := NewMockRepository(gomock.NewController(t))
+			tt.prepare(repo)
+
+			r := httptest.NewRequest("POST", "/api/user/password/reset", nil)
+			r.RemoteAddr = "192.0.2.1:1234"
+			err := New(repo, config.HTTPServer{}).DoRequestPasswordReset(ctx, "test", r)
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestRequestPasswordResetWithoutNotifier(t *testing.T) {
+	ctx := l.ContextWithLogger(context.Background(), l.LoggerNew(config.Cfg.ProjectRoot))
+	// Без Notifier токен не создается, поэтому база данных не используется
+	assert.NoError(t, (&UseCase{}).RequestPasswordReset(ctx, "test"))
+}
+
+func TestDoChangePasswordLoginAttempts(t *testing.T) {
+	errDB := errors.New("db error")
+	tests := []struct {
+		name    string
+		prepare func(repo *MockRepository)
+		want    error
+	}{
+		{
+			name: "Throttled",
+			prepare: func(repo *MockRepository) {
+				repo.EXPECT().ReserveLoginAttempt(gomock.Any(), "test", "192.0.2.1").Return(&ThrottleError{RetryAfter: time.Second})
+			},
+			want: ErrTooManyAttempts,
+		},
+		{
+			name: "Wrong current password stays counted",
+			prepare: func(repo *MockRepository) {
+				repo.EXPECT().ReserveLoginAttempt(gomock.Any(), "test", "192.0.2.1").Return(nil)
+				repo.EXPECT().ChangePassword(gomock.Any(), "test", "guess", "new-password").Return(ErrPassword)
+			},
+			want: ErrPassword,
+		},
+		{
+			name: "Internal error releases attempt",
+			prepare: func(repo *MockRepository) {
+				repo.EXPECT().ReserveLoginAttempt(gomock.Any(), "test", "192.0.2.1").Return(nil)
+				repo.EXPECT().ChangePassword(gomock.Any(), "test", "guess", "new-password").Return(errDB)
+				repo.EXPECT().ReleaseLoginAttempt(gomock.Any(), "test", "192.0.2.1").Return(nil)
+			},
+			want: errDB,
+		},
+		{
+			name: "Success releases attempt",
+			prepare: func(repo *MockRepository) {
+				repo.EXPECT().ReserveLoginAttempt(gomock.Any(), "test", "192.0.2.1").Return(nil)
+				repo.EXPECT().ChangePassword(gomock.Any(), "test", "guess", "new-password").Return(nil)
+				repo.EXPECT().ReleaseLoginAttempt(gomock.Any(), "test", "192.0.2.1").Return(nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := l.ContextWithLogger(context.Background(), l.LoggerNew(config.Cfg.ProjectRoot))
+			repo := NewMockRepository(gomock.NewController(t))
+			tt.prepare(repo)
+
+			r := httptest.NewRequest("POST", "/api/user/password", nil)
+			r.RemoteAddr = "192.0.2.1:1234"
+			err := New(repo, config.HTTPServer{}).DoChangePassword(ctx, "test", "guess", "new-password", r)
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
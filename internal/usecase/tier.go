@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nextlag/gomart/internal/config"
+	"github.com/nextlag/gomart/internal/entity"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+const (
+	selectUsersAccrued = `
+		SELECT u.login, u.tier, COALESCE(SUM(COALESCE(o.base_accrual, o.accrual)), 0)
+		FROM users u
+		LEFT JOIN orders o ON o.user_name = u.login AND o.status = 'PROCESSED' AND o.uploaded_at >= $1
+		GROUP BY u.login, u.tier
+	`
+	selectUserAccrued = `
+		SELECT u.tier, COALESCE(SUM(COALESCE(o.base_accrual, o.accrual)), 0)
+		FROM users u
+		LEFT JOIN orders o ON o.user_name = u.login AND o.status = 'PROCESSED' AND o.uploaded_at >= $2
+		WHERE u.login = $1
+		GROUP BY u.tier
+	`
+	updateUserTier = `
+		UPDATE users
+		SET tier = $1
+		WHERE login = $2
+	`
+)
+
+// tierWindowStart возвращает начало скользящего 12-месячного окна, за которое учитываются начисления.
+func tierWindowStart(now time.Time) time.Time {
+	return now.AddDate(-1, 0, 0)
+}
+
+// RecalculateTiers пересчитывает уровни всех пользователей по сумме баллов, начисленных за последние 12 месяцев.
+// Учитываются начисления системы расчета без множителя уровня (base_accrual), иначе повышенные начисления
+// сами поднимали бы уровень пользователя.
+// Уровни и пороги берутся из config.Cfg.Tiers, в базе данных обновляются только изменившиеся уровни.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//
+// Возвращаемое значение:
+//   - error: ошибка при выполнении запроса к базе данных.
+func (uc *UseCase) RecalculateTiers(ctx context.Context) error {
+	log := l.L(ctx)
+	type change struct {
+		login, tier string
+	}
+	var changes []change
+
+	rows, err := uc.DB.QueryContext(ctx, selectUsersAccrued, tierWindowStart(time.Now()))
+	if err != nil {
+		return fmt.Errorf("error selecting accrued points: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			login, tier string
+			accrued     float32
+		)
+		if err = rows.Scan(&login, &tier, &accrued); err != nil {
+			return err
+		}
+		if newTier := config.Cfg.Tiers.ForPoints(accrued).Name; newTier != tier {
+			changes = append(changes, change{login: login, tier: newTier})
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, c := range changes {
+		if _, err = uc.DB.ExecContext(ctx, updateUserTier, c.tier, c.login); err != nil {
+			return fmt.Errorf("error updating user tier: %v", err)
+		}
+		log.Info("loyalty tier changed", "login", c.login, "tier", c.tier)
+	}
+	return nil
+}
+
+// TierSync периодически пересчитывает уровни пользователей с интервалом config.Cfg.TierInterval.
+// Первый пересчет выполняется сразу после запуска. Функция работает до отмены контекста.
+func (uc *UseCase) TierSync(ctx context.Context) error {
+	log := l.L(ctx)
+	ticker := time.NewTicker(config.Cfg.TierInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := uc.RecalculateTiers(ctx); err != nil {
+			log.Error("error recalculating loyalty tiers", l.ErrAttr(err))
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil // В случае получения сигнала остановки, завершаем выполнение без ошибок
+		}
+	}
+}
+
+// GetTier возвращает текущий уровень пользователя и прогресс до следующего уровня.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - login: логин пользователя.
+//
+// Возвращаемые значения:
+//   - entity.TierProgress: текущий уровень, его множитель, сумма начислений за 12 месяцев без множителя
+//     и следующий уровень.
+//   - error: ошибка при выполнении запроса к базе данных.
+func (uc *UseCase) GetTier(ctx context.Context, login string) (entity.TierProgress, error) {
+	var progress entity.TierProgress
+
+	err := uc.DB.QueryRowContext(ctx, selectUserAccrued, login, tierWindowStart(time.Now())).Scan(
+		&progress.Tier,
+		&progress.Accrued,
+	)
+	if err != nil {
+		return progress, err
+	}
+
+	return tierProgress(config.Cfg.Tiers, progress.Tier, progress.Accrued), nil
+}
+
+// tierProgress возвращает прогресс пользователя с уровнем tier и суммой начислений accrued до следующего уровня.
+func tierProgress(tiers config.Tiers, tier string, accrued float32) entity.TierProgress {
+	progress := entity.TierProgress{
+		Tier:       tier,
+		Multiplier: tiers.ByName(tier).Multiplier,
+		Accrued:    accrued,
+	}
+	if next, ok := tiers.Next(tier); ok {
+		progress.NextTier = next.Name
+		progress.NextThreshold = next.Threshold
+		if remaining := next.Threshold - accrued; remaining > 0 {
+			progress.Remaining = remaining
+		}
+	}
+	return progress
+}
+
+// tierAccrual возвращает начисление accrual системы расчета, увеличенное множителем уровня tier.
+func tierAccrual(tiers config.Tiers, tier string, accrual float32) float32 {
+	return accrual * tiers.ByName(tier).Multiplier
+}
@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nextlag/gomart/internal/config"
+	"github.com/nextlag/gomart/internal/entity"
+)
+
+func TestTierProgress(t *testing.T) {
+	var tiers config.Tiers
+	require.NoError(t, tiers.Set("SILVER:1000:1.05,GOLD:5000:1.1"))
+
+	tests := []struct {
+		name    string
+		tier    string
+		accrued float32
+		want    entity.TierProgress
+	}{
+		{
+			name:    "Base tier",
+			tier:    config.BaseTier,
+			accrued: 400,
+			want:    entity.TierProgress{Tier: config.BaseTier, Multiplier: 1, Accrued: 400, NextTier: "SILVER", NextThreshold: 1000, Remaining: 600},
+		},
+		{
+			name:    "Threshold already reached",
+			tier:    "SILVER",
+			accrued: 6000,
+			want:    entity.TierProgress{Tier: "SILVER", Multiplier: 1.05, Accrued: 6000, NextTier: "GOLD", NextThreshold: 5000},
+		},
+		{
+			name:    "Highest tier",
+			tier:    "GOLD",
+			accrued: 7000,
+			want:    entity.TierProgress{Tier: "GOLD", Multiplier: 1.1, Accrued: 7000},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tierProgress(tiers, tt.tier, tt.accrued))
+		})
+	}
+}
+
+func TestTierAccrual(t *testing.T) {
+	var tiers config.Tiers
+	require.NoError(t, tiers.Set("SILVER:1000:1.05,GOLD:5000:1.5"))
+
+	assert.Equal(t, float32(150), tierAccrual(tiers, "GOLD", 100))
+	assert.Equal(t, float32(100), tierAccrual(tiers, config.BaseTier, 100))
+	assert.Equal(t, float32(100), tierAccrual(tiers, "REMOVED", 100), "Уровень, удаленный из конфигурации, не меняет начисление")
+}
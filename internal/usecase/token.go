@@ -0,0 +1,262 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nextlag/gomart/internal/config"
+	"github.com/nextlag/gomart/internal/entity"
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+// refreshTokenLength - длина refresh-токена в байтах до кодирования
+const refreshTokenLength = 32
+
+const (
+	insertRefreshToken = `
+		INSERT INTO refresh_tokens (token_hash, login, expires_at, created_at, session_id)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	selectRefreshTokenForUpdate = `
+		SELECT login, expires_at, revoked_at, session_id
+		FROM refresh_tokens
+		WHERE token_hash = $1
+		FOR UPDATE
+	`
+	revokeRefreshToken = `
+		UPDATE refresh_tokens
+		SET revoked_at = $2
+		WHERE token_hash = $1 AND revoked_at IS NULL
+	`
+	revokeUserRefreshTokens = `
+		UPDATE refresh_tokens
+		SET revoked_at = $2
+		WHERE login = $1 AND revoked_at IS NULL
+	`
+	deleteExpiredRefreshTokens = `
+		DELETE FROM refresh_tokens
+		WHERE expires_at < $1
+	`
+	insertRevokedToken = `
+		INSERT INTO revoked_tokens (jti, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING
+	`
+	selectRevokedToken = `
+		SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)
+			OR EXISTS (SELECT 1 FROM users WHERE login = $2 AND tokens_valid_after > $3)
+			OR EXISTS (SELECT 1 FROM sessions WHERE id = $4 AND revoked_at IS NOT NULL)
+	`
+	updateTokensValidAfter = `
+		UPDATE users
+		SET tokens_valid_after = $2
+		WHERE login = $1
+	`
+	deleteExpiredRevokedTokens = `
+		DELETE FROM revoked_tokens
+		WHERE expires_at < $1
+	`
+)
+
+// newRefreshToken генерирует случайный refresh-токен и возвращает его вместе с хешем для хранения в базе данных.
+func newRefreshToken() (string, string, error) {
+	b := make([]byte, refreshTokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+// hashToken возвращает SHA-256 хеш токена; в базе данных хранятся только хеши refresh-токенов.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IssueRefreshToken начинает новый сеанс пользователя и выдает в нем refresh-токен
+// со сроком действия config.Cfg.RefreshTokenTTL. Истекшие refresh-токены всех пользователей при этом удаляются.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - session: логин пользователя, User-Agent и IP-адрес клиента.
+//
+// Возвращаемые значения:
+//   - entity.Session: созданный сеанс.
+//   - string: refresh-токен; в базе данных сохраняется только его хеш.
+//   - error: ошибка генерации токена или выполнения запроса к базе данных.
+func (uc *UseCase) IssueRefreshToken(ctx context.Context, session entity.Session) (entity.Session, string, error) {
+	now := time.Now()
+	if _, err := uc.DB.ExecContext(ctx, deleteExpiredRefreshTokens, now); err != nil {
+		return session, "", fmt.Errorf("error deleting expired refresh tokens: %v", err)
+	}
+
+	tx, err := uc.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return session, "", err
+	}
+	defer tx.Rollback()
+
+	if session, err = createSession(ctx, tx, session, now); err != nil {
+		return session, "", err
+	}
+	token, hash, err := newRefreshToken()
+	if err != nil {
+		return session, "", err
+	}
+	_, err = tx.ExecContext(ctx, insertRefreshToken, hash, session.Login, now.Add(config.Cfg.RefreshTokenTTL), now, session.ID)
+	if err != nil {
+		return session, "", fmt.Errorf("error inserting refresh token: %v", err)
+	}
+	return session, token, tx.Commit()
+}
+
+// RotateRefreshToken обменивает действующий refresh-токен на новый в том же сеансе; предъявленный токен отзывается,
+// а сеанс запоминает User-Agent, IP-адрес клиента и время обмена. Токену, выданному до появления сеансов,
+// назначается новый сеанс. Повторное предъявление уже отозванного токена означает его утечку, поэтому в этом случае
+// отзываются все refresh-токены пользователя и сеанс, в котором был выдан токен.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - token: предъявленный refresh-токен.
+//   - client: User-Agent и IP-адрес клиента.
+//
+// Возвращаемые значения:
+//   - entity.Session: сеанс владельца токена.
+//   - string: новый refresh-токен.
+//   - error: ErrRefreshToken, если токен неизвестен, истек или отозван, ошибка базы данных в остальных случаях.
+func (uc *UseCase) RotateRefreshToken(ctx context.Context, token string, client entity.Session) (entity.Session, string, error) {
+	var (
+		session   = client
+		expiresAt time.Time
+		revokedAt sql.NullTime
+		sessionID sql.NullString
+		now       = time.Now()
+	)
+
+	tx, err := uc.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return session, "", err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, selectRefreshTokenForUpdate, hashToken(token)).Scan(&session.Login, &expiresAt,
+		&revokedAt, &sessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return session, "", ErrRefreshToken
+	}
+	if err != nil {
+		return session, "", fmt.Errorf("error selecting refresh token: %v", err)
+	}
+
+	switch {
+	case revokedAt.Valid:
+		// Токен уже был обменян или отозван - отзываем все токены пользователя и сеанс токена
+		l.L(ctx).Error("revoked refresh token reused", "login", session.Login, "session", sessionID.String)
+		if _, err = tx.ExecContext(ctx, revokeUserRefreshTokens, session.Login, now); err != nil {
+			return session, "", fmt.Errorf("error revoking refresh tokens: %v", err)
+		}
+		if _, err = tx.ExecContext(ctx, revokeSession, sessionID.String, session.Login, now); err != nil {
+			return session, "", fmt.Errorf("error revoking session: %v", err)
+		}
+		if err = tx.Commit(); err != nil {
+			return session, "", err
+		}
+		return session, "", ErrRefreshToken
+	case now.After(expiresAt):
+		return session, "", ErrRefreshToken
+	}
+
+	if _, err = tx.ExecContext(ctx, revokeRefreshToken, hashToken(token), now); err != nil {
+		return session, "", fmt.Errorf("error revoking refresh token: %v", err)
+	}
+	if sessionID.Valid {
+		session, err = touchSession(ctx, tx, sessionID.String, session, now)
+	} else {
+		session, err = createSession(ctx, tx, session, now)
+	}
+	if err != nil {
+		return session, "", err
+	}
+	newToken, hash, err := newRefreshToken()
+	if err != nil {
+		return session, "", err
+	}
+	_, err = tx.ExecContext(ctx, insertRefreshToken, hash, session.Login, now.Add(config.Cfg.RefreshTokenTTL), now, session.ID)
+	if err != nil {
+		return session, "", fmt.Errorf("error inserting refresh token: %v", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return session, "", err
+	}
+	return session, newToken, nil
+}
+
+// RevokeRefreshToken отзывает refresh-токен. Неизвестный или уже отозванный токен ошибкой не считается.
+func (uc *UseCase) RevokeRefreshToken(ctx context.Context, token string) error {
+	if _, err := uc.DB.ExecContext(ctx, revokeRefreshToken, hashToken(token), time.Now()); err != nil {
+		return fmt.Errorf("error revoking refresh token: %v", err)
+	}
+	return nil
+}
+
+// RevokeAccessToken добавляет идентификатор (jti) токена доступа в список отозванных до истечения его срока действия.
+// Записи с истекшим сроком действия при этом удаляются.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - jti: идентификатор токена доступа.
+//   - expiresAt: время истечения токена доступа.
+//
+// Возвращаемое значение:
+//   - error: ошибка при выполнении запроса к базе данных.
+func (uc *UseCase) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	if _, err := uc.DB.ExecContext(ctx, deleteExpiredRevokedTokens, time.Now()); err != nil {
+		return fmt.Errorf("error deleting expired revoked tokens: %v", err)
+	}
+	if _, err := uc.DB.ExecContext(ctx, insertRevokedToken, jti, expiresAt); err != nil {
+		return fmt.Errorf("error revoking access token: %v", err)
+	}
+	return nil
+}
+
+// IsAccessTokenRevoked сообщает, отозван ли токен доступа с указанным идентификатором (jti):
+// по отдельности, вместе с сеансом sessionID или вместе со всеми токенами пользователя, выданными до смены пароля.
+// Для действующего токена обновляется время последней активности сеанса, но не чаще раза в минуту.
+func (uc *UseCase) IsAccessTokenRevoked(ctx context.Context, jti, login, sessionID string, issuedAt time.Time) (bool, error) {
+	var revoked bool
+	err := uc.DB.QueryRowContext(ctx, selectRevokedToken, jti, login, issuedAt, sessionID).Scan(&revoked)
+	if err != nil {
+		return false, fmt.Errorf("error selecting revoked token: %v", err)
+	}
+	if !revoked && sessionID != "" {
+		now := time.Now()
+		if _, err = uc.DB.ExecContext(ctx, updateSessionLastSeen, sessionID, now, now.Add(-sessionSeenInterval)); err != nil {
+			return false, fmt.Errorf("error updating session: %v", err)
+		}
+	}
+	return revoked, nil
+}
+
+// revokeUserTokens отзывает в рамках транзакции tx все сеансы и refresh-токены пользователя и все токены доступа,
+// выданные до текущего момента. Время выпуска токена доступа хранится с точностью до секунды,
+// поэтому граница также округляется до секунды.
+func revokeUserTokens(ctx context.Context, tx *sql.Tx, login string, now time.Time) error {
+	if _, err := tx.ExecContext(ctx, revokeUserRefreshTokens, login, now); err != nil {
+		return fmt.Errorf("error revoking refresh tokens: %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, revokeUserSessions, login, now); err != nil {
+		return fmt.Errorf("error revoking sessions: %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, updateTokensValidAfter, login, now.Truncate(time.Second)); err != nil {
+		return fmt.Errorf("error revoking access tokens: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nextlag/gomart/internal/config"
+	"github.com/nextlag/gomart/internal/entity"
+	"github.com/nextlag/gomart/pkg/totp"
+)
+
+const (
+	// recoveryCodeCount - количество кодов восстановления, выдаваемых при подключении двухфакторной аутентификации
+	recoveryCodeCount = 10
+	// recoveryCodeLength - длина кода восстановления в байтах до кодирования
+	recoveryCodeLength = 5
+	// totpSkew - допустимое расхождение часов клиента и сервера в периодах TOTP
+	totpSkew = 1
+)
+
+const (
+	selectTOTPSecretForUpdate = `
+		SELECT secret, last_counter, confirmed_at
+		FROM totp_secrets
+		WHERE login = $1
+		FOR UPDATE
+	`
+	upsertTOTPSecret = `
+		INSERT INTO totp_secrets (login, secret, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (login) DO UPDATE
+		SET secret = $2, last_counter = 0, created_at = $3, confirmed_at = NULL
+	`
+	confirmTOTPSecret = `
+		UPDATE totp_secrets
+		SET confirmed_at = $2, last_counter = $3
+		WHERE login = $1
+	`
+	updateTOTPCounter = `
+		UPDATE totp_secrets
+		SET last_counter = $2
+		WHERE login = $1
+	`
+	deleteRecoveryCodes = `
+		DELETE FROM recovery_codes
+		WHERE login = $1
+	`
+	insertRecoveryCode = `
+		INSERT INTO recovery_codes (login, code_hash)
+		VALUES ($1, $2)
+	`
+	useRecoveryCode = `
+		UPDATE recovery_codes
+		SET used_at = $3
+		WHERE login = $1 AND code_hash = $2 AND used_at IS NULL
+	`
+)
+
+// recoveryEncoding - кодирование кодов восстановления без символов, которые легко перепутать при вводе
+var recoveryEncoding = base32.NewEncoding("abcdefghijkmnpqrstuvwxyz23456789").WithPadding(base32.NoPadding)
+
+// totpSecret - секрет TOTP пользователя.
+type totpSecret struct {
+	secret      string
+	lastCounter int64
+	confirmed   bool
+}
+
+// selectTOTPSecret блокирует и возвращает в рамках транзакции tx секрет TOTP пользователя.
+// Возвращает sql.ErrNoRows, если пользователь не начинал подключение двухфакторной аутентификации.
+func selectTOTPSecret(ctx context.Context, tx *sql.Tx, login string) (totpSecret, error) {
+	var (
+		s           totpSecret
+		confirmedAt sql.NullTime
+	)
+	err := tx.QueryRowContext(ctx, selectTOTPSecretForUpdate, login).Scan(&s.secret, &s.lastCounter, &confirmedAt)
+	s.confirmed = confirmedAt.Valid
+	return s, err
+}
+
+// normalizeRecoveryCode приводит код восстановления к виду, в котором хранится его хеш.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// newRecoveryCodes генерирует коды восстановления в формате xxxx-xxxx.
+func newRecoveryCodes() ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, recoveryCodeLength)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		code := recoveryEncoding.EncodeToString(b)
+		codes = append(codes, code[:len(code)/2]+"-"+code[len(code)/2:])
+	}
+	return codes, nil
+}
+
+// EnrollTOTP начинает подключение двухфакторной аутентификации: создает новый секрет TOTP, который начинает
+// действовать после подтверждения кодом из приложения-аутентификатора. Повторный вызов до подтверждения
+// заменяет секрет.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - login: логин пользователя.
+//
+// Возвращаемые значения:
+//   - entity.TOTPEnrollment: секрет и ссылка otpauth:// для приложения-аутентификатора.
+//   - error: ErrTOTPEnabled, если двухфакторная аутентификация уже подключена, ошибка базы данных в остальных случаях.
+func (uc *UseCase) EnrollTOTP(ctx context.Context, login string) (entity.TOTPEnrollment, error) {
+	var enrollment entity.TOTPEnrollment
+
+	tx, err := uc.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return enrollment, err
+	}
+	defer tx.Rollback()
+
+	current, err := selectTOTPSecret(ctx, tx, login)
+	switch {
+	case err == nil && current.confirmed:
+		return enrollment, ErrTOTPEnabled
+	case err != nil && !errors.Is(err, sql.ErrNoRows):
+		return enrollment, fmt.Errorf("error selecting TOTP secret: %v", err)
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return enrollment, err
+	}
+	if _, err = tx.ExecContext(ctx, upsertTOTPSecret, login, secret, time.Now()); err != nil {
+		return enrollment, fmt.Errorf("error inserting TOTP secret: %v", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return enrollment, err
+	}
+
+	enrollment.Secret = secret
+	enrollment.URI = totp.URI(config.Cfg.TOTPIssuer, login, secret)
+	return enrollment, nil
+}
+
+// ConfirmTOTP завершает подключение двухфакторной аутентификации кодом из приложения-аутентификатора
+// и выдает коды восстановления. Каждый код восстановления можно использовать вместо одноразового кода один раз;
+// в базе данных хранятся только их хеши.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - login: логин пользователя.
+//   - code: одноразовый код.
+//
+// Возвращаемые значения:
+//   - []string: коды восстановления.
+//   - error: ErrTOTPNotEnrolled, если подключение не начато, ErrTOTPEnabled, если уже завершено,
+//     ErrOTPCode, если код неверен, ошибка базы данных в остальных случаях.
+func (uc *UseCase) ConfirmTOTP(ctx context.Context, login, code string) ([]string, error) {
+	now := time.Now()
+
+	tx, err := uc.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := selectTOTPSecret(ctx, tx, login)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, ErrTOTPNotEnrolled
+	case err != nil:
+		return nil, fmt.Errorf("error selecting TOTP secret: %v", err)
+	case current.confirmed:
+		return nil, ErrTOTPEnabled
+	}
+
+	counter, ok, err := totp.Verify(current.secret, code, now, totpSkew)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrOTPCode
+	}
+	if _, err = tx.ExecContext(ctx, confirmTOTPSecret, login, now, counter); err != nil {
+		return nil, fmt.Errorf("error confirming TOTP secret: %v", err)
+	}
+
+	codes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, deleteRecoveryCodes, login); err != nil {
+		return nil, fmt.Errorf("error deleting recovery codes: %v", err)
+	}
+	for _, c := range codes {
+		if _, err = tx.ExecContext(ctx, insertRecoveryCode, login, hashToken(normalizeRecoveryCode(c))); err != nil {
+			return nil, fmt.Errorf("error inserting recovery code: %v", err)
+		}
+	}
+	return codes, tx.Commit()
+}
+
+// secondFactorStore - операции проверки второго фактора в рамках одной транзакции.
+type secondFactorStore interface {
+	// totpSecret блокирует и возвращает секрет TOTP пользователя или sql.ErrNoRows
+	totpSecret(ctx context.Context, login string) (totpSecret, error)
+	// setTOTPCounter сохраняет номер периода последнего принятого одноразового кода
+	setTOTPCounter(ctx context.Context, login string, counter int64) error
+	// useRecoveryCode отмечает неиспользованный код восстановления с хешем hash использованным
+	// и сообщает, был ли такой код
+	useRecoveryCode(ctx context.Context, login, hash string, now time.Time) (bool, error)
+}
+
+// txSecondFactorStore - secondFactorStore на основе транзакции базы данных.
+type txSecondFactorStore struct {
+	tx *sql.Tx
+}
+
+func (s txSecondFactorStore) totpSecret(ctx context.Context, login string) (totpSecret, error) {
+	return selectTOTPSecret(ctx, s.tx, login)
+}
+
+func (s txSecondFactorStore) setTOTPCounter(ctx context.Context, login string, counter int64) error {
+	if _, err := s.tx.ExecContext(ctx, updateTOTPCounter, login, counter); err != nil {
+		return fmt.Errorf("error updating TOTP counter: %v", err)
+	}
+	return nil
+}
+
+func (s txSecondFactorStore) useRecoveryCode(ctx context.Context, login, hash string, now time.Time) (bool, error) {
+	res, err := s.tx.ExecContext(ctx, useRecoveryCode, login, hash, now)
+	if err != nil {
+		return false, fmt.Errorf("error using recovery code: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// VerifySecondFactor проверяет второй фактор пользователя: одноразовый код из приложения-аутентификатора
+// или неиспользованный код восстановления. Каждый одноразовый код принимается только один раз.
+// Для пользователя без подключенной двухфакторной аутентификации проверка не выполняется.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - login: логин пользователя.
+//   - code: одноразовый код или код восстановления.
+//
+// Возвращаемое значение:
+//   - error: ErrOTPRequired, если код не передан, ErrOTPCode, если код неверен или уже использован,
+//     ошибка базы данных в остальных случаях.
+func (uc *UseCase) VerifySecondFactor(ctx context.Context, login, code string) error {
+	tx, err := uc.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = verifySecondFactor(ctx, txSecondFactorStore{tx: tx}, login, code, time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// verifySecondFactor проверяет второй фактор пользователя в момент now. Одноразовый код принимается, только если
+// его период позже периода последнего принятого кода, поэтому перехваченный код нельзя использовать повторно;
+// код восстановления отмечается использованным.
+func verifySecondFactor(ctx context.Context, store secondFactorStore, login, code string, now time.Time) error {
+	current, err := store.totpSecret(ctx, login)
+	switch {
+	case errors.Is(err, sql.ErrNoRows) || (err == nil && !current.confirmed):
+		return nil
+	case err != nil:
+		return fmt.Errorf("error selecting TOTP secret: %v", err)
+	case code == "":
+		return ErrOTPRequired
+	}
+
+	counter, ok, err := totp.Verify(current.secret, code, now, totpSkew)
+	if err != nil {
+		return err
+	}
+	if ok && counter > current.lastCounter {
+		return store.setTOTPCounter(ctx, login, counter)
+	}
+
+	used, err := store.useRecoveryCode(ctx, login, hashToken(normalizeRecoveryCode(code)), now)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrOTPCode
+	}
+	return nil
+}
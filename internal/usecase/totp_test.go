@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nextlag/gomart/pkg/totp"
+)
+
+// memorySecondFactorStore - secondFactorStore в памяти для тестов.
+type memorySecondFactorStore struct {
+	secret   *totpSecret
+	recovery map[string]bool // Хеш кода восстановления - признак использования
+	err      error
+}
+
+func (s *memorySecondFactorStore) totpSecret(context.Context, string) (totpSecret, error) {
+	if s.err != nil {
+		return totpSecret{}, s.err
+	}
+	if s.secret == nil {
+		return totpSecret{}, sql.ErrNoRows
+	}
+	return *s.secret, nil
+}
+
+func (s *memorySecondFactorStore) setTOTPCounter(_ context.Context, _ string, counter int64) error {
+	s.secret.lastCounter = counter
+	return nil
+}
+
+func (s *memorySecondFactorStore) useRecoveryCode(_ context.Context, _, hash string, _ time.Time) (bool, error) {
+	used, ok := s.recovery[hash]
+	if !ok || used {
+		return false, nil
+	}
+	s.recovery[hash] = true
+	return true, nil
+}
+
+func TestVerifySecondFactor(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	secret, err := totp.NewSecret()
+	require.NoError(t, err)
+	code := func(at time.Time) string {
+		c, err := totp.Code(secret, at)
+		require.NoError(t, err)
+		return c
+	}
+	current, previous, next := code(now), code(now.Add(-totp.Period)), code(now.Add(totp.Period))
+
+	// attempt - код и ожидаемый результат очередной проверки
+	type attempt struct {
+		code string
+		want error
+	}
+	tests := []struct {
+		name     string
+		store    func() *memorySecondFactorStore
+		attempts []attempt
+	}{
+		{
+			name:     "Without second factor",
+			store:    func() *memorySecondFactorStore { return &memorySecondFactorStore{} },
+			attempts: []attempt{{code: ""}},
+		},
+		{
+			name: "Enrollment not confirmed",
+			store: func() *memorySecondFactorStore {
+				return &memorySecondFactorStore{secret: &totpSecret{secret: secret}}
+			},
+			attempts: []attempt{{code: ""}},
+		},
+		{
+			name:     "Code required",
+			attempts: []attempt{{code: "", want: ErrOTPRequired}},
+		},
+		{
+			name:     "Wrong code",
+			attempts: []attempt{{code: "000000", want: ErrOTPCode}},
+		},
+		{
+			name:     "Replayed code",
+			attempts: []attempt{{code: current}, {code: current, want: ErrOTPCode}},
+		},
+		{
+			name:     "Earlier code after later one",
+			attempts: []attempt{{code: current}, {code: previous, want: ErrOTPCode}},
+		},
+		{
+			name:     "Later code after earlier one",
+			attempts: []attempt{{code: previous}, {code: current}, {code: next}},
+		},
+		{
+			name:     "Recovery code used once",
+			attempts: []attempt{{code: "ABCD-EFGH"}, {code: "abcdefgh", want: ErrOTPCode}},
+		},
+		{
+			name:     "Unknown recovery code",
+			attempts: []attempt{{code: "zzzz-zzzz", want: ErrOTPCode}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memorySecondFactorStore{
+				secret:   &totpSecret{secret: secret, lastCounter: 1, confirmed: true},
+				recovery: map[string]bool{hashToken(normalizeRecoveryCode("abcd-efgh")): false},
+			}
+			if tt.store != nil {
+				store = tt.store()
+			}
+			for i, a := range tt.attempts {
+				err := verifySecondFactor(context.Background(), store, "test", a.code, now)
+				if a.want == nil {
+					assert.NoError(t, err, "attempt %d", i)
+					continue
+				}
+				assert.ErrorIs(t, err, a.want, "attempt %d", i)
+			}
+		})
+	}
+}
+
+func TestVerifySecondFactorStoreError(t *testing.T) {
+	store := &memorySecondFactorStore{err: errors.New("db error")}
+	err := verifySecondFactor(context.Background(), store, "test", "123456", time.Now())
+	assert.ErrorContains(t, err, "db error")
+}
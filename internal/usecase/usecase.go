@@ -3,10 +3,17 @@ package usecase
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"net"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/nextlag/gomart/internal/config"
 	"github.com/nextlag/gomart/internal/entity"
+	"github.com/nextlag/gomart/pkg/events"
+	"github.com/nextlag/gomart/pkg/logger/l"
+	"github.com/nextlag/gomart/pkg/oidc"
 )
 
 type Logger interface {
@@ -15,6 +22,31 @@ type Logger interface {
 	Error(msg string, args ...any)
 }
 
+// PasswordHasher хеширует пароли пользователей и проверяет их при аутентификации.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password, encoded string) (ok, rehash bool, err error)
+}
+
+// PasswordPolicy проверяет пароли пользователей по правилам политики паролей и генерирует пароли,
+// удовлетворяющие ей.
+type PasswordPolicy interface {
+	Validate(login, password string) error
+	Generate(login string) (string, error)
+}
+
+// Notifier доставляет уведомления пользователям, например токены сброса пароля.
+type Notifier interface {
+	Notify(ctx context.Context, login, subject, body string) error
+}
+
+// IdentityProvider - внешний поставщик удостоверений OpenID Connect для входа по схеме authorization code с PKCE.
+type IdentityProvider interface {
+	Issuer() string
+	AuthCodeURL(ctx context.Context, state, nonce, verifier, loginHint string) (string, error)
+	Exchange(ctx context.Context, code, verifier, nonce string) (oidc.Claims, error)
+}
+
 //go:generate mockgen -destination=mocks.go -package=usecase github.com/nextlag/gomart/internal/usecase Repository
 type Repository interface {
 	// Register - регистрация пользователя
@@ -31,13 +63,123 @@ type Repository interface {
 	Debit(ctx context.Context, user, order string, sum float32) error
 	// GetWithdrawals - получение информации о выводе средств
 	GetWithdrawals(ctx context.Context, user string) ([]byte, error)
+	// Subscribe - подписка на события пользователя
+	Subscribe(login string, lastID uint64) (*events.Subscription, bool)
+	// GetOrder - заказ пользователя с историей статусов
+	GetOrder(ctx context.Context, user, number string) (entity.OrderDetails, error)
+	// GetOrdersPage - постраничное получение заказов с фильтрами
+	GetOrdersPage(ctx context.Context, user string, f entity.HistoryFilter) ([]entity.Order, string, error)
+	// GetWithdrawalsPage - постраничное получение списаний с фильтрами
+	GetWithdrawalsPage(ctx context.Context, user string, f entity.HistoryFilter) ([]entity.Withdrawal, string, error)
+	// GetTier - текущий уровень пользователя в программе лояльности
+	GetTier(ctx context.Context, login string) (entity.TierProgress, error)
+	// Statement - выписка по счету за период
+	Statement(ctx context.Context, user string, from, to time.Time, w StatementWriter) error
+	// AdjustBalance - ручное начисление или списание баллов
+	AdjustBalance(ctx context.Context, adj entity.Adjustment) (entity.Adjustment, error)
+	// GetAdjustments - история ручных корректировок и промо-начислений пользователя
+	GetAdjustments(ctx context.Context, login string) ([]entity.Adjustment, error)
+	// CreateCampaign - создание промо-кампании
+	CreateCampaign(ctx context.Context, c entity.Campaign) (entity.Campaign, error)
+	// GetCampaigns - список промо-кампаний
+	GetCampaigns(ctx context.Context) ([]entity.Campaign, error)
+	// SetCampaignActive - включение и выключение промо-кампании
+	SetCampaignActive(ctx context.Context, name string, active bool) error
+	// GrantCampaign - начисление по промо-кампании списку пользователей
+	GrantCampaign(ctx context.Context, name string, logins []string, createdBy string) ([]string, error)
+	// GeneratePassword - генерация пароля, удовлетворяющего политике паролей
+	GeneratePassword(login string) (string, error)
+	// ChangePassword - смена пароля с проверкой текущего
+	ChangePassword(ctx context.Context, login, current, password string) error
+	// RequestPasswordReset - отправка токена сброса пароля
+	RequestPasswordReset(ctx context.Context, login string) error
+	// ReservePasswordReset - ограничение запросов сброса пароля
+	ReservePasswordReset(ctx context.Context, login, ip string) error
+	// ResetPassword - установка нового пароля по токену сброса
+	ResetPassword(ctx context.Context, token, password string) (string, error)
+	// GetRole - роль пользователя
+	GetRole(ctx context.Context, login string) (string, error)
+	// SetRole - назначение роли пользователю
+	SetRole(ctx context.Context, login, role string) error
+	// HasAdmin - наличие пользователя с ролью администратора
+	HasAdmin(ctx context.Context) (bool, error)
+	// CreateAPIKey - создание API-ключа сервисной учетной записи
+	CreateAPIKey(ctx context.Context, key entity.APIKey) (entity.APIKey, error)
+	// GetAPIKeys - список API-ключей
+	GetAPIKeys(ctx context.Context) ([]entity.APIKey, error)
+	// RevokeAPIKey - отзыв API-ключа
+	RevokeAPIKey(ctx context.Context, id string) error
+	// AuthenticateAPIKey - проверка API-ключа
+	AuthenticateAPIKey(ctx context.Context, key, login string) (entity.APIKey, error)
+	// CountAPIKeyRequest - учет запроса API-ключа и проверка ограничения запросов
+	CountAPIKeyRequest(ctx context.Context, id string, limit int) (time.Duration, error)
+	// ReserveLoginAttempt - проверка ограничения попыток входа и учет попытки как неудачной
+	ReserveLoginAttempt(ctx context.Context, login, ip string) error
+	// ReleaseLoginAttempt - возврат попытки входа, которая не оказалась неудачной
+	ReleaseLoginAttempt(ctx context.Context, login, ip string) error
+	// ResetLoginAttempts - сброс счетчика неудачных попыток входа
+	ResetLoginAttempts(ctx context.Context, login string) error
+	// GetWithdrawalViolations - журнал списаний, отклоненных правилами ограничения
+	GetWithdrawalViolations(ctx context.Context, login string) ([]entity.WithdrawalViolation, error)
+	// GetWithdrawLimits - ограничения на списание, назначенные пользователю
+	GetWithdrawLimits(ctx context.Context, login string) (entity.WithdrawLimits, error)
+	// SetWithdrawLimits - назначение пользователю ограничений на списание
+	SetWithdrawLimits(ctx context.Context, limits entity.WithdrawLimits) (entity.WithdrawLimits, error)
+	// IssueRefreshToken - выдача refresh-токена
+	IssueRefreshToken(ctx context.Context, session entity.Session) (entity.Session, string, error)
+	// RotateRefreshToken - обмен refresh-токена на новый
+	RotateRefreshToken(ctx context.Context, token string, client entity.Session) (entity.Session, string, error)
+	// RevokeRefreshToken - отзыв refresh-токена
+	RevokeRefreshToken(ctx context.Context, token string) error
+	// RevokeAccessToken - отзыв токена доступа
+	RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+	// EnrollTOTP - начало подключения двухфакторной аутентификации
+	EnrollTOTP(ctx context.Context, login string) (entity.TOTPEnrollment, error)
+	// ConfirmTOTP - подтверждение подключения двухфакторной аутентификации
+	ConfirmTOTP(ctx context.Context, login, code string) ([]string, error)
+	// VerifySecondFactor - проверка одноразового кода или кода восстановления
+	VerifySecondFactor(ctx context.Context, login, code string) error
+	// StartOIDCLogin - начало входа через поставщика удостоверений
+	StartOIDCLogin(ctx context.Context, loginHint string) (string, string, error)
+	// FinishOIDCLogin - завершение входа через поставщика удостоверений
+	FinishOIDCLogin(ctx context.Context, state, code string) (string, bool, error)
+	// CreateOIDCChallenge - сохранение входа через поставщика удостоверений до проверки второго фактора
+	CreateOIDCChallenge(ctx context.Context, login string) (string, error)
+	// ConsumeOIDCChallenge - получение логина незавершенного входа через поставщика удостоверений
+	ConsumeOIDCChallenge(ctx context.Context, challenge string) (string, error)
+	// ExportAccount - выгрузка всех данных пользователя
+	ExportAccount(ctx context.Context, login string) (entity.AccountExport, error)
+	// DeleteAccount - удаление учетной записи пользователя
+	DeleteAccount(ctx context.Context, login, password string) error
+	// StartSession - начало сеанса без refresh-токена
+	StartSession(ctx context.Context, session entity.Session) (entity.Session, error)
+	// GetSessions - действующие сеансы пользователя
+	GetSessions(ctx context.Context, login string) ([]entity.Session, error)
+	// RevokeSession - завершение сеанса пользователя
+	RevokeSession(ctx context.Context, login, id string) error
+	// IsAccessTokenRevoked - проверка отзыва токена доступа
+	IsAccessTokenRevoked(ctx context.Context, jti, login, sessionID string, issuedAt time.Time) (bool, error)
+	// ReserveIdempotencyKey - резервирование ключа идемпотентности
+	ReserveIdempotencyKey(ctx context.Context, login, key, hash string) (*entity.Idempotency, error)
+	// SaveIdempotencyResponse - сохранение ответа на идемпотентный запрос
+	SaveIdempotencyResponse(ctx context.Context, login, key string, status int, contentType string, body []byte) error
+	// DeleteIdempotencyKey - освобождение ключа идемпотентности
+	DeleteIdempotencyKey(ctx context.Context, login, key string) error
 }
 
 type UseCase struct {
-	repo   Repository // interface Repository
-	cfg    config.HTTPServer
-	entity *entity.AllEntity // struct entity
-	DB     *sql.DB
+	repo     Repository // interface Repository
+	cfg      config.HTTPServer
+	entity   *entity.AllEntity // struct entity
+	DB       *sql.DB
+	Hasher   PasswordHasher   // Хеширование паролей
+	Policy   PasswordPolicy   // Политика паролей
+	Notifier Notifier         // Доставка уведомлений пользователям
+	IdP      IdentityProvider // Вход через внешний поставщик удостоверений; nil отключает вход
+	Events   *events.Hub      // События пользователей в реальном времени; nil отключает их
+
+	dummyOnce sync.Once // Однократное вычисление dummy
+	dummy     string    // Хеш, по которому проверяется пароль несуществующего пользователя
 }
 
 func New(r Repository, cfg config.HTTPServer) *UseCase {
@@ -56,12 +198,78 @@ func (uc *UseCase) Do() *UseCase {
 }
 
 func (uc *UseCase) DoRegister(ctx context.Context, login, password string, _ *http.Request) error {
-	err := uc.repo.Register(ctx, login, password)
+	return uc.repo.Register(ctx, login, password)
+}
+
+// DoAuth аутентифицирует пользователя с защитой от подбора пароля: попытка отклоняется с *ThrottleError,
+// пока для логина или IP-адреса клиента действует задержка после неудачных попыток или блокировка.
+// Попытка резервируется до проверки пароля, поэтому параллельные попытки не обходят задержку.
+// Пользователь с подключенной двухфакторной аутентификацией должен передать одноразовый код otp
+// или код восстановления; неверный код считается неудачной попыткой входа.
+func (uc *UseCase) DoAuth(ctx context.Context, login, password, otp string, r *http.Request) error {
+	ip := clientIP(r)
+	if err := uc.repo.ReserveLoginAttempt(ctx, login, ip); err != nil {
+		return err
+	}
+
+	err := uc.repo.Auth(ctx, login, password)
+	switch {
+	case errors.Is(err, ErrUnauthorized):
+		return err
+	case err != nil:
+		uc.releaseLoginAttempt(ctx, login, ip)
+		return err
+	}
+
+	if err = uc.verifySecondFactor(ctx, login, otp, ip); err != nil {
+		return err
+	}
+
+	uc.releaseLoginAttempt(ctx, login, ip)
+	if err = uc.repo.ResetLoginAttempts(ctx, login); err != nil {
+		l.L(ctx).Error("error resetting login attempts", "login", login, l.ErrAttr(err))
+	}
+	return nil
+}
+
+// verifySecondFactor проверяет второй фактор пользователя после ReserveLoginAttempt. Неверный код остается учтенным
+// как неудачная попытка входа, чтобы одноразовые коды нельзя было подобрать; при других ошибках попытка возвращается.
+func (uc *UseCase) verifySecondFactor(ctx context.Context, login, otp, ip string) error {
+	err := uc.repo.VerifySecondFactor(ctx, login, otp)
+	if err != nil && !errors.Is(err, ErrOTPCode) {
+		uc.releaseLoginAttempt(ctx, login, ip)
+	}
 	return err
 }
-func (uc *UseCase) DoAuth(ctx context.Context, login, password string, _ *http.Request) error {
-	return uc.repo.Auth(ctx, login, password)
+
+// releaseLoginAttempt возвращает зарезервированную попытку входа; ошибка только записывается в журнал.
+func (uc *UseCase) releaseLoginAttempt(ctx context.Context, login, ip string) {
+	if err := uc.repo.ReleaseLoginAttempt(ctx, login, ip); err != nil {
+		l.L(ctx).Error("error releasing login attempt", "login", login, l.ErrAttr(err))
+	}
+}
+
+// clientIP возвращает IP-адрес клиента из адреса соединения.
+func clientIP(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// sessionClient возвращает сеанс пользователя login с User-Agent и IP-адресом клиента из запроса r.
+func sessionClient(login string, r *http.Request) entity.Session {
+	session := entity.Session{Login: login, IP: clientIP(r)}
+	if r != nil {
+		session.UserAgent = r.UserAgent()
+	}
+	return session
 }
+
 func (uc *UseCase) DoInsertOrder(ctx context.Context, user string, order string) error {
 	return uc.repo.InsertOrder(ctx, user, order)
 }
@@ -74,10 +282,273 @@ func (uc *UseCase) DoGetBalance(ctx context.Context, login string) (float32, flo
 	return uc.repo.GetBalance(ctx, login)
 }
 
-func (uc *UseCase) DoDebit(ctx context.Context, user, order string, sum float32) error {
+// DoDebit списывает баллы пользователя. Списание суммы больше config.Cfg.WithdrawLimits.StepUpSum пользователем
+// с подключенной двухфакторной аутентификацией требует одноразового кода otp; неверные коды ограничиваются
+// так же, как неудачные попытки входа.
+func (uc *UseCase) DoDebit(ctx context.Context, user, order string, sum float32, otp string, r *http.Request) error {
+	if limit := config.Cfg.WithdrawLimits.StepUpSum; limit > 0 && sum > limit {
+		ip := clientIP(r)
+		if err := uc.repo.ReserveLoginAttempt(ctx, user, ip); err != nil {
+			return err
+		}
+		if err := uc.verifySecondFactor(ctx, user, otp, ip); err != nil {
+			return err
+		}
+		uc.releaseLoginAttempt(ctx, user, ip)
+	}
 	return uc.repo.Debit(ctx, user, order, sum)
 }
 
 func (uc *UseCase) DoGetWithdrawals(ctx context.Context, user string) ([]byte, error) {
 	return uc.repo.GetWithdrawals(ctx, user)
 }
+
+func (uc *UseCase) DoSubscribe(login string, lastID uint64) (*events.Subscription, bool) {
+	return uc.repo.Subscribe(login, lastID)
+}
+
+func (uc *UseCase) DoGetOrder(ctx context.Context, user, number string) (entity.OrderDetails, error) {
+	return uc.repo.GetOrder(ctx, user, number)
+}
+
+func (uc *UseCase) DoGetOrdersPage(ctx context.Context, user string, f entity.HistoryFilter) ([]entity.Order, string, error) {
+	return uc.repo.GetOrdersPage(ctx, user, f)
+}
+
+func (uc *UseCase) DoGetWithdrawalsPage(ctx context.Context, user string, f entity.HistoryFilter) ([]entity.Withdrawal, string, error) {
+	return uc.repo.GetWithdrawalsPage(ctx, user, f)
+}
+
+func (uc *UseCase) DoGetTier(ctx context.Context, login string) (entity.TierProgress, error) {
+	return uc.repo.GetTier(ctx, login)
+}
+
+func (uc *UseCase) DoStatement(ctx context.Context, user string, from, to time.Time, w StatementWriter) error {
+	return uc.repo.Statement(ctx, user, from, to, w)
+}
+
+func (uc *UseCase) DoAdjustBalance(ctx context.Context, adj entity.Adjustment) (entity.Adjustment, error) {
+	return uc.repo.AdjustBalance(ctx, adj)
+}
+
+func (uc *UseCase) DoGetAdjustments(ctx context.Context, login string) ([]entity.Adjustment, error) {
+	return uc.repo.GetAdjustments(ctx, login)
+}
+
+func (uc *UseCase) DoCreateCampaign(ctx context.Context, c entity.Campaign) (entity.Campaign, error) {
+	return uc.repo.CreateCampaign(ctx, c)
+}
+
+func (uc *UseCase) DoGetCampaigns(ctx context.Context) ([]entity.Campaign, error) {
+	return uc.repo.GetCampaigns(ctx)
+}
+
+func (uc *UseCase) DoSetCampaignActive(ctx context.Context, name string, active bool) error {
+	return uc.repo.SetCampaignActive(ctx, name, active)
+}
+
+func (uc *UseCase) DoGrantCampaign(ctx context.Context, name string, logins []string, createdBy string) ([]string, error) {
+	return uc.repo.GrantCampaign(ctx, name, logins, createdBy)
+}
+
+func (uc *UseCase) DoGeneratePassword(login string) (string, error) {
+	return uc.repo.GeneratePassword(login)
+}
+
+// DoChangePassword меняет пароль пользователя. Неверный текущий пароль считается неудачной попыткой входа,
+// поэтому по украденному токену доступа текущий пароль нельзя подобрать быстрее, чем при входе.
+func (uc *UseCase) DoChangePassword(ctx context.Context, login, current, password string, r *http.Request) error {
+	ip := clientIP(r)
+	if err := uc.repo.ReserveLoginAttempt(ctx, login, ip); err != nil {
+		return err
+	}
+	err := uc.repo.ChangePassword(ctx, login, current, password)
+	if !errors.Is(err, ErrPassword) {
+		uc.releaseLoginAttempt(ctx, login, ip)
+	}
+	return err
+}
+
+// DoRequestPasswordReset отправляет токен сброса пароля. Запросы ограничиваются счетчиками логина и IP-адреса
+// клиента так же, как попытки входа; при превышении возвращается *ThrottleError.
+func (uc *UseCase) DoRequestPasswordReset(ctx context.Context, login string, r *http.Request) error {
+	if err := uc.repo.ReservePasswordReset(ctx, login, clientIP(r)); err != nil {
+		return err
+	}
+	return uc.repo.RequestPasswordReset(ctx, login)
+}
+
+func (uc *UseCase) DoResetPassword(ctx context.Context, token, password string) (string, error) {
+	return uc.repo.ResetPassword(ctx, token, password)
+}
+
+func (uc *UseCase) DoGetRole(ctx context.Context, login string) (string, error) {
+	return uc.repo.GetRole(ctx, login)
+}
+
+func (uc *UseCase) DoCreateAPIKey(ctx context.Context, key entity.APIKey) (entity.APIKey, error) {
+	return uc.repo.CreateAPIKey(ctx, key)
+}
+
+func (uc *UseCase) DoGetAPIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	return uc.repo.GetAPIKeys(ctx)
+}
+
+func (uc *UseCase) DoRevokeAPIKey(ctx context.Context, id string) error {
+	return uc.repo.RevokeAPIKey(ctx, id)
+}
+
+func (uc *UseCase) DoAuthenticateAPIKey(ctx context.Context, key, login string) (entity.APIKey, error) {
+	return uc.repo.AuthenticateAPIKey(ctx, key, login)
+}
+
+func (uc *UseCase) DoCountAPIKeyRequest(ctx context.Context, id string, limit int) (time.Duration, error) {
+	return uc.repo.CountAPIKeyRequest(ctx, id, limit)
+}
+
+func (uc *UseCase) DoSetRole(ctx context.Context, login, role string) error {
+	return uc.repo.SetRole(ctx, login, role)
+}
+
+func (uc *UseCase) DoHasAdmin(ctx context.Context) (bool, error) {
+	return uc.repo.HasAdmin(ctx)
+}
+
+func (uc *UseCase) DoGetWithdrawalViolations(ctx context.Context, login string) ([]entity.WithdrawalViolation, error) {
+	return uc.repo.GetWithdrawalViolations(ctx, login)
+}
+
+func (uc *UseCase) DoGetWithdrawLimits(ctx context.Context, login string) (entity.WithdrawLimits, error) {
+	return uc.repo.GetWithdrawLimits(ctx, login)
+}
+
+func (uc *UseCase) DoSetWithdrawLimits(ctx context.Context, limits entity.WithdrawLimits) (entity.WithdrawLimits, error) {
+	return uc.repo.SetWithdrawLimits(ctx, limits)
+}
+
+// DoIssueRefreshToken начинает сеанс пользователя с User-Agent и IP-адресом клиента из запроса r.
+func (uc *UseCase) DoIssueRefreshToken(ctx context.Context, login string, r *http.Request) (entity.Session, string, error) {
+	return uc.repo.IssueRefreshToken(ctx, sessionClient(login, r))
+}
+
+// DoStartSession начинает сеанс пользователя без refresh-токена с User-Agent и IP-адресом клиента из запроса r.
+func (uc *UseCase) DoStartSession(ctx context.Context, login string, r *http.Request) (entity.Session, error) {
+	return uc.repo.StartSession(ctx, sessionClient(login, r))
+}
+
+// DoRotateRefreshToken обменивает refresh-токен, запоминая в сеансе User-Agent и IP-адрес клиента из запроса r.
+func (uc *UseCase) DoRotateRefreshToken(ctx context.Context, token string, r *http.Request) (entity.Session, string, error) {
+	return uc.repo.RotateRefreshToken(ctx, token, sessionClient("", r))
+}
+
+func (uc *UseCase) DoEnrollTOTP(ctx context.Context, login string) (entity.TOTPEnrollment, error) {
+	return uc.repo.EnrollTOTP(ctx, login)
+}
+
+func (uc *UseCase) DoConfirmTOTP(ctx context.Context, login, code string) ([]string, error) {
+	return uc.repo.ConfirmTOTP(ctx, login, code)
+}
+
+func (uc *UseCase) DoStartOIDCLogin(ctx context.Context, loginHint string) (string, string, error) {
+	return uc.repo.StartOIDCLogin(ctx, loginHint)
+}
+
+// DoFinishOIDCLogin завершает вход через поставщика удостоверений. Поставщик не проверяет второй фактор
+// пользователя, поэтому при подключенной двухфакторной аутентификации вход сохраняется до проверки кода
+// в DoFinishOIDCChallenge: возвращается идентификатор незавершенного входа и ошибка ErrOTPRequired.
+func (uc *UseCase) DoFinishOIDCLogin(ctx context.Context, state, code string) (string, string, error) {
+	login, _, err := uc.repo.FinishOIDCLogin(ctx, state, code)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = uc.repo.VerifySecondFactor(ctx, login, "")
+	switch {
+	case errors.Is(err, ErrOTPRequired):
+		challenge, err := uc.repo.CreateOIDCChallenge(ctx, login)
+		if err != nil {
+			return "", "", err
+		}
+		return login, challenge, ErrOTPRequired
+	case err != nil:
+		return "", "", err
+	}
+	return login, "", nil
+}
+
+// DoFinishOIDCChallenge завершает вход через поставщика удостоверений, сохраненный DoFinishOIDCLogin, после проверки
+// одноразового кода или кода восстановления otp. Неверный код учитывается как неудачная попытка входа, как в DoAuth.
+func (uc *UseCase) DoFinishOIDCChallenge(ctx context.Context, challenge, otp string, r *http.Request) (string, error) {
+	login, err := uc.repo.ConsumeOIDCChallenge(ctx, challenge)
+	if err != nil {
+		return "", err
+	}
+
+	ip := clientIP(r)
+	if err = uc.repo.ReserveLoginAttempt(ctx, login, ip); err != nil {
+		return "", err
+	}
+	if err = uc.verifySecondFactor(ctx, login, otp, ip); err != nil {
+		return "", err
+	}
+
+	uc.releaseLoginAttempt(ctx, login, ip)
+	if err = uc.repo.ResetLoginAttempts(ctx, login); err != nil {
+		l.L(ctx).Error("error resetting login attempts", "login", login, l.ErrAttr(err))
+	}
+	return login, nil
+}
+
+func (uc *UseCase) DoExportAccount(ctx context.Context, login string) (entity.AccountExport, error) {
+	return uc.repo.ExportAccount(ctx, login)
+}
+
+// DoDeleteAccount удаляет учетную запись пользователя после проверки пароля и, если подключена двухфакторная
+// аутентификация, одноразового кода otp. Неверный пароль или код считается неудачной попыткой входа,
+// поэтому подбор ограничивается так же, как при входе.
+func (uc *UseCase) DoDeleteAccount(ctx context.Context, login, password, otp string, r *http.Request) error {
+	ip := clientIP(r)
+	if err := uc.repo.ReserveLoginAttempt(ctx, login, ip); err != nil {
+		return err
+	}
+	if err := uc.verifySecondFactor(ctx, login, otp, ip); err != nil {
+		return err
+	}
+	err := uc.repo.DeleteAccount(ctx, login, password)
+	if !errors.Is(err, ErrPassword) {
+		uc.releaseLoginAttempt(ctx, login, ip)
+	}
+	return err
+}
+
+func (uc *UseCase) DoGetSessions(ctx context.Context, login string) ([]entity.Session, error) {
+	return uc.repo.GetSessions(ctx, login)
+}
+
+func (uc *UseCase) DoRevokeSession(ctx context.Context, login, id string) error {
+	return uc.repo.RevokeSession(ctx, login, id)
+}
+
+func (uc *UseCase) DoRevokeRefreshToken(ctx context.Context, token string) error {
+	return uc.repo.RevokeRefreshToken(ctx, token)
+}
+
+func (uc *UseCase) DoRevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	return uc.repo.RevokeAccessToken(ctx, jti, expiresAt)
+}
+
+func (uc *UseCase) DoIsAccessTokenRevoked(ctx context.Context, jti, login, sessionID string, issuedAt time.Time) (bool, error) {
+	return uc.repo.IsAccessTokenRevoked(ctx, jti, login, sessionID, issuedAt)
+}
+
+func (uc *UseCase) DoReserveIdempotencyKey(ctx context.Context, login, key, hash string) (*entity.Idempotency, error) {
+	return uc.repo.ReserveIdempotencyKey(ctx, login, key, hash)
+}
+
+func (uc *UseCase) DoSaveIdempotencyResponse(ctx context.Context, login, key string, status int, contentType string, body []byte) error {
+	return uc.repo.SaveIdempotencyResponse(ctx, login, key, status, contentType, body)
+}
+
+func (uc *UseCase) DoDeleteIdempotencyKey(ctx context.Context, login, key string) error {
+	return uc.repo.DeleteIdempotencyKey(ctx, login, key)
+}
@@ -0,0 +1,163 @@
+// Package events - доставка событий пользователям внутри процесса по схеме publish/subscribe
+package events
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Event - событие, адресованное одному пользователю.
+type Event struct {
+	ID    uint64          `json:"id"`
+	Type  string          `json:"type"`
+	Data  json.RawMessage `json:"data"`
+	Time  time.Time       `json:"time"`
+	login string
+}
+
+// Subscription - подписка на события пользователя. Канал C закрывается при вызове Close
+// и при переполнении буфера подписки, если подписчик не успевает читать события.
+type Subscription struct {
+	C <-chan Event
+
+	hub     *Hub
+	login   string
+	ch      chan Event
+	done    chan struct{}
+	closed  bool
+	dropped bool
+}
+
+// Done возвращает канал, который закрывается при отмене подписки. В отличие от C, он сообщает об отмене,
+// даже если в буфере подписки остались непрочитанные события.
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Close отменяет подписку.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
+
+// Dropped сообщает, была ли подписка отменена из-за переполнения буфера.
+func (s *Subscription) Dropped() bool {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.dropped
+}
+
+// Hub рассылает события подписчикам и хранит последние события для возобновления подписки
+// после переподключения. Идентификаторы событий возрастают и начинаются со времени создания Hub в наносекундах,
+// поэтому идентификатор, полученный до перезапуска процесса, не совпадает с новыми событиями.
+// Нулевой указатель на Hub допустим: события не публикуются, подписки ничего не получают.
+type Hub struct {
+	mu      sync.Mutex
+	lastID  uint64
+	history []Event
+	size    int
+	buffer  int
+	subs    map[string]map[*Subscription]struct{}
+}
+
+// NewHub создает Hub, хранящий history последних событий, с буфером buffer событий на подписку.
+func NewHub(history, buffer int) *Hub {
+	return &Hub{
+		lastID: uint64(time.Now().UnixNano()),
+		size:   history,
+		buffer: buffer,
+		subs:   make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+// Publish отправляет событие typ с данными data всем подпискам пользователя login.
+// Подписки, буфер которых переполнен, отменяются: подписчик может возобновить их с последнего полученного события.
+func (h *Hub) Publish(login, typ string, data any) (Event, error) {
+	if h == nil {
+		return Event{}, nil
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	e := Event{ID: h.lastID, Type: typ, Data: raw, Time: time.Now(), login: login}
+	if h.size > 0 {
+		if len(h.history) == h.size {
+			h.history = append(h.history[:0], h.history[1:]...)
+		}
+		h.history = append(h.history, e)
+	}
+
+	for s := range h.subs[login] {
+		select {
+		case s.ch <- e:
+		default:
+			s.dropped = true
+			h.remove(s)
+		}
+	}
+	return e, nil
+}
+
+// Subscribe подписывается на события пользователя login. Если lastID не равен нулю, в канал подписки
+// сначала передаются сохраненные события пользователя после события lastID.
+//
+// Возвращаемые значения:
+//   - *Subscription: подписка.
+//   - bool: false, если события после lastID уже не хранятся и часть из них пропущена.
+func (h *Hub) Subscribe(login string, lastID uint64) (*Subscription, bool) {
+	if h == nil {
+		ch := make(chan Event)
+		return &Subscription{C: ch, hub: &Hub{}, ch: ch, done: make(chan struct{})}, lastID == 0
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var missed []Event
+	complete := true
+	if lastID != 0 {
+		oldest := h.lastID + 1
+		if len(h.history) > 0 {
+			oldest = h.history[0].ID
+		}
+		complete = lastID+1 >= oldest && lastID <= h.lastID
+		for _, e := range h.history {
+			if e.ID > lastID && e.login == login {
+				missed = append(missed, e)
+			}
+		}
+	}
+
+	ch := make(chan Event, h.buffer+len(missed))
+	for _, e := range missed {
+		ch <- e
+	}
+	s := &Subscription{C: ch, hub: h, login: login, ch: ch, done: make(chan struct{})}
+	if h.subs[login] == nil {
+		h.subs[login] = make(map[*Subscription]struct{})
+	}
+	h.subs[login][s] = struct{}{}
+	return s, complete
+}
+
+// remove отменяет подписку s; вызывается под блокировкой h.mu.
+func (h *Hub) remove(s *Subscription) {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.ch)
+	close(s.done)
+	delete(h.subs[s.login], s)
+	if len(h.subs[s.login]) == 0 {
+		delete(h.subs, s.login)
+	}
+}
@@ -0,0 +1,81 @@
+// Package notify - доставка уведомлений пользователям
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nextlag/gomart/pkg/logger/l"
+)
+
+// Виды доставки уведомлений.
+const (
+	NoneKind = "none"
+	LogKind  = "log"
+	FileKind = "file"
+)
+
+// Notifier доставляет уведомление пользователю.
+type Notifier interface {
+	Notify(ctx context.Context, login, subject, body string) error
+}
+
+// New возвращает Notifier указанного вида. Виды log и file записывают уведомления, включая секреты
+// (например, токены сброса пароля), в открытом виде и предназначены только для локальной разработки,
+// поэтому включаются явно. Для вида none или пустого значения возвращается nil: уведомления не отправляются.
+//
+// Параметры:
+//   - kind: string - вид доставки: none, log или file.
+//   - path: string - файл для доставки вида file.
+//
+// Возвращаемые значения:
+//   - Notifier: объект доставки уведомлений.
+//   - error: ошибка, если вид доставки неизвестен или не задан файл.
+func New(kind, path string) (Notifier, error) {
+	switch strings.ToLower(kind) {
+	case "", NoneKind:
+		return nil, nil
+	case LogKind:
+		return Log{}, nil
+	case FileKind:
+		if path == "" {
+			return nil, fmt.Errorf("notify file is not set")
+		}
+		return &File{Path: path}, nil
+	default:
+		return nil, fmt.Errorf("unknown notifier %q", kind)
+	}
+}
+
+// Log записывает уведомления в лог приложения; предназначен для локальной разработки.
+type Log struct{}
+
+func (Log) Notify(ctx context.Context, login, subject, body string) error {
+	l.L(ctx).Info("notification", "login", login, "subject", subject, "body", body)
+	return nil
+}
+
+// File дописывает уведомления в файл; предназначен для локальной разработки и тестов.
+type File struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func (f *File) Notify(_ context.Context, login, subject, body string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(file, "%s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC3339), login, subject, body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
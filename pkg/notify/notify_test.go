@@ -0,0 +1,45 @@
+package notify
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	for _, kind := range []string{"", "none", "NONE"} {
+		n, err := New(kind, "")
+		require.NoError(t, err)
+		assert.Nil(t, n, "Вид %q отключает уведомления", kind)
+	}
+
+	n, err := New("log", "")
+	require.NoError(t, err)
+	assert.IsType(t, Log{}, n)
+
+	n, err = New("file", "notify.txt")
+	require.NoError(t, err)
+	assert.IsType(t, &File{}, n)
+
+	_, err = New("file", "")
+	assert.Error(t, err)
+
+	_, err = New("smtp", "")
+	assert.Error(t, err)
+}
+
+func TestFileNotify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notify.txt")
+	n := &File{Path: path}
+	require.NoError(t, n.Notify(context.Background(), "test", "subject", "first"))
+	require.NoError(t, n.Notify(context.Background(), "test", "subject", "second"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "To: test\nSubject: subject\n\nfirst\n")
+	assert.Contains(t, string(data), "second")
+}
@@ -0,0 +1,337 @@
+// Package oidc - вход через внешний поставщик удостоверений OpenID Connect по схеме authorization code с PKCE
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	// discoveryPath - путь документа с параметрами поставщика относительно его идентификатора (issuer)
+	discoveryPath = "/.well-known/openid-configuration"
+	// verifierLength - длина code_verifier PKCE в байтах до кодирования
+	verifierLength = 32
+	// requestTimeout - время ожидания ответа поставщика
+	requestTimeout = 10 * time.Second
+)
+
+var (
+	// ErrProvider - поставщик удостоверений недоступен или вернул ошибку
+	ErrProvider = errors.New("identity provider error")
+	// ErrIDToken - ID-токен не прошел проверку
+	ErrIDToken = errors.New("invalid ID token")
+)
+
+// Config содержит параметры клиента поставщика удостоверений.
+type Config struct {
+	Issuer       string // Идентификатор поставщика; параметры поставщика загружаются из Issuer + /.well-known/openid-configuration
+	ClientID     string // Идентификатор клиента, зарегистрированного у поставщика
+	ClientSecret string // Секрет клиента; пустое значение для публичных клиентов
+	RedirectURL  string // Адрес возврата после входа у поставщика
+}
+
+// Claims - клеймы ID-токена, используемые при входе.
+type Claims struct {
+	Nonce             string `json:"nonce,omitempty"`
+	Email             string `json:"email,omitempty"`
+	EmailVerified     bool   `json:"email_verified,omitempty"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	Name              string `json:"name,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Claim возвращает значение клейма по имени: sub, email, preferred_username или name.
+// Адрес электронной почты возвращается, только если поставщик подтвердил его (email_verified).
+func (c Claims) Claim(name string) string {
+	switch name {
+	case "sub":
+		return c.Subject
+	case "email":
+		if c.EmailVerified {
+			return c.Email
+		}
+	case "preferred_username":
+		return c.PreferredUsername
+	case "name":
+		return c.Name
+	}
+	return ""
+}
+
+// metadata - параметры поставщика из документа discovery.
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// jwks - набор открытых ключей поставщика.
+type jwks struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+// tokenResponse - ответ конечной точки token поставщика.
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Provider - клиент поставщика удостоверений. Параметры поставщика загружаются при первом обращении,
+// а ключи подписи ID-токенов - при первом обращении и при появлении неизвестного ключа.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu   sync.Mutex
+	meta *metadata
+	keys map[string]*rsa.PublicKey
+}
+
+// New создает клиент поставщика удостоверений.
+func New(cfg Config) *Provider {
+	return &Provider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// Issuer возвращает идентификатор поставщика.
+func (p *Provider) Issuer() string {
+	return p.cfg.Issuer
+}
+
+// NewVerifier генерирует случайный code_verifier PKCE.
+func NewVerifier() (string, error) {
+	b := make([]byte, verifierLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Challenge вычисляет code_challenge PKCE методом S256.
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL возвращает адрес страницы входа поставщика.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - state: значение, связывающее ответ поставщика с начатым входом.
+//   - nonce: значение, которое поставщик включает в ID-токен.
+//   - verifier: code_verifier PKCE; поставщику передается только его хеш.
+//   - loginHint: подсказка поставщику, каким пользователем выполнить вход; может быть пустой.
+//
+// Возвращаемые значения:
+//   - string: адрес страницы входа.
+//   - error: ErrProvider, если параметры поставщика не удалось загрузить.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier, loginHint string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {"openid profile email"},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {Challenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	if loginHint != "" {
+		q.Set("login_hint", loginHint)
+	}
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange обменивает код авторизации на ID-токен и проверяет его подпись, поставщика, получателя,
+// срок действия и nonce.
+//
+// Параметры:
+//   - ctx: контекст выполнения запроса.
+//   - code: код авторизации из ответа поставщика.
+//   - verifier: code_verifier PKCE, с которым был начат вход.
+//   - nonce: значение nonce, с которым был начат вход.
+//
+// Возвращаемые значения:
+//   - Claims: клеймы проверенного ID-токена.
+//   - error: ErrProvider, если поставщик отклонил код или недоступен, ErrIDToken, если ID-токен не прошел проверку.
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (Claims, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {verifier},
+	}
+	if p.cfg.ClientSecret == "" {
+		form.Set("client_id", p.cfg.ClientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Claims{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %v", ErrProvider, err)
+	}
+	defer resp.Body.Close()
+
+	var token tokenResponse
+	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return Claims{}, fmt.Errorf("%w: decode token response: %v", ErrProvider, err)
+	}
+	if resp.StatusCode != http.StatusOK || token.Error != "" {
+		return Claims{}, fmt.Errorf("%w: token endpoint: %s %s", ErrProvider, token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return Claims{}, fmt.Errorf("%w: no id_token in token response", ErrIDToken)
+	}
+	return p.verify(ctx, meta, token.IDToken, nonce)
+}
+
+// verify проверяет ID-токен.
+func (p *Provider) verify(ctx context.Context, meta *metadata, raw, nonce string) (Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(raw, &claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Method.Alg() != jwt.SigningMethodRS256.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+		}
+		kid, _ := t.Header["kid"].(string)
+		return p.key(ctx, meta, kid)
+	})
+	switch {
+	case err != nil:
+		return claims, fmt.Errorf("%w: %v", ErrIDToken, err)
+	case claims.Issuer != meta.Issuer:
+		return claims, fmt.Errorf("%w: unexpected issuer %q", ErrIDToken, claims.Issuer)
+	case !claims.VerifyAudience(p.cfg.ClientID, true):
+		return claims, fmt.Errorf("%w: unexpected audience", ErrIDToken)
+	case claims.ExpiresAt == nil:
+		return claims, fmt.Errorf("%w: no expiration time", ErrIDToken)
+	case claims.Subject == "":
+		return claims, fmt.Errorf("%w: no subject", ErrIDToken)
+	case claims.Nonce != nonce:
+		return claims, fmt.Errorf("%w: nonce mismatch", ErrIDToken)
+	}
+	return claims, nil
+}
+
+// discover загружает параметры поставщика; после успешной загрузки они не запрашиваются повторно.
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	var meta metadata
+	if err := p.getJSON(ctx, strings.TrimSuffix(p.cfg.Issuer, "/")+discoveryPath, &meta); err != nil {
+		return nil, err
+	}
+	if meta.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("%w: discovery issuer %q does not match %q", ErrProvider, meta.Issuer, p.cfg.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: incomplete discovery document", ErrProvider)
+	}
+	p.meta = &meta
+	return p.meta, nil
+}
+
+// key возвращает открытый ключ поставщика по идентификатору kid. Набор ключей загружается заново,
+// если ключ не найден, чтобы поддержать ротацию ключей у поставщика.
+func (p *Provider) key(ctx context.Context, meta *metadata, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	var set jwks
+	if err := p.getJSON(ctx, meta.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("decode key %q modulus: %v", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("decode key %q exponent: %v", k.Kid, err)
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	p.keys = keys
+
+	key, ok := keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+// getJSON выполняет GET-запрос к поставщику и декодирует ответ в формате JSON в v.
+func (p *Provider) getJSON(ctx context.Context, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrProvider, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: GET %s: %s", ErrProvider, u, resp.Status)
+	}
+	if err = json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("%w: decode %s: %v", ErrProvider, u, err)
+	}
+	return nil
+}
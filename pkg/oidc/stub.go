@@ -0,0 +1,223 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	// StubLogin - пользователь, вход которым подтверждает Stub, если клиент не передал login_hint
+	StubLogin = "stub-user"
+	// stubKeyBits - длина ключа подписи ID-токенов Stub
+	stubKeyBits = 2048
+	// stubCodeTTL - время действия кода авторизации Stub
+	stubCodeTTL = time.Minute
+	// stubTokenTTL - время действия ID-токена Stub
+	stubTokenTTL = 5 * time.Minute
+)
+
+// stubCode - выданный Stub код авторизации.
+type stubCode struct {
+	clientID    string
+	redirectURI string
+	challenge   string
+	nonce       string
+	login       string
+	expiresAt   time.Time
+}
+
+// Stub - локальный поставщик удостоверений для разработки и тестов. Он подтверждает вход пользователя
+// из параметра login_hint (или StubLogin) без пароля, проверяет PKCE и выдает ID-токены, подписанные ключом,
+// созданным при запуске. Stub обслуживает пути относительно своего идентификатора: его нужно подключать
+// к роутеру с удалением префикса, например через http.StripPrefix.
+type Stub struct {
+	issuer string
+	kid    string
+	key    *rsa.PrivateKey
+	mux    *http.ServeMux
+
+	mu    sync.Mutex
+	codes map[string]stubCode
+}
+
+// NewStub создает локальный поставщик удостоверений с идентификатором issuer.
+func NewStub(issuer string) (*Stub, error) {
+	key, err := rsa.GenerateKey(rand.Reader, stubKeyBits)
+	if err != nil {
+		return nil, err
+	}
+	kid, err := randomString(8)
+	if err != nil {
+		return nil, err
+	}
+	s := &Stub{
+		issuer: strings.TrimSuffix(issuer, "/"),
+		kid:    kid,
+		key:    key,
+		mux:    http.NewServeMux(),
+		codes:  make(map[string]stubCode),
+	}
+	s.mux.HandleFunc(discoveryPath, s.discovery)
+	s.mux.HandleFunc("/authorize", s.authorize)
+	s.mux.HandleFunc("/token", s.token)
+	s.mux.HandleFunc("/jwks", s.jwks)
+	return s, nil
+}
+
+func (s *Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// discovery отдает параметры поставщика.
+func (s *Stub) discovery(w http.ResponseWriter, _ *http.Request) {
+	stubJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                s.issuer,
+		"authorization_endpoint":                s.issuer + "/authorize",
+		"token_endpoint":                        s.issuer + "/token",
+		"jwks_uri":                              s.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{jwt.SigningMethodRS256.Alg()},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+// authorize подтверждает вход без взаимодействия с пользователем и перенаправляет клиента
+// на redirect_uri с кодом авторизации.
+func (s *Stub) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirectURI, err := url.Parse(q.Get("redirect_uri"))
+	switch {
+	case err != nil || !redirectURI.IsAbs():
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	case q.Get("response_type") != "code", q.Get("client_id") == "":
+		http.Error(w, "unsupported authorization request", http.StatusBadRequest)
+		return
+	case q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "":
+		http.Error(w, "PKCE with S256 is required", http.StatusBadRequest)
+		return
+	}
+
+	login := q.Get("login_hint")
+	if login == "" {
+		login = StubLogin
+	}
+	code, err := randomString(16)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.mu.Lock()
+	s.codes[code] = stubCode{
+		clientID:    q.Get("client_id"),
+		redirectURI: redirectURI.String(),
+		challenge:   q.Get("code_challenge"),
+		nonce:       q.Get("nonce"),
+		login:       login,
+		expiresAt:   time.Now().Add(stubCodeTTL),
+	}
+	s.mu.Unlock()
+
+	params := redirectURI.Query()
+	params.Set("code", code)
+	params.Set("state", q.Get("state"))
+	redirectURI.RawQuery = params.Encode()
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+// token обменивает код авторизации на ID-токен.
+func (s *Stub) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		stubJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	clientID := r.PostForm.Get("client_id")
+	if id, _, ok := r.BasicAuth(); ok {
+		clientID, _ = url.QueryUnescape(id)
+	}
+
+	s.mu.Lock()
+	code, ok := s.codes[r.PostForm.Get("code")]
+	delete(s.codes, r.PostForm.Get("code"))
+	s.mu.Unlock()
+
+	challenge := Challenge(r.PostForm.Get("code_verifier"))
+	if !ok || time.Now().After(code.expiresAt) || code.clientID != clientID ||
+		code.redirectURI != r.PostForm.Get("redirect_uri") ||
+		subtle.ConstantTimeCompare([]byte(challenge), []byte(code.challenge)) != 1 {
+		stubJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, Claims{
+		Nonce:             code.nonce,
+		Email:             code.login + "@stub.local",
+		EmailVerified:     true,
+		PreferredUsername: code.login,
+		Name:              code.login,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.issuer,
+			Subject:   code.login,
+			Audience:  jwt.ClaimStrings{code.clientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(stubTokenTTL)),
+		},
+	})
+	token.Header["kid"] = s.kid
+	idToken, err := token.SignedString(s.key)
+	if err != nil {
+		stubJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	stubJSON(w, http.StatusOK, map[string]any{
+		"access_token": code.login,
+		"token_type":   "Bearer",
+		"expires_in":   int64(stubTokenTTL.Seconds()),
+		"id_token":     idToken,
+	})
+}
+
+// jwks отдает открытый ключ подписи ID-токенов.
+func (s *Stub) jwks(w http.ResponseWriter, _ *http.Request) {
+	stubJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": jwt.SigningMethodRS256.Alg(),
+			"kid": s.kid,
+			"n":   base64.RawURLEncoding.EncodeToString(s.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.key.E)).Bytes()),
+		}},
+	})
+}
+
+// stubJSON отправляет v в формате JSON с указанным статусом.
+func stubJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// randomString возвращает n случайных байт в шестнадцатеричном виде.
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,93 @@
+package passwd
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// argon2idPrefix - префикс хеша argon2id в формате PHC
+const argon2idPrefix = "$argon2id$"
+
+// Argon2id хеширует пароли алгоритмом argon2id и хранит их в формате PHC:
+// $argon2id$v=19$m=65536,t=3,p=4$<соль>$<хеш>.
+type Argon2id struct {
+	Time    uint32 // Количество проходов
+	Memory  uint32 // Объем памяти в КиБ
+	Threads uint8  // Степень параллелизма
+	SaltLen uint32 // Длина соли в байтах
+	KeyLen  uint32 // Длина хеша в байтах
+}
+
+// NewArgon2id возвращает Argon2id с параметрами, рекомендованными RFC 9106 для ограниченной памяти.
+func NewArgon2id() *Argon2id {
+	return &Argon2id{Time: 3, Memory: 64 * 1024, Threads: 4, SaltLen: 16, KeyLen: 32}
+}
+
+func (a *Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, a.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, a.Time, a.Memory, a.Threads, a.KeyLen)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version, a.Memory, a.Time, a.Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (a *Argon2id) Verify(password, encoded string) (bool, bool, error) {
+	if !strings.HasPrefix(encoded, argon2idPrefix) {
+		// Хеш другого алгоритма или пароль в открытом виде пересчитываются после успешной проверки
+		ok, err := verify(password, encoded)
+		return ok, ok, err
+	}
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, false, err
+	}
+	if !params.compare(password, salt, key) {
+		return false, false, nil
+	}
+	rehash := params.Time != a.Time || params.Memory != a.Memory || params.Threads != a.Threads ||
+		uint32(len(salt)) != a.SaltLen || params.KeyLen != a.KeyLen
+	return true, rehash, nil
+}
+
+// compare вычисляет хеш пароля с параметрами a и сравнивает его с key за постоянное время.
+func (a *Argon2id) compare(password string, salt, key []byte) bool {
+	other := argon2.IDKey([]byte(password), salt, a.Time, a.Memory, a.Threads, a.KeyLen)
+	return subtle.ConstantTimeCompare(key, other) == 1
+}
+
+// decodeArgon2id разбирает хеш argon2id в формате PHC.
+func decodeArgon2id(encoded string) (*Argon2id, []byte, []byte, error) {
+	if !strings.HasPrefix(encoded, argon2idPrefix) {
+		return nil, nil, nil, ErrHashFormat
+	}
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return nil, nil, nil, ErrHashFormat
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, ErrHashFormat
+	}
+	params := &Argon2id{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return nil, nil, nil, ErrHashFormat
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, ErrHashFormat
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return nil, nil, nil, ErrHashFormat
+	}
+	params.SaltLen = uint32(len(salt))
+	params.KeyLen = uint32(len(key))
+	return params, salt, key, nil
+}
@@ -0,0 +1,75 @@
+package passwd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcryptMaxBytes - максимальная длина пароля, которую принимает bcrypt
+const bcryptMaxBytes = 72
+
+// Bcrypt хеширует пароли алгоритмом bcrypt.
+// Bcrypt учитывает только первые 72 байта пароля, более длинные пароли отклоняются при хешировании.
+type Bcrypt struct {
+	Cost int // Сложность хеширования
+}
+
+// NewBcrypt возвращает Bcrypt со сложностью по умолчанию.
+func NewBcrypt() *Bcrypt {
+	return &Bcrypt{Cost: bcrypt.DefaultCost}
+}
+
+// Hash возвращает хеш пароля. Пароль длиннее 72 байт отклоняется ошибкой *PolicyError с правилом max_length:
+// политика считает длину в символах, а пароль из многобайтовых символов может уложиться в нее и превысить
+// предел bcrypt.
+func (b *Bcrypt) Hash(password string) (string, error) {
+	if len(password) > bcryptMaxBytes {
+		return "", &PolicyError{Rule: RuleMaxLength, Message: fmt.Sprintf("must be at most %d bytes", bcryptMaxBytes)}
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (b *Bcrypt) Verify(password, encoded string) (bool, bool, error) {
+	if !isBcrypt(encoded) {
+		// Хеш другого алгоритма или пароль в открытом виде пересчитываются после успешной проверки
+		ok, err := verify(password, encoded)
+		return ok, ok, err
+	}
+	ok, err := compareBcrypt(password, encoded)
+	if err != nil || !ok {
+		return false, false, err
+	}
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return false, false, ErrHashFormat
+	}
+	return true, cost != b.Cost, nil
+}
+
+// isBcrypt сообщает, является ли значение хешем bcrypt.
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// compareBcrypt проверяет пароль по хешу bcrypt; возвращает ErrHashFormat, если значение не является хешем bcrypt.
+func compareBcrypt(password, encoded string) (bool, error) {
+	if !isBcrypt(encoded) {
+		return false, ErrHashFormat
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+		return false, nil
+	default:
+		return false, ErrHashFormat
+	}
+}
@@ -0,0 +1,70 @@
+// Package passwd - хеширование и проверка паролей пользователей
+package passwd
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Алгоритмы хеширования паролей.
+const (
+	Argon2idAlgorithm = "argon2id"
+	BcryptAlgorithm   = "bcrypt"
+)
+
+// ErrHashFormat - сохраненный хеш пароля имеет неизвестный или поврежденный формат
+var ErrHashFormat = errors.New("invalid password hash format")
+
+// Hasher хеширует пароли и проверяет их по сохраненному хешу.
+type Hasher interface {
+	// Hash возвращает хеш пароля вместе с алгоритмом, параметрами и солью.
+	Hash(password string) (string, error)
+	// Verify проверяет пароль по сохраненному хешу. Флаг rehash сообщает, что хеш создан другим алгоритмом,
+	// с другими параметрами или пароль хранится в открытом виде, и его следует пересчитать.
+	Verify(password, encoded string) (ok, rehash bool, err error)
+}
+
+// New возвращает Hasher для указанного алгоритма с параметрами по умолчанию.
+//
+// Параметры:
+//   - algorithm: string - алгоритм хеширования: argon2id или bcrypt.
+//
+// Возвращаемые значения:
+//   - Hasher: объект хеширования паролей.
+//   - error: ошибка, если алгоритм неизвестен.
+func New(algorithm string) (Hasher, error) {
+	switch strings.ToLower(algorithm) {
+	case Argon2idAlgorithm:
+		return NewArgon2id(), nil
+	case BcryptAlgorithm:
+		return NewBcrypt(), nil
+	default:
+		return nil, fmt.Errorf("unknown password hash algorithm %q", algorithm)
+	}
+}
+
+// verify проверяет пароль по хешу любого поддерживаемого формата.
+// Значение без префикса argon2id или bcrypt считается паролем, сохраненным в открытом виде до перехода
+// на хеширование. Поврежденный хеш с известным префиксом не сравнивается как пароль в открытом виде,
+// иначе сама строка из базы данных подходила бы как пароль: для него возвращается ErrHashFormat.
+func verify(password, encoded string) (bool, error) {
+	switch {
+	case strings.HasPrefix(encoded, argon2idPrefix):
+		params, salt, key, err := decodeArgon2id(encoded)
+		if err != nil {
+			return false, err
+		}
+		return params.compare(password, salt, key), nil
+	case isBcrypt(encoded):
+		return compareBcrypt(password, encoded)
+	default:
+		return plaintext(password, encoded), nil
+	}
+}
+
+// plaintext сравнивает пароль с паролем, сохраненным в открытом виде, за постоянное время.
+func plaintext(password, stored string) bool {
+	return subtle.ConstantTimeCompare([]byte(password), []byte(stored)) == 1
+}
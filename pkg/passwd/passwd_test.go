@@ -0,0 +1,175 @@
+package passwd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fastArgon2id возвращает Argon2id с минимальными параметрами, чтобы тесты выполнялись быстро.
+func fastArgon2id() *Argon2id {
+	return &Argon2id{Time: 1, Memory: 64, Threads: 1, SaltLen: 16, KeyLen: 32}
+}
+
+func TestNew(t *testing.T) {
+	h, err := New("Argon2id")
+	require.NoError(t, err)
+	assert.IsType(t, &Argon2id{}, h)
+
+	h, err = New("bcrypt")
+	require.NoError(t, err)
+	assert.IsType(t, &Bcrypt{}, h)
+
+	_, err = New("md5")
+	assert.Error(t, err)
+}
+
+func TestHashRoundTrip(t *testing.T) {
+	hashers := map[string]Hasher{
+		"argon2id": fastArgon2id(),
+		"bcrypt":   &Bcrypt{Cost: bcrypt.MinCost},
+	}
+	for name, h := range hashers {
+		t.Run(name, func(t *testing.T) {
+			encoded, err := h.Hash("correct horse")
+			require.NoError(t, err)
+			assert.NotContains(t, encoded, "correct horse")
+
+			ok, rehash, err := h.Verify("correct horse", encoded)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.False(t, rehash, "Хеш с текущими параметрами не требует пересчета")
+
+			ok, rehash, err = h.Verify("wrong horse", encoded)
+			require.NoError(t, err)
+			assert.False(t, ok)
+			assert.False(t, rehash)
+
+			// Соль случайная, поэтому хеши одного пароля различаются
+			other, err := h.Hash("correct horse")
+			require.NoError(t, err)
+			assert.NotEqual(t, encoded, other)
+		})
+	}
+}
+
+func TestBcryptMaxBytes(t *testing.T) {
+	policy := &Policy{MaxLength: 72}
+	tests := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		{name: "72 ASCII bytes", password: strings.Repeat("a", 72)},
+		{name: "36 Cyrillic letters", password: strings.Repeat("я", 36)},
+		{name: "40 Cyrillic letters", password: strings.Repeat("я", 40), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, policy.Validate("", tt.password), "Политика считает длину в символах")
+
+			_, err := (&Bcrypt{Cost: bcrypt.MinCost}).Hash(tt.password)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			var policyErr *PolicyError
+			require.ErrorAs(t, err, &policyErr)
+			assert.Equal(t, RuleMaxLength, policyErr.Rule)
+			assert.ErrorIs(t, err, ErrPolicy)
+		})
+	}
+}
+
+func TestArgon2idFormat(t *testing.T) {
+	encoded, err := fastArgon2id().Hash("password")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encoded, "$argon2id$v=19$m=64,t=1,p=1$"), encoded)
+}
+
+func TestNeedsRehash(t *testing.T) {
+	argon, err := fastArgon2id().Hash("password")
+	require.NoError(t, err)
+	bcryptHash, err := (&Bcrypt{Cost: bcrypt.MinCost}).Hash("password")
+	require.NoError(t, err)
+
+	stronger := fastArgon2id()
+	stronger.Time = 2
+
+	tests := []struct {
+		name    string
+		hasher  Hasher
+		encoded string
+		rehash  bool
+	}{
+		{name: "Argon2id with other parameters", hasher: stronger, encoded: argon, rehash: true},
+		{name: "Bcrypt with other cost", hasher: &Bcrypt{Cost: bcrypt.MinCost + 1}, encoded: bcryptHash, rehash: true},
+		{name: "Bcrypt hash with argon2id hasher", hasher: fastArgon2id(), encoded: bcryptHash, rehash: true},
+		{name: "Argon2id hash with bcrypt hasher", hasher: &Bcrypt{Cost: bcrypt.MinCost}, encoded: argon, rehash: true},
+		{name: "Plaintext with argon2id hasher", hasher: fastArgon2id(), encoded: "password", rehash: true},
+		{name: "Plaintext with bcrypt hasher", hasher: &Bcrypt{Cost: bcrypt.MinCost}, encoded: "password", rehash: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, rehash, err := tt.hasher.Verify("password", tt.encoded)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, tt.rehash, rehash)
+
+			ok, rehash, err = tt.hasher.Verify("other", tt.encoded)
+			require.NoError(t, err)
+			assert.False(t, ok)
+			assert.False(t, rehash, "Неверный пароль не приводит к пересчету хеша")
+		})
+	}
+}
+
+func TestPlaintextFallback(t *testing.T) {
+	// Пароли, сохраненные в открытом виде до перехода на хеширование, могут начинаться с "$"
+	for _, stored := range []string{"$ecret", "$1$salt$hash", "plain"} {
+		for name, h := range map[string]Hasher{"argon2id": fastArgon2id(), "bcrypt": &Bcrypt{Cost: bcrypt.MinCost}} {
+			t.Run(name+" "+stored, func(t *testing.T) {
+				ok, rehash, err := h.Verify(stored, stored)
+				require.NoError(t, err)
+				assert.True(t, ok)
+				assert.True(t, rehash)
+
+				ok, _, err = h.Verify(stored+"x", stored)
+				require.NoError(t, err)
+				assert.False(t, ok)
+			})
+		}
+	}
+}
+
+func TestMalformedHash(t *testing.T) {
+	argon, err := fastArgon2id().Hash("password")
+	require.NoError(t, err)
+	bcryptHash, err := (&Bcrypt{Cost: bcrypt.MinCost}).Hash("password")
+	require.NoError(t, err)
+
+	// Поврежденный хеш с известным префиксом не должен подходить как пароль, совпадающий со строкой из базы данных
+	tests := map[string]string{
+		"Truncated argon2id":    argon[:len(argon)-10],
+		"Argon2id without key":  argon[:strings.LastIndex(argon, "$")],
+		"Argon2id version 16":   strings.Replace(argon, "v=19", "v=16", 1),
+		"Argon2id broken":       "$argon2id$v=19$broken",
+		"Truncated bcrypt":      bcryptHash[:20],
+		"Bcrypt with bad cost":  "$2a$99" + bcryptHash[6:],
+		"Bcrypt broken":         "$2a$broken",
+		"Bcrypt $2y$ truncated": "$2y$10$short",
+	}
+	for name, stored := range tests {
+		for hasher, h := range map[string]Hasher{"argon2id": fastArgon2id(), "bcrypt": &Bcrypt{Cost: bcrypt.MinCost}} {
+			t.Run(hasher+" "+name, func(t *testing.T) {
+				ok, rehash, err := h.Verify(stored, stored)
+				assert.ErrorIs(t, err, ErrHashFormat)
+				assert.False(t, ok, "Строка поврежденного хеша принята как пароль")
+				assert.False(t, rehash)
+			})
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package passwd
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	_ "embed"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Правила политики паролей; имя правила возвращается в PolicyError.
+const (
+	RuleMinLength = "min_length"
+	RuleMaxLength = "max_length"
+	RuleUpper     = "upper"
+	RuleLower     = "lower"
+	RuleDigit     = "digit"
+	RuleSymbol    = "symbol"
+	RuleDenylist  = "denylist"
+	RuleLogin     = "login"
+)
+
+// Наборы символов для генерации паролей.
+const (
+	upperChars  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	lowerChars  = "abcdefghijklmnopqrstuvwxyz"
+	digitChars  = "0123456789"
+	symbolChars = "!#$%&*+-=?@^_~"
+)
+
+// generateAttempts - количество попыток сгенерировать пароль, удовлетворяющий политике
+const generateAttempts = 100
+
+// ErrPolicy - пароль не удовлетворяет политике паролей
+var ErrPolicy = errors.New("password does not meet policy")
+
+// commonPasswords - встроенный список распространенных паролей
+//
+//go:embed common.txt
+var commonPasswords []byte
+
+// PolicyError - нарушение правила политики паролей. Оборачивает ErrPolicy.
+type PolicyError struct {
+	Rule    string // Имя нарушенного правила
+	Message string // Описание правила
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", ErrPolicy, e.Rule, e.Message)
+}
+
+func (e *PolicyError) Unwrap() error {
+	return ErrPolicy
+}
+
+// Policy - политика паролей: длина, обязательные классы символов и список запрещенных паролей.
+// Нулевое значение длины отключает соответствующее правило.
+type Policy struct {
+	MinLength       int                 // Минимальная длина в символах
+	MaxLength       int                 // Максимальная длина в символах
+	RequireUpper    bool                // Обязательна заглавная буква
+	RequireLower    bool                // Обязательна строчная буква
+	RequireDigit    bool                // Обязательна цифра
+	RequireSymbol   bool                // Обязателен символ, не являющийся буквой или цифрой
+	GeneratedLength int                 // Длина генерируемых паролей
+	Denylist        map[string]struct{} // Запрещенные пароли в нижнем регистре
+}
+
+// LoadDenylist заполняет список запрещенных паролей встроенным списком распространенных паролей
+// и паролями из файла path, по одному в строке. Пустой path загружает только встроенный список.
+//
+// Параметры:
+//   - path: string - путь к файлу со списком запрещенных паролей.
+//
+// Возвращаемые значения:
+//   - error: ошибка чтения файла.
+func (p *Policy) LoadDenylist(path string) error {
+	p.Denylist = make(map[string]struct{})
+	p.addDenylist(commonPasswords)
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read password denylist: %v", err)
+	}
+	p.addDenylist(data)
+	return nil
+}
+
+// addDenylist добавляет в список запрещенных паролей непустые строки data.
+func (p *Policy) addDenylist(data []byte) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			p.Denylist[strings.ToLower(line)] = struct{}{}
+		}
+	}
+}
+
+// Validate проверяет пароль пользователя по правилам политики.
+//
+// Параметры:
+//   - login: string - логин пользователя; пароль не должен совпадать с ним.
+//   - password: string - проверяемый пароль.
+//
+// Возвращаемые значения:
+//   - error: *PolicyError с именем первого нарушенного правила или nil.
+func (p *Policy) Validate(login, password string) error {
+	length := utf8.RuneCountInString(password)
+	switch {
+	case p.MinLength > 0 && length < p.MinLength:
+		return &PolicyError{Rule: RuleMinLength, Message: fmt.Sprintf("must be at least %d characters", p.MinLength)}
+	case p.MaxLength > 0 && length > p.MaxLength:
+		return &PolicyError{Rule: RuleMaxLength, Message: fmt.Sprintf("must be at most %d characters", p.MaxLength)}
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsLetter(r) && !unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	switch {
+	case p.RequireUpper && !upper:
+		return &PolicyError{Rule: RuleUpper, Message: "must contain an uppercase letter"}
+	case p.RequireLower && !lower:
+		return &PolicyError{Rule: RuleLower, Message: "must contain a lowercase letter"}
+	case p.RequireDigit && !digit:
+		return &PolicyError{Rule: RuleDigit, Message: "must contain a digit"}
+	case p.RequireSymbol && !symbol:
+		return &PolicyError{Rule: RuleSymbol, Message: "must contain a symbol"}
+	}
+
+	if login != "" && strings.EqualFold(password, login) {
+		return &PolicyError{Rule: RuleLogin, Message: "must not match the login"}
+	}
+	if _, ok := p.Denylist[strings.ToLower(password)]; ok {
+		return &PolicyError{Rule: RuleDenylist, Message: "is too common"}
+	}
+	return nil
+}
+
+// Generate генерирует случайный пароль длиной GeneratedLength (но не короче MinLength) из букв, цифр
+// и символов с помощью криптографически стойкого генератора. Пароль содержит все обязательные классы символов
+// и удовлетворяет политике.
+//
+// Параметры:
+//   - login: string - логин пользователя, для которого генерируется пароль.
+//
+// Возвращаемые значения:
+//   - string: сгенерированный пароль.
+//   - error: ошибка генератора случайных чисел или политики, которой невозможно удовлетворить.
+func (p *Policy) Generate(login string) (string, error) {
+	length := p.GeneratedLength
+	if length < p.MinLength {
+		length = p.MinLength
+	}
+	classes := []string{upperChars, lowerChars, digitChars}
+	if p.RequireSymbol {
+		classes = append(classes, symbolChars)
+	}
+	if length < len(classes) || (p.MaxLength > 0 && length > p.MaxLength) {
+		return "", fmt.Errorf("can't generate password of length %d", length)
+	}
+	alphabet := strings.Join(classes, "")
+
+	for attempt := 0; attempt < generateAttempts; attempt++ {
+		password := make([]byte, length)
+		// По одному символу каждого класса, остальные - из общего алфавита
+		for i := range password {
+			chars := alphabet
+			if i < len(classes) {
+				chars = classes[i]
+			}
+			c, err := randomChar(chars)
+			if err != nil {
+				return "", err
+			}
+			password[i] = c
+		}
+		if err := shuffle(password); err != nil {
+			return "", err
+		}
+		if p.Validate(login, string(password)) == nil {
+			return string(password), nil
+		}
+	}
+	return "", errors.New("can't generate password satisfying policy")
+}
+
+// randomChar возвращает случайный символ из chars.
+func randomChar(chars string) (byte, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(chars))))
+	if err != nil {
+		return 0, err
+	}
+	return chars[n.Int64()], nil
+}
+
+// shuffle перемешивает байты b алгоритмом Фишера-Йетса.
+func shuffle(b []byte) error {
+	for i := len(b) - 1; i > 0; i-- {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return err
+		}
+		j := n.Int64()
+		b[i], b[j] = b[j], b[i]
+	}
+	return nil
+}
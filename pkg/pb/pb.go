@@ -0,0 +1,4 @@
+// Package pb - клиент и сервер gRPC API накопительной системы лояльности, сгенерированные из gophermart.proto
+package pb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative gophermart.proto
@@ -0,0 +1,132 @@
+// Package totp - одноразовые пароли на основе времени (TOTP, RFC 6238) для двухфакторной аутентификации
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Параметры одноразовых паролей; приложения-аутентификаторы используют их по умолчанию.
+const (
+	Digits     = 6                // Количество цифр в коде
+	Period     = 30 * time.Second // Время действия кода
+	SecretSize = 20               // Размер секрета в байтах (рекомендуемый RFC 4226 для HMAC-SHA1)
+)
+
+// encoding - кодирование секрета, принятое в приложениях-аутентификаторах
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewSecret генерирует случайный секрет в кодировке base32.
+func NewSecret() (string, error) {
+	b := make([]byte, SecretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(b), nil
+}
+
+// URI возвращает ссылку otpauth:// для добавления секрета в приложение-аутентификатор (например, через QR-код).
+//
+// Параметры:
+//   - issuer: string - название сервиса.
+//   - account: string - имя учетной записи пользователя.
+//   - secret: string - секрет в кодировке base32.
+//
+// Возвращаемые значения:
+//   - string: ссылка otpauth://totp/...
+func URI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(Digits))
+	query.Set("period", fmt.Sprint(int(Period.Seconds())))
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + account,
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
+
+// Code возвращает код для момента времени t.
+func Code(secret string, t time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return code(key, counter(t)), nil
+}
+
+// Verify проверяет код для момента времени t с допуском skew периодов в обе стороны,
+// учитывающим расхождение часов клиента и сервера.
+//
+// Параметры:
+//   - secret: string - секрет в кодировке base32.
+//   - passcode: string - проверяемый код.
+//   - t: time.Time - момент проверки.
+//   - skew: int - допустимое количество периодов до и после t.
+//
+// Возвращаемые значения:
+//   - int64: номер периода, которому соответствует код; повторное использование кода того же или более раннего
+//     периода следует отклонять.
+//   - bool: признак того, что код верен.
+//   - error: ошибка, если секрет имеет неверный формат.
+func Verify(secret, passcode string, t time.Time, skew int) (int64, bool, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, false, err
+	}
+	passcode = strings.TrimSpace(passcode)
+	if len(passcode) != Digits {
+		return 0, false, nil
+	}
+	current := counter(t)
+	for i := -int64(skew); i <= int64(skew); i++ {
+		if subtle.ConstantTimeCompare([]byte(code(key, current+i)), []byte(passcode)) == 1 {
+			return current + i, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// counter возвращает номер периода для момента времени t.
+func counter(t time.Time) int64 {
+	return t.Unix() / int64(Period.Seconds())
+}
+
+// decodeSecret декодирует секрет из base32 без учета регистра и пробелов.
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	key, err := encoding.DecodeString(strings.TrimRight(secret, "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TOTP secret: %v", err)
+	}
+	return key, nil
+}
+
+// code вычисляет код HOTP (RFC 4226) для счетчика c.
+func code(key []byte, c int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(c))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", Digits, value%mod)
+}
@@ -0,0 +1,119 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret - секрет "12345678901234567890" из тестовых векторов RFC 4226 и RFC 6238 в кодировке base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestHOTPVectors(t *testing.T) {
+	// RFC 4226, приложение D
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	key, err := decodeSecret(rfcSecret)
+	require.NoError(t, err)
+	for c, hotp := range want {
+		assert.Equal(t, hotp, code(key, int64(c)), "counter %d", c)
+	}
+}
+
+func TestTOTPVectors(t *testing.T) {
+	// RFC 6238, приложение B (SHA1); коды из 6 цифр - последние 6 цифр 8-значных кодов RFC
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1111111111, code: "050471"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 2000000000, code: "279037"},
+		{unix: 20000000000, code: "353130"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			now := time.Unix(tt.unix, 0)
+			got, err := Code(rfcSecret, now)
+			require.NoError(t, err)
+			assert.Equal(t, tt.code, got)
+
+			counter, ok, err := Verify(rfcSecret, tt.code, now, 0)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, tt.unix/30, counter)
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	previous, err := Code(rfcSecret, now.Add(-Period))
+	require.NoError(t, err)
+	old, err := Code(rfcSecret, now.Add(-2*Period))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		secret   string
+		passcode string
+		skew     int
+		ok       bool
+		counter  int64
+		wantErr  bool
+	}{
+		{name: "Current code", secret: rfcSecret, passcode: "050471", ok: true, counter: 1111111111 / 30},
+		{name: "Code with spaces", secret: rfcSecret, passcode: " 050471 ", ok: true, counter: 1111111111 / 30},
+		{name: "Lowercase secret with spaces", secret: strings.ToLower("GEZD GNBV GY3T QOJQ GEZD GNBV GY3T QOJQ"), passcode: "050471", ok: true, counter: 1111111111 / 30},
+		{name: "Previous period within skew", secret: rfcSecret, passcode: previous, skew: 1, ok: true, counter: 1111111111/30 - 1},
+		{name: "Previous period without skew", secret: rfcSecret, passcode: previous},
+		{name: "Outside skew", secret: rfcSecret, passcode: old, skew: 1},
+		{name: "Wrong code", secret: rfcSecret, passcode: "000000", skew: 1},
+		{name: "Wrong length", secret: rfcSecret, passcode: "05047", skew: 1},
+		{name: "Invalid secret", secret: "not base32!", passcode: "050471", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter, ok, err := Verify(tt.secret, tt.passcode, now, tt.skew)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.counter, counter)
+		})
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	secret, err := NewSecret()
+	require.NoError(t, err)
+	key, err := decodeSecret(secret)
+	require.NoError(t, err)
+	assert.Len(t, key, SecretSize)
+
+	other, err := NewSecret()
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, other)
+}
+
+func TestURI(t *testing.T) {
+	u, err := url.Parse(URI("GopherMart", "alice", rfcSecret))
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/GopherMart:alice", u.Path)
+	assert.Equal(t, url.Values{
+		"secret":    {rfcSecret},
+		"issuer":    {"GopherMart"},
+		"algorithm": {"SHA1"},
+		"digits":    {"6"},
+		"period":    {"30"},
+	}, u.Query())
+}